@@ -18,6 +18,7 @@ import (
 	"github.com/docker/engine-api/client"
 	"github.com/docker/go-connections/sockets"
 	"github.com/docker/go-connections/tlsconfig"
+	"golang.org/x/net/context"
 )
 
 // DockerCli represents the docker command line client.
@@ -48,6 +49,9 @@ type DockerCli struct {
 	client client.APIClient
 	// state holds the terminal state
 	state *term.State
+	// ctx is the context of the running command, carrying the sink of its
+	// result
+	ctx context.Context
 }
 
 // Initialize calls the init function that will setup the configuration for the client
@@ -59,6 +63,12 @@ func (cli *DockerCli) Initialize() error {
 	return cli.init()
 }
 
+// SetContext sets the context of the command about to run, which reports
+// its result to the sink the context carries, if any.
+func (cli *DockerCli) SetContext(ctx context.Context) {
+	cli.ctx = ctx
+}
+
 // CheckTtyInput checks if we are trying to attach to a container tty
 // from a non-tty client input stream, and if so, returns an error.
 func (cli *DockerCli) CheckTtyInput(attachStdin, ttyMode bool) error {
@@ -143,6 +153,9 @@ func NewDockerCli(in io.ReadCloser, out, err io.Writer, clientFlags *cli.ClientF
 			customHeaders = map[string]string{}
 		}
 		customHeaders["User-Agent"] = clientUserAgent()
+		if clientFlags.RequestID != "" {
+			customHeaders[api.RequestIDHeader] = clientFlags.RequestID
+		}
 
 		verStr := api.DefaultVersion.String()
 		if tmpStr := os.Getenv("DOCKER_API_VERSION"); tmpStr != "" {
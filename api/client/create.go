@@ -175,6 +175,7 @@ func (cli *DockerCli) CmdCreate(args ...string) error {
 	if err != nil {
 		return err
 	}
+	Cli.SetResult(cli.ctx, response)
 	fmt.Fprintf(cli.out, "%s\n", response.ID)
 	return nil
 }
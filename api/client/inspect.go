@@ -101,7 +101,7 @@ func (cli *DockerCli) inspectElements(tmplStr string, references []string, searc
 	}
 
 	if status := cli.inspectErrorStatus(inspectErr); status != 0 {
-		return Cli.StatusError{StatusCode: status}
+		return Cli.StatusError{StatusCode: status, Code: ErrorCode(inspectErr)}
 	}
 	return nil
 }
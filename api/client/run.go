@@ -49,13 +49,13 @@ func (cid *cidFile) Write(id string) error {
 // return 125 for generic docker daemon failures
 func runStartContainerErr(err error) error {
 	trimmedErr := strings.Trim(err.Error(), "Error response from daemon: ")
-	statusError := Cli.StatusError{StatusCode: 125}
+	statusError := Cli.StatusError{StatusCode: 125, Code: ErrorCode(err)}
 
 	if strings.HasPrefix(trimmedErr, "Container command") {
 		if strings.Contains(trimmedErr, errCmdNotFound) {
-			statusError = Cli.StatusError{StatusCode: 127}
+			statusError = Cli.StatusError{StatusCode: 127, Code: Cli.CodeCommandNotFound}
 		} else if strings.Contains(trimmedErr, errCmdCouldNotBeInvoked) {
-			statusError = Cli.StatusError{StatusCode: 126}
+			statusError = Cli.StatusError{StatusCode: 126, Code: Cli.CodeCommandNotExecutable}
 		}
 	}
 
@@ -152,6 +152,9 @@ func (cli *DockerCli) CmdRun(args ...string) error {
 		cmd.ReportError(err.Error(), true)
 		return runStartContainerErr(err)
 	}
+	// the container is reported even if it fails to start or exits with an
+	// error
+	Cli.SetResult(cli.ctx, createResponse)
 	if sigProxy {
 		sigc := cli.forwardAllSignals(createResponse.ID)
 		defer signal.StopCatch(sigc)
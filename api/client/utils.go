@@ -10,11 +10,13 @@ import (
 	gosignal "os/signal"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"golang.org/x/net/context"
 
 	"github.com/Sirupsen/logrus"
+	Cli "github.com/docker/docker/cli"
 	"github.com/docker/docker/pkg/signal"
 	"github.com/docker/docker/pkg/term"
 	"github.com/docker/docker/registry"
@@ -200,3 +202,35 @@ func (cli *DockerCli) retrieveAuthConfigs() map[string]types.AuthConfig {
 	acs, _ := getAllCredentials(cli.configFile)
 	return acs
 }
+
+// ErrorCode returns the machine-readable code of an error returned by the
+// daemon, such as Cli.CodeImageNotFound, or an empty string if the error is
+// not recognized. The code of a Cli.StatusError is its Code.
+func ErrorCode(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case isStatusError(err):
+		return err.(Cli.StatusError).Code
+	case client.IsErrImageNotFound(err):
+		return Cli.CodeImageNotFound
+	case client.IsErrContainerNotFound(err):
+		return Cli.CodeContainerNotFound
+	case client.IsErrNetworkNotFound(err):
+		return Cli.CodeNetworkNotFound
+	case client.IsErrVolumeNotFound(err):
+		return Cli.CodeVolumeNotFound
+	case client.IsErrUnauthorized(err):
+		return Cli.CodeUnauthorized
+	}
+	msg := strings.ToLower(strings.TrimPrefix(err.Error(), "Error response from daemon: "))
+	if strings.HasPrefix(msg, "conflict") {
+		return Cli.CodeConflict
+	}
+	return ""
+}
+
+func isStatusError(err error) bool {
+	_, ok := err.(Cli.StatusError)
+	return ok
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"fmt"
+	"testing"
+
+	Cli "github.com/docker/docker/cli"
+)
+
+func TestRunStartContainerErrCode(t *testing.T) {
+	cases := []struct {
+		err        error
+		statusCode int
+		code       string
+	}{
+		{fmt.Errorf("Error response from daemon: Container command 'foo' not found or does not exist."), 127, Cli.CodeCommandNotFound},
+		{fmt.Errorf("Error response from daemon: Container command '/etc' could not be invoked."), 126, Cli.CodeCommandNotExecutable},
+		{fmt.Errorf("Error response from daemon: Conflict. The name \"/foo\" is already in use by container 1234."), 125, Cli.CodeConflict},
+		{fmt.Errorf("Error response from daemon: something went wrong"), 125, ""},
+	}
+
+	for _, c := range cases {
+		err, ok := runStartContainerErr(c.err).(Cli.StatusError)
+		if !ok {
+			t.Fatalf("expected a StatusError for %v", c.err)
+		}
+		if err.StatusCode != c.statusCode || err.Code != c.code {
+			t.Fatalf("expected status code %d and code %q for %v, got %d and %q", c.statusCode, c.code, c.err, err.StatusCode, err.Code)
+		}
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	cases := []struct {
+		err  error
+		code string
+	}{
+		{nil, ""},
+		{fmt.Errorf("Error response from daemon: Conflict. The name \"/foo\" is already in use by container 1234."), Cli.CodeConflict},
+		{fmt.Errorf("Error response from daemon: something went wrong"), ""},
+		{Cli.StatusError{StatusCode: 1, Code: Cli.CodeImageNotFound}, Cli.CodeImageNotFound},
+	}
+
+	for _, c := range cases {
+		if code := ErrorCode(c.err); code != c.code {
+			t.Fatalf("expected code %q for %v, got %q", c.code, c.err, code)
+		}
+	}
+}
@@ -26,6 +26,10 @@ const (
 	// NoBaseImageSpecifier is the symbol used by the FROM
 	// command to specify that no base image is to be used.
 	NoBaseImageSpecifier string = "scratch"
+
+	// RequestIDHeader is the HTTP header carrying the ID of an API
+	// request, used to correlate a client command with daemon events.
+	RequestIDHeader string = "X-Request-Id"
 )
 
 // byPortInfo is a temporary type used to sort types.Port by its fields
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api"
+)
+
+// AccessLogConfig configures the access log of the API server.
+type AccessLogConfig struct {
+	// Out is where the access log is written, one JSON entry per line.
+	Out io.Writer
+	// Verbose logs the GET and HEAD requests too, which don't change the
+	// state of the daemon.
+	Verbose bool
+}
+
+// accessLogEntry is the access log entry of a request.
+type accessLogEntry struct {
+	Time      time.Time `json:"time"`
+	Listener  string    `json:"listener"`
+	Remote    string    `json:"remote,omitempty"`
+	Identity  string    `json:"identity,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+	Method    string    `json:"method"`
+	Route     string    `json:"route,omitempty"`
+	Path      string    `json:"path"`
+	Status    int       `json:"status,omitempty"`
+	Hijacked  bool      `json:"hijacked,omitempty"`
+	Duration  float64   `json:"duration"`
+}
+
+// accessLogHandler writes the access log of the requests served by a
+// listener.
+type accessLogHandler struct {
+	cfg      *AccessLogConfig
+	mu       *sync.Mutex // serializes the writes to cfg.Out
+	listener string
+	next     http.Handler
+}
+
+func (s *Server) newAccessLogHandler(listener string, next http.Handler) http.Handler {
+	return &accessLogHandler{
+		cfg:      s.cfg.AccessLog,
+		mu:       &s.accessLogMu,
+		listener: listener,
+		next:     next,
+	}
+}
+
+func (h *accessLogHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.cfg.Verbose && (r.Method == "GET" || r.Method == "HEAD") {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	start := time.Now()
+	aw := &accessLogWriter{ResponseWriter: w}
+	h.next.ServeHTTP(aw, r)
+
+	entry := accessLogEntry{
+		Time:      start.UTC(),
+		Listener:  h.listener,
+		Remote:    r.RemoteAddr,
+		RequestID: w.Header().Get(api.RequestIDHeader),
+		Method:    r.Method,
+		Route:     aw.route,
+		Path:      r.URL.Path,
+		Status:    aw.status,
+		Hijacked:  aw.hijacked,
+		Duration:  time.Since(start).Seconds(),
+	}
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		entry.Identity = r.TLS.PeerCertificates[0].Subject.CommonName
+	}
+	if entry.Status == 0 && !entry.Hijacked {
+		entry.Status = http.StatusOK
+	}
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		logrus.Errorf("Error encoding the access log entry of %s %s: %v", r.Method, r.URL.Path, err)
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, err := h.cfg.Out.Write(append(b, '\n')); err != nil {
+		logrus.Errorf("Error writing the access log: %v", err)
+	}
+}
+
+// accessLogRoute records the route template of the requests it serves in
+// the access log.
+func accessLogRoute(route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if aw, ok := w.(*accessLogWriter); ok {
+			aw.route = route
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// accessLogWriter records the status of a response. It implements the
+// optional interfaces of the http.ResponseWriter of the http package,
+// which the handlers rely on.
+type accessLogWriter struct {
+	http.ResponseWriter
+	status   int
+	route    string
+	hijacked bool
+}
+
+func (w *accessLogWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessLogWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher.
+func (w *accessLogWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// CloseNotify implements http.CloseNotifier.
+func (w *accessLogWriter) CloseNotify() <-chan bool {
+	if cn, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return cn.CloseNotify()
+	}
+	return make(chan bool)
+}
+
+// Hijack implements http.Hijacker.
+func (w *accessLogWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer does not support hijacking")
+	}
+	w.hijacked = true
+	return h.Hijack()
+}
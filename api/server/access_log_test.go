@@ -0,0 +1,67 @@
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/server/router"
+	"golang.org/x/net/context"
+)
+
+func TestAccessLog(t *testing.T) {
+	out := &bytes.Buffer{}
+	srv := &Server{
+		cfg: &Config{AccessLog: &AccessLogConfig{Out: out}},
+	}
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	srv.InitRouter(false, testRouter{[]router.Route{
+		router.NewGetRoute("/containers/json", handler),
+		router.NewPostRoute("/containers/{name:.*}/start", handler),
+	}})
+	h := srv.newAccessLogHandler("127.0.0.1:2376", srv.routerSwapper)
+
+	req, _ := http.NewRequest("GET", "/containers/json", nil)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if out.Len() != 0 {
+		t.Fatalf("Expected the GET request not to be logged, got %q", out.String())
+	}
+
+	req, _ = http.NewRequest("POST", "/v1.23/containers/web/start", nil)
+	req.RemoteAddr = "10.0.0.1:45678"
+	req.TLS = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "alice"}}},
+	}
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry accessLogEntry
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a JSON access log entry, got %q: %v", out.String(), err)
+	}
+	if entry.Listener != "127.0.0.1:2376" || entry.Remote != "10.0.0.1:45678" || entry.Identity != "alice" {
+		t.Fatalf("Expected the listener, remote address and identity of the request, got %+v", entry)
+	}
+	if entry.Method != "POST" || entry.Route != "/containers/{name:.*}/start" || entry.Path != "/v1.23/containers/web/start" {
+		t.Fatalf("Expected the route template and path of the request, got %+v", entry)
+	}
+	if entry.Status != http.StatusNoContent || entry.RequestID == "" {
+		t.Fatalf("Expected the status and request ID of the response, got %+v", entry)
+	}
+
+	out.Reset()
+	srv.cfg.AccessLog.Verbose = true
+	req, _ = http.NewRequest("GET", "/nonexistent", nil)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if !strings.Contains(out.String(), `"status":404`) || strings.Contains(out.String(), `"route"`) {
+		t.Fatalf("Expected the unmatched GET request to be logged without route, got %q", out.String())
+	}
+}
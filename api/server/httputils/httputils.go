@@ -19,6 +19,9 @@ const APIVersionKey = "api-version"
 // UAStringKey is used as key type for user-agent string in net/context struct
 const UAStringKey = "upstream-user-agent"
 
+// RequestIDKey is used as key type for the ID of the API request in net/context struct
+const RequestIDKey = "request-id"
+
 // APIFunc is an adapter to allow the use of ordinary functions as Docker API endpoints.
 // Any function that has the appropriate signature can be registered as a API endpoint (e.g. getVersion).
 type APIFunc func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error
@@ -93,6 +96,16 @@ func WriteJSON(w http.ResponseWriter, code int, v interface{}) error {
 	return json.NewEncoder(w).Encode(v)
 }
 
+// RequestIDFromContext returns the ID of the API request from the context
+// using RequestIDKey, or an empty string if the context has none.
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(RequestIDKey).(string)
+	return id
+}
+
 // VersionFromContext returns an API version from the context using APIVersionKey.
 // It panics if the context value does not have version.Version type.
 func VersionFromContext(ctx context.Context) (ver version.Version) {
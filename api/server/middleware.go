@@ -26,6 +26,9 @@ func (s *Server) handleWithGlobalMiddlewares(handler httputils.APIFunc) httputil
 	handleUserAgent := middleware.NewUserAgentMiddleware(s.cfg.Version)
 	next = handleUserAgent(next)
 
+	handleRequestID := middleware.NewRequestIDMiddleware()
+	next = handleRequestID(next)
+
 	// Only want this on debug level
 	if s.cfg.Logging && logrus.GetLevel() == logrus.DebugLevel {
 		next = middleware.DebugRequestMiddleware(next)
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/docker/distribution/uuid"
+	"github.com/docker/docker/api"
+	"github.com/docker/docker/api/server/httputils"
+	"golang.org/x/net/context"
+)
+
+// NewRequestIDMiddleware creates a new RequestID middleware.
+// It stores the request ID sent by the client in the request context,
+// generating one if the client didn't send any, and returns it in the
+// response headers.
+func NewRequestIDMiddleware() Middleware {
+	return func(handler httputils.APIFunc) httputils.APIFunc {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+			id := r.Header.Get(api.RequestIDHeader)
+			if id == "" {
+				id = uuid.Generate().String()
+			}
+			w.Header().Set(api.RequestIDHeader, id)
+
+			ctx = context.WithValue(ctx, httputils.RequestIDKey, id)
+			return handler(ctx, w, r, vars)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docker/docker/api"
+	"github.com/docker/docker/api/server/httputils"
+	"golang.org/x/net/context"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var requestID string
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		requestID = httputils.RequestIDFromContext(ctx)
+		return nil
+	}
+	h := NewRequestIDMiddleware()(handler)
+
+	req, _ := http.NewRequest("POST", "/containers/create", nil)
+	req.Header.Set(api.RequestIDHeader, "deploy-42")
+	resp := httptest.NewRecorder()
+	if err := h(context.Background(), resp, req, map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if requestID != "deploy-42" {
+		t.Fatalf("Expected request ID deploy-42 in the context, got %q", requestID)
+	}
+	if id := resp.Header().Get(api.RequestIDHeader); id != "deploy-42" {
+		t.Fatalf("Expected request ID deploy-42 in the response, got %q", id)
+	}
+}
+
+func TestRequestIDMiddlewareGeneratesID(t *testing.T) {
+	var requestID string
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		requestID = httputils.RequestIDFromContext(ctx)
+		return nil
+	}
+	h := NewRequestIDMiddleware()(handler)
+
+	req, _ := http.NewRequest("POST", "/containers/create", nil)
+	resp := httptest.NewRecorder()
+	if err := h(context.Background(), resp, req, map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if requestID == "" {
+		t.Fatal("Expected a generated request ID in the context")
+	}
+	if id := resp.Header().Get(api.RequestIDHeader); id != requestID {
+		t.Fatalf("Expected request ID %s in the response, got %q", requestID, id)
+	}
+}
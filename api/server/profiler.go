@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/pprof"
 
+	"github.com/docker/docker/pkg/metrics"
 	"github.com/gorilla/mux"
 )
 
@@ -14,6 +15,7 @@ const debugPathPrefix = "/debug/"
 func profilerSetup(mainRouter *mux.Router) {
 	var r = mainRouter.PathPrefix(debugPathPrefix).Subrouter()
 	r.HandleFunc("/vars", expVars)
+	r.HandleFunc("/metrics", metrics.Handler)
 	r.HandleFunc("/pprof/", pprof.Index)
 	r.HandleFunc("/pprof/cmdline", pprof.Cmdline)
 	r.HandleFunc("/pprof/profile", pprof.Profile)
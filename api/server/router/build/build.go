@@ -5,13 +5,16 @@ import "github.com/docker/docker/api/server/router"
 // buildRouter is a router to talk with the build controller
 type buildRouter struct {
 	backend Backend
+	sinks   *LogSinks
 	routes  []router.Route
 }
 
-// NewRouter initializes a new build router
-func NewRouter(b Backend) router.Router {
+// NewRouter initializes a new build router, mirroring the output of the
+// builds to the log sinks, if not nil.
+func NewRouter(b Backend, sinks *LogSinks) router.Router {
 	r := &buildRouter{
 		backend: b,
+		sinks:   sinks,
 	}
 	r.initRoutes()
 	return r
@@ -24,6 +27,6 @@ func (r *buildRouter) Routes() []router.Route {
 
 func (r *buildRouter) initRoutes() {
 	r.routes = []router.Route{
-		router.NewPostRoute("/build", r.postBuild),
+		router.LongRunning(router.NewPostRoute("/build", r.postBuild)),
 	}
 }
@@ -0,0 +1,235 @@
+package build
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/RackSec/srslog"
+	"github.com/Sirupsen/logrus"
+)
+
+// logSinkBufferSize is the number of build messages a sink buffers before
+// dropping them, so that a slow sink doesn't slow the builds down.
+const logSinkBufferSize = 1024
+
+// LogSink mirrors the output of the builds to a file or to syslog, in
+// addition to the clients requesting the builds. Each message is written as
+// a JSON object on its own line, with the ID of the build and the message
+// streamed to the client.
+type LogSink struct {
+	spec     string
+	w        io.WriteCloser
+	messages chan []byte
+	dropped  int64
+	done     chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// logSinkMessage is a message written to the build log sinks.
+type logSinkMessage struct {
+	Build   string          `json:"build"`
+	Message json.RawMessage `json:"message"`
+}
+
+// NewLogSink opens the build log sink spec: the absolute path of a file,
+// "syslog" for the local syslog daemon, or "syslog+udp://host:port",
+// "syslog+tcp://host:port" or "syslog+unix:///path" for another one.
+func NewLogSink(spec string) (*LogSink, error) {
+	w, err := openLogSink(spec)
+	if err != nil {
+		return nil, fmt.Errorf("Error opening the build log sink %s: %v", spec, err)
+	}
+	return newLogSink(spec, w, logSinkBufferSize), nil
+}
+
+func newLogSink(spec string, w io.WriteCloser, size int) *LogSink {
+	s := &LogSink{
+		spec:     spec,
+		w:        w,
+		messages: make(chan []byte, size),
+		done:     make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// ValidateLogSink checks the build log sink spec, without opening it.
+func ValidateLogSink(spec string) error {
+	if _, _, _, err := parseLogSink(spec); err != nil {
+		return fmt.Errorf("Invalid build log sink %s: %v", spec, err)
+	}
+	return nil
+}
+
+// parseLogSink returns the file path of the build log sink spec, or the
+// protocol and address of its syslog daemon, empty for the local one.
+func parseLogSink(spec string) (path, proto, addr string, err error) {
+	if spec == "syslog" {
+		return "", "", "", nil
+	}
+	if strings.HasPrefix(spec, "syslog+") {
+		parts := strings.SplitN(strings.TrimPrefix(spec, "syslog+"), "://", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return "", "", "", fmt.Errorf("invalid syslog address")
+		}
+		switch parts[0] {
+		case "udp", "tcp", "unix":
+		default:
+			return "", "", "", fmt.Errorf("unsupported syslog protocol %s", parts[0])
+		}
+		return "", parts[0], parts[1], nil
+	}
+	if !strings.HasPrefix(spec, "/") {
+		return "", "", "", fmt.Errorf("it must be an absolute path, syslog, or a syslog address")
+	}
+	return spec, "", "", nil
+}
+
+func openLogSink(spec string) (io.WriteCloser, error) {
+	path, proto, addr, err := parseLogSink(spec)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return srslog.Dial(proto, addr, srslog.LOG_DAEMON|srslog.LOG_INFO, "docker-build")
+	}
+	return os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+}
+
+// send queues the message of a build, dropping it if the sink is full.
+func (s *LogSink) send(build string, message []byte) {
+	line, err := json.Marshal(logSinkMessage{
+		Build:   build,
+		Message: json.RawMessage(message),
+	})
+	if err != nil {
+		return
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.messages <- append(line, '\n'):
+	default:
+		if atomic.AddInt64(&s.dropped, 1) == 1 {
+			logrus.Warnf("The build log sink %s is too slow, dropping build messages", s.spec)
+		}
+	}
+}
+
+func (s *LogSink) run() {
+	defer close(s.done)
+	for m := range s.messages {
+		if _, err := s.w.Write(m); err != nil {
+			logrus.Warnf("Error writing to the build log sink %s: %v", s.spec, err)
+		}
+		if dropped := atomic.SwapInt64(&s.dropped, 0); dropped > 0 {
+			logrus.Warnf("Dropped %d build messages for the build log sink %s", dropped, s.spec)
+		}
+	}
+}
+
+// Close writes the buffered messages and closes the sink.
+func (s *LogSink) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.messages)
+	}
+	s.mu.Unlock()
+	<-s.done
+	return s.w.Close()
+}
+
+// LogSinks are the build log sinks of the daemon, which are replaced when
+// its configuration is reloaded.
+type LogSinks struct {
+	mu    sync.RWMutex
+	sinks []*LogSink
+}
+
+// NewLogSinks opens the build log sinks specs.
+func NewLogSinks(specs []string) (*LogSinks, error) {
+	s := &LogSinks{}
+	if err := s.Reload(specs); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload opens the build log sinks specs and replaces the current sinks,
+// which are closed once their buffered messages are written. The current
+// sinks are kept if any of specs can't be opened.
+func (s *LogSinks) Reload(specs []string) error {
+	var sinks []*LogSink
+	for _, spec := range specs {
+		sink, err := NewLogSink(spec)
+		if err != nil {
+			for _, sink := range sinks {
+				sink.Close()
+			}
+			return err
+		}
+		sinks = append(sinks, sink)
+	}
+
+	s.mu.Lock()
+	previous := s.sinks
+	s.sinks = sinks
+	s.mu.Unlock()
+
+	for _, sink := range previous {
+		if err := sink.Close(); err != nil {
+			logrus.Warnf("Error closing the build log sink %s: %v", sink.spec, err)
+		}
+	}
+	return nil
+}
+
+// Close closes the build log sinks.
+func (s *LogSinks) Close() error {
+	return s.Reload(nil)
+}
+
+func (s *LogSinks) current() []*LogSink {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sinks
+}
+
+// teeWriter writes the output of a build to the client and to the build
+// log sinks. A write may hold several messages, or part of one: each
+// message, terminated by a newline, is sent to the sinks once complete.
+type teeWriter struct {
+	io.Writer
+	build   string
+	sinks   *LogSinks
+	pending []byte
+}
+
+func (t *teeWriter) Write(b []byte) (int, error) {
+	t.pending = append(t.pending, b...)
+	for {
+		i := bytes.IndexByte(t.pending, '\n')
+		if i < 0 {
+			break
+		}
+		if message := bytes.TrimSpace(t.pending[:i]); len(message) > 0 {
+			for _, s := range t.sinks.current() {
+				s.send(t.build, message)
+			}
+		}
+		t.pending = t.pending[i+1:]
+	}
+	return t.Writer.Write(b)
+}
@@ -0,0 +1,153 @@
+package build
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// blockingWriter blocks the writes until it is released.
+type blockingWriter struct {
+	release chan struct{}
+	mu      sync.Mutex
+	buf     bytes.Buffer
+}
+
+func (w *blockingWriter) Write(b []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(b)
+}
+
+func (w *blockingWriter) Close() error {
+	return nil
+}
+
+func TestLogSinkMirrorsBuildOutput(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "build-log-sink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	path := filepath.Join(tmp, "builds.log")
+
+	sink, err := NewLogSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var client bytes.Buffer
+	out := &teeWriter{Writer: &client, build: "b1", sinks: &LogSinks{sinks: []*LogSink{sink}}}
+	messages := []string{`{"stream":"Step 1 : FROM busybox\n"}`, `{"stream":"Pulling from library/busybox\n"}`, `{"stream":"Step 2 : RUN true\n"}`}
+	// two messages in a write, and one split across writes
+	writes := []string{messages[0] + "\r\n" + messages[1] + "\r\n" + messages[2][:10], messages[2][10:] + "\r\n"}
+	for _, w := range writes {
+		if _, err := out.Write([]byte(w)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if client.String() != strings.Join(writes, "") {
+		t.Fatalf("Expected the client to get the messages, got %q", client.String())
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != len(messages) {
+		t.Fatalf("Expected a line per message in the sink, got %s", b)
+	}
+	for i, line := range lines {
+		var m logSinkMessage
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatal(err)
+		}
+		if m.Build != "b1" || string(m.Message) != messages[i] {
+			t.Fatalf("Unexpected message in the sink: %s", line)
+		}
+	}
+
+	// closed sinks drop the messages
+	out.Write([]byte(messages[0] + "\r\n"))
+}
+
+func TestLogSinkDropsWhenFull(t *testing.T) {
+	w := &blockingWriter{release: make(chan struct{})}
+	sink := newLogSink("slow", w, 1)
+
+	var client bytes.Buffer
+	out := &teeWriter{Writer: &client, build: "b1", sinks: &LogSinks{sinks: []*LogSink{sink}}}
+	for i := 0; i < 10; i++ {
+		if _, err := out.Write([]byte(`{"stream":"line"}` + "\r\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := strings.Count(client.String(), "line"); n != 10 {
+		t.Fatalf("Expected the client to get all the messages, got %d", n)
+	}
+
+	close(w.release)
+	sink.Close()
+	// one message is being written, one is buffered, the others are dropped
+	if n := strings.Count(w.buf.String(), "line"); n < 1 || n > 2 {
+		t.Fatalf("Expected the messages not fitting in the buffer to be dropped, got %d", n)
+	}
+}
+
+func TestNewLogSinkInvalid(t *testing.T) {
+	for _, spec := range []string{"relative/path", "syslog+http://host:80", "syslog+udp://"} {
+		if _, err := NewLogSink(spec); err == nil {
+			t.Fatalf("Expected %s to be rejected", spec)
+		}
+		if err := ValidateLogSink(spec); err == nil {
+			t.Fatalf("Expected %s to be invalid", spec)
+		}
+	}
+}
+
+func TestLogSinksReload(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "build-log-sink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	first, second := filepath.Join(tmp, "first.log"), filepath.Join(tmp, "second.log")
+
+	sinks, err := NewLogSinks([]string{first})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := &teeWriter{Writer: ioutil.Discard, build: "b1", sinks: sinks}
+	out.Write([]byte(`{"stream":"first"}` + "\r\n"))
+
+	if err := sinks.Reload([]string{second, "relative/path"}); err == nil {
+		t.Fatal("Expected the reload with an invalid sink to fail")
+	}
+	if err := sinks.Reload([]string{second}); err != nil {
+		t.Fatal(err)
+	}
+	// the builds in progress write to the reloaded sinks
+	out.Write([]byte(`{"stream":"second"}` + "\r\n"))
+	if err := sinks.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, expected := range map[string]string{first: "first", second: "second"} {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Count(string(b), "\n") != 1 || !strings.Contains(string(b), expected) {
+			t.Fatalf("Expected %s to hold the %s message only, got %s", path, expected, b)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/docker/docker/pkg/version"
 	"github.com/docker/engine-api/types"
 	"github.com/docker/engine-api/types/container"
+	"golang.org/x/net/context"
 )
 
 // execBackend includes functions to implement to provide exec functionality.
@@ -31,18 +32,21 @@ type copyBackend interface {
 
 // stateBackend includes functions to implement to provide container state lifecycle functionality.
 type stateBackend interface {
-	ContainerCreate(types.ContainerCreateConfig) (types.ContainerCreateResponse, error)
+	ContainerCreate(ctx context.Context, params backend.ContainerCreateConfig) (backend.ContainerCreateResponse, error)
+	PullImageIfMissing(ctx context.Context, name string, metaHeaders map[string][]string, authConfig *types.AuthConfig, outStream io.Writer) error
 	ContainerKill(name string, sig uint64) error
 	ContainerPause(name string) error
 	ContainerRename(oldName, newName string) error
 	ContainerResize(name string, height, width int) error
 	ContainerRestart(name string, seconds int) error
 	ContainerRm(name string, config *types.ContainerRmConfig) error
-	ContainerStart(name string, hostConfig *container.HostConfig) error
+	ContainerStart(ctx context.Context, name string, hostConfig *container.HostConfig, paused bool) error
 	ContainerStop(name string, seconds int) error
 	ContainerUnpause(name string) error
 	ContainerUpdate(name string, hostConfig *container.HostConfig) ([]string, error)
 	ContainerWait(name string, timeout time.Duration) (int, error)
+	FreezeContainers(atomic bool) (*backend.FreezeResult, error)
+	ThawContainers(ids []string) (*backend.FreezeResult, error)
 }
 
 // monitorBackend includes functions to implement to provide containers monitoring functionality.
@@ -50,6 +54,7 @@ type monitorBackend interface {
 	ContainerChanges(name string) ([]archive.Change, error)
 	ContainerInspect(name string, size bool, version version.Version) (interface{}, error)
 	ContainerLogs(name string, config *backend.ContainerLogsConfig, started chan struct{}) error
+	ContainerSpec(name string) (interface{}, error)
 	ContainerStats(name string, config *backend.ContainerStatsConfig) error
 	ContainerTop(name string, psArgs string) (*types.ContainerProcessList, error)
 
@@ -26,37 +26,40 @@ func (r *containerRouter) Routes() []router.Route {
 func (r *containerRouter) initRoutes() {
 	r.routes = []router.Route{
 		// HEAD
-		router.NewHeadRoute("/containers/{name:.*}/archive", r.headContainersArchive),
+		router.LongRunning(router.NewHeadRoute("/containers/{name:.*}/archive", r.headContainersArchive)),
 		// GET
 		router.NewGetRoute("/containers/json", r.getContainersJSON),
-		router.NewGetRoute("/containers/{name:.*}/export", r.getContainersExport),
+		router.LongRunning(router.NewGetRoute("/containers/{name:.*}/export", r.getContainersExport)),
 		router.NewGetRoute("/containers/{name:.*}/changes", r.getContainersChanges),
 		router.NewGetRoute("/containers/{name:.*}/json", r.getContainersByName),
 		router.NewGetRoute("/containers/{name:.*}/top", r.getContainersTop),
-		router.NewGetRoute("/containers/{name:.*}/logs", r.getContainersLogs),
-		router.NewGetRoute("/containers/{name:.*}/stats", r.getContainersStats),
-		router.NewGetRoute("/containers/{name:.*}/attach/ws", r.wsContainersAttach),
+		router.LongRunning(router.NewGetRoute("/containers/{name:.*}/logs", r.getContainersLogs)),
+		router.LongRunning(router.NewGetRoute("/containers/{name:.*}/stats", r.getContainersStats)),
+		router.NewGetRoute("/containers/{name:.*}/spec", r.getContainersSpec),
+		router.LongRunning(router.NewGetRoute("/containers/{name:.*}/attach/ws", r.wsContainersAttach)),
 		router.NewGetRoute("/exec/{id:.*}/json", r.getExecByID),
-		router.NewGetRoute("/containers/{name:.*}/archive", r.getContainersArchive),
+		router.LongRunning(router.NewGetRoute("/containers/{name:.*}/archive", r.getContainersArchive)),
 		// POST
 		router.NewPostRoute("/containers/create", r.postContainersCreate),
+		router.NewPostRoute("/containers/pause", r.postContainersPauseAll),
+		router.NewPostRoute("/containers/unpause", r.postContainersUnpauseAll),
 		router.NewPostRoute("/containers/{name:.*}/kill", r.postContainersKill),
 		router.NewPostRoute("/containers/{name:.*}/pause", r.postContainersPause),
 		router.NewPostRoute("/containers/{name:.*}/unpause", r.postContainersUnpause),
-		router.NewPostRoute("/containers/{name:.*}/restart", r.postContainersRestart),
+		router.LongRunning(router.NewPostRoute("/containers/{name:.*}/restart", r.postContainersRestart)),
 		router.NewPostRoute("/containers/{name:.*}/start", r.postContainersStart),
-		router.NewPostRoute("/containers/{name:.*}/stop", r.postContainersStop),
-		router.NewPostRoute("/containers/{name:.*}/wait", r.postContainersWait),
+		router.LongRunning(router.NewPostRoute("/containers/{name:.*}/stop", r.postContainersStop)),
+		router.LongRunning(router.NewPostRoute("/containers/{name:.*}/wait", r.postContainersWait)),
 		router.NewPostRoute("/containers/{name:.*}/resize", r.postContainersResize),
-		router.NewPostRoute("/containers/{name:.*}/attach", r.postContainersAttach),
-		router.NewPostRoute("/containers/{name:.*}/copy", r.postContainersCopy),
+		router.LongRunning(router.NewPostRoute("/containers/{name:.*}/attach", r.postContainersAttach)),
+		router.LongRunning(router.NewPostRoute("/containers/{name:.*}/copy", r.postContainersCopy)),
 		router.NewPostRoute("/containers/{name:.*}/exec", r.postContainerExecCreate),
-		router.NewPostRoute("/exec/{name:.*}/start", r.postContainerExecStart),
+		router.LongRunning(router.NewPostRoute("/exec/{name:.*}/start", r.postContainerExecStart)),
 		router.NewPostRoute("/exec/{name:.*}/resize", r.postContainerExecResize),
 		router.NewPostRoute("/containers/{name:.*}/rename", r.postContainerRename),
 		router.NewPostRoute("/containers/{name:.*}/update", r.postContainerUpdate),
 		// PUT
-		router.NewPutRoute("/containers/{name:.*}/archive", r.putContainersArchive),
+		router.LongRunning(router.NewPutRoute("/containers/{name:.*}/archive", r.putContainersArchive)),
 		// DELETE
 		router.NewDeleteRoute("/containers/{name:.*}", r.deleteContainers),
 	}
@@ -1,9 +1,11 @@
 package container
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"strconv"
 	"strings"
@@ -137,6 +139,10 @@ func (s *containerRouter) getContainersExport(ctx context.Context, w http.Respon
 }
 
 func (s *containerRouter) postContainersStart(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
 	// If contentLength is -1, we can assumed chunked encoding
 	// or more technically that the length is unknown
 	// https://golang.org/src/pkg/net/http/request.go#L139
@@ -157,7 +163,21 @@ func (s *containerRouter) postContainersStart(ctx context.Context, w http.Respon
 		hostConfig = c
 	}
 
-	if err := s.backend.ContainerStart(vars["name"], hostConfig); err != nil {
+	// abort the start if the client goes away
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if notifier, ok := w.(http.CloseNotifier); ok {
+		closeNotify := notifier.CloseNotify()
+		go func() {
+			select {
+			case <-closeNotify:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	if err := s.backend.ContainerStart(ctx, vars["name"], hostConfig, httputils.BoolValue(r, "paused")); err != nil {
 		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -248,6 +268,34 @@ func (s *containerRouter) postContainersPause(ctx context.Context, w http.Respon
 	return nil
 }
 
+// postContainersPauseAll pauses all the running containers, and returns the
+// ones it paused.
+func (s *containerRouter) postContainersPauseAll(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	result, err := s.backend.FreezeContainers(httputils.BoolValue(r, "atomic"))
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusOK, result)
+}
+
+// postContainersUnpauseAll unpauses the containers given by id, or all the
+// paused containers, and returns the ones it unpaused.
+func (s *containerRouter) postContainersUnpauseAll(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	result, err := s.backend.ThawContainers(r.Form["id"])
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusOK, result)
+}
+
 func (s *containerRouter) postContainersUnpause(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := httputils.ParseForm(r); err != nil {
 		return err
@@ -295,6 +343,15 @@ func (s *containerRouter) getContainersTop(ctx context.Context, w http.ResponseW
 	return httputils.WriteJSON(w, http.StatusOK, procList)
 }
 
+func (s *containerRouter) getContainersSpec(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	spec, err := s.backend.ContainerSpec(vars["name"])
+	if err != nil {
+		return err
+	}
+
+	return httputils.WriteJSON(w, http.StatusOK, spec)
+}
+
 func (s *containerRouter) postContainerRename(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := httputils.ParseForm(r); err != nil {
 		return err
@@ -357,20 +414,78 @@ func (s *containerRouter) postContainersCreate(ctx context.Context, w http.Respo
 	version := httputils.VersionFromContext(ctx)
 	adjustCPUShares := version.LessThan("1.19")
 
-	ccr, err := s.backend.ContainerCreate(types.ContainerCreateConfig{
-		Name:             name,
-		Config:           config,
-		HostConfig:       hostConfig,
-		NetworkingConfig: networkingConfig,
-		AdjustCPUShares:  adjustCPUShares,
+	// abort the pull and the creation if the client goes away
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if notifier, ok := w.(http.CloseNotifier); ok {
+		closeNotify := notifier.CloseNotify()
+		go func() {
+			select {
+			case <-closeNotify:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	if httputils.BoolValue(r, "pull") {
+		if config == nil {
+			return fmt.Errorf("Config cannot be empty in order to create a container")
+		}
+		if err := s.pullImageIfMissing(ctx, r, config.Image); err != nil {
+			return err
+		}
+	}
+
+	ccr, err := s.backend.ContainerCreate(ctx, backend.ContainerCreateConfig{
+		ContainerCreateConfig: types.ContainerCreateConfig{
+			Name:             name,
+			Config:           config,
+			HostConfig:       hostConfig,
+			NetworkingConfig: networkingConfig,
+			AdjustCPUShares:  adjustCPUShares,
+		},
+		DeferRWLayer:    httputils.BoolValue(r, "deferLayer"),
+		ReplaceExisting: httputils.BoolValue(r, "replace"),
 	})
 	if err != nil {
 		return err
 	}
+	if version.LessThan("1.23") {
+		ccr.WarningDetails = nil
+	}
 
 	return httputils.WriteJSON(w, http.StatusCreated, ccr)
 }
 
+// pullImageIfMissing pulls the image of a container being created if it isn't
+// present locally. The registry credentials are read from the X-Registry-Auth
+// header, as for image pulls. The pull is silent: its progress is discarded
+// rather than sent to the client, the image events report the pull instead.
+func (s *containerRouter) pullImageIfMissing(ctx context.Context, r *http.Request, image string) error {
+	if image == "" {
+		return nil
+	}
+
+	metaHeaders := map[string][]string{}
+	for k, v := range r.Header {
+		if strings.HasPrefix(k, "X-Meta-") {
+			metaHeaders[k] = v
+		}
+	}
+
+	authConfig := &types.AuthConfig{}
+	if authEncoded := r.Header.Get("X-Registry-Auth"); authEncoded != "" {
+		authJSON := base64.NewDecoder(base64.URLEncoding, strings.NewReader(authEncoded))
+		if err := json.NewDecoder(authJSON).Decode(authConfig); err != nil {
+			// for a pull it is not an error if no auth was given
+			authConfig = &types.AuthConfig{}
+		}
+	}
+
+	return s.backend.PullImageIfMissing(ctx, image, metaHeaders, authConfig, ioutil.Discard)
+}
+
 func (s *containerRouter) deleteContainers(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := httputils.ParseForm(r); err != nil {
 		return err
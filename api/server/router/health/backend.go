@@ -0,0 +1,7 @@
+package health
+
+// Backend is the methods that need to be implemented to provide
+// the readiness of the daemon
+type Backend interface {
+	Ready() error
+}
@@ -0,0 +1,32 @@
+package health
+
+import "github.com/docker/docker/api/server/router"
+
+// healthRouter is a router serving cheap health checks of the daemon,
+// which don't depend on the state of the other routers.
+type healthRouter struct {
+	backend Backend
+	routes  []router.Route
+}
+
+// NewRouter initializes a new health router
+func NewRouter(b Backend) router.Router {
+	r := &healthRouter{
+		backend: b,
+	}
+	r.initRoutes()
+	return r
+}
+
+// Routes returns the available routes to the health checks
+func (r *healthRouter) Routes() []router.Route {
+	return r.routes
+}
+
+func (r *healthRouter) initRoutes() {
+	r.routes = []router.Route{
+		// GET
+		router.NewGetRoute("/_ping", pingHandler),
+		router.NewGetRoute("/_ready", r.getReady),
+	}
+}
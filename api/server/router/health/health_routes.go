@@ -0,0 +1,21 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/docker/docker/errors"
+	"golang.org/x/net/context"
+)
+
+func pingHandler(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	_, err := w.Write([]byte{'O', 'K'})
+	return err
+}
+
+func (r *healthRouter) getReady(ctx context.Context, w http.ResponseWriter, req *http.Request, vars map[string]string) error {
+	if err := r.backend.Ready(); err != nil {
+		return errors.NewErrorWithStatusCode(err, http.StatusServiceUnavailable)
+	}
+	_, err := w.Write([]byte{'O', 'K'})
+	return err
+}
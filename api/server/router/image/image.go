@@ -28,15 +28,15 @@ func (r *imageRouter) initRoutes() {
 		// GET
 		router.NewGetRoute("/images/json", r.getImagesJSON),
 		router.NewGetRoute("/images/search", r.getImagesSearch),
-		router.NewGetRoute("/images/get", r.getImagesGet),
-		router.NewGetRoute("/images/{name:.*}/get", r.getImagesGet),
+		router.LongRunning(router.NewGetRoute("/images/get", r.getImagesGet)),
+		router.LongRunning(router.NewGetRoute("/images/{name:.*}/get", r.getImagesGet)),
 		router.NewGetRoute("/images/{name:.*}/history", r.getImagesHistory),
 		router.NewGetRoute("/images/{name:.*}/json", r.getImagesByName),
 		// POST
-		router.NewPostRoute("/commit", r.postCommit),
-		router.NewPostRoute("/images/create", r.postImagesCreate),
-		router.NewPostRoute("/images/load", r.postImagesLoad),
-		router.NewPostRoute("/images/{name:.*}/push", r.postImagesPush),
+		router.LongRunning(router.NewPostRoute("/commit", r.postCommit)),
+		router.LongRunning(router.NewPostRoute("/images/create", r.postImagesCreate)),
+		router.LongRunning(router.NewPostRoute("/images/load", r.postImagesLoad)),
+		router.LongRunning(router.NewPostRoute("/images/{name:.*}/push", r.postImagesPush)),
 		router.NewPostRoute("/images/{name:.*}/tag", r.postImagesTag),
 		// DELETE
 		router.NewDeleteRoute("/images/{name:.*}", r.deleteImages),
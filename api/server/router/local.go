@@ -30,6 +30,22 @@ func NewRoute(method, path string, handler httputils.APIFunc) Route {
 	return localRoute{method, path, handler}
 }
 
+// longRunningRoute is a route marked with LongRunning.
+type longRunningRoute struct {
+	Route
+}
+
+// LongRunning returns true, the route runs for long.
+func (longRunningRoute) LongRunning() bool {
+	return true
+}
+
+// LongRunning marks route as long running, which exempts it from the read
+// and write timeouts of the server.
+func LongRunning(route Route) Route {
+	return longRunningRoute{route}
+}
+
 // NewGetRoute initializes a new route with the http method GET.
 func NewGetRoute(path string, handler httputils.APIFunc) Route {
 	return NewRoute("GET", path, handler)
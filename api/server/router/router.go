@@ -17,3 +17,12 @@ type Route interface {
 	// Path returns the subpath where the route responds to.
 	Path() string
 }
+
+// LongRunningRoute is a route streaming its request or response, or waiting
+// on containers, which is exempt from the read and write timeouts of the
+// server. The routes are marked as such with LongRunning.
+type LongRunningRoute interface {
+	Route
+	// LongRunning returns whether the route runs for long.
+	LongRunning() bool
+}
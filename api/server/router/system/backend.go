@@ -1,6 +1,8 @@
 package system
 
 import (
+	"github.com/docker/docker/api/server/router"
+	"github.com/docker/docker/api/types/backend"
 	"github.com/docker/engine-api/types"
 	"github.com/docker/engine-api/types/events"
 	"github.com/docker/engine-api/types/filters"
@@ -15,4 +17,14 @@ type Backend interface {
 	SubscribeToEvents(since, sinceNano int64, ef filters.Args) ([]events.Message, chan interface{})
 	UnsubscribeFromEvents(chan interface{})
 	AuthenticateToRegistry(ctx context.Context, authConfig *types.AuthConfig) (string, string, error)
+	SetDrainMode(drain bool)
+	LayerMounts() []backend.LayerMount
+	ContainerdOrphans() ([]string, error)
+	RetainedContainerdBundles() ([]string, error)
+	ReloadStatus() *backend.ReloadStatus
+}
+
+// RouteLister lists the routes served by the API server.
+type RouteLister interface {
+	Routes() []router.Route
 }
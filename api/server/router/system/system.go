@@ -5,28 +5,41 @@ import "github.com/docker/docker/api/server/router"
 // systemRouter provides information about the Docker system overall.
 // It gathers information about host, daemon and container events.
 type systemRouter struct {
-	backend Backend
-	routes  []router.Route
+	backend     Backend
+	routeLister RouteLister
+	routes      []router.Route
 }
 
 // NewRouter initializes a new system router
-func NewRouter(b Backend) router.Router {
+func NewRouter(b Backend, rl RouteLister) router.Router {
 	r := &systemRouter{
-		backend: b,
+		backend:     b,
+		routeLister: rl,
 	}
 
 	r.routes = []router.Route{
 		router.NewOptionsRoute("/{anyroute:.*}", optionsHandler),
-		router.NewGetRoute("/_ping", pingHandler),
-		router.NewGetRoute("/events", r.getEvents),
+		router.LongRunning(router.NewGetRoute("/events", r.getEvents)),
 		router.NewGetRoute("/info", r.getInfo),
 		router.NewGetRoute("/version", r.getVersion),
+		router.NewGetRoute("/debug/routes", r.getDebugRoutes),
+		router.NewGetRoute("/debug/mounts", r.getDebugMounts),
+		router.NewGetRoute("/debug/containerd-orphans", r.getDebugContainerdOrphans),
+		router.NewGetRoute("/debug/containerd-retained", r.getDebugContainerdRetained),
 		router.NewPostRoute("/auth", r.postAuth),
+		router.NewGetRoute("/system/reload-status", r.getReloadStatus),
+		router.NewPostRoute("/system/drain", r.postDrain),
 	}
 
 	return r
 }
 
+// routeInfo describes a route served by the API server.
+type routeInfo struct {
+	Method string
+	Path   string
+}
+
 // Routes returns all the API routes dedicated to the docker system
 func (s *systemRouter) Routes() []router.Route {
 	return s.routes
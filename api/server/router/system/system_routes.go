@@ -2,13 +2,16 @@ package system
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/api"
 	"github.com/docker/docker/api/server/httputils"
+	"github.com/docker/docker/errors"
 	"github.com/docker/docker/pkg/ioutils"
+	"github.com/docker/docker/utils"
 	"github.com/docker/engine-api/types"
 	"github.com/docker/engine-api/types/events"
 	"github.com/docker/engine-api/types/filters"
@@ -21,11 +24,6 @@ func optionsHandler(ctx context.Context, w http.ResponseWriter, r *http.Request,
 	return nil
 }
 
-func pingHandler(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
-	_, err := w.Write([]byte{'O', 'K'})
-	return err
-}
-
 func (s *systemRouter) getInfo(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	info, err := s.backend.SystemInfo()
 	if err != nil {
@@ -42,6 +40,56 @@ func (s *systemRouter) getVersion(ctx context.Context, w http.ResponseWriter, r
 	return httputils.WriteJSON(w, http.StatusOK, info)
 }
 
+func (s *systemRouter) getDebugRoutes(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if !utils.IsDebugEnabled() {
+		return errors.NewRequestNotFoundError(fmt.Errorf("the list of routes is only available in debug mode"))
+	}
+
+	var routes []routeInfo
+	for _, route := range s.routeLister.Routes() {
+		routes = append(routes, routeInfo{
+			Method: route.Method(),
+			Path:   route.Path(),
+		})
+	}
+	return httputils.WriteJSON(w, http.StatusOK, routes)
+}
+
+func (s *systemRouter) getDebugMounts(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if !utils.IsDebugEnabled() {
+		return errors.NewRequestNotFoundError(fmt.Errorf("the layer mounts are only available in debug mode"))
+	}
+	return httputils.WriteJSON(w, http.StatusOK, s.backend.LayerMounts())
+}
+
+func (s *systemRouter) getDebugContainerdOrphans(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if !utils.IsDebugEnabled() {
+		return errors.NewRequestNotFoundError(fmt.Errorf("the orphaned containerd directories are only available in debug mode"))
+	}
+	orphans, err := s.backend.ContainerdOrphans()
+	if err != nil {
+		return err
+	}
+	if orphans == nil {
+		orphans = []string{}
+	}
+	return httputils.WriteJSON(w, http.StatusOK, orphans)
+}
+
+func (s *systemRouter) getDebugContainerdRetained(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if !utils.IsDebugEnabled() {
+		return errors.NewRequestNotFoundError(fmt.Errorf("the retained containerd bundles are only available in debug mode"))
+	}
+	bundles, err := s.backend.RetainedContainerdBundles()
+	if err != nil {
+		return err
+	}
+	if bundles == nil {
+		bundles = []string{}
+	}
+	return httputils.WriteJSON(w, http.StatusOK, bundles)
+}
+
 func (s *systemRouter) getEvents(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := httputils.ParseForm(r); err != nil {
 		return err
@@ -123,3 +171,20 @@ func (s *systemRouter) postAuth(ctx context.Context, w http.ResponseWriter, r *h
 		IdentityToken: token,
 	})
 }
+
+func (s *systemRouter) getReloadStatus(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	status := s.backend.ReloadStatus()
+	if status == nil {
+		return errors.NewRequestNotFoundError(fmt.Errorf("the daemon configuration has not been reloaded"))
+	}
+	return httputils.WriteJSON(w, http.StatusOK, status)
+}
+
+func (s *systemRouter) postDrain(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+	s.backend.SetDrainMode(httputils.BoolValueOrDefault(r, "drain", true))
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
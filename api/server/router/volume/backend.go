@@ -11,5 +11,6 @@ type Backend interface {
 	Volumes(filter string) ([]*types.Volume, []string, error)
 	VolumeInspect(name string) (*types.Volume, error)
 	VolumeCreate(name, driverName string, opts, labels map[string]string) (*types.Volume, error)
+	VolumeCreateOrGet(name, driverName string, opts, labels map[string]string) (*types.Volume, bool, error)
 	VolumeRm(name string) error
 }
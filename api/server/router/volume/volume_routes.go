@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/docker/docker/api/server/httputils"
+	"github.com/docker/docker/api/types/backend"
 	"github.com/docker/engine-api/types"
 	"golang.org/x/net/context"
 )
@@ -47,6 +48,18 @@ func (v *volumeRouter) postVolumesCreate(ctx context.Context, w http.ResponseWri
 		return err
 	}
 
+	if httputils.BoolValue(r, "reuse") {
+		volume, created, err := v.backend.VolumeCreateOrGet(req.Name, req.Driver, req.DriverOpts, req.Labels)
+		if err != nil {
+			return err
+		}
+		status := http.StatusCreated
+		if !created {
+			status = http.StatusOK
+		}
+		return httputils.WriteJSON(w, status, &backend.VolumeCreateResult{Volume: *volume, Created: created})
+	}
+
 	volume, err := v.backend.VolumeCreate(req.Name, req.Driver, req.DriverOpts, req.Labels)
 	if err != nil {
 		return err
@@ -5,6 +5,8 @@ import (
 	"net"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/api/server/httputils"
@@ -27,6 +29,16 @@ type Config struct {
 	Version                  string
 	SocketGroup              string
 	TLSConfig                *tls.Config
+	// AccessLog configures the access log of the requests, nil disables
+	// it.
+	AccessLog *AccessLogConfig
+	// ReadTimeout and WriteTimeout are the maximum durations of reading a
+	// request and writing its response, except for the long running
+	// routes. IdleTimeout is how long an idle keep-alive connection is
+	// kept open. Zero means no timeout.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
 }
 
 // Server contains instance details for the server
@@ -36,13 +48,17 @@ type Server struct {
 	routers       []router.Router
 	authZPlugins  []authorization.Plugin
 	routerSwapper *routerSwapper
+	accessLogMu   sync.Mutex
+	connsMu       sync.Mutex
+	conns         map[string]*apiConn
 }
 
 // New returns a new instance of the server based on the specified configuration.
 // It allocates resources which will be needed for ServeAPI(ports, unix-sockets).
 func New(cfg *Config) *Server {
 	return &Server{
-		cfg: cfg,
+		cfg:   cfg,
+		conns: make(map[string]*apiConn),
 	}
 }
 
@@ -55,6 +71,12 @@ func (s *Server) Accept(addr string, listeners ...net.Listener) {
 			},
 			l: listener,
 		}
+		if s.hasTimeouts() {
+			httpServer.srv.ConnState = s.connState
+			if !strings.HasPrefix(listener.Addr().Network(), "tcp") {
+				httpServer.l = &numberedListener{Listener: listener}
+			}
+		}
 		s.servers = append(s.servers, httpServer)
 	}
 }
@@ -75,6 +97,9 @@ func (s *Server) serveAPI() error {
 	//每一个httpServer都用一个goroutine进行启动
 	for _, srv := range s.servers {
 		srv.srv.Handler = s.routerSwapper
+		if s.cfg.AccessLog != nil {
+			srv.srv.Handler = s.newAccessLogHandler(srv.l.Addr().String(), s.routerSwapper)
+		}
 		go func(srv *HTTPServer) {
 			var err error
 			logrus.Infof("API listen on %s", srv.l.Addr())
@@ -148,11 +173,25 @@ func (s *Server) InitRouter(enableProfiler bool, routers ...router.Router) {
 	if enableProfiler {
 		profilerSetup(m)
 	}
+	// the servers may already serve the routers initialized before
+	if s.routerSwapper != nil {
+		s.routerSwapper.Swap(m)
+		return
+	}
 	s.routerSwapper = &routerSwapper{
 		router: m,
 	}
 }
 
+// Routes returns all the API routes registered in the server.
+func (s *Server) Routes() []router.Route {
+	var routes []router.Route
+	for _, apiRouter := range s.routers {
+		routes = append(routes, apiRouter.Routes()...)
+	}
+	return routes
+}
+
 // createMux initializes the main router the server uses.
 func (s *Server) createMux() *mux.Router {
 	m := mux.NewRouter()
@@ -160,7 +199,13 @@ func (s *Server) createMux() *mux.Router {
 	logrus.Debugf("Registering routers")
 	for _, apiRouter := range s.routers {
 		for _, r := range apiRouter.Routes() {
-			f := s.makeHTTPHandler(r.Handler())
+			var f http.Handler = s.makeHTTPHandler(r.Handler())
+			if _, ok := r.(router.LongRunningRoute); ok && s.hasTimeouts() {
+				f = s.withoutTimeouts(f)
+			}
+			if s.cfg.AccessLog != nil {
+				f = accessLogRoute(r.Path(), f)
+			}
 
 			logrus.Debugf("Registering %s, %s", r.Method(), r.Path())
 			m.Path(versionMatcher + r.Path()).Methods(r.Method()).Handler(f)
@@ -1,11 +1,19 @@
 package server
 
 import (
+	"bufio"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/docker/docker/api/server/httputils"
+	"github.com/docker/docker/api/server/router"
 
 	"golang.org/x/net/context"
 )
@@ -32,3 +40,157 @@ func TestMiddlewares(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+type testRouter struct {
+	routes []router.Route
+}
+
+func (r testRouter) Routes() []router.Route {
+	return r.routes
+}
+
+func TestRoutes(t *testing.T) {
+	srv := &Server{
+		cfg: &Config{},
+	}
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		return nil
+	}
+	srv.InitRouter(false,
+		testRouter{[]router.Route{router.NewGetRoute("/containers/json", handler)}},
+		testRouter{[]router.Route{router.NewPostRoute("/networks/create", handler), router.NewDeleteRoute("/networks/{id:.*}", handler)}},
+	)
+
+	routes := srv.Routes()
+	if len(routes) != 3 {
+		t.Fatalf("Expected 3 routes, got %d", len(routes))
+	}
+	if routes[1].Method() != "POST" || routes[1].Path() != "/networks/create" {
+		t.Fatalf("Expected POST /networks/create, got %s %s", routes[1].Method(), routes[1].Path())
+	}
+}
+
+// serveTest serves the routes on a local listener, returning its address.
+func serveTest(t *testing.T, cfg *Config, routes ...router.Route) (*Server, string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := New(cfg)
+	srv.Accept("127.0.0.1", l)
+	srv.InitRouter(false, testRouter{routes})
+	go srv.serveAPI()
+	return srv, l.Addr().String()
+}
+
+func TestIdleConnectionsClosed(t *testing.T) {
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		return nil
+	}
+	srv, addr := serveTest(t, &Config{IdleTimeout: 100 * time.Millisecond}, router.NewGetRoute("/_ping", handler))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("GET /_ping HTTP/1.1\r\nHost: docker\r\n\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// the connection is kept alive, then closed once idle
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	start := time.Now()
+	if _, err := br.ReadByte(); err == nil {
+		t.Fatal("Expected the idle connection to be closed")
+	} else if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+		t.Fatal("Expected the idle connection to be closed by the server")
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Fatalf("Expected the idle connection to be closed after the idle timeout, took %v", d)
+	}
+}
+
+func TestLongRunningRoutesWithoutTimeouts(t *testing.T) {
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		time.Sleep(300 * time.Millisecond)
+		w.Write([]byte("done"))
+		return nil
+	}
+	srv, addr := serveTest(t, &Config{WriteTimeout: 100 * time.Millisecond},
+		router.LongRunning(router.NewGetRoute("/events", handler)),
+		router.NewGetRoute("/containers/json", handler),
+	)
+	defer srv.Close()
+
+	resp, err := http.Get("http://" + addr + "/v1.23/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || string(b) != "done" {
+		t.Fatalf("Expected a long running route not to time out, got %q: %v", b, err)
+	}
+
+	resp, err = http.Get("http://" + addr + "/containers/json")
+	if err == nil {
+		b, err = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	if err == nil {
+		t.Fatalf("Expected the write timeout to abort the response, got %q", b)
+	}
+}
+
+func TestLongRunningRoutesWithoutTimeoutsOnUnixSocket(t *testing.T) {
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		time.Sleep(300 * time.Millisecond)
+		w.Write([]byte(r.RemoteAddr))
+		return nil
+	}
+	tmp, err := ioutil.TempDir("", "api-server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	sock := filepath.Join(tmp, "docker.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := New(&Config{WriteTimeout: 100 * time.Millisecond})
+	srv.Accept(sock, l)
+	srv.InitRouter(false, testRouter{[]router.Route{router.LongRunning(router.NewGetRoute("/events", handler))}})
+	go srv.serveAPI()
+	defer srv.Close()
+
+	// the unnamed clients of the socket are told apart
+	addrs := make(chan string, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			client := &http.Client{Transport: &http.Transport{
+				Dial: func(string, string) (net.Conn, error) { return net.Dial("unix", sock) },
+			}}
+			resp, err := client.Get("http://docker/events")
+			if err != nil {
+				addrs <- err.Error()
+				return
+			}
+			b, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			addrs <- string(b)
+		}()
+	}
+	first, second := <-addrs, <-addrs
+	if !strings.HasPrefix(first, "@") || !strings.HasPrefix(second, "@") || first == second {
+		t.Fatalf("Expected a long running route not to time out on distinct connections, got %q and %q", first, second)
+	}
+}
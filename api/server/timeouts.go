@@ -0,0 +1,148 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// hasTimeouts returns whether the API server has a read, write or idle
+// timeout.
+func (s *Server) hasTimeouts() bool {
+	return s.cfg.ReadTimeout > 0 || s.cfg.WriteTimeout > 0 || s.cfg.IdleTimeout > 0
+}
+
+// apiConn is a connection to the API server, with the timer closing it
+// when it waits too long for a request.
+type apiConn struct {
+	net.Conn
+	waiting *time.Timer
+}
+
+// wait closes the connection if it doesn't send a request within timeout,
+// zero for no timeout.
+func (c *apiConn) wait(timeout time.Duration) {
+	if c.waiting != nil {
+		c.waiting.Stop()
+		c.waiting = nil
+	}
+	if timeout > 0 {
+		c.waiting = time.AfterFunc(timeout, func() { c.Close() })
+	}
+}
+
+// connState applies the timeouts of the API server to the connection c as
+// its state changes: a new connection is closed if it doesn't send a
+// request within the read timeout, and an idle one within the idle timeout,
+// or else the read timeout. Once the headers of a request are read, the
+// read and write timeouts are set as deadlines on the connection. The
+// connections are tracked by their remote address, for the long running
+// routes to lift their deadlines.
+func (s *Server) connState(c net.Conn, state http.ConnState) {
+	addr := c.RemoteAddr().String()
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+
+	ac, ok := s.conns[addr]
+	if !ok || ac.Conn != c {
+		if state != http.StateNew {
+			return
+		}
+		ac = &apiConn{Conn: c}
+		s.conns[addr] = ac
+	}
+
+	switch state {
+	case http.StateNew:
+		ac.wait(s.cfg.ReadTimeout)
+	case http.StateActive:
+		ac.wait(0)
+		now := time.Now()
+		c.SetReadDeadline(deadline(now, s.cfg.ReadTimeout))
+		c.SetWriteDeadline(deadline(now, s.cfg.WriteTimeout))
+	case http.StateIdle:
+		c.SetReadDeadline(time.Time{})
+		c.SetWriteDeadline(time.Time{})
+		if s.cfg.IdleTimeout > 0 {
+			ac.wait(s.cfg.IdleTimeout)
+		} else {
+			ac.wait(s.cfg.ReadTimeout)
+		}
+	case http.StateHijacked, http.StateClosed:
+		ac.wait(0)
+		delete(s.conns, addr)
+	}
+}
+
+// deadline returns the deadline of timeout from now, or no deadline if
+// timeout is zero.
+func deadline(now time.Time, timeout time.Duration) time.Time {
+	if timeout == 0 {
+		return time.Time{}
+	}
+	return now.Add(timeout)
+}
+
+// withoutTimeouts lifts the read and write deadlines set on the connection
+// by the read and write timeouts of the API server, for a long running
+// route.
+func (s *Server) withoutTimeouts(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.connsMu.Lock()
+		c, ok := s.conns[r.RemoteAddr]
+		s.connsMu.Unlock()
+		if !ok {
+			logrus.Debugf("Cannot lift the timeouts of %s %s: unknown connection from %s", r.Method, r.URL.Path, r.RemoteAddr)
+		} else {
+			c.SetReadDeadline(time.Time{})
+			c.SetWriteDeadline(time.Time{})
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// numberedListener numbers the connections it accepts, for a unix socket or
+// a named pipe whose clients all have the same remote address, so that their
+// remote addresses tell them apart for the timeouts of the API server.
+type numberedListener struct {
+	net.Listener
+	conns uint64
+}
+
+func (l *numberedListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	addr := numberedAddr{network: l.Addr().Network(), n: atomic.AddUint64(&l.conns, 1)}
+	return &numberedConn{Conn: c, remoteAddr: addr}, nil
+}
+
+// numberedAddr is the remote address of the connection n accepted by a
+// numberedListener.
+type numberedAddr struct {
+	network string
+	n       uint64
+}
+
+func (a numberedAddr) Network() string {
+	return a.network
+}
+
+func (a numberedAddr) String() string {
+	return fmt.Sprintf("@%d", a.n)
+}
+
+// numberedConn is a connection accepted by a numberedListener.
+type numberedConn struct {
+	net.Conn
+	remoteAddr net.Addr
+}
+
+func (c *numberedConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
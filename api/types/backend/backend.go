@@ -5,6 +5,7 @@ package backend
 
 import (
 	"io"
+	"time"
 
 	"github.com/docker/engine-api/types"
 )
@@ -34,6 +35,52 @@ type ContainerLogsConfig struct {
 	Stop      <-chan bool
 }
 
+// ContainerCreateConfig is the parameter set to ContainerCreate(), with the
+// options of the daemon the engine API has no field for.
+type ContainerCreateConfig struct {
+	types.ContainerCreateConfig
+	// DeferRWLayer defers the creation of the writable layer of the
+	// container until it is needed, at the latest when it first starts.
+	DeferRWLayer bool
+	// ReplaceExisting removes the stopped container which already has the
+	// name of the container, if any, before creating it.
+	ReplaceExisting bool
+}
+
+// ContainerCreateResponse is the response to the creation of a container,
+// with the details the daemon reports besides the engine API response.
+type ContainerCreateResponse struct {
+	types.ContainerCreateResponse
+	// WarningDetails are the same warnings along with their category.
+	WarningDetails []ContainerWarning `json:",omitempty"`
+	// Security is the effective security settings the container runs with,
+	// on the platforms supporting capabilities and seccomp.
+	Security *ContainerSecurity `json:",omitempty"`
+}
+
+// ContainerWarning is a warning encountered during the creation of a
+// container, with the category it belongs to, e.g. "resource".
+type ContainerWarning struct {
+	Category string
+	Message  string
+}
+
+// ContainerSecurity is the effective security settings of a container,
+// after applying its security options to the defaults of the daemon.
+type ContainerSecurity struct {
+	// Privileged is true if the container runs privileged.
+	Privileged bool
+	// Capabilities are the capabilities the container runs with.
+	Capabilities []string
+	// CapabilitiesAdded and CapabilitiesDropped are the differences
+	// between Capabilities and the default capabilities.
+	CapabilitiesAdded   []string
+	CapabilitiesDropped []string
+	// Seccomp is the seccomp profile of the container: "default",
+	// "unconfined" or "custom".
+	Seccomp string
+}
+
 // ContainerStatsConfig holds information for configuring the runtime
 // behavior of a backend.ContainerStats() call.
 type ContainerStatsConfig struct {
@@ -67,3 +114,43 @@ type ExecProcessConfig struct {
 	Privileged *bool    `json:"privileged,omitempty"`
 	User       string   `json:"user,omitempty"`
 }
+
+// LayerMount holds the reference counts of a read-write layer, used to
+// debug leaked mounts.
+type LayerMount struct {
+	Name       string
+	MountID    string
+	Container  string // ID of the container using the layer, empty if none does
+	References int
+	Mounts     int
+}
+
+// ReloadStatus is the result of the most recent reload of the daemon
+// configuration.
+type ReloadStatus struct {
+	Time    time.Time
+	Success bool
+	Error   string `json:",omitempty"`
+	// Changed holds the keys of the configuration file whose value changed
+	// since the configuration was last applied, sorted.
+	Changed []string
+}
+
+// FreezeResult is the result of pausing, or unpausing, all the containers.
+type FreezeResult struct {
+	// Containers holds the IDs of the containers paused, or unpaused,
+	// sorted.
+	Containers []string
+	// Errors holds the errors of the containers which could not be paused,
+	// or unpaused, by ID.
+	Errors map[string]string `json:",omitempty"`
+}
+
+// VolumeCreateResult is the result of creating a volume, or getting the
+// existing one with the same name.
+type VolumeCreateResult struct {
+	types.Volume
+	// Created is true if the volume was created, false if the existing
+	// volume was returned.
+	Created bool
+}
@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types/backend"
 	"github.com/docker/docker/builder"
 	"github.com/docker/docker/builder/dockerfile/parser"
 	"github.com/docker/docker/pkg/archive"
@@ -185,7 +186,9 @@ func (b *Builder) runContextCommand(args []string, allowRemote bool, allowLocalD
 		return nil
 	}
 
-	container, err := b.docker.ContainerCreate(types.ContainerCreateConfig{Config: b.runConfig})
+	container, err := b.docker.ContainerCreate(b.clientCtx, backend.ContainerCreateConfig{
+		ContainerCreateConfig: types.ContainerCreateConfig{Config: b.runConfig},
+	})
 	if err != nil {
 		return err
 	}
@@ -515,9 +518,11 @@ func (b *Builder) create() (string, error) {
 	config := *b.runConfig
 
 	// Create the container
-	c, err := b.docker.ContainerCreate(types.ContainerCreateConfig{
-		Config:     b.runConfig,
-		HostConfig: hostConfig,
+	c, err := b.docker.ContainerCreate(b.clientCtx, backend.ContainerCreateConfig{
+		ContainerCreateConfig: types.ContainerCreateConfig{
+			Config:     b.runConfig,
+			HostConfig: hostConfig,
+		},
 	})
 	if err != nil {
 		return "", err
@@ -555,7 +560,7 @@ func (b *Builder) run(cID string) (err error) {
 		}
 	}()
 
-	if err := b.docker.ContainerStart(cID, nil); err != nil {
+	if err := b.docker.ContainerStart(b.clientCtx, cID, nil, false); err != nil {
 		return err
 	}
 
@@ -9,12 +9,16 @@ import (
 	"strings"
 
 	flag "github.com/docker/docker/pkg/mflag"
+	"golang.org/x/net/context"
 )
 
 // Cli represents a command line interface.
 type Cli struct {
 	Stderr   io.Writer
 	handlers []Handler
+	// commands holds the commands described by the handlers registered
+	// with RegisterHandler, by name.
+	commands map[string]Command
 	Usage    func()
 }
 
@@ -29,6 +33,43 @@ type Initializer interface {
 	Initialize() error
 }
 
+// CommandDescriber can be optionally implemented by a Handler passed to
+// RegisterHandler to list its commands in the usage output.
+type CommandDescriber interface {
+	Commands() []Command
+}
+
+// ContextSetter can be optionally implemented by a Handler to receive the
+// context of each of its commands before it runs. The context carries the
+// sink the command reports its result to with SetResult.
+type ContextSetter interface {
+	SetContext(ctx context.Context)
+}
+
+// Result is the structured result of a command run with RunWithResult, such
+// as the *types.ContainerCreateResponse of "docker create", holding the ID of
+// the created container. It is nil for the commands which don't report one.
+type Result interface{}
+
+type resultSinkKey struct{}
+
+// resultSink holds the result reported by the command run with
+// RunWithResult.
+type resultSink struct {
+	result Result
+}
+
+// SetResult reports result as the result of the command running with ctx.
+// It does nothing when the command wasn't run with RunWithResult.
+func SetResult(ctx context.Context, result Result) {
+	if ctx == nil {
+		return
+	}
+	if sink, ok := ctx.Value(resultSinkKey{}).(*resultSink); ok {
+		sink.result = result
+	}
+}
+
 // New instantiates a ready-to-use Cli.
 func New(handlers ...Handler) *Cli {
 	// make the generic Cli object the first cli handler
@@ -38,28 +79,133 @@ func New(handlers ...Handler) *Cli {
 	return cli
 }
 
+// RegisterHandler adds a handler after the ones the Cli was created with,
+// so commands can be added at runtime. It returns an error if one of the
+// `Cmd` methods of the handler is already provided by another handler, or
+// if one of the commands described by a handler implementing
+// CommandDescriber is already described. The described commands are listed
+// by Commands.
+func (cli *Cli) RegisterHandler(h Handler) error {
+	if h == nil {
+		return errors.New("cannot register a nil handler")
+	}
+	t := reflect.TypeOf(h)
+	for i := 0; i < t.NumMethod(); i++ {
+		name := t.Method(i).Name
+		if !strings.HasPrefix(name, "Cmd") {
+			continue
+		}
+		for _, c := range cli.handlers {
+			if c == nil {
+				continue
+			}
+			if reflect.ValueOf(c).MethodByName(name).IsValid() {
+				return fmt.Errorf("a handler for %s is already registered", name)
+			}
+		}
+	}
+
+	var commands []Command
+	if d, ok := h.(CommandDescriber); ok {
+		commands = d.Commands()
+	}
+	names := make(map[string]bool)
+	for _, cmd := range commands {
+		_, builtin := DockerCommands[cmd.Name]
+		_, registered := cli.commands[cmd.Name]
+		if builtin || registered || names[cmd.Name] {
+			return fmt.Errorf("command %s is already registered", cmd.Name)
+		}
+		names[cmd.Name] = true
+	}
+
+	cli.handlers = append(cli.handlers, h)
+	if len(commands) > 0 && cli.commands == nil {
+		cli.commands = make(map[string]Command)
+	}
+	for _, cmd := range commands {
+		cli.commands[cmd.Name] = cmd
+	}
+	return nil
+}
+
+// Commands returns the commands of DockerCommands and the ones described by
+// the handlers registered with RegisterHandler, in no particular order.
+func (cli *Cli) Commands() []Command {
+	commands := make([]Command, 0, len(DockerCommands)+len(cli.commands))
+	for _, cmd := range DockerCommands {
+		commands = append(commands, cmd)
+	}
+	for _, cmd := range cli.commands {
+		commands = append(commands, cmd)
+	}
+	return commands
+}
+
+// Handlers returns the handlers of the Cli, in the order they are
+// looked up. The first one is always the Cli itself.
+func (cli *Cli) Handlers() []Handler {
+	handlers := make([]Handler, len(cli.handlers))
+	copy(handlers, cli.handlers)
+	return handlers
+}
+
 // initErr is an error returned upon initialization of a handler implementing Initializer.
 type initErr struct{ error }
 
 func (err initErr) Error() string {
-	return err.Error()
+	return err.error.Error()
 }
 
-//该函数比较关键，会通过反射机制运行参数对应的函数。
+var (
+	// ErrCommandNotFound is returned when no handler implements a command.
+	ErrCommandNotFound = errors.New("command not found")
+	// ErrEmptyCommand is returned when a command or subcommand name is empty.
+	ErrEmptyCommand = errors.New("empty command")
+)
+
 func (cli *Cli) command(args ...string) (func(...string) error, error) {
+	return cli.commandContext(context.Background(), args...)
+}
+
+//该函数比较关键，会通过反射机制运行参数对应的函数。
+func (cli *Cli) commandContext(ctx context.Context, args ...string) (func(...string) error, error) {
+	exactArgs := make([]string, len(args))
+	camelArgs := make([]string, len(args))
+	for i, s := range args {
+		if len(s) == 0 {
+			return nil, ErrEmptyCommand
+		}
+		exactArgs[i] = strings.ToUpper(s[:1]) + s[1:]
+		camelArgs[i] = strings.ToUpper(s[:1]) + strings.ToLower(s[1:])
+	}
+
+	//获取方法的名称，根据传入的参数和“Cmd”合并而来
+	command, err := cli.lookupCommand(ctx, "Cmd"+strings.Join(exactArgs, ""))
+	if err != ErrCommandNotFound {
+		return command, err
+	}
+
+	// Fall back to matching the command regardless of case, this
+	// is kept for backward compatibility.
+	command, err = cli.lookupCommand(ctx, "Cmd"+strings.Join(camelArgs, ""))
+	if err == nil {
+		if cli.Stderr == nil {
+			cli.Stderr = os.Stderr
+		}
+		fmt.Fprintf(cli.Stderr, "Warning: '%s' does not match the case of a docker command, use '%s' instead. Case-insensitive commands are deprecated.\n", strings.Join(args, " "), strings.ToLower(strings.Join(args, " ")))
+	}
+	return command, err
+}
+
+// lookupCommand returns the method called methodName of the first
+// handler implementing it, after passing ctx to the handler if it
+// implements ContextSetter.
+func (cli *Cli) lookupCommand(ctx context.Context, methodName string) (func(...string) error, error) {
 	for _, c := range cli.handlers {
 		if c == nil {
 			continue
 		}
-		camelArgs := make([]string, len(args))
-		for i, s := range args {
-			if len(s) == 0 {
-				return nil, errors.New("empty command")
-			}
-			camelArgs[i] = strings.ToUpper(s[:1]) + strings.ToLower(s[1:])
-		}
-		//获取方法的名称，根据传入的参数和“Cmd”合并而来
-		methodName := "Cmd" + strings.Join(camelArgs, "")
 		//通过reflect包的反射函数获取方法的句柄。
 		method := reflect.ValueOf(c).MethodByName(methodName)
 		if method.IsValid() {
@@ -69,20 +215,38 @@ func (cli *Cli) command(args ...string) (func(...string) error, error) {
 					return nil, initErr{err}
 				}
 			}
+			if c, ok := c.(ContextSetter); ok {
+				c.SetContext(ctx)
+			}
 			//运行对应的方法。
 			//client模式下的对应方法在api/client/包中，每一个函数都是Cmd开头的方法；
 			//daemon模式下的对应方法在docker/daemon.go中，CmdDaemon函数。
 			return method.Interface().(func(...string) error), nil
 		}
 	}
-	return nil, errors.New("command not found")
+	return nil, ErrCommandNotFound
 }
 
 // Run executes the specified command.
 // 该函数还会调用上面的command函数
 func (cli *Cli) Run(args ...string) error {
+	_, err := cli.RunWithResult(args...)
+	return err
+}
+
+// RunWithResult executes the specified command like Run, and returns the
+// result the command reported with SetResult, or nil if it reported none.
+// This lets the programs embedding the Cli get, for example, the ID of the
+// container created by "create" without parsing its output.
+func (cli *Cli) RunWithResult(args ...string) (Result, error) {
+	sink := &resultSink{}
+	err := cli.run(context.WithValue(context.Background(), resultSinkKey{}, sink), args...)
+	return sink.result, err
+}
+
+func (cli *Cli) run(ctx context.Context, args ...string) error {
 	if len(args) > 1 {
-		command, err := cli.command(args[:2]...)
+		command, err := cli.commandContext(ctx, args[:2]...)
 		switch err := err.(type) {
 		case nil:
 			return command(args[2:]...)
@@ -91,13 +255,16 @@ func (cli *Cli) Run(args ...string) error {
 		}
 	}
 	if len(args) > 0 {
-		command, err := cli.command(args[0])
+		command, err := cli.commandContext(ctx, args[0])
 		switch err := err.(type) {
 		case nil:
 			return command(args[1:]...)
 		case initErr:
 			return err.error
 		}
+		if err == ErrEmptyCommand {
+			return err
+		}
 		cli.noSuchCommand(args[0])
 	}
 	return cli.CmdHelp()
@@ -136,6 +303,9 @@ func (cli *Cli) CmdHelp(args ...string) error {
 		case initErr:
 			return err.error
 		}
+		if err == ErrEmptyCommand {
+			return err
+		}
 		cli.noSuchCommand(args[0])
 	}
 
@@ -194,15 +364,34 @@ func Subcmd(name string, synopses []string, description string, exitOnError bool
 		fmt.Fprintf(flags.Out(), "\n\n%s\n", description)
 	}
 
+	recordSubcmd(name, synopses, flags)
 	return flags
 }
 
+// Machine-readable codes of a StatusError.
+const (
+	CodeImageNotFound        = "image_not_found"
+	CodeContainerNotFound    = "container_not_found"
+	CodeNetworkNotFound      = "network_not_found"
+	CodeVolumeNotFound       = "volume_not_found"
+	CodeUnauthorized         = "unauthorized"
+	CodeConflict             = "conflict"
+	CodeCommandNotFound      = "command_not_found"
+	CodeCommandNotExecutable = "command_not_executable"
+)
+
 // An StatusError reports an unsuccessful exit by a command.
 type StatusError struct {
 	Status     string
 	StatusCode int
+	// Code is a stable, machine-readable reason for the error, such as
+	// CodeImageNotFound. It is empty when the reason is unknown.
+	Code string
 }
 
 func (e StatusError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("Status: %s, Code: %d, Reason: %s", e.Status, e.StatusCode, e.Code)
+	}
 	return fmt.Sprintf("Status: %s, Code: %d", e.Status, e.StatusCode)
 }
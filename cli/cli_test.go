@@ -0,0 +1,323 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	flag "github.com/docker/docker/pkg/mflag"
+	"golang.org/x/net/context"
+)
+
+type testHandler struct {
+	called string
+}
+
+func (h *testHandler) CmdDaemon(args ...string) error {
+	h.called = "daemon"
+	return nil
+}
+
+func (h *testHandler) CmdNetworkLs(args ...string) error {
+	h.called = "network ls"
+	return nil
+}
+
+func TestCommandExactMatch(t *testing.T) {
+	for _, args := range [][]string{{"daemon"}, {"network", "ls"}} {
+		h := &testHandler{}
+		stderr := &bytes.Buffer{}
+		cli := New(h)
+		cli.Stderr = stderr
+
+		command, err := cli.command(args...)
+		if err != nil {
+			t.Fatalf("Expected %v to match a command, got %v", args, err)
+		}
+		if err := command(); err != nil {
+			t.Fatal(err)
+		}
+		if h.called != strings.Join(args, " ") {
+			t.Fatalf("Expected %v to call %q, called %q", args, strings.Join(args, " "), h.called)
+		}
+		if stderr.Len() != 0 {
+			t.Fatalf("Expected no warning for %v, got %q", args, stderr.String())
+		}
+	}
+}
+
+func TestCommandCaseInsensitiveFallback(t *testing.T) {
+	for _, args := range [][]string{{"daeMON"}, {"NETWORK", "lS"}} {
+		h := &testHandler{}
+		stderr := &bytes.Buffer{}
+		cli := New(h)
+		cli.Stderr = stderr
+
+		command, err := cli.command(args...)
+		if err != nil {
+			t.Fatalf("Expected %v to match a command, got %v", args, err)
+		}
+		if err := command(); err != nil {
+			t.Fatal(err)
+		}
+		if h.called != strings.ToLower(strings.Join(args, " ")) {
+			t.Fatalf("Expected %v to call %q, called %q", args, strings.ToLower(strings.Join(args, " ")), h.called)
+		}
+		if !strings.Contains(stderr.String(), "Warning:") {
+			t.Fatalf("Expected a warning for %v, got %q", args, stderr.String())
+		}
+	}
+}
+
+func TestCommandNotFound(t *testing.T) {
+	cli := New(&testHandler{})
+	if _, err := cli.command("network", "rm"); err != ErrCommandNotFound {
+		t.Fatalf("Expected command not found, got %v", err)
+	}
+	if _, err := cli.command("network", ""); err != ErrEmptyCommand {
+		t.Fatalf("Expected empty command error, got %v", err)
+	}
+}
+
+func TestRunEmptyCommand(t *testing.T) {
+	cli := New(&testHandler{})
+	if err := cli.Run(""); err != ErrEmptyCommand {
+		t.Fatalf("Expected empty command error, got %v", err)
+	}
+	if err := cli.CmdHelp(""); err != ErrEmptyCommand {
+		t.Fatalf("Expected empty command error, got %v", err)
+	}
+}
+
+func TestInitErr(t *testing.T) {
+	err := initErr{errors.New("initialization failed")}
+	if err.Error() != "initialization failed" {
+		t.Fatalf("Expected %q, got %q", "initialization failed", err.Error())
+	}
+}
+
+type pluginHandler struct{}
+
+func (pluginHandler) CmdPluginRun(args ...string) error {
+	return nil
+}
+
+func (pluginHandler) Commands() []Command {
+	return []Command{{"plugin-run", "Run a plugin"}}
+}
+
+// runDescriber describes a command already in DockerCommands.
+type runDescriber struct{}
+
+func (runDescriber) CmdPluginStart(args ...string) error {
+	return nil
+}
+
+func (runDescriber) Commands() []Command {
+	return []Command{{"run", "Run a plugin"}}
+}
+
+// pluginDescriber describes the command of pluginHandler.
+type pluginDescriber struct{}
+
+func (pluginDescriber) CmdPluginStop(args ...string) error {
+	return nil
+}
+
+func (pluginDescriber) Commands() []Command {
+	return []Command{{"plugin-run", "Run a plugin"}}
+}
+
+type helpHandler struct{}
+
+func (helpHandler) CmdHelp(args ...string) error {
+	return nil
+}
+
+func TestRegisterHandler(t *testing.T) {
+	cli := New(&testHandler{})
+
+	if err := cli.RegisterHandler(pluginHandler{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.command("plugin", "run"); err != nil {
+		t.Fatalf("Expected the registered command to be found, got %v", err)
+	}
+	described := false
+	for _, cmd := range cli.Commands() {
+		if cmd.Name == "plugin-run" {
+			described = true
+		}
+	}
+	if !described {
+		t.Fatal("Expected the registered command to be described")
+	}
+	if _, ok := DockerCommands["plugin-run"]; ok {
+		t.Fatal("Expected the registered command not to be added to DockerCommands")
+	}
+	if len(New().Commands()) != len(DockerCommands) {
+		t.Fatal("Expected the registered command to be described by its Cli only")
+	}
+
+	handlers := cli.Handlers()
+	if len(handlers) != 3 {
+		t.Fatalf("Expected 3 handlers, got %d", len(handlers))
+	}
+	if handlers[0] != cli {
+		t.Fatal("Expected the Cli to be the first handler")
+	}
+}
+
+func TestRegisterHandlerDuplicateCommand(t *testing.T) {
+	cli := New(&testHandler{})
+
+	if err := cli.RegisterHandler(&testHandler{}); err == nil {
+		t.Fatal("Expected an error registering a handler with duplicate commands")
+	}
+	if err := cli.RegisterHandler(helpHandler{}); err == nil {
+		t.Fatal("Expected an error registering a handler overriding help")
+	}
+	if err := cli.RegisterHandler(runDescriber{}); err == nil {
+		t.Fatal("Expected an error registering a handler describing run")
+	}
+	if err := cli.RegisterHandler(pluginHandler{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.RegisterHandler(pluginDescriber{}); err == nil {
+		t.Fatal("Expected an error registering a handler describing plugin-run again")
+	}
+	if len(cli.Handlers()) != 3 {
+		t.Fatalf("Expected a single handler to be added, got %d", len(cli.Handlers()))
+	}
+}
+
+func TestStatusErrorMessage(t *testing.T) {
+	err := StatusError{Status: "not found", StatusCode: 1}
+	if expected := "Status: not found, Code: 1"; err.Error() != expected {
+		t.Fatalf("expected %q, got %q", expected, err.Error())
+	}
+	err.Code = CodeImageNotFound
+	if expected := "Status: not found, Code: 1, Reason: image_not_found"; err.Error() != expected {
+		t.Fatalf("expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestValidateSubcmd(t *testing.T) {
+	cases := []struct {
+		synopses []string
+		setup    func(*flag.FlagSet)
+		warnings []string
+	}{
+		{
+			synopses: []string{"CONTAINER [CONTAINER...]"},
+			setup: func(fs *flag.FlagSet) {
+				fs.Bool([]string{"f", "-force"}, false, "")
+				fs.Require(flag.Min, 1)
+			},
+		},
+		{
+			synopses: []string{"PATH | URL | -"},
+			setup:    func(fs *flag.FlagSet) { fs.Require(flag.Exact, 1) },
+		},
+		{
+			synopses: []string{"CONTAINER [ps OPTIONS]"},
+			setup:    func(fs *flag.FlagSet) { fs.Require(flag.Min, 1) },
+		},
+		{
+			synopses: []string{"[--force] CONTAINER"},
+			setup:    func(fs *flag.FlagSet) { fs.Bool([]string{"-force"}, false, "") },
+		},
+		{
+			synopses: []string{"[OPTIONS] CONTAINER"},
+			setup:    func(fs *flag.FlagSet) { fs.Bool([]string{"f"}, false, "") },
+			warnings: []string{`docker test: synopsis "[OPTIONS] CONTAINER" repeats [OPTIONS], which the usage already shows`},
+		},
+		{
+			synopses: []string{"[-q] IMAGE"},
+			warnings: []string{`docker test: synopsis "[-q] IMAGE" mentions the unknown flag -q`},
+		},
+		{
+			synopses: []string{"[IMAGE]"},
+			setup:    func(fs *flag.FlagSet) { fs.Require(flag.Exact, 2) },
+			warnings: []string{"docker test: requires 2 arguments but its synopses only document 0"},
+		},
+	}
+
+	for _, c := range cases {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		if c.setup != nil {
+			c.setup(fs)
+		}
+		warnings := ValidateSubcmd("test", c.synopses, fs)
+		if strings.Join(warnings, "\n") != strings.Join(c.warnings, "\n") {
+			t.Fatalf("expected warnings %q for %q, got %q", c.warnings, c.synopses, warnings)
+		}
+	}
+}
+
+func TestStrictSubcmd(t *testing.T) {
+	StrictSubcmd = true
+	defer func() {
+		StrictSubcmd = false
+		subcmds = nil
+	}()
+
+	cmd := Subcmd("rename", []string{"OLD_NAME"}, "Rename a container", false)
+	cmd.Require(flag.Exact, 2)
+
+	warnings := SubcmdWarnings()
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "requires 2 arguments") {
+		t.Fatalf("expected a warning about the undocumented argument, got %q", warnings)
+	}
+}
+
+type resultHandler struct {
+	ctx context.Context
+}
+
+func (h *resultHandler) SetContext(ctx context.Context) {
+	h.ctx = ctx
+}
+
+func (h *resultHandler) CmdCreate(args ...string) error {
+	SetResult(h.ctx, "created "+strings.Join(args, " "))
+	return nil
+}
+
+func (h *resultHandler) CmdFail(args ...string) error {
+	SetResult(h.ctx, "partial")
+	return errors.New("failed")
+}
+
+func (h *resultHandler) CmdPs(args ...string) error {
+	return nil
+}
+
+func TestRunWithResult(t *testing.T) {
+	h := &resultHandler{}
+	cli := New(h)
+
+	result, err := cli.RunWithResult("create", "busybox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "created busybox" {
+		t.Fatalf("Expected the result of the command, got %v", result)
+	}
+
+	if result, err := cli.RunWithResult("ps"); err != nil || result != nil {
+		t.Fatalf("Expected no result for a command which doesn't report one, got %v, %v", result, err)
+	}
+
+	if result, err := cli.RunWithResult("fail"); err == nil || result != "partial" {
+		t.Fatalf("Expected the error and the result of the failed command, got %v, %v", result, err)
+	}
+
+	// Run discards the result, and SetResult without a sink does nothing
+	if err := cli.Run("create", "busybox"); err != nil {
+		t.Fatal(err)
+	}
+	SetResult(context.Background(), "ignored")
+	SetResult(nil, "ignored")
+}
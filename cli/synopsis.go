@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	flag "github.com/docker/docker/pkg/mflag"
+)
+
+// StrictSubcmd enables the recording of the subcommands created by Subcmd,
+// so that SubcmdWarnings can check their synopses against their flags. It
+// is meant to be enabled in tests, and doesn't change the output of the
+// subcommands.
+var StrictSubcmd bool
+
+type subcmd struct {
+	name     string
+	synopses []string
+	flags    *flag.FlagSet
+}
+
+var (
+	subcmdsMu sync.Mutex
+	subcmds   []subcmd
+)
+
+func recordSubcmd(name string, synopses []string, flags *flag.FlagSet) {
+	if !StrictSubcmd {
+		return
+	}
+	subcmdsMu.Lock()
+	subcmds = append(subcmds, subcmd{name, synopses, flags})
+	subcmdsMu.Unlock()
+}
+
+// SubcmdWarnings validates the synopses of the subcommands created since
+// StrictSubcmd was enabled, and returns the problems found.
+func SubcmdWarnings() []string {
+	subcmdsMu.Lock()
+	defer subcmdsMu.Unlock()
+
+	var warnings []string
+	for _, c := range subcmds {
+		warnings = append(warnings, ValidateSubcmd(c.name, c.synopses, c.flags)...)
+	}
+	return warnings
+}
+
+// ValidateSubcmd checks that the synopses of a subcommand match its flags
+// and argument requirements. It reports the flags mentioned in a synopsis
+// but not registered, an [OPTIONS] token repeating the one added by the
+// usage, and required arguments missing from all the synopses.
+func ValidateSubcmd(name string, synopses []string, flags *flag.FlagSet) []string {
+	var warnings []string
+	warn := func(format string, args ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf("docker %s: ", name)+fmt.Sprintf(format, args...))
+	}
+
+	documented := 0
+	for _, synopsis := range synopses {
+		required := 0
+		for _, arg := range synopsisArgs(synopsis) {
+			switch {
+			case arg == "[OPTIONS]":
+				if flags.FlagCountUndeprecated() > 0 {
+					warn("synopsis %q repeats [OPTIONS], which the usage already shows", synopsis)
+				}
+			case isFlagToken(arg):
+				if !hasFlag(flags, arg) {
+					warn("synopsis %q mentions the unknown flag %s", synopsis, strings.Trim(arg, "[]"))
+				}
+			case !strings.HasPrefix(arg, "["):
+				required++
+			}
+		}
+		if required > documented {
+			documented = required
+		}
+	}
+
+	if min := flags.MinArgs(); min > documented {
+		warn("requires %d arguments but its synopses only document %d", min, documented)
+	}
+	return warnings
+}
+
+// synopsisArgs splits a synopsis into its arguments, keeping together the
+// optional groups containing spaces, such as "[ps OPTIONS]", and the
+// alternatives separated by " | ".
+func synopsisArgs(synopsis string) []string {
+	var args []string
+	join := false
+	depth := 0
+	for _, f := range strings.Fields(synopsis) {
+		switch {
+		case f == "|":
+			join = true
+			f = ""
+		case (join || depth > 0) && len(args) > 0:
+			sep := " "
+			if join {
+				sep = "|"
+			}
+			args[len(args)-1] += sep + f
+			join = false
+		default:
+			args = append(args, f)
+		}
+		depth += strings.Count(f, "[") - strings.Count(f, "]")
+	}
+	return args
+}
+
+func isFlagToken(arg string) bool {
+	arg = strings.TrimPrefix(arg, "[")
+	return strings.HasPrefix(arg, "-") && arg != "-" && !strings.Contains(arg, "|")
+}
+
+// hasFlag reports whether a flag token such as "-f", "--force" or
+// "[--format=FORMAT]" names a registered flag.
+func hasFlag(flags *flag.FlagSet, arg string) bool {
+	arg = strings.Trim(arg, "[]")
+	if i := strings.IndexAny(arg, "= "); i >= 0 {
+		arg = arg[:i]
+	}
+	// mflag stores the long flags with a single leading dash
+	name := strings.TrimPrefix(arg, "-")
+	return flags.Lookup(name) != nil
+}
@@ -72,12 +72,27 @@ type CommonContainer struct {
 	RestartCount           int
 	HasBeenStartedBefore   bool
 	HasBeenManuallyStopped bool // used for unless-stopped restart policy
-	MountPoints            map[string]*volume.MountPoint
-	HostConfig             *containertypes.HostConfig `json:"-"` // do not serialize the host config in the json, otherwise we'll make the container unportable
-	ExecCommands           *exec.Store                `json:"-"`
+	// DeferredRWLayer is set for a container created with DeferRWLayer
+	// until it first starts. Its writable layer, RWLayer, is only created
+	// when it is first mounted, and the settings needing it are set up on
+	// start.
+	DeferredRWLayer bool
+	MountPoints     map[string]*volume.MountPoint
+	HostConfig      *containertypes.HostConfig `json:"-"` // do not serialize the host config in the json, otherwise we'll make the container unportable
+	ExecCommands    *exec.Store                `json:"-"`
 	// logDriver for closing
 	LogDriver      logger.Logger  `json:"-"`
 	LogCopier      *logger.Copier `json:"-"`
+	StartRequestID string         `json:"-"` // ID of the API request starting the container
+	// NetworkPending is set while the network of a container started with
+	// lazy networking is not set up yet. It is saved with the container, for
+	// a restored container not to release a network it never set up.
+	// NetworkMu serializes setting up and releasing that network.
+	NetworkPending bool
+	NetworkMu      sync.Mutex `json:"-"`
+	// LogBuffer retains the last messages logged by the container when the
+	// daemon sets a log buffer size. It is kept across restarts.
+	LogBuffer      *logger.RingBuffer `json:"-"`
 	restartManager restartmanager.RestartManager
 	attachContext  *attachContext
 }
@@ -574,6 +589,15 @@ func (container *Container) StopSignal() int {
 	return int(stopSignal)
 }
 
+// StopTimeout returns the number of seconds to wait for the container to
+// stop before killing it, set with the StopTimeoutLabel, or defaultTimeout
+// if the container doesn't set it.
+func (container *Container) StopTimeout(defaultTimeout int) int {
+	// the label is validated when the container is created
+	timeout, _ := runconfig.StopTimeoutFromLabels(container.Config.Labels, defaultTimeout)
+	return timeout
+}
+
 // InitDNSHostConfig ensures that the dns fields are never nil.
 // New containers don't ever have those fields nil,
 // but pre created containers can still have those nil values.
@@ -913,7 +937,14 @@ func (container *Container) RestartManager(reset bool) restartmanager.RestartMan
 		container.restartManager = nil
 	}
 	if container.restartManager == nil {
-		container.restartManager = restartmanager.New(container.HostConfig.RestartPolicy)
+		// the restart policy of the engine API has no field for the backoff,
+		// which is carried in the labels, verified when the container was
+		// created
+		base, max, err := runconfig.RestartBackoffFromLabels(container.Config.Labels)
+		if err != nil {
+			logrus.Warnf("%s: %v, using the default restart backoff", container.ID, err)
+		}
+		container.restartManager = restartmanager.NewWithBackoff(container.HostConfig.RestartPolicy, restartmanager.Backoff{Base: base, Max: max})
 	}
 	return container.restartManager
 }
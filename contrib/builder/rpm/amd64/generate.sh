@@ -67,6 +67,7 @@ for version in "${versions[@]}"; do
 	# this list is sorted alphabetically; please keep it that way
 	packages=(
 		btrfs-progs-devel # for "btrfs/ioctl.h" (and "version.h" if possible)
+		cmake # for building tini, the init binary
 		device-mapper-devel # for "libdevmapper.h"
 		glibc-static
 		libseccomp-devel # for "seccomp.h" & "libseccomp.so"
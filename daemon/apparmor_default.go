@@ -3,14 +3,18 @@
 package daemon
 
 import (
+	"fmt"
+
 	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/aaparser"
 	aaprofile "github.com/docker/docker/profiles/apparmor"
 	"github.com/opencontainers/runc/libcontainer/apparmor"
 )
 
 // Define constants for native driver
 const (
-	defaultApparmorProfile = "docker-default"
+	defaultApparmorProfile    = "docker-default"
+	unconfinedApparmorProfile = "unconfined"
 )
 
 func installDefaultAppArmorProfile() {
@@ -28,3 +32,60 @@ func installDefaultAppArmorProfile() {
 		}
 	}
 }
+
+// validateAppArmorProfileFile checks that the AppArmor profile file at path,
+// given instead of the name of a loaded profile, declares a profile. The
+// profile is also checked by apparmor_parser when AppArmor is enabled.
+func validateAppArmorProfileFile(path string) error {
+	if _, err := appArmorProfileFileName(path); err != nil {
+		return err
+	}
+	if !apparmor.IsEnabled() {
+		return nil
+	}
+	return aaparser.ValidateProfile(path)
+}
+
+// appArmorProfileFileName returns the name of the profile declared by the
+// AppArmor profile file at path, which must not be a profile of the daemon.
+func appArmorProfileFileName(path string) (string, error) {
+	name, err := aaparser.ProfileName(path)
+	if err != nil {
+		return "", err
+	}
+	if name == defaultApparmorProfile || name == unconfinedApparmorProfile {
+		return "", fmt.Errorf("the profile name %s is reserved", name)
+	}
+	return name, nil
+}
+
+// loadAppArmorProfileFile loads the AppArmor profile file at path in the
+// kernel and returns the name of the profile it declares. The file is loaded
+// on every container start, so that changes to it are applied and that it is
+// loaded again after the host reboots. A profile which is already loaded is
+// only replaced when a container started before was given the same file, so
+// that the profiles of the host are never replaced.
+func (daemon *Daemon) loadAppArmorProfileFile(path string) (string, error) {
+	name, err := appArmorProfileFileName(path)
+	if err != nil {
+		return "", err
+	}
+	if aaprofile.IsLoaded(name) == nil && !daemon.appArmorProfileFileStarted(path) {
+		return "", fmt.Errorf("the profile %s is already loaded, and not from this file", name)
+	}
+	if err := aaparser.LoadProfile(path); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// appArmorProfileFileStarted returns whether a container was started with
+// the AppArmor profile file at path.
+func (daemon *Daemon) appArmorProfileFileStarted(path string) bool {
+	for _, c := range daemon.containers.List() {
+		if c.AppArmorProfile == path && !c.StartedAt.IsZero() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+//go:build linux
+// +build linux
+
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/container"
+)
+
+func TestAppArmorProfileFileName(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-apparmor-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	for content, expected := range map[string]string{
+		"profile custom flags=(attach_disconnected) {\n}\n": "",
+		"profile docker-default {\n}\n":                     "reserved",
+		"profile unconfined {\n}\n":                         "reserved",
+	} {
+		path := filepath.Join(tmp, "profile")
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := appArmorProfileFileName(path)
+		if expected == "" && err != nil {
+			t.Fatalf("Expected %q to be accepted, got %v", content, err)
+		}
+		if expected != "" && (err == nil || !strings.Contains(err.Error(), expected)) {
+			t.Fatalf("Expected %q to be rejected as %s, got %v", content, expected, err)
+		}
+	}
+}
+
+func TestAppArmorProfileFileStarted(t *testing.T) {
+	daemon := &Daemon{containers: container.NewMemoryStore()}
+	created := container.NewBaseContainer("created", "")
+	created.AppArmorProfile = "/etc/apparmor.d/custom"
+	daemon.containers.Add(created.ID, created)
+
+	if daemon.appArmorProfileFileStarted("/etc/apparmor.d/custom") {
+		t.Fatal("Expected a profile file of a container never started not to be trusted")
+	}
+
+	created.StartedAt = time.Now().UTC()
+	if !daemon.appArmorProfileFileStarted("/etc/apparmor.d/custom") {
+		t.Fatal("Expected the profile file of a started container to be trusted")
+	}
+	if daemon.appArmorProfileFileStarted("/etc/apparmor.d/other") {
+		t.Fatal("Expected another profile file not to be trusted")
+	}
+}
@@ -4,3 +4,7 @@ package daemon
 
 func installDefaultAppArmorProfile() {
 }
+
+func validateAppArmorProfileFile(path string) error {
+	return nil
+}
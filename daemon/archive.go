@@ -250,7 +250,7 @@ func (daemon *Daemon) containerExtractToDir(container *container.Container, path
 		return ErrRootFSReadOnly
 	}
 
-	uid, gid := daemon.GetRemappedUIDGID()
+	uid, gid := daemon.containerRootUIDGID(container)
 	options := &archive.TarOptions{
 		NoOverwriteDirNonDir: noOverwriteDirNonDir,
 		ChownOpts: &archive.TarChownOptions{
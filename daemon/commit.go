@@ -109,6 +109,12 @@ func (daemon *Daemon) Commit(name string, c *types.ContainerCommitConfig) (strin
 		return "", fmt.Errorf("Windows does not support commit of a running container")
 	}
 
+	// the files of the container are owned by its own ID maps, the layers
+	// of the images by the daemon's
+	if hasOwnIDMaps(container.Config) {
+		return "", fmt.Errorf("Cannot commit container %s, created with its own UID and GID maps", container.ID)
+	}
+
 	if c.Pause && !container.IsPaused() {
 		daemon.containerPause(container)
 		defer daemon.containerUnpause(container)
@@ -6,10 +6,13 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/server/router/build"
 	"github.com/docker/docker/opts"
 	"github.com/docker/docker/pkg/discovery"
 	flag "github.com/docker/docker/pkg/mflag"
@@ -20,6 +23,22 @@ import (
 const (
 	defaultNetworkMtu    = 1500
 	disableNetworkBridge = "none"
+	// defaultShutdownTimeout is the default number of seconds the daemon
+	// waits for a clean shutdown before forcing it.
+	defaultShutdownTimeout = 15
+	// defaultShutdownStopTimeout is the default number of seconds the
+	// daemon waits for a container to stop on shutdown before killing it.
+	defaultShutdownStopTimeout = 10
+	// defaultNetworkTimeout is the default number of seconds the daemon
+	// waits for the network of a container to be allocated on start.
+	defaultNetworkTimeout = 120
+)
+
+const (
+	// LogFormatText is the log format for plain text daemon logs.
+	LogFormatText = "text"
+	// LogFormatJSON is the log format for JSON formatted daemon logs.
+	LogFormatJSON = "json"
 )
 
 // flatOptions contains configuration keys
@@ -68,10 +87,106 @@ type CommonConfig struct {
 	Mtu                  int                 `json:"mtu,omitempty"`
 	Pidfile              string              `json:"pidfile,omitempty"`
 	RawLogs              bool                `json:"raw-logs,omitempty"`
+	LogFormat            string              `json:"log-format,omitempty"`
 	Root                 string              `json:"graph,omitempty"`
 	SocketGroup          string              `json:"group,omitempty"`
 	TrustKeyPath         string              `json:"-"`
 
+	// ShutdownTimeout is the number of seconds the daemon waits for a
+	// clean shutdown before it forces it.
+	ShutdownTimeout int `json:"shutdown-timeout,omitempty"`
+
+	// LogLevels overrides the log level of the daemon for subsystems, e.g.
+	// libnetwork or layer, by name.
+	LogLevels map[string]string `json:"log-levels,omitempty"`
+
+	// ShutdownStopTimeout is the number of seconds the daemon waits for
+	// a container to stop on shutdown before it kills it, unless the
+	// container sets its own stop timeout.
+	ShutdownStopTimeout int `json:"shutdown-stop-timeout,omitempty"`
+
+	// MissingBindSource is the policy for the bind mounts whose source
+	// path does not exist, MissingBindSourceCreate or
+	// MissingBindSourceReject.
+	MissingBindSource string `json:"missing-bind-source,omitempty"`
+
+	// VerifyImageOnStart fails the start of the containers whose image was
+	// deleted, or whose image reference now refers to another image.
+	VerifyImageOnStart bool `json:"verify-image-on-start,omitempty"`
+
+	// NameGenerator is the name generator, random or friendly, of the names
+	// of the containers and volumes created without a name. If empty, the
+	// containers get friendly names and the volumes random IDs.
+	NameGenerator string `json:"name-generator,omitempty"`
+
+	// AccessLog is the file the API requests are logged to, the access
+	// log is disabled if it is empty. AccessLogVerbose logs the GET and
+	// HEAD requests too.
+	AccessLog        string `json:"access-log,omitempty"`
+	AccessLogVerbose bool   `json:"access-log-verbose,omitempty"`
+
+	// APIReadTimeout, APIWriteTimeout and APIIdleTimeout are the number of
+	// seconds the API server waits for a request to be read, for its
+	// response to be written, and for the next request on an idle
+	// connection. Zero disables the timeout.
+	APIReadTimeout  int `json:"api-read-timeout,omitempty"`
+	APIWriteTimeout int `json:"api-write-timeout,omitempty"`
+	APIIdleTimeout  int `json:"api-idle-timeout,omitempty"`
+
+	// BuildLogSinks are the files and syslog addresses the output of the
+	// builds is mirrored to.
+	BuildLogSinks []string `json:"build-log-sinks,omitempty"`
+
+	// NetworkTimeout is the number of seconds the daemon waits for the
+	// network drivers to allocate the network of a container being started.
+	// Zero waits forever.
+	NetworkTimeout int `json:"network-timeout,omitempty"`
+
+	// MaxConcurrentStarts is the maximum number of containers the daemon
+	// starts at the same time. Zero uses the number of CPUs.
+	MaxConcurrentStarts int `json:"max-concurrent-starts,omitempty"`
+
+	// RequiredGraphDriver is the storage driver the daemon must use. The
+	// daemon fails to start if another storage driver is selected.
+	RequiredGraphDriver string `json:"require-graphdriver,omitempty"`
+
+	// DefaultResources are the rules setting the resource limits of the
+	// containers which don't set them, depending on their labels.
+	DefaultResources []string `json:"default-resources,omitempty"`
+
+	// StartupProgress is the file, FIFO or unix socket the progress of
+	// the daemon startup is reported to.
+	StartupProgress string `json:"startup-progress,omitempty"`
+
+	// RequireResourceLimits rejects the containers without memory and CPU
+	// limits, unless they have one of the ResourceLimitsExemptLabels.
+	RequireResourceLimits      bool     `json:"require-resource-limits,omitempty"`
+	ResourceLimitsExemptLabels []string `json:"resource-limits-exempt-labels,omitempty"`
+
+	// ContainerQuotas are the rules capping the number of containers with a
+	// label, e.g. the containers of a tenant.
+	ContainerQuotas []string `json:"container-quotas,omitempty"`
+
+	// AllowPlatformMismatch allows creating containers from images built
+	// for another OS or architecture than the host's.
+	AllowPlatformMismatch bool `json:"allow-platform-mismatch,omitempty"`
+
+	// HostnameTemplate is the template generating the hostnames of the
+	// containers created without one, e.g. {{.Labels.app}}-{{.Index}}.
+	HostnameTemplate string `json:"hostname-template,omitempty"`
+
+	// EphemeralAnonymousVolumes removes the anonymous volumes of the
+	// containers along with the last container referencing them.
+	EphemeralAnonymousVolumes bool `json:"ephemeral-anonymous-volumes,omitempty"`
+
+	// EventsWebhook is the URL the lifecycle events of the containers are
+	// posted to.
+	EventsWebhook string `json:"events-webhook,omitempty"`
+
+	// LogBufferSize is the size of the in-memory buffers retaining the last
+	// messages logged by the containers, e.g. 256k, or 0 for none.
+	LogBufferSize string `json:"log-buffer-size,omitempty"`
+
 	// ClusterStore is the storage backend used for the cluster information. It is used by both
 	// multihost networking (to store networks and endpoints information) and by the node discovery
 	// mechanism.
@@ -120,6 +235,8 @@ func (config *Config) InstallCommonFlags(cmd *flag.FlagSet, usageFn func(string)
 	cmd.StringVar(&config.GraphDriver, []string{"s", "-storage-driver"}, "", usageFn("Storage driver to use"))
 	cmd.IntVar(&config.Mtu, []string{"#mtu", "-mtu"}, 0, usageFn("Set the containers network MTU"))
 	cmd.BoolVar(&config.RawLogs, []string{"-raw-logs"}, false, usageFn("Full timestamps without ANSI coloring"))
+	cmd.StringVar(&config.LogFormat, []string{"-log-format"}, LogFormatText, usageFn("Set the format of the daemon logs (text, json)"))
+	cmd.Var(opts.NewNamedMapOpts("log-levels", config.LogLevels, ValidateSubsystemLogLevel), []string{"-subsystem-log-level"}, usageFn("Set the log level of a subsystem of the daemon, e.g. libnetwork=debug"))
 	// FIXME: why the inconsistency between "hosts" and "sockets"?
 	cmd.Var(opts.NewListOptsRef(&config.DNS, opts.ValidateIPAddress), []string{"#dns", "-dns"}, usageFn("DNS server to use"))
 	cmd.Var(opts.NewNamedListOptsRef("dns-opts", &config.DNSOptions, nil), []string{"-dns-opt"}, usageFn("DNS options to use"))
@@ -130,6 +247,30 @@ func (config *Config) InstallCommonFlags(cmd *flag.FlagSet, usageFn func(string)
 	cmd.StringVar(&config.ClusterAdvertise, []string{"-cluster-advertise"}, "", usageFn("Address or interface name to advertise"))
 	cmd.StringVar(&config.ClusterStore, []string{"-cluster-store"}, "", usageFn("Set the cluster store"))
 	cmd.Var(opts.NewNamedMapOpts("cluster-store-opts", config.ClusterOpts, nil), []string{"-cluster-store-opt"}, usageFn("Set cluster store options"))
+	cmd.IntVar(&config.ShutdownTimeout, []string{"-shutdown-timeout"}, defaultShutdownTimeout, usageFn("Set the timeout in seconds to wait for a clean daemon shutdown"))
+	cmd.IntVar(&config.ShutdownStopTimeout, []string{"-shutdown-stop-timeout"}, defaultShutdownStopTimeout, usageFn("Set the timeout in seconds to wait for a container to stop on daemon shutdown before killing it"))
+	cmd.StringVar(&config.MissingBindSource, []string{"-missing-bind-source"}, MissingBindSourceCreate, usageFn("Create or reject the missing source paths of bind mounts"))
+	cmd.BoolVar(&config.VerifyImageOnStart, []string{"-verify-image-on-start"}, false, usageFn("Refuse to start containers whose image was deleted or replaced"))
+	cmd.StringVar(&config.NameGenerator, []string{"-name-generator"}, "", usageFn("Generate the names of the containers and volumes created without a name as random IDs (random) or friendly names (friendly)"))
+	cmd.StringVar(&config.AccessLog, []string{"-access-log"}, "", usageFn("Log the API requests to this file"))
+	cmd.BoolVar(&config.AccessLogVerbose, []string{"-access-log-verbose"}, false, usageFn("Log the GET and HEAD API requests too"))
+	cmd.IntVar(&config.APIReadTimeout, []string{"-api-read-timeout"}, 0, usageFn("Set the timeout in seconds to read an API request, 0 for none"))
+	cmd.IntVar(&config.APIWriteTimeout, []string{"-api-write-timeout"}, 0, usageFn("Set the timeout in seconds to write an API response, 0 for none"))
+	cmd.IntVar(&config.APIIdleTimeout, []string{"-api-idle-timeout"}, 0, usageFn("Set the timeout in seconds to close idle API connections, 0 for none"))
+	cmd.Var(opts.NewNamedListOptsRef("build-log-sinks", &config.BuildLogSinks, nil), []string{"-build-log-sink"}, usageFn("Mirror the output of the builds to this file or syslog address"))
+	cmd.IntVar(&config.NetworkTimeout, []string{"-network-timeout"}, defaultNetworkTimeout, usageFn("Set the timeout in seconds to wait for the network of a container to be allocated"))
+	cmd.IntVar(&config.MaxConcurrentStarts, []string{"-max-concurrent-starts"}, 0, usageFn("Set the maximum number of containers started concurrently, 0 uses the number of CPUs"))
+	cmd.StringVar(&config.RequiredGraphDriver, []string{"-require-graphdriver"}, "", usageFn("Fail to start if the selected storage driver is not this one"))
+	cmd.Var(opts.NewNamedListOptsRef("default-resources", &config.DefaultResources, ValidateDefaultResources), []string{"-default-resources"}, usageFn("Set default resource limits for containers with a label, e.g. tier=batch:memory=512m"))
+	cmd.StringVar(&config.StartupProgress, []string{"-startup-progress"}, "", usageFn("Report the startup progress to this file, FIFO or unix socket"))
+	cmd.BoolVar(&config.RequireResourceLimits, []string{"-require-resource-limits"}, false, usageFn("Refuse to create and start containers without memory and CPU limits"))
+	cmd.Var(opts.NewNamedListOptsRef("resource-limits-exempt-labels", &config.ResourceLimitsExemptLabels, ValidateExemptLabel), []string{"-resource-limits-exempt-label"}, usageFn("Exempt the containers with this label, as key or key=value, from --require-resource-limits"))
+	cmd.Var(opts.NewNamedListOptsRef("container-quotas", &config.ContainerQuotas, ValidateContainerQuota), []string{"-container-quota"}, usageFn("Cap the number of containers with a label, e.g. tenant=acme:10, or tenant:5 for each tenant"))
+	cmd.BoolVar(&config.AllowPlatformMismatch, []string{"-allow-platform-mismatch"}, false, usageFn("Allow creating containers from images built for another OS or architecture"))
+	cmd.StringVar(&config.HostnameTemplate, []string{"-hostname-template"}, "", usageFn("Template generating the hostnames of containers created without one"))
+	cmd.BoolVar(&config.EphemeralAnonymousVolumes, []string{"-ephemeral-anonymous-volumes"}, false, usageFn("Remove the anonymous volumes of containers with the last container using them"))
+	cmd.StringVar(&config.EventsWebhook, []string{"-events-webhook"}, "", usageFn("Post the lifecycle events of containers to this URL"))
+	cmd.StringVar(&config.LogBufferSize, []string{"-log-buffer-size"}, "0", usageFn("Retain the last logs of each container in memory up to this size, 0 for none"))
 }
 
 // IsValueSet returns true if a configuration value
@@ -158,14 +299,15 @@ func parseClusterAdvertiseSettings(clusterStore, clusterAdvertise string) (strin
 }
 
 // ReloadConfiguration reads the configuration in the host and reloads the daemon and server.
-func ReloadConfiguration(configFile string, flags *flag.FlagSet, reload func(*Config)) error {
-	logrus.Infof("Got signal to reload configuration, reloading from: %s", configFile)
-	newConfig, err := getConflictFreeConfiguration(configFile, flags)
+// The configuration files are merged in order, see readConfigurationFiles.
+func ReloadConfiguration(configFiles []string, flags *flag.FlagSet, reload func(*Config)) error {
+	logrus.Infof("Got signal to reload configuration, reloading from: %s", strings.Join(configFiles, ", "))
+	newConfig, err := getConflictFreeConfiguration(configFiles, flags)
 	if err != nil {
 		return err
 	}
 
-	if err := validateConfiguration(newConfig); err != nil {
+	if err := ValidateConfiguration(newConfig); err != nil {
 		return fmt.Errorf("file configuration validation failed (%v)", err)
 	}
 
@@ -179,17 +321,36 @@ type boolValue interface {
 	IsBoolFlag() bool
 }
 
-// MergeDaemonConfigurations reads a configuration file,
+// MergeDaemonConfigurations reads the configuration files,
 // loads the file configuration in an isolated structure,
 // and merges the configuration provided from flags on top
-// if there are no conflicts.
-func MergeDaemonConfigurations(flagsConfig *Config, flags *flag.FlagSet, configFile string) (*Config, error) {
-	fileConfig, err := getConflictFreeConfiguration(configFile, flags)
+// if there are no conflicts. The files are merged in order,
+// see readConfigurationFiles.
+func MergeDaemonConfigurations(flagsConfig *Config, flags *flag.FlagSet, configFiles ...string) (*Config, error) {
+	b, err := readConfigurationFiles(configFiles)
+	if err != nil {
+		return nil, err
+	}
+	return mergeDaemonConfigurations(flagsConfig, flags, b)
+}
+
+// MergeDaemonConfigurationsFromReader is like MergeDaemonConfigurations,
+// with the configuration read from r instead of a file.
+func MergeDaemonConfigurationsFromReader(flagsConfig *Config, flags *flag.FlagSet, r io.Reader) (*Config, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return mergeDaemonConfigurations(flagsConfig, flags, b)
+}
+
+func mergeDaemonConfigurations(flagsConfig *Config, flags *flag.FlagSet, b []byte) (*Config, error) {
+	fileConfig, err := parseConflictFreeConfiguration(b, flags)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := validateConfiguration(fileConfig); err != nil {
+	if err := ValidateConfiguration(fileConfig); err != nil {
 		return nil, fmt.Errorf("file configuration validation failed (%v)", err)
 	}
 
@@ -201,15 +362,58 @@ func MergeDaemonConfigurations(flagsConfig *Config, flags *flag.FlagSet, configF
 	return fileConfig, nil
 }
 
-// getConflictFreeConfiguration loads the configuration from a JSON file.
+// getConflictFreeConfiguration loads the configuration from JSON files.
 // It compares that configuration with the one provided by the flags,
 // and returns an error if there are conflicts.
-func getConflictFreeConfiguration(configFile string, flags *flag.FlagSet) (*Config, error) {
-	b, err := ioutil.ReadFile(configFile)
+func getConflictFreeConfiguration(configFiles []string, flags *flag.FlagSet) (*Config, error) {
+	b, err := readConfigurationFiles(configFiles)
 	if err != nil {
 		return nil, err
 	}
+	return parseConflictFreeConfiguration(b, flags)
+}
+
+// readConfigurationFiles reads the configuration files in order, a
+// directory standing for the .json files it holds in lexical order, and
+// merges them into a single JSON configuration. A key set in a file
+// replaces the value set in the files before it, maps and lists included.
+func readConfigurationFiles(configFiles []string) ([]byte, error) {
+	var files []string
+	for _, configFile := range configFiles {
+		if fi, err := os.Stat(configFile); err == nil && fi.IsDir() {
+			matches, err := filepath.Glob(filepath.Join(configFile, "*.json"))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, matches...)
+			continue
+		}
+		files = append(files, configFile)
+	}
+	if len(files) == 1 {
+		return ioutil.ReadFile(files[0])
+	}
+
+	merged := make(map[string]*json.RawMessage)
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var values map[string]*json.RawMessage
+		if err := json.Unmarshal(b, &values); err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		for key, value := range values {
+			merged[key] = value
+		}
+	}
+	return json.Marshal(merged)
+}
 
+// parseConflictFreeConfiguration is like getConflictFreeConfiguration, with
+// the JSON configuration in b.
+func parseConflictFreeConfiguration(b []byte, flags *flag.FlagSet) (*Config, error) {
 	var config Config
 	var reader io.Reader
 	if flags != nil {
@@ -259,7 +463,7 @@ func getConflictFreeConfiguration(configFile string, flags *flag.FlagSet) (*Conf
 	}
 
 	reader = bytes.NewReader(b)
-	err = json.NewDecoder(reader).Decode(&config)
+	err := json.NewDecoder(reader).Decode(&config)
 	return &config, err
 }
 
@@ -346,9 +550,22 @@ func findConfigurationConflicts(config map[string]interface{}, flags *flag.FlagS
 	return nil
 }
 
-// validateConfiguration validates some specific configs.
+// ValidateSubsystemLogLevel validates the log level of a subsystem of the
+// daemon, in the form subsystem=level.
+func ValidateSubsystemLogLevel(val string) (string, error) {
+	kv := strings.SplitN(val, "=", 2)
+	if len(kv) != 2 || kv[0] == "" {
+		return "", fmt.Errorf("invalid subsystem log level %q, the format is subsystem=level", val)
+	}
+	if _, err := logrus.ParseLevel(kv[1]); err != nil {
+		return "", fmt.Errorf("invalid log level %q for subsystem %s, it must be one of debug, info, warn, error, fatal or panic", kv[1], kv[0])
+	}
+	return val, nil
+}
+
+// ValidateConfiguration validates some specific configs.
 // such as config.DNS, config.Labels, config.DNSSearch
-func validateConfiguration(config *Config) error {
+func ValidateConfiguration(config *Config) error {
 	// validate DNS
 	for _, dns := range config.DNS {
 		if _, err := opts.ValidateIPAddress(dns); err != nil {
@@ -370,5 +587,119 @@ func validateConfiguration(config *Config) error {
 		}
 	}
 
+	// validate ShutdownTimeout
+	if config.ShutdownTimeout < 0 {
+		return fmt.Errorf("invalid shutdown timeout %d, it must be a non-negative number of seconds", config.ShutdownTimeout)
+	}
+
+	// validate ShutdownStopTimeout
+	if config.ShutdownStopTimeout < 0 {
+		return fmt.Errorf("invalid shutdown stop timeout %d, it must be a non-negative number of seconds", config.ShutdownStopTimeout)
+	}
+
+	// validate MissingBindSource
+	switch config.MissingBindSource {
+	case "", MissingBindSourceCreate, MissingBindSourceReject:
+	default:
+		return fmt.Errorf("invalid missing bind source policy %q, it must be %q or %q", config.MissingBindSource, MissingBindSourceCreate, MissingBindSourceReject)
+	}
+
+	// validate NameGenerator
+	if config.NameGenerator != "" {
+		if _, err := lookupNameGenerator(config.NameGenerator); err != nil {
+			return err
+		}
+	}
+
+	// validate HostnameTemplate
+	if config.HostnameTemplate != "" {
+		if _, err := parseHostnameTemplate(config.HostnameTemplate); err != nil {
+			return err
+		}
+	}
+
+	// validate BuildLogSinks
+	for _, spec := range config.BuildLogSinks {
+		if err := build.ValidateLogSink(spec); err != nil {
+			return err
+		}
+	}
+
+	// validate EventsWebhook
+	if config.EventsWebhook != "" {
+		if err := validateEventsWebhook(config.EventsWebhook); err != nil {
+			return err
+		}
+	}
+
+	// validate LogBufferSize
+	if _, err := parseLogBufferSize(config.LogBufferSize); err != nil {
+		return err
+	}
+
+	// validate the API timeouts
+	for name, timeout := range map[string]int{"read": config.APIReadTimeout, "write": config.APIWriteTimeout, "idle": config.APIIdleTimeout} {
+		if timeout < 0 {
+			return fmt.Errorf("invalid API %s timeout %d, it must be a non-negative number of seconds", name, timeout)
+		}
+	}
+
+	// validate NetworkTimeout
+	if config.NetworkTimeout < 0 {
+		return fmt.Errorf("invalid network timeout %d, it must be a non-negative number of seconds", config.NetworkTimeout)
+	}
+
+	// validate RequiredGraphDriver
+	if config.RequiredGraphDriver != "" && config.GraphDriver != "" && config.RequiredGraphDriver != config.GraphDriver {
+		return fmt.Errorf("the storage driver %s conflicts with the required storage driver %s", config.GraphDriver, config.RequiredGraphDriver)
+	}
+
+	// validate DefaultResources
+	for _, rule := range config.DefaultResources {
+		if _, err := ValidateDefaultResources(rule); err != nil {
+			return err
+		}
+	}
+
+	// validate MirrorWeights
+	for _, val := range config.MirrorWeights {
+		if _, err := registry.ValidateMirrorWeight(val); err != nil {
+			return err
+		}
+	}
+
+	// validate ResourceLimitsExemptLabels
+	for _, label := range config.ResourceLimitsExemptLabels {
+		if _, err := ValidateExemptLabel(label); err != nil {
+			return err
+		}
+	}
+
+	// validate ContainerQuotas
+	for _, rule := range config.ContainerQuotas {
+		if _, err := ValidateContainerQuota(rule); err != nil {
+			return err
+		}
+	}
+
+	// validate MaxConcurrentStarts
+	if config.MaxConcurrentStarts < 0 {
+		return fmt.Errorf("invalid maximum number of concurrent starts %d, it must be a non-negative number", config.MaxConcurrentStarts)
+	}
+
+	// validate LogLevels
+	for subsystem, level := range config.LogLevels {
+		if _, err := ValidateSubsystemLogLevel(subsystem + "=" + level); err != nil {
+			return err
+		}
+	}
+
+	// validate LogFormat
+	switch config.LogFormat {
+	case "", LogFormatText, LogFormatJSON:
+	default:
+		return fmt.Errorf("invalid log format %q, it must be %s or %s", config.LogFormat, LogFormatText, LogFormatJSON)
+	}
+
 	return nil
 }
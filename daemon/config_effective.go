@@ -0,0 +1,92 @@
+package daemon
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// redactedValue replaces the secrets in the effective configuration.
+const redactedValue = "<redacted>"
+
+// secretLogOpts are the substrings of the names of the log options holding
+// secrets, such as splunk-token.
+var secretLogOpts = []string{"token", "password", "secret"}
+
+// urlValues are the keys of the values of the configuration which are URLs,
+// or lists of URLs, which may embed credentials.
+var urlValues = []string{"cluster-store", "events-webhook", "registry-mirrors", "build-log-sinks"}
+
+// EffectiveValues returns the configuration as a map of the keys of the
+// configuration file to their values, including the ones left to their
+// defaults, which the configuration file omits. The secrets it holds, the
+// log options such as tokens and the passwords of URLs, are redacted. The
+// paths, such as the ones of the TLS certificates and keys, are kept.
+func (config *Config) EffectiveValues() map[string]interface{} {
+	values := make(map[string]interface{})
+	effectiveValues(reflect.ValueOf(config).Elem(), values)
+
+	if logOpts, ok := values["log-opts"].(map[string]string); ok && logOpts != nil {
+		redacted := make(map[string]string, len(logOpts))
+		for k, v := range logOpts {
+			redacted[k] = v
+			for _, s := range secretLogOpts {
+				if strings.Contains(strings.ToLower(k), s) {
+					redacted[k] = redactedValue
+				}
+			}
+		}
+		values["log-opts"] = redacted
+	}
+	for _, key := range urlValues {
+		switch v := values[key].(type) {
+		case string:
+			values[key] = redactURL(v)
+		case []string:
+			if v == nil {
+				continue
+			}
+			redacted := make([]string, len(v))
+			for i, u := range v {
+				redacted[i] = redactURL(u)
+			}
+			values[key] = redacted
+		}
+	}
+	return values
+}
+
+// effectiveValues adds the fields of struct v to values, by the names of
+// their json tags, flattening the embedded structs as the configuration
+// file does.
+func effectiveValues(v reflect.Value, values map[string]interface{}) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			effectiveValues(v.Field(i), values)
+			continue
+		}
+		if f.PkgPath != "" || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		values[name] = v.Field(i).Interface()
+	}
+}
+
+// redactURL replaces the password of rawurl, if any.
+func redactURL(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil || u.User == nil {
+		return rawurl
+	}
+	if _, ok := u.User.Password(); !ok {
+		return rawurl
+	}
+	u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	return u.String()
+}
@@ -31,7 +31,30 @@ type Config struct {
 	RemappedRoot         string                   `json:"userns-remap,omitempty"`
 	CgroupParent         string                   `json:"cgroup-parent,omitempty"`
 	Ulimits              map[string]*units.Ulimit `json:"default-ulimits,omitempty"`
+	MaxUlimits           map[string]*units.Ulimit `json:"max-ulimits,omitempty"`
 	ContainerdAddr       string                   `json:"containerd,omitempty"`
+	NoPivotRoot          bool                     `json:"no-pivot-root,omitempty"`
+	InitPath             string                   `json:"init-path,omitempty"`
+	RetainFailedBundles  int                      `json:"retain-failed-bundles,omitempty"`
+	FifoTimeout          int                      `json:"containerd-fifo-timeout,omitempty"`
+	LazyUnmountFallback  bool                     `json:"lazy-unmount-fallback,omitempty"`
+
+	// ContainerdTLSCACert, ContainerdTLSCert and ContainerdTLSKey are the
+	// TLS material to connect to containerd over TCP with mutual TLS.
+	ContainerdTLSCACert string `json:"containerd-tlscacert,omitempty"`
+	ContainerdTLSCert   string `json:"containerd-tlscert,omitempty"`
+	ContainerdTLSKey    string `json:"containerd-tlskey,omitempty"`
+
+	// TmpTmpfsLabels are the labels of the containers getting a tmpfs of
+	// TmpTmpfsSize mounted on /tmp, as key or key=value.
+	TmpTmpfsLabels []string `json:"tmp-tmpfs-labels,omitempty"`
+	TmpTmpfsSize   string   `json:"tmp-tmpfs-size,omitempty"`
+
+	// VerifyInitLayer checks the init layer of each container created
+	// matches InitLayerDigest, or the digest of an init layer set up by the
+	// daemon itself when it is empty.
+	VerifyInitLayer bool   `json:"verify-init-layer,omitempty"`
+	InitLayerDigest string `json:"init-layer-digest,omitempty"`
 }
 
 // bridgeConfig stores all the bridge driver specific
@@ -65,6 +88,8 @@ func (config *Config) InstallFlags(cmd *flag.FlagSet, usageFn func(string) strin
 	cmd.StringVar(&config.SocketGroup, []string{"G", "-group"}, "docker", usageFn("Group for the unix socket"))
 	config.Ulimits = make(map[string]*units.Ulimit)
 	cmd.Var(runconfigopts.NewUlimitOpt(&config.Ulimits), []string{"-default-ulimit"}, usageFn("Set default ulimits for containers"))
+	config.MaxUlimits = make(map[string]*units.Ulimit)
+	cmd.Var(runconfigopts.NewUlimitOpt(&config.MaxUlimits), []string{"-max-ulimit"}, usageFn("Set the maximum ulimits containers can request"))
 	cmd.BoolVar(&config.bridgeConfig.EnableIPTables, []string{"#iptables", "-iptables"}, true, usageFn("Enable addition of iptables rules"))
 	cmd.BoolVar(&config.bridgeConfig.EnableIPForward, []string{"#ip-forward", "-ip-forward"}, true, usageFn("Enable net.ipv4.ip_forward"))
 	cmd.BoolVar(&config.bridgeConfig.EnableIPMasq, []string{"-ip-masq"}, true, usageFn("Enable IP masquerading"))
@@ -82,7 +107,19 @@ func (config *Config) InstallFlags(cmd *flag.FlagSet, usageFn func(string) strin
 	cmd.StringVar(&config.CorsHeaders, []string{"-api-cors-header"}, "", usageFn("Set CORS headers in the remote API"))
 	cmd.StringVar(&config.CgroupParent, []string{"-cgroup-parent"}, "", usageFn("Set parent cgroup for all containers"))
 	cmd.StringVar(&config.RemappedRoot, []string{"-userns-remap"}, "", usageFn("User/Group setting for user namespaces"))
-	cmd.StringVar(&config.ContainerdAddr, []string{"-containerd"}, "", usageFn("Path to containerd socket"))
+	cmd.StringVar(&config.ContainerdAddr, []string{"-containerd"}, "", usageFn("Path to containerd socket, or tcp:// address to connect to with TLS"))
+	cmd.StringVar(&config.ContainerdTLSCACert, []string{"-containerd-tlscacert"}, "", usageFn("Trust certs signed only by this CA when connecting to containerd over TCP"))
+	cmd.StringVar(&config.ContainerdTLSCert, []string{"-containerd-tlscert"}, "", usageFn("Path to TLS certificate file to connect to containerd over TCP"))
+	cmd.StringVar(&config.ContainerdTLSKey, []string{"-containerd-tlskey"}, "", usageFn("Path to TLS key file to connect to containerd over TCP"))
+	cmd.StringVar(&config.InitPath, []string{"-init-path"}, "", usageFn("Path to the init binary run in containers started with --init"))
+	cmd.BoolVar(&config.NoPivotRoot, []string{"-no-pivot-root"}, false, usageFn("Disable the use of pivot_root to change the container root filesystem"))
+	cmd.IntVar(&config.RetainFailedBundles, []string{"-retain-failed-bundles"}, 0, usageFn("Number of containerd bundles of containers that exited with an error to keep for debugging"))
+	cmd.IntVar(&config.FifoTimeout, []string{"-containerd-fifo-timeout"}, 0, usageFn("Set the timeout in seconds to wait for containerd to open the fifos of a container, 0 waits forever"))
+	cmd.Var(opts.NewNamedListOptsRef("tmp-tmpfs-labels", &config.TmpTmpfsLabels, ValidateTmpTmpfsLabel), []string{"-tmp-tmpfs-label"}, usageFn("Mount a tmpfs on /tmp in the containers with this label, as key or key=value"))
+	cmd.StringVar(&config.TmpTmpfsSize, []string{"-tmp-tmpfs-size"}, "64m", usageFn("Size of the tmpfs mounted on /tmp by --tmp-tmpfs-label"))
+	cmd.BoolVar(&config.LazyUnmountFallback, []string{"-lazy-unmount-fallback"}, false, usageFn("Detach the mounts of containers which are still busy once they stopped"))
+	cmd.BoolVar(&config.VerifyInitLayer, []string{"-verify-init-layer"}, false, usageFn("Refuse to create containers whose init layer doesn't match the expected digest"))
+	cmd.StringVar(&config.InitLayerDigest, []string{"-init-layer-digest"}, "", usageFn("Expected digest of the init layer checked by --verify-init-layer"))
 
 	config.attachExperimentalFlags(cmd, usageFn)
 }
@@ -6,7 +6,9 @@ import (
 	"net"
 	"os"
 	"path"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/container"
@@ -20,6 +22,7 @@ import (
 	"github.com/docker/libnetwork/netlabel"
 	"github.com/docker/libnetwork/options"
 	"github.com/docker/libnetwork/types"
+	"golang.org/x/net/context"
 )
 
 var (
@@ -29,12 +32,22 @@ var (
 	getPortMapInfo    = container.GetSandboxPortMapInfo
 )
 
+// dnsSearch returns the DNS search domains of container: its own, or the
+// default ones of the daemon when it doesn't set any. The defaults are read
+// when the network of the container is set up, so a reload applies to the
+// containers started afterwards.
+func (daemon *Daemon) dnsSearch(container *container.Container) []string {
+	if len(container.HostConfig.DNSSearch) > 0 {
+		return container.HostConfig.DNSSearch
+	}
+	return daemon.configStore.DNSSearch
+}
+
 func (daemon *Daemon) buildSandboxOptions(container *container.Container, n libnetwork.Network) ([]libnetwork.SandboxOption, error) {
 	var (
 		sboxOptions []libnetwork.SandboxOption
 		err         error
 		dns         []string
-		dnsSearch   []string
 		dnsOptions  []string
 		bindings    = make(nat.PortMap)
 		pbList      []types.PortBinding
@@ -76,13 +89,7 @@ func (daemon *Daemon) buildSandboxOptions(container *container.Container, n libn
 		sboxOptions = append(sboxOptions, libnetwork.OptionDNS(d))
 	}
 
-	if len(container.HostConfig.DNSSearch) > 0 {
-		dnsSearch = container.HostConfig.DNSSearch
-	} else if len(daemon.configStore.DNSSearch) > 0 {
-		dnsSearch = daemon.configStore.DNSSearch
-	}
-
-	for _, ds := range dnsSearch {
+	for _, ds := range daemon.dnsSearch(container) {
 		sboxOptions = append(sboxOptions, libnetwork.OptionDNSSearch(ds))
 	}
 
@@ -316,7 +323,9 @@ func (daemon *Daemon) updateNetwork(container *container.Container) error {
 	return nil
 }
 
-// updateContainerNetworkSettings update the network settings
+// updateContainerNetworkSettings update the network settings. The networks
+// are stored in a map, the order they are connected in when the container
+// starts is decided by sortedNetworkNames.
 func (daemon *Daemon) updateContainerNetworkSettings(container *container.Container, endpointsConfig map[string]*networktypes.EndpointSettings) error {
 	var (
 		n   libnetwork.Network
@@ -374,6 +383,12 @@ func (daemon *Daemon) updateContainerNetworkSettings(container *container.Contai
 
 //分配网络的主要代码。
 func (daemon *Daemon) allocateNetwork(container *container.Container) error {
+	return daemon.allocateNetworkContext(context.Background(), container)
+}
+
+// allocateNetworkContext is like allocateNetwork, but stops connecting the
+// container to its networks once ctx is cancelled.
+func (daemon *Daemon) allocateNetworkContext(ctx context.Context, container *container.Container) error {
 
 	//controller是一个libnetwork.NetworkController的接口，主要有如下方法：
 	/*
@@ -446,9 +461,18 @@ func (daemon *Daemon) allocateNetwork(container *container.Container) error {
 		updateSettings = true
 	}
 
+	primary := container.HostConfig.NetworkMode.NetworkName()
+	if container.HostConfig.NetworkMode.IsDefault() {
+		primary = controller.Config().Daemon.DefaultNetwork
+	}
+
 	//容器可能添加了多个网络，因此需要对每一网络进行连接。
 	//其中,n代表网络的名字或者id，nConf代表网络的配置。
-	for n, nConf := range container.NetworkSettings.Networks {
+	for _, n := range sortedNetworkNames(primary, container.NetworkSettings.Networks) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		nConf := container.NetworkSettings.Networks[n]
 		if err := daemon.connectToNetwork(container, n, nConf, updateSettings); err != nil {
 			return err
 		}
@@ -458,6 +482,26 @@ func (daemon *Daemon) allocateNetwork(container *container.Container) error {
 	return container.WriteHostConfig()
 }
 
+// sortedNetworkNames returns the names of the networks in the order the
+// container is connected to them, which decides the interface each network
+// gets inside the container. The primary network, the one of the container's
+// network mode, comes first so that it is always eth0. The other networks
+// follow sorted by name, so that the same container always gets the same
+// interfaces.
+func sortedNetworkNames(primary string, networks map[string]*networktypes.EndpointSettings) []string {
+	names := make([]string, 0, len(networks))
+	for n := range networks {
+		if n != primary {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	if _, ok := networks[primary]; ok {
+		names = append([]string{primary}, names...)
+	}
+	return names
+}
+
 func (daemon *Daemon) getNetworkSandbox(container *container.Container) libnetwork.Sandbox {
 	var sb libnetwork.Sandbox
 	daemon.netController.WalkSandboxes(func(s libnetwork.Sandbox) bool {
@@ -756,6 +800,9 @@ func (daemon *Daemon) initializeNetworking(container *container.Container) error
 		if err != nil {
 			return err
 		}
+		if err := daemon.setupLazyNetwork(nc); err != nil {
+			return err
+		}
 		container.HostnamePath = nc.HostnamePath
 		container.HostsPath = nc.HostsPath
 		container.ResolvConfPath = nc.ResolvConfPath
@@ -772,8 +819,15 @@ func (daemon *Daemon) initializeNetworking(container *container.Container) error
 		}
 	}
 
+	if isLazyNetwork(container) {
+		if err := daemon.deferNetwork(container); err != nil {
+			return err
+		}
+		return container.BuildHostnameFile()
+	}
+
 	//分配网络的核心代码。就在本页。
-	if err := daemon.allocateNetwork(container); err != nil {
+	if err := daemon.allocateNetworkWithTimeout(container); err != nil {
 		return err
 	}
 
@@ -781,6 +835,39 @@ func (daemon *Daemon) initializeNetworking(container *container.Container) error
 	return container.BuildHostnameFile()
 }
 
+// allocateNetworkWithTimeout allocates the network of the container, giving
+// up if the network drivers don't respond within the network timeout. A call
+// to a network driver can't be interrupted, so the allocation is only
+// stopped before connecting the container to its next network: the timeout
+// error is returned once the pending call returns, so that the container is
+// not modified after it's unlocked. The caller releases the partial
+// allocation, like after any other error.
+func (daemon *Daemon) allocateNetworkWithTimeout(container *container.Container) error {
+	timeout := time.Duration(daemon.configStore.NetworkTimeout) * time.Second
+	if timeout <= 0 {
+		return daemon.allocateNetwork(container)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- daemon.allocateNetworkContext(ctx, container)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-errCh:
+		return err
+	case <-timer.C:
+	}
+
+	cancel()
+	logrus.Warnf("Timeout after %v waiting for the network of container %s to be allocated, waiting for the pending network driver call to return", timeout, container.ID)
+	<-errCh
+	return fmt.Errorf("Timeout after %v waiting for the network of container %s to be allocated", timeout, container.ID)
+}
+
 func (daemon *Daemon) getNetworkedContainer(containerID, connectedContainerID string) (*container.Container, error) {
 	nc, err := daemon.GetContainer(connectedContainerID)
 	if err != nil {
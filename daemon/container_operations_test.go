@@ -0,0 +1,67 @@
+package daemon
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/container"
+	containertypes "github.com/docker/engine-api/types/container"
+	networktypes "github.com/docker/engine-api/types/network"
+)
+
+func TestSortedNetworkNames(t *testing.T) {
+	networks := map[string]*networktypes.EndpointSettings{
+		"frontend": {},
+		"backend":  {},
+		"bridge":   {},
+		"metrics":  {},
+	}
+
+	expected := []string{"metrics", "backend", "bridge", "frontend"}
+	for i := 0; i < 10; i++ {
+		names := sortedNetworkNames("metrics", networks)
+		if !reflect.DeepEqual(names, expected) {
+			t.Fatalf("Expected networks %v, got %v", expected, names)
+		}
+	}
+
+	expected = []string{"backend", "bridge", "frontend", "metrics"}
+	if names := sortedNetworkNames("none", networks); !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Expected networks %v, got %v", expected, names)
+	}
+}
+
+func TestDNSSearch(t *testing.T) {
+	daemon := &Daemon{configStore: &Config{}, containers: container.NewMemoryStore()}
+	daemon.configStore.DNSSearch = []string{"example.com"}
+
+	c := &container.Container{}
+	c.HostConfig = &containertypes.HostConfig{DNSSearch: []string{}}
+	if search := daemon.dnsSearch(c); !reflect.DeepEqual(search, []string{"example.com"}) {
+		t.Fatalf("Expected the container to inherit the daemon DNS search domains, got %v", search)
+	}
+
+	explicit := &container.Container{}
+	explicit.HostConfig = &containertypes.HostConfig{DNSSearch: []string{"internal.example.org"}}
+	if search := daemon.dnsSearch(explicit); !reflect.DeepEqual(search, []string{"internal.example.org"}) {
+		t.Fatalf("Expected the container DNS search domains to override the daemon ones, got %v", search)
+	}
+
+	newConfig := &Config{
+		CommonConfig: CommonConfig{
+			DNSSearch: []string{"example.net"},
+			valuesSet: map[string]interface{}{
+				"dns-search": []string{"example.net"},
+			},
+		},
+	}
+	if err := daemon.Reload(newConfig); err != nil {
+		t.Fatal(err)
+	}
+	if search := daemon.dnsSearch(c); !reflect.DeepEqual(search, []string{"example.net"}) {
+		t.Fatalf("Expected the container to inherit the reloaded DNS search domains, got %v", search)
+	}
+	if search := daemon.dnsSearch(explicit); !reflect.DeepEqual(search, []string{"internal.example.org"}) {
+		t.Fatalf("Expected the container DNS search domains to be kept on reload, got %v", search)
+	}
+}
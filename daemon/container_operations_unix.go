@@ -73,6 +73,10 @@ func (daemon *Daemon) getSize(container *container.Container) (int64, int64) {
 		err                error
 	)
 
+	if container.RWLayer == nil {
+		// the writable layer is deferred, don't create it to measure it
+		return sizeRw, sizeRootfs
+	}
 	if err := daemon.Mount(container); err != nil {
 		logrus.Errorf("Failed to compute size of container rootfs %s: %s", container.ID, err)
 		return sizeRw, sizeRootfs
@@ -101,6 +105,9 @@ func (daemon *Daemon) getSize(container *container.Container) (int64, int64) {
 
 // ConnectToNetwork connects a container to a network
 func (daemon *Daemon) ConnectToNetwork(container *container.Container, idOrName string, endpointConfig *networktypes.EndpointSettings) error {
+	if err := daemon.setupLazyNetwork(container); err != nil {
+		return err
+	}
 	if !container.Running {
 		if container.RemovalInProgress || container.Dead {
 			return errRemovalContainer(container.ID)
@@ -127,6 +134,9 @@ func (daemon *Daemon) DisconnectFromNetwork(container *container.Container, n li
 	if container.HostConfig.NetworkMode.IsHost() && containertypes.NetworkMode(n.Type()).IsHost() {
 		return runconfig.ErrConflictHostNetwork
 	}
+	if err := daemon.setupLazyNetwork(container); err != nil {
+		return err
+	}
 	if !container.Running {
 		if container.RemovalInProgress || container.Dead {
 			return errRemovalContainer(container.ID)
@@ -182,6 +192,21 @@ func (daemon *Daemon) getIpcContainer(container *container.Container) (*containe
 	return c, nil
 }
 
+// ipcShmPath returns the path on the host of the /dev/shm setupIpcDirs sets up
+// for the container c, without creating or mounting it.
+func (daemon *Daemon) ipcShmPath(c *container.Container) (string, error) {
+	if c.HostConfig.IpcMode.IsContainer() {
+		ic, err := daemon.getIpcContainer(c)
+		if err != nil {
+			return "", err
+		}
+		return ic.ShmPath, nil
+	} else if c.HostConfig.IpcMode.IsHost() {
+		return "/dev/shm", nil
+	}
+	return c.ShmResourcePath()
+}
+
 func (daemon *Daemon) setupIpcDirs(c *container.Container) error {
 	var err error
 
@@ -202,7 +227,7 @@ func (daemon *Daemon) setupIpcDirs(c *container.Container) error {
 		}
 		c.ShmPath = "/dev/shm"
 	} else {
-		rootUID, rootGID := daemon.GetRemappedUIDGID()
+		rootUID, rootGID := daemon.containerRootUIDGID(c)
 		if !c.HasMountFor("/dev/shm") {
 			shmPath, err := c.ShmResourcePath()
 			if err != nil {
@@ -0,0 +1,84 @@
+package daemon
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	containertypes "github.com/docker/engine-api/types/container"
+)
+
+// containerQuota caps the number of containers labeled with label=value, or
+// with each value of label when value is not set.
+type containerQuota struct {
+	label    string
+	value    string
+	hasValue bool
+	limit    int
+}
+
+// parseContainerQuota parses a container quota, in the form
+// label[=value]:count, e.g. tenant=acme:10 or tenant:5.
+func parseContainerQuota(rule string) (containerQuota, error) {
+	var q containerQuota
+	i := strings.LastIndex(rule, ":")
+	if i < 0 {
+		return q, fmt.Errorf("invalid container quota %q, the format is label[=value]:count", rule)
+	}
+	kv := strings.SplitN(rule[:i], "=", 2)
+	if kv[0] == "" {
+		return q, fmt.Errorf("invalid container quota %q, the format is label[=value]:count", rule)
+	}
+	q.label = kv[0]
+	if len(kv) == 2 {
+		q.value, q.hasValue = kv[1], true
+	}
+	limit, err := strconv.Atoi(rule[i+1:])
+	if err != nil || limit < 0 {
+		return q, fmt.Errorf("invalid count %q in container quota %q, it must be a positive number", rule[i+1:], rule)
+	}
+	q.limit = limit
+	return q, nil
+}
+
+// ValidateContainerQuota validates a container quota of the daemon.
+func ValidateContainerQuota(val string) (string, error) {
+	if _, err := parseContainerQuota(val); err != nil {
+		return "", err
+	}
+	return val, nil
+}
+
+// verifyContainerQuotas checks that creating a container with the labels in
+// config keeps the number of containers within the quotas of the daemon
+// matching these labels. The containers being removed are not counted.
+// Callers hold daemon.quotaMu until the container is registered, so that
+// concurrent creations cannot both take the last place of a quota.
+func (daemon *Daemon) verifyContainerQuotas(config *containertypes.Config) error {
+	if daemon.configStore == nil || config == nil {
+		return nil
+	}
+	for _, rule := range daemon.configStore.ContainerQuotas {
+		q, err := parseContainerQuota(rule)
+		if err != nil {
+			return err
+		}
+		value, ok := config.Labels[q.label]
+		if !ok || (q.hasValue && value != q.value) {
+			continue
+		}
+		count := 0
+		for _, c := range daemon.containers.List() {
+			if c.RemovalInProgress || c.Dead || c.Config == nil {
+				continue
+			}
+			if v, ok := c.Config.Labels[q.label]; ok && v == value {
+				count++
+			}
+		}
+		if count >= q.limit {
+			return fmt.Errorf("Container quota exceeded for %s=%s: the limit is %d containers and %d already exist", q.label, value, q.limit, count)
+		}
+	}
+	return nil
+}
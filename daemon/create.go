@@ -2,80 +2,207 @@ package daemon
 
 import (
 	"fmt"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/server/httputils"
+	"github.com/docker/docker/api/types/backend"
 	"github.com/docker/docker/container"
+	"github.com/docker/docker/errors"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/layer"
 	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/docker/volume"
+	volumedrivers "github.com/docker/docker/volume/drivers"
 	volumestore "github.com/docker/docker/volume/store"
 	"github.com/docker/engine-api/types"
 	containertypes "github.com/docker/engine-api/types/container"
 	networktypes "github.com/docker/engine-api/types/network"
 	"github.com/opencontainers/runc/libcontainer/label"
+	"golang.org/x/net/context"
+)
+
+var (
+	// rollbackRetryDelay is the delay before retrying the removal of a
+	// container whose creation failed, it doubles with every retry.
+	rollbackRetryDelay = time.Second
+	// rollbackRetryMaxDelay caps the delay between the retries.
+	rollbackRetryMaxDelay = time.Minute
 )
 
 // ContainerCreate creates a container.
 //这个就是那个backend(daemon)调用的ContainerCreate，他还会调用create（）
 //create还会调用daemon.go中的NewContainer()
 //让我们从这个函数入手，分析一下如何创建一个容器。
-func (daemon *Daemon) ContainerCreate(params types.ContainerCreateConfig) (types.ContainerCreateResponse, error) {
+// The ID of the API request in ctx, if any, is recorded in the create event.
+// Cancelling ctx aborts the creation and removes the partially created
+// container.
+func (daemon *Daemon) ContainerCreate(ctx context.Context, params backend.ContainerCreateConfig) (backend.ContainerCreateResponse, error) {
+	return daemon.containerCreate(ctx, params, "")
+}
+
+// CreateInterceptor is called with the configuration of a container after
+// it has been verified and before the container is created. It can modify
+// the configuration, or return an error to reject the creation.
+type CreateInterceptor func(params *backend.ContainerCreateConfig) error
+
+// AddCreateInterceptor adds fn to the interceptors called before a container
+// is created. Interceptors are called in the order they are added. They must
+// be added when the daemon is initialized, before it serves the API.
+func (daemon *Daemon) AddCreateInterceptor(fn CreateInterceptor) {
+	daemon.createInterceptors = append(daemon.createInterceptors, fn)
+}
+
+// ContainerCreateFromImageID creates a container from an image the caller
+// has already resolved to imgID. The image reference in params.Config.Image
+// is not resolved again, it is only recorded as the image of the container.
+func (daemon *Daemon) ContainerCreateFromImageID(ctx context.Context, params backend.ContainerCreateConfig, imgID image.ID) (backend.ContainerCreateResponse, error) {
+	return daemon.containerCreate(ctx, params, imgID)
+}
+
+func (daemon *Daemon) containerCreate(ctx context.Context, params backend.ContainerCreateConfig, imgID image.ID) (backend.ContainerCreateResponse, error) {
+	if daemon.IsDrained() {
+		return backend.ContainerCreateResponse{}, errDaemonDrained
+	}
+
 	//这个函数几乎不做什么事情，主要是检查参数是否配置正确
 	if params.Config == nil {
-		return types.ContainerCreateResponse{}, fmt.Errorf("Config cannot be empty in order to create a container")
+		return backend.ContainerCreateResponse{}, fmt.Errorf("Config cannot be empty in order to create a container")
+	}
+
+	if params.HostConfig == nil {
+		params.HostConfig = &containertypes.HostConfig{}
+	}
+	// the default resources are verified like the limits set by the user
+	if err := daemon.applyDefaultResources(params.HostConfig, params.Config); err != nil {
+		return backend.ContainerCreateResponse{}, err
 	}
 
 	warnings, err := daemon.verifyContainerSettings(params.HostConfig, params.Config, false)
 	if err != nil {
-		return types.ContainerCreateResponse{Warnings: warnings}, err
+		return createResponse("", warnings), err
 	}
 
 	err = daemon.verifyNetworkingConfig(params.NetworkingConfig)
 	if err != nil {
-		return types.ContainerCreateResponse{}, err
+		return backend.ContainerCreateResponse{}, err
 	}
 
-	if params.HostConfig == nil {
-		params.HostConfig = &containertypes.HostConfig{}
-	}
-	err = daemon.adaptContainerSettings(params.HostConfig, params.AdjustCPUShares)
+	w, err := daemon.adaptContainerSettings(params.HostConfig, params.AdjustCPUShares)
+	warnings = append(warnings, w...)
 	if err != nil {
-		return types.ContainerCreateResponse{Warnings: warnings}, err
+		return createResponse("", warnings), err
+	}
+
+	for _, intercept := range daemon.createInterceptors {
+		if err := intercept(&params); err != nil {
+			return createResponse("", warnings), err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return createResponse("", warnings), err
+	}
+
+	if daemon.configStore != nil && len(daemon.configStore.ContainerQuotas) > 0 {
+		daemon.quotaMu.Lock()
+		defer daemon.quotaMu.Unlock()
+		if err := daemon.verifyContainerQuotas(params.Config); err != nil {
+			return createResponse("", warnings), err
+		}
 	}
 
 	//调用create函数。
-	container, err := daemon.create(params)
+	container, err := daemon.create(ctx, params, imgID)
+	if err != nil {
+		return createResponse("", warnings), daemon.imageNotExistToErrcode(err)
+	}
+
+	resp := createResponse(container.ID, warnings)
+	security, err := daemon.securityReport(container)
 	if err != nil {
-		return types.ContainerCreateResponse{Warnings: warnings}, daemon.imageNotExistToErrcode(err)
+		logrus.Warnf("Cannot report the security settings of container %s: %v", container.ID, err)
+	} else if security != nil {
+		logrus.Debugf("Container %s runs with capabilities %v (added %v, dropped %v) and the %s seccomp profile",
+			container.ID, security.Capabilities, security.CapabilitiesAdded, security.CapabilitiesDropped, security.Seccomp)
+		resp.Security = security
 	}
+	return resp, nil
+}
 
-	return types.ContainerCreateResponse{ID: container.ID, Warnings: warnings}, nil
+// createResponse returns the response to the creation of the container id,
+// with both the messages of the warnings and the categorized warnings.
+func createResponse(id string, warnings containerWarnings) backend.ContainerCreateResponse {
+	return backend.ContainerCreateResponse{
+		ContainerCreateResponse: types.ContainerCreateResponse{
+			ID:       id,
+			Warnings: warnings.messages(),
+		},
+		WarningDetails: warnings,
+	}
 }
 
 // Create creates a new container from the given configuration with a given name.
-func (daemon *Daemon) create(params types.ContainerCreateConfig) (retC *container.Container, retErr error) {
+// If imgID is not empty the container is created from that image without
+// resolving params.Config.Image. The creation is aborted, and what was
+// created so far removed, if ctx is cancelled before the container is saved.
+func (daemon *Daemon) create(ctx context.Context, params backend.ContainerCreateConfig, imgID image.ID) (retC *container.Container, retErr error) {
 	var (
 		container *container.Container
 		img       *image.Image
-		imgID     image.ID
 		err       error
 	)
 
-	if params.Config.Image != "" {
-		//获取镜像
-		img, err = daemon.GetImage(params.Config.Image)
-		if err != nil {
-			return nil, err
-		}
+	timer := newCreateTimer()
+	phaseStart := time.Now()
+	//获取镜像
+	img, err = daemon.getCreateImage(params.Config, imgID)
+	if err != nil {
+		return nil, err
+	}
+	timer.observe(createPhaseImage, phaseStart)
+	if img != nil {
 		//获取镜像ID号
 		imgID = img.ID()
 	}
+	if err := daemon.verifyImagePlatform(img); err != nil {
+		return nil, err
+	}
 
 	//合并并且检查参数
 	if err := daemon.mergeAndVerifyConfig(params.Config, img); err != nil {
 		return nil, err
 	}
+	// the labels of the image are verified like the ones set by the user
+	if err := daemon.verifyContainerLabels(params.HostConfig, params.Config); err != nil {
+		return nil, err
+	}
+	// the exempt labels are the ones of the container and its image, as
+	// when ContainerStart checks the limits again
+	if err := daemon.verifyResourceLimits(params.HostConfig, params.Config); err != nil {
+		return nil, err
+	}
+	if hasOwnIDMaps(params.Config) {
+		daemon.idMapsMu.Lock()
+		defer daemon.idMapsMu.Unlock()
+		if err := daemon.verifyIDMapsOverlap(params.Config); err != nil {
+			return nil, err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// the container which has the name is only removed once the new one
+	// has been verified, right before the name is reserved again
+	if params.ReplaceExisting && params.Name != "" {
+		if err := daemon.replaceExisting(params.Name, daemon.removeReplacedContainer); err != nil {
+			return nil, err
+		}
+	}
 
 	//创建一个新的容器，此时需要跟踪分析newContainer方法，该方法在daemon/daemon.go中。
 	//该方法返回的是container，是一个这样的结构：
@@ -97,16 +224,16 @@ func (daemon *Daemon) create(params types.ContainerCreateConfig) (retC *containe
 	//创建容器实例，实际上只是在代码中创建，并没有创建文件系统和namespace。
 	//实际上，创建容器的过程中最多也就创建到文件系统，namespace只有在容器
 	//运行时候才会生效。
+	phaseStart = time.Now()
 	if container, err = daemon.newContainer(params.Name, params.Config, imgID); err != nil {
 		return nil, err
 	}
+	timer.observe(createPhaseContainer, phaseStart)
 
 	//如果创建容器出错，就试图删除容器。
 	defer func() {
 		if retErr != nil {
-			if err := daemon.ContainerRm(container.ID, &types.ContainerRmConfig{ForceRemove: true}); err != nil {
-				logrus.Errorf("Clean up Error! Cannot destroy container %s: %v", container.ID, err)
-			}
+			daemon.rollbackCreate(container, daemon.removeFailedContainer)
 		}
 	}()
 
@@ -115,10 +242,21 @@ func (daemon *Daemon) create(params types.ContainerCreateConfig) (retC *containe
 		return nil, err
 	}
 
+	container.HostConfig.StorageOpt = params.HostConfig.StorageOpt
+
 	// Set RWLayer for container after mount labels have been set
 	// 设置可读写层，就是获取layID等信息,包括镜像层、容器层。
 	//详情请见setRWLayer函数，就在本文件中。
-	if err := daemon.setRWLayer(container); err != nil {
+	if params.DeferRWLayer {
+		container.DeferredRWLayer = true
+	} else {
+		phaseStart = time.Now()
+		if err := daemon.setRWLayer(container); err != nil {
+			return nil, err
+		}
+		timer.observe(createPhaseRWLayer, phaseStart)
+	}
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
@@ -127,7 +265,7 @@ func (daemon *Daemon) create(params types.ContainerCreateConfig) (retC *containe
 	if err := daemon.Register(container); err != nil {
 		return nil, err
 	}
-	rootUID, rootGID, err := idtools.GetRootUIDGID(daemon.uidMaps, daemon.gidMaps)
+	rootUID, rootGID, err := idtools.GetRootUIDGID(daemon.containerIDMaps(container.Config))
 	if err != nil {
 		return nil, err
 	}
@@ -139,9 +277,11 @@ func (daemon *Daemon) create(params types.ContainerCreateConfig) (retC *containe
 	//这个方法主要做两件事情：
 	//挂载volumes;
 	//设置容器之间的链接links；
+	phaseStart = time.Now()
 	if err := daemon.setHostConfig(container, params.HostConfig); err != nil {
 		return nil, err
 	}
+	timer.observe(createPhaseHostConfig, phaseStart)
 	defer func() {
 		if retErr != nil {
 			if err := daemon.removeMountPoints(container, true); err != nil {
@@ -197,7 +337,16 @@ func (daemon *Daemon) create(params types.ContainerCreateConfig) (retC *containe
 		}
 	*/
 	//在这一步骤里面进行一些跟平台相关的设置，主要为mount目录文件，以及volume挂载。
-	if err := daemon.createContainerPlatformSpecificSettings(container, params.Config, params.HostConfig); err != nil {
+	// the settings of a container with a deferred writable layer are set up
+	// when it first starts
+	if !container.DeferredRWLayer {
+		phaseStart = time.Now()
+		if err := daemon.createContainerPlatformSpecificSettings(container, params.Config, params.HostConfig); err != nil {
+			return nil, err
+		}
+		timer.observe(createPhasePlatform, phaseStart)
+	}
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
@@ -209,9 +358,11 @@ func (daemon *Daemon) create(params types.ContainerCreateConfig) (retC *containe
 
 	//更新网路配置，在daemon/container_operations.go中，调用了libnetwork模块，需要仔细研究。
 	//这里仅仅是更新container.NetworkSettings.Networks[]数组中的网络模式。并没有真正创建网络。
+	phaseStart = time.Now()
 	if err := daemon.updateContainerNetworkSettings(container, endpointsConfigs); err != nil {
 		return nil, err
 	}
+	timer.observe(createPhaseNetwork, phaseStart)
 
 	//将容器的配置保存到磁盘。但是这个和另外一个todisk的区别是什么？
 	if err := container.ToDiskLocking(); err != nil {
@@ -220,10 +371,141 @@ func (daemon *Daemon) create(params types.ContainerCreateConfig) (retC *containe
 	}
 
 	//记录容器的事件日志。
-	daemon.LogContainerEvent(container, "create")
+	daemon.LogContainerEventWithAttributes(container, "create", createEventAttributes(ctx, container))
+	timer.log(container.ID)
 	return container, nil
 }
 
+// rollbackCreate removes a container whose creation failed. If the removal
+// fails, the container is marked dead so that it can't be started, a
+// "rollback-incomplete" event is emitted and the removal is retried in the
+// background until it succeeds or the daemon shuts down.
+func (daemon *Daemon) rollbackCreate(container *container.Container, remove func(*container.Container) error) {
+	err := remove(container)
+	if err == nil {
+		return
+	}
+	logrus.Errorf("Clean up Error! Cannot destroy container %s: %v", container.ID, err)
+
+	container.SetDead()
+	if err := container.ToDiskLocking(); err != nil && !os.IsNotExist(err) {
+		logrus.Errorf("Error saving dead container %s to disk: %v", container.ID, err)
+	}
+	daemon.LogContainerEventWithAttributes(container, "rollback-incomplete", map[string]string{"error": err.Error()})
+
+	go daemon.retryRollbackCreate(container, remove)
+}
+
+// retryRollbackCreate retries the removal of a container whose creation
+// failed, doubling the delay between the attempts up to
+// rollbackRetryMaxDelay.
+func (daemon *Daemon) retryRollbackCreate(container *container.Container, remove func(*container.Container) error) {
+	var done <-chan struct{}
+	if daemon.shutdownCtx != nil {
+		done = daemon.shutdownCtx.Done()
+	}
+	delay := rollbackRetryDelay
+	for {
+		select {
+		case <-time.After(delay):
+		case <-done:
+			logrus.Warnf("Daemon shutting down, giving up removing container %s", container.ID)
+			return
+		}
+		err := remove(container)
+		if err == nil {
+			logrus.Infof("Removed container %s after its creation failed", container.ID)
+			return
+		}
+		if delay *= 2; delay > rollbackRetryMaxDelay {
+			delay = rollbackRetryMaxDelay
+		}
+		logrus.Warnf("Failed to remove container %s, retrying in %v: %v", container.ID, delay, err)
+	}
+}
+
+// removeFailedContainer removes a container whose creation failed. A
+// container which isn't registered, because its creation failed before
+// it was or a previous removal unregistered it, has its name, metadata and
+// layer cleaned up directly.
+func (daemon *Daemon) removeFailedContainer(container *container.Container) error {
+	if daemon.containers.Get(container.ID) != nil {
+		return daemon.ContainerRm(container.ID, &types.ContainerRmConfig{ForceRemove: true})
+	}
+	return daemon.cleanupContainer(container, true)
+}
+
+// replaceExisting removes the container which has the given name, if any, so
+// that a new container can be created with that name. Only a stopped
+// container is replaced, the name of a running one remains in conflict.
+func (daemon *Daemon) replaceExisting(name string, remove func(*container.Container) error) error {
+	if name[0] != '/' {
+		name = "/" + name
+	}
+	id, err := daemon.nameIndex.Get(name)
+	if err != nil {
+		// the name is not in use
+		return nil
+	}
+	existing := daemon.containers.Get(id)
+	if existing == nil {
+		return nil
+	}
+	if existing.IsRunning() || existing.IsRestarting() {
+		return fmt.Errorf("Conflict. The name %q is already in use by running container %s. You have to stop and remove (or rename) that container to be able to reuse that name.", name, id)
+	}
+	if err := remove(existing); err != nil {
+		return fmt.Errorf("Unable to replace container %s: %v", id, err)
+	}
+	return nil
+}
+
+// removeReplacedContainer removes a stopped container replaced by a new one,
+// keeping its volumes as docker rm does.
+func (daemon *Daemon) removeReplacedContainer(container *container.Container) error {
+	return daemon.ContainerRm(container.ID, &types.ContainerRmConfig{})
+}
+
+// createEventAttributes returns the attributes of the create event, which
+// record the resolved image and the resources requested for the container.
+// The container environment is left out as it may hold secrets.
+func createEventAttributes(ctx context.Context, container *container.Container) map[string]string {
+	attributes := map[string]string{}
+	if id := httputils.RequestIDFromContext(ctx); id != "" {
+		attributes["requestID"] = id
+	}
+	if container.ImageID != "" {
+		attributes["imageID"] = container.ImageID.String()
+	}
+	if container.HostConfig != nil {
+		attributes["memory"] = strconv.FormatInt(container.HostConfig.Memory, 10)
+		attributes["cpuShares"] = strconv.FormatInt(container.HostConfig.CPUShares, 10)
+	}
+	return attributes
+}
+
+// getCreateImage returns the image a new container is created from.
+// An explicit imgID is looked up directly in the image store, otherwise
+// the image reference in config is resolved. It returns nil if neither
+// is set.
+func (daemon *Daemon) getCreateImage(config *containertypes.Config, imgID image.ID) (*image.Image, error) {
+	if imgID == "" {
+		if config.Image == "" {
+			return nil, nil
+		}
+		return daemon.GetImage(config.Image)
+	}
+
+	img, err := daemon.imageStore.Get(imgID)
+	if err != nil {
+		return nil, ErrImageDoesNotExist{imgID.String()}
+	}
+	if config.Image == "" {
+		config.Image = imgID.String()
+	}
+	return img, nil
+}
+
 func (daemon *Daemon) generateSecurityOpt(ipcMode containertypes.IpcMode, pidMode containertypes.PidMode) ([]string, error) {
 	if ipcMode.IsHost() || pidMode.IsHost() {
 		return label.DisableSecOpt(), nil
@@ -251,23 +533,59 @@ func (daemon *Daemon) setRWLayer(container *container.Container) error {
 	}
 	//通过镜像层ID，容器ID，MountLabel以及初始化层（例如/dev/pts,/proc,/sys,/etc/hosts等目录）创建可读写层
 	//关于setupInitLayer中的内容可以参考daemon/daemon_unix.go
-	rwLayer, err := daemon.layerStore.CreateRWLayer(container.ID, layerID, container.MountLabel, daemon.setupInitLayer)
+	rwLayer, err := daemon.layerStore.CreateRWLayer(container.ID, layerID, container.MountLabel, daemon.initLayerFunc(container), container.HostConfig.StorageOpt)
 	if err != nil {
 		return err
 	}
+	if err := daemon.shiftRootfsIDs(container, rwLayer); err != nil {
+		if _, rerr := daemon.layerStore.ReleaseRWLayer(rwLayer); rerr != nil {
+			logrus.Errorf("Error releasing the writable layer of container %s: %v", container.ID, rerr)
+		}
+		return fmt.Errorf("Error changing the owner of the root filesystem to the ID maps of the container: %v", err)
+	}
 	container.RWLayer = rwLayer
 
 	return nil
 }
 
+// materializeRWLayer creates the writable layer of a container created
+// with DeferRWLayer, if it wasn't mounted yet, and sets up the settings
+// deferred with it, such as the volumes populated from the image. It is
+// called when the container starts, with the container locked.
+func (daemon *Daemon) materializeRWLayer(container *container.Container) error {
+	if !container.DeferredRWLayer {
+		return nil
+	}
+	if container.RWLayer == nil {
+		if err := daemon.setRWLayer(container); err != nil {
+			return err
+		}
+	}
+	if err := daemon.createContainerPlatformSpecificSettings(container, container.Config, container.HostConfig); err != nil {
+		return err
+	}
+	container.DeferredRWLayer = false
+	return container.ToDisk()
+}
+
 // VolumeCreate creates a volume with the specified name, driver, and opts
 // This is called directly from the remote API
+// A volume created without a name gets one from the name generator set in
+// the daemon configuration.
 func (daemon *Daemon) VolumeCreate(name, driverName string, opts, labels map[string]string) (*types.Volume, error) {
-	if name == "" {
-		name = stringid.GenerateNonCryptoID()
+	if err := validateVolumeOpts(driverName, opts); err != nil {
+		return nil, err
 	}
 
-	v, err := daemon.volumes.Create(name, driverName, opts, labels)
+	var (
+		v   volume.Volume
+		err error
+	)
+	if name == "" {
+		v, err = daemon.createGeneratedVolume(driverName, opts, labels)
+	} else {
+		v, err = daemon.volumes.Create(name, driverName, opts, labels)
+	}
 	if err != nil {
 		if volumestore.IsNameConflict(err) {
 			return nil, fmt.Errorf("A volume named %s already exists. Choose a different volume name.", name)
@@ -278,3 +596,69 @@ func (daemon *Daemon) VolumeCreate(name, driverName string, opts, labels map[str
 	daemon.LogVolumeEvent(v.Name(), "create", map[string]string{"driver": v.DriverName()})
 	return volumeToAPIType(v), nil
 }
+
+// createGeneratedVolume creates a volume named by the name generator of the
+// daemon. A generated name already used by another volume is generated
+// again, up to maxGeneratedNameAttempts times before falling back to a
+// random ID.
+func (daemon *Daemon) createGeneratedVolume(driverName string, opts, labels map[string]string) (volume.Volume, error) {
+	generator, err := daemon.nameGenerator(NameGeneratorRandom)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < maxGeneratedNameAttempts; i++ {
+		v, created, err := daemon.volumes.CreateOrGet(generator.GenerateName(i), driverName, opts, labels)
+		if err != nil && !volumestore.IsNameConflict(err) {
+			return nil, err
+		}
+		if created {
+			return v, nil
+		}
+	}
+	return daemon.volumes.Create(stringid.GenerateNonCryptoID(), driverName, opts, labels)
+}
+
+// validateVolumeOpts rejects the options the volume driver doesn't accept,
+// before the volume gets created.
+func validateVolumeOpts(driverName string, opts map[string]string) error {
+	if len(opts) == 0 {
+		return nil
+	}
+	vd, err := volumedrivers.GetDriver(driverName)
+	if err != nil {
+		// let the volume store report the missing driver
+		return nil
+	}
+	if err := volume.ValidateOpts(vd, opts); err != nil {
+		return errors.NewBadRequestError(err)
+	}
+	return nil
+}
+
+// VolumeCreateOrGet creates a volume with the specified name, driver, and opts,
+// or returns the existing volume with that name if it was created with the same
+// driver, opts and labels. The returned boolean reports whether the volume was
+// created.
+func (daemon *Daemon) VolumeCreateOrGet(name, driverName string, opts, labels map[string]string) (*types.Volume, bool, error) {
+	if name == "" {
+		v, err := daemon.VolumeCreate(name, driverName, opts, labels)
+		return v, err == nil, err
+	}
+
+	if err := validateVolumeOpts(driverName, opts); err != nil {
+		return nil, false, err
+	}
+
+	v, created, err := daemon.volumes.CreateOrGet(name, driverName, opts, labels)
+	if err != nil {
+		if volumestore.IsNameConflict(err) {
+			return nil, false, fmt.Errorf("Conflict. A volume named %s already exists with a different driver, options or labels. Choose a different volume name.", name)
+		}
+		return nil, false, err
+	}
+
+	if created {
+		daemon.LogVolumeEvent(v.Name(), "create", map[string]string{"driver": v.DriverName()})
+	}
+	return volumeToAPIType(v), created, nil
+}
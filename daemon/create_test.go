@@ -0,0 +1,782 @@
+package daemon
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/backend"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/events"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/layer"
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/docker/pkg/registrar"
+	"github.com/docker/docker/runconfig"
+	"github.com/docker/docker/utils"
+	"github.com/docker/engine-api/types"
+	containertypes "github.com/docker/engine-api/types/container"
+	eventtypes "github.com/docker/engine-api/types/events"
+	"golang.org/x/net/context"
+)
+
+type nopLayerGetReleaser struct{}
+
+func (nopLayerGetReleaser) Get(layer.ChainID) (layer.Layer, error) {
+	return nil, layer.ErrLayerDoesNotExist
+}
+
+func (nopLayerGetReleaser) Release(layer.Layer) ([]layer.Metadata, error) {
+	return nil, nil
+}
+
+func newTestImageStore(t *testing.T) (image.Store, func()) {
+	tmp, err := ioutil.TempDir("", "docker-daemon-create-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs, err := image.NewFSStoreBackend(tmp)
+	if err != nil {
+		os.RemoveAll(tmp)
+		t.Fatal(err)
+	}
+	is, err := image.NewImageStore(fs, nopLayerGetReleaser{})
+	if err != nil {
+		os.RemoveAll(tmp)
+		t.Fatal(err)
+	}
+	return is, func() { os.RemoveAll(tmp) }
+}
+
+func TestGetCreateImageWithImageID(t *testing.T) {
+	is, cleanup := newTestImageStore(t)
+	defer cleanup()
+
+	imgID, err := is.Create([]byte(`{"comment": "abc", "rootfs": {"type": "layers"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// no reference store is set, resolving the reference would panic
+	daemon := &Daemon{imageStore: is}
+	config := &containertypes.Config{Image: "bogus/reference:latest"}
+
+	img, err := daemon.getCreateImage(config, imgID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.ID() != imgID {
+		t.Fatalf("Expected image %s, got %s", imgID, img.ID())
+	}
+	if config.Image != "bogus/reference:latest" {
+		t.Fatalf("Expected image name to be kept, got %s", config.Image)
+	}
+}
+
+func TestGetCreateImageWithImageIDOnly(t *testing.T) {
+	is, cleanup := newTestImageStore(t)
+	defer cleanup()
+
+	imgID, err := is.Create([]byte(`{"comment": "abc", "rootfs": {"type": "layers"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	daemon := &Daemon{imageStore: is}
+	config := &containertypes.Config{}
+
+	if _, err := daemon.getCreateImage(config, imgID); err != nil {
+		t.Fatal(err)
+	}
+	if config.Image != imgID.String() {
+		t.Fatalf("Expected image name %s, got %s", imgID, config.Image)
+	}
+}
+
+func TestGetCreateImageWithUnknownImageID(t *testing.T) {
+	is, cleanup := newTestImageStore(t)
+	defer cleanup()
+
+	daemon := &Daemon{imageStore: is}
+	config := &containertypes.Config{Image: "bogus/reference:latest"}
+
+	_, err := daemon.getCreateImage(config, image.ID("sha256:0000000000000000000000000000000000000000000000000000000000000000"))
+	if _, ok := err.(ErrImageDoesNotExist); !ok {
+		t.Fatalf("Expected ErrImageDoesNotExist, got %v", err)
+	}
+}
+
+func TestContainerCreateDrained(t *testing.T) {
+	daemon := &Daemon{}
+	daemon.SetDrainMode(true)
+
+	_, err := daemon.ContainerCreate(context.Background(), backend.ContainerCreateConfig{ContainerCreateConfig: types.ContainerCreateConfig{Config: &containertypes.Config{}}})
+	if err != errDaemonDrained {
+		t.Fatalf("Expected drain mode error, got %v", err)
+	}
+
+	daemon.SetDrainMode(false)
+	if daemon.IsDrained() {
+		t.Fatal("Expected drain mode to be disabled")
+	}
+}
+
+func TestContainerCreateInterceptors(t *testing.T) {
+	daemon := &Daemon{configStore: &Config{}}
+
+	var called []string
+	daemon.AddCreateInterceptor(func(params *backend.ContainerCreateConfig) error {
+		called = append(called, "first")
+		params.HostConfig.ReadonlyRootfs = true
+		return nil
+	})
+	daemon.AddCreateInterceptor(func(params *backend.ContainerCreateConfig) error {
+		called = append(called, "second")
+		if !params.HostConfig.ReadonlyRootfs {
+			t.Fatal("Expected the configuration changed by the first interceptor")
+		}
+		return fmt.Errorf("rejected by policy")
+	})
+
+	_, err := daemon.ContainerCreate(context.Background(), backend.ContainerCreateConfig{ContainerCreateConfig: types.ContainerCreateConfig{Config: &containertypes.Config{}}})
+	if err == nil || err.Error() != "rejected by policy" {
+		t.Fatalf("Expected the interceptor error, got %v", err)
+	}
+	if len(called) != 2 || called[0] != "first" || called[1] != "second" {
+		t.Fatalf("Expected the interceptors to be called in order, got %v", called)
+	}
+}
+
+func TestContainerCreateCancelled(t *testing.T) {
+	daemon := &Daemon{configStore: &Config{}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// no name index is set, replacing the existing container would panic
+	params := backend.ContainerCreateConfig{
+		ContainerCreateConfig: types.ContainerCreateConfig{Name: "cancelled", Config: &containertypes.Config{}},
+		ReplaceExisting:       true,
+	}
+	if _, err := daemon.ContainerCreate(ctx, params); err != context.Canceled {
+		t.Fatalf("Expected the creation to be cancelled, got %v", err)
+	}
+}
+
+func TestPullImageIfMissingWithImageID(t *testing.T) {
+	is, cleanup := newTestImageStore(t)
+	defer cleanup()
+
+	imgID, err := is.Create([]byte(`{"comment": "abc", "rootfs": {"type": "layers"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// no registry service is set, pulling the image would panic
+	daemon := &Daemon{imageStore: is}
+	if err := daemon.PullImageIfMissing(context.Background(), imgID.String(), nil, nil, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	missing := "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	if err := daemon.PullImageIfMissing(context.Background(), missing, nil, nil, ioutil.Discard); err != nil {
+		t.Fatalf("Expected an image ID not to be pulled, got %v", err)
+	}
+}
+
+func TestRollbackCreateRetriesFailedRemoval(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-daemon-create-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	defer func(delay time.Duration) { rollbackRetryDelay = delay }(rollbackRetryDelay)
+	rollbackRetryDelay = time.Millisecond
+
+	e := events.New()
+	_, l, _ := e.Subscribe()
+	defer e.Evict(l)
+	daemon := &Daemon{EventsService: e}
+
+	c := &container.Container{
+		CommonContainer: container.CommonContainer{
+			ID:     "test",
+			Root:   tmp,
+			State:  container.NewState(),
+			Config: &containertypes.Config{},
+		},
+	}
+	attempts := 0
+	removed := make(chan struct{})
+	daemon.rollbackCreate(c, func(*container.Container) error {
+		attempts++
+		if attempts < 3 {
+			return fmt.Errorf("device or resource busy")
+		}
+		close(removed)
+		return nil
+	})
+
+	if !c.Dead {
+		t.Fatal("Expected the container to be marked dead")
+	}
+	select {
+	case msg := <-l:
+		jm := msg.(eventtypes.Message)
+		if jm.Action != "rollback-incomplete" || jm.Actor.Attributes["error"] != "device or resource busy" {
+			t.Fatalf("Expected a rollback-incomplete event with the error, got %+v", jm)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a rollback-incomplete event")
+	}
+	select {
+	case <-removed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the removal to be retried until it succeeds")
+	}
+}
+
+func TestRollbackCreate(t *testing.T) {
+	daemon := &Daemon{}
+	c := &container.Container{
+		CommonContainer: container.CommonContainer{
+			ID:    "test",
+			State: container.NewState(),
+		},
+	}
+	daemon.rollbackCreate(c, func(*container.Container) error { return nil })
+	if c.Dead {
+		t.Fatal("Expected a removed container not to be marked dead")
+	}
+}
+
+// deferredLayerStore creates fake writable layers mounted on dir.
+type deferredLayerStore struct {
+	layer.Store
+	dir     string
+	created int
+}
+
+func (ls *deferredLayerStore) CreateRWLayer(id string, parent layer.ChainID, mountLabel string, initFunc layer.MountInit, storageOpt map[string]string) (layer.RWLayer, error) {
+	ls.created++
+	return &deferredRWLayer{dir: ls.dir}, nil
+}
+
+type deferredRWLayer struct {
+	layer.RWLayer
+	dir string
+}
+
+func (l *deferredRWLayer) Mount(mountLabel string) (string, error) { return l.dir, nil }
+func (l *deferredRWLayer) Unmount() error                          { return nil }
+func (l *deferredRWLayer) Metadata() (map[string]string, error) {
+	return map[string]string{"MergedDir": l.dir}, nil
+}
+
+func TestDeferredRWLayer(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-daemon-create-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	ls := &deferredLayerStore{dir: tmp}
+	daemon := &Daemon{layerStore: ls, linkIndex: newLinkIndex()}
+	c := container.NewBaseContainer("test", tmp)
+	c.Config = &containertypes.Config{}
+	c.HostConfig = &containertypes.HostConfig{}
+	c.DeferredRWLayer = true
+
+	inspect, err := daemon.getInspectData(c, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inspect.GraphDriver.Data["Deferred"] != "true" {
+		t.Fatalf("Expected the writable layer to be reported as deferred, got %+v", inspect.GraphDriver)
+	}
+	if changes, err := daemon.changes(c); err != nil || len(changes) != 0 {
+		t.Fatalf("Expected no changes, got %v %v", changes, err)
+	}
+	if err := daemon.Unmount(c); err != nil {
+		t.Fatal(err)
+	}
+	if ls.created != 0 {
+		t.Fatal("Expected the writable layer not to be created by inspect, diff and unmount")
+	}
+
+	if err := daemon.materializeRWLayer(c); err != nil {
+		t.Fatal(err)
+	}
+	if ls.created != 1 || c.RWLayer == nil || c.DeferredRWLayer {
+		t.Fatalf("Expected the writable layer to be created once on start, got %d layers", ls.created)
+	}
+	if err := daemon.materializeRWLayer(c); err != nil || ls.created != 1 {
+		t.Fatalf("Expected the writable layer of a started container to be left alone, got %d layers: %v", ls.created, err)
+	}
+
+	inspect, err = daemon.getInspectData(c, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inspect.GraphDriver.Data["Deferred"] != "" || inspect.GraphDriver.Data["MergedDir"] != tmp {
+		t.Fatalf("Expected the graph driver data of the writable layer, got %+v", inspect.GraphDriver)
+	}
+}
+
+func TestReplaceExisting(t *testing.T) {
+	daemon := &Daemon{
+		containers: container.NewMemoryStore(),
+		nameIndex:  registrar.NewRegistrar(),
+	}
+	for _, id := range []string{"stopped", "running"} {
+		c := container.NewBaseContainer(id, "")
+		c.Name = "/" + id
+		daemon.containers.Add(id, c)
+		if err := daemon.nameIndex.Reserve(c.Name, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	daemon.containers.Get("running").SetRunning(1234, true)
+
+	var removed []string
+	remove := func(c *container.Container) error {
+		removed = append(removed, c.ID)
+		return nil
+	}
+
+	if err := daemon.replaceExisting("running", remove); err == nil || !strings.Contains(err.Error(), "already in use by running container") {
+		t.Fatalf("Expected a conflict with the running container, got %v", err)
+	}
+	if len(removed) != 0 {
+		t.Fatalf("Expected the running container to be kept, removed %v", removed)
+	}
+
+	if err := daemon.replaceExisting("stopped", remove); err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0] != "stopped" {
+		t.Fatalf("Expected the stopped container to be removed, removed %v", removed)
+	}
+
+	if err := daemon.replaceExisting("unused", remove); err != nil || len(removed) != 1 {
+		t.Fatalf("Expected nothing to be replaced for an unused name, got %v and %v", err, removed)
+	}
+
+	failing := func(*container.Container) error { return fmt.Errorf("device or resource busy") }
+	if err := daemon.replaceExisting("/stopped", failing); err == nil || !strings.Contains(err.Error(), "device or resource busy") {
+		t.Fatalf("Expected the removal error, got %v", err)
+	}
+}
+
+func TestReplaceExistingAfterVerification(t *testing.T) {
+	is, cleanup := newTestImageStore(t)
+	defer cleanup()
+
+	daemon := &Daemon{
+		configStore: &Config{},
+		imageStore:  is,
+		containers:  container.NewMemoryStore(),
+		nameIndex:   registrar.NewRegistrar(),
+	}
+	c := container.NewBaseContainer("existing", "")
+	c.Name = "/existing"
+	daemon.containers.Add(c.ID, c)
+	if err := daemon.nameIndex.Reserve(c.Name, c.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	params := backend.ContainerCreateConfig{
+		ContainerCreateConfig: types.ContainerCreateConfig{Name: "existing", Config: &containertypes.Config{}},
+		ReplaceExisting:       true,
+	}
+	imgID := image.ID("sha256:0000000000000000000000000000000000000000000000000000000000000000")
+	if _, err := daemon.ContainerCreateFromImageID(context.Background(), params, imgID); err == nil {
+		t.Fatal("Expected the creation from an unknown image to fail")
+	}
+	if daemon.containers.Get("existing") == nil {
+		t.Fatal("Expected the existing container to be kept when the new one is invalid")
+	}
+}
+
+func TestVerifyContainerQuotas(t *testing.T) {
+	daemon := &Daemon{
+		containers:  container.NewMemoryStore(),
+		configStore: &Config{},
+	}
+	add := func(id, tenant string) *container.Container {
+		c := container.NewBaseContainer(id, "")
+		c.Config = &containertypes.Config{Labels: map[string]string{"tenant": tenant}}
+		daemon.containers.Add(id, c)
+		return c
+	}
+	acme := &containertypes.Config{Labels: map[string]string{"tenant": "acme"}}
+	add("acme1", "acme")
+	add("acme2", "acme")
+	add("other1", "other")
+
+	if err := daemon.verifyContainerQuotas(acme); err != nil {
+		t.Fatalf("Expected no quota by default, got %v", err)
+	}
+
+	newConfig := &Config{
+		CommonConfig: CommonConfig{
+			ContainerQuotas: []string{"tenant:2"},
+			valuesSet:       map[string]interface{}{"container-quotas": []string{"tenant:2"}},
+		},
+	}
+	if err := daemon.Reload(newConfig); err != nil {
+		t.Fatal(err)
+	}
+	err := daemon.verifyContainerQuotas(acme)
+	if err == nil || !strings.Contains(err.Error(), "tenant=acme") || !strings.Contains(err.Error(), "limit is 2 containers and 2 already exist") {
+		t.Fatalf("Expected the third container of the tenant to be rejected, got %v", err)
+	}
+	if err := daemon.verifyContainerQuotas(&containertypes.Config{Labels: map[string]string{"tenant": "other"}}); err != nil {
+		t.Fatalf("Expected another tenant to have its own quota, got %v", err)
+	}
+	if err := daemon.verifyContainerQuotas(&containertypes.Config{}); err != nil {
+		t.Fatalf("Expected a container without the label not to be counted, got %v", err)
+	}
+
+	daemon.containers.Get("acme1").SetRemovalInProgress()
+	if err := daemon.verifyContainerQuotas(acme); err != nil {
+		t.Fatalf("Expected a container being removed not to be counted, got %v", err)
+	}
+	daemon.containers.Get("acme1").ResetRemovalInProgress()
+	daemon.containers.Delete("acme1")
+	if err := daemon.verifyContainerQuotas(acme); err != nil {
+		t.Fatalf("Expected the removal of a container to free its place, got %v", err)
+	}
+
+	// a quota on a label value only applies to that value
+	daemon.configStore.ContainerQuotas = []string{"tenant=other:1"}
+	add("acme3", "acme")
+	if err := daemon.verifyContainerQuotas(acme); err != nil {
+		t.Fatalf("Expected the tenant without quota to be accepted, got %v", err)
+	}
+	if err := daemon.verifyContainerQuotas(&containertypes.Config{Labels: map[string]string{"tenant": "other"}}); err == nil {
+		t.Fatal("Expected the second container of the tenant with a quota of 1 to be rejected")
+	}
+}
+
+func TestValidateContainerQuota(t *testing.T) {
+	for _, valid := range []string{"tenant:5", "tenant=acme:10", "tenant=:0", "com.example/tenant=a:b:1"} {
+		if _, err := ValidateContainerQuota(valid); err != nil {
+			t.Fatalf("Expected %q to be valid, got %v", valid, err)
+		}
+	}
+	for _, invalid := range []string{"", "tenant", "tenant=acme", ":5", "=acme:5", "tenant:-1", "tenant:many"} {
+		if _, err := ValidateContainerQuota(invalid); err == nil {
+			t.Fatalf("Expected %q to be invalid", invalid)
+		}
+	}
+}
+
+func TestCheckImagePlatform(t *testing.T) {
+	for _, tc := range []struct {
+		os, arch string
+		valid    bool
+	}{
+		{"linux", "amd64", true},
+		{"linux", "x86_64", true},
+		{"", "", true},
+		{"linux", "", true},
+		{"", "amd64", true},
+		{"linux", "arm64", false},
+		{"linux", "aarch64", false},
+		{"windows", "amd64", false},
+		{"windows", "", false},
+	} {
+		img := &image.Image{V1Image: image.V1Image{OS: tc.os, Architecture: tc.arch}}
+		err := checkImagePlatform(img, "linux", "amd64")
+		if tc.valid && err != nil {
+			t.Fatalf("Expected an image built for %s/%s to run on linux/amd64, got %v", tc.os, tc.arch, err)
+		}
+		if !tc.valid && (err == nil || !strings.Contains(err.Error(), "the host is linux/amd64")) {
+			t.Fatalf("Expected an image built for %s/%s to be refused on linux/amd64, got %v", tc.os, tc.arch, err)
+		}
+	}
+
+	img := &image.Image{V1Image: image.V1Image{OS: "linux", Architecture: "aarch64"}}
+	if err := checkImagePlatform(img, "linux", "arm64"); err != nil {
+		t.Fatalf("Expected aarch64 to match arm64, got %v", err)
+	}
+	err := checkImagePlatform(img, "linux", "amd64")
+	if err == nil || !strings.Contains(err.Error(), "was built for linux/arm64") {
+		t.Fatalf("Expected the error to name the platform of the image, got %v", err)
+	}
+}
+
+func TestVerifyImagePlatformAllowMismatch(t *testing.T) {
+	arch := "arm64"
+	if runtime.GOARCH == arch {
+		arch = "amd64"
+	}
+	img := &image.Image{V1Image: image.V1Image{OS: runtime.GOOS, Architecture: arch}}
+	daemon := &Daemon{configStore: &Config{}}
+	if err := daemon.verifyImagePlatform(img); err == nil {
+		t.Fatalf("Expected an image built for %s to be refused on %s", arch, runtime.GOARCH)
+	}
+	daemon.configStore.AllowPlatformMismatch = true
+	if err := daemon.verifyImagePlatform(img); err != nil {
+		t.Fatalf("Expected the mismatch to be allowed, got %v", err)
+	}
+}
+
+func TestCreateTimerOnlyInDebug(t *testing.T) {
+	if timer := newCreateTimer(); timer != nil {
+		t.Fatal("Expected no timer outside of debug mode")
+	}
+	// a nil timer records nothing
+	var timer *createTimer
+	timer.observe(createPhaseImage, time.Now())
+	timer.log("ctr")
+
+	utils.EnableDebug()
+	defer utils.DisableDebug()
+	timer = newCreateTimer()
+	if timer == nil {
+		t.Fatal("Expected a timer in debug mode")
+	}
+	timer.start = timer.start.Add(-time.Second)
+	timer.observe(createPhaseImage, timer.start)
+	timer.log("ctr")
+	for _, phase := range []string{createPhaseImage, createPhaseTotal} {
+		d, err := time.ParseDuration(timer.phases[phase].(string))
+		if err != nil || d < time.Second {
+			t.Fatalf("Expected phase %s to take at least 1s, got %v (%v)", phase, timer.phases[phase], err)
+		}
+	}
+	if timer.phases["container"] != "ctr" {
+		t.Fatalf("Expected the durations to be logged with the container ID, got %v", timer.phases["container"])
+	}
+}
+
+func TestGenerateHostnameTemplate(t *testing.T) {
+	daemon := &Daemon{
+		containers:    container.NewMemoryStore(),
+		hostnameIndex: registrar.NewRegistrar(),
+		configStore:   &Config{},
+	}
+	id := "0123456789abcdef0123456789abcdef"
+
+	config := &containertypes.Config{}
+	if err := daemon.generateHostname(id, "/web", config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Hostname != "0123456789ab" {
+		t.Fatalf("Expected the truncated ID without a template, got %s", config.Hostname)
+	}
+
+	config = &containertypes.Config{Hostname: "explicit"}
+	daemon.configStore.HostnameTemplate = "{{.Labels.app}}-{{.Index}}"
+	if err := daemon.generateHostname(id, "/web", config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Hostname != "explicit" {
+		t.Fatalf("Expected the hostname of the container to be kept, got %s", config.Hostname)
+	}
+
+	for i, expected := range []string{"api-1", "api-2", "api-3"} {
+		config = &containertypes.Config{Labels: map[string]string{"app": "api"}}
+		if err := daemon.generateHostname(id, "/web", config); err != nil {
+			t.Fatal(err)
+		}
+		if config.Hostname != expected {
+			t.Fatalf("Expected hostname %s, got %s", expected, config.Hostname)
+		}
+		c := container.NewBaseContainer(fmt.Sprintf("api%d", i), "")
+		c.Config = config
+		daemon.containers.Add(c.ID, c)
+	}
+
+	daemon.configStore.HostnameTemplate = "{{.Name}}"
+	if err := daemon.generateHostname(id, "/api-1", &containertypes.Config{}); err == nil || !strings.Contains(err.Error(), "already used") {
+		t.Fatalf("Expected a hostname without index in use to be an error, got %v", err)
+	}
+
+	daemon.configStore.HostnameTemplate = "{{.Labels.app}}-{{.Index}}"
+	if err := daemon.generateHostname(id, "/web", &containertypes.Config{}); err == nil || !strings.Contains(err.Error(), "no entry for key") {
+		t.Fatalf("Expected a missing label to be an error, got %v", err)
+	}
+	daemon.configStore.HostnameTemplate = "{{.Name}}"
+	config = &containertypes.Config{}
+	if err := daemon.generateHostname(id, "/web_1", config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Hostname != "web-1" {
+		t.Fatalf("Expected the underscores to be replaced by hyphens, got %s", config.Hostname)
+	}
+	if err := daemon.generateHostname(id, "/web.1", &containertypes.Config{}); err == nil || !strings.Contains(err.Error(), "not a valid hostname") {
+		t.Fatalf("Expected an invalid hostname to be an error, got %v", err)
+	}
+}
+
+func TestGenerateHostnameTemplateReserved(t *testing.T) {
+	daemon := &Daemon{
+		containers:    container.NewMemoryStore(),
+		hostnameIndex: registrar.NewRegistrar(),
+		configStore:   &Config{HostnameTemplate: "{{.Labels.app}}-{{.Index}}"},
+	}
+
+	// the containers being created are not in the store yet
+	for i, expected := range []string{"api-1", "api-2"} {
+		config := &containertypes.Config{Labels: map[string]string{"app": "api"}}
+		if err := daemon.generateHostname(fmt.Sprintf("%032d", i), "/web", config); err != nil {
+			t.Fatal(err)
+		}
+		if config.Hostname != expected {
+			t.Fatalf("Expected hostname %s, got %s", expected, config.Hostname)
+		}
+	}
+
+	daemon.hostnameIndex.Delete(fmt.Sprintf("%032d", 0))
+	config := &containertypes.Config{Labels: map[string]string{"app": "api"}}
+	if err := daemon.generateHostname(fmt.Sprintf("%032d", 2), "/web", config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Hostname != "api-1" {
+		t.Fatalf("Expected the hostname of a removed container to be reused, got %s", config.Hostname)
+	}
+}
+
+func TestHostnameTemplateTruncation(t *testing.T) {
+	tmpl, err := parseHostnameTemplate("{{.Labels.app}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := strings.Repeat("a", 62) + "-b" + strings.Repeat("c", 10)
+	hostname, err := executeHostnameTemplate(tmpl, hostnameTemplateData{Labels: map[string]string{"app": app}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostname != strings.Repeat("a", 62) {
+		t.Fatalf("Expected the hostname to be truncated to a DNS label, got %s (%d)", hostname, len(hostname))
+	}
+}
+
+func TestHostnameTemplateTruncationKeepsIndex(t *testing.T) {
+	daemon := &Daemon{
+		containers:    container.NewMemoryStore(),
+		hostnameIndex: registrar.NewRegistrar(),
+		configStore:   &Config{HostnameTemplate: "{{.Labels.app}}-{{.Index}}"},
+	}
+	app := strings.Repeat("a", 62)
+	for i, expected := range []string{strings.Repeat("a", 61) + "-1", strings.Repeat("a", 61) + "-2"} {
+		config := &containertypes.Config{Labels: map[string]string{"app": app}}
+		if err := daemon.generateHostname(fmt.Sprintf("%032d", i), "/web", config); err != nil {
+			t.Fatal(err)
+		}
+		if config.Hostname != expected {
+			t.Fatalf("Expected hostname %s, got %s (%d)", expected, config.Hostname, len(config.Hostname))
+		}
+	}
+}
+
+func TestValidateHostnameTemplate(t *testing.T) {
+	if err := ValidateConfiguration(&Config{CommonConfig: CommonConfig{HostnameTemplate: "{{.Name}}-{{.Index}}"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateConfiguration(&Config{CommonConfig: CommonConfig{HostnameTemplate: "{{.Name"}}); err == nil {
+		t.Fatal("Expected an invalid hostname template to be rejected")
+	}
+}
+
+func TestVerifyIDMaps(t *testing.T) {
+	daemon := &Daemon{}
+	hostConfig := &containertypes.HostConfig{}
+	config := &containertypes.Config{Labels: map[string]string{
+		runconfig.UIDMapsLabel: "0:200000:65536",
+		runconfig.GIDMapsLabel: "0:200000:65536",
+	}}
+	if err := daemon.verifyIDMaps(hostConfig, config); err == nil || !strings.Contains(err.Error(), "--userns-remap") {
+		t.Fatalf("Expected the ID maps to require user remapping, got %v", err)
+	}
+
+	daemon.uidMaps = []idtools.IDMap{{ContainerID: 0, HostID: 100000, Size: 165536}}
+	daemon.gidMaps = []idtools.IDMap{{ContainerID: 0, HostID: 100000, Size: 165536}}
+	if err := daemon.verifyIDMaps(hostConfig, config); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		maps string
+		err  string
+	}{
+		// exhausts the subordinate range
+		{"0:220000:65536", "not within the subordinate UIDs"},
+		{"0:50000:1000", "not within the subordinate UIDs"},
+		{"0:0:65536", "not within the subordinate UIDs"},
+		{"0:33:1", "not within the subordinate UIDs"},
+		{"0:200000:1000,1000:4294967000:1000", "not within the subordinate UIDs"},
+		{"0:200000:1000,500:210000:1000", "overlap"},
+		{"0:200000:1000,1000:200500:1000", "overlap"},
+		{"1000:200000:1000", "must map the root"},
+		{"0:200000:0", "the size must be positive"},
+		{"0:200000", "expected containerID:hostID:size"},
+	} {
+		config.Labels[runconfig.UIDMapsLabel] = tc.maps
+		if err := daemon.verifyIDMaps(hostConfig, config); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Fatalf("Expected %s to be rejected with %q, got %v", tc.maps, tc.err, err)
+		}
+	}
+
+	delete(config.Labels, runconfig.UIDMapsLabel)
+	if err := daemon.verifyIDMaps(hostConfig, config); err == nil || !strings.Contains(err.Error(), "Both the UID and GID maps") {
+		t.Fatalf("Expected the GID maps to require UID maps, got %v", err)
+	}
+}
+
+func TestVerifyIDMapsOverlap(t *testing.T) {
+	daemon := &Daemon{containers: container.NewMemoryStore()}
+	tenant := container.NewBaseContainer("tenant-a", "")
+	tenant.Config = &containertypes.Config{Labels: map[string]string{
+		runconfig.UIDMapsLabel: "0:200000:65536",
+		runconfig.GIDMapsLabel: "0:200000:65536",
+	}}
+	daemon.containers.Add(tenant.ID, tenant)
+	shared := container.NewBaseContainer("shared", "")
+	shared.Config = &containertypes.Config{}
+	daemon.containers.Add(shared.ID, shared)
+
+	config := &containertypes.Config{Labels: map[string]string{
+		runconfig.UIDMapsLabel: "0:265535:65536",
+		runconfig.GIDMapsLabel: "0:265536:65536",
+	}}
+	if err := daemon.verifyIDMapsOverlap(config); err == nil || !strings.Contains(err.Error(), "of container tenant-a") {
+		t.Fatalf("Expected the UID maps overlapping another container to be rejected, got %v", err)
+	}
+	config.Labels[runconfig.UIDMapsLabel] = "0:265536:65536"
+	if err := daemon.verifyIDMapsOverlap(config); err != nil {
+		t.Fatal(err)
+	}
+
+	uid, gid := daemon.containerRootUIDGID(tenant)
+	if uid != 200000 || gid != 200000 {
+		t.Fatalf("Expected the root of the container to be mapped to 200000:200000, got %d:%d", uid, gid)
+	}
+}
+
+func TestShiftID(t *testing.T) {
+	remapped := []idtools.IDMap{{ContainerID: 0, HostID: 100000, Size: 65536}}
+	own := []idtools.IDMap{{ContainerID: 0, HostID: 200000, Size: 1000}, {ContainerID: 1000, HostID: 300000, Size: 10}}
+	for _, tc := range []struct {
+		id, shifted int
+	}{
+		{100000, 200000},
+		{100999, 200999},
+		{101005, 300005},
+		// not mapped by the container, or already owned by it
+		{165534, 165534},
+		{200000, 200000},
+	} {
+		if shifted := shiftID(tc.id, remapped, own); shifted != tc.shifted {
+			t.Fatalf("Expected %d to be shifted to %d, got %d", tc.id, tc.shifted, shifted)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package daemon
+
+import (
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/utils"
+)
+
+// Phases of a container creation recorded by createTimer.
+const (
+	createPhaseImage      = "image"
+	createPhaseContainer  = "newcontainer"
+	createPhaseRWLayer    = "rwlayer"
+	createPhaseHostConfig = "hostconfig"
+	createPhasePlatform   = "platform"
+	createPhaseNetwork    = "network"
+	createPhaseTotal      = "total"
+)
+
+// createTimer records the duration of the phases of a container creation,
+// logged once the container is created. Timings are only collected in debug
+// mode.
+type createTimer struct {
+	start  time.Time
+	phases logrus.Fields
+}
+
+// newCreateTimer returns a timer for a creation starting now, or nil outside
+// of debug mode.
+func newCreateTimer() *createTimer {
+	if !utils.IsDebugEnabled() {
+		return nil
+	}
+	return &createTimer{start: time.Now(), phases: logrus.Fields{}}
+}
+
+// observe records the time elapsed since start for the given phase.
+func (t *createTimer) observe(phase string, start time.Time) {
+	if t == nil {
+		return
+	}
+	t.phases[phase] = time.Since(start).String()
+}
+
+// log logs the durations of the phases of the creation of containerID.
+func (t *createTimer) log(containerID string) {
+	if t == nil {
+		return
+	}
+	t.observe(createPhaseTotal, t.start)
+	t.phases["container"] = containerID
+	logrus.WithFields(t.phases).Debug("Container created")
+}
@@ -6,14 +6,20 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/container"
 	"github.com/docker/docker/pkg/stringid"
 	containertypes "github.com/docker/engine-api/types/container"
+	"github.com/docker/go-units"
 	"github.com/opencontainers/runc/libcontainer/label"
 )
 
+// tmpTmpfsDestination is where a tmpfs is mounted in the containers with
+// one of the TmpTmpfsLabels of the daemon.
+const tmpTmpfsDestination = "/tmp"
+
 // createContainerPlatformSpecificSettings performs platform specific container create functionality
 func (daemon *Daemon) createContainerPlatformSpecificSettings(container *container.Container, config *containertypes.Config, hostConfig *containertypes.HostConfig) error {
 	if err := daemon.Mount(container); err != nil {
@@ -21,11 +27,15 @@ func (daemon *Daemon) createContainerPlatformSpecificSettings(container *contain
 	}
 	defer daemon.Unmount(container)
 
-	rootUID, rootGID := daemon.GetRemappedUIDGID()
+	rootUID, rootGID := daemon.containerRootUIDGID(container)
 	if err := container.SetupWorkingDirectory(rootUID, rootGID); err != nil {
 		return err
 	}
 
+	if err := daemon.setTmpTmpfs(container, config, hostConfig); err != nil {
+		return err
+	}
+
 	for spec := range config.Volumes {
 		name := stringid.GenerateNonCryptoID()
 		destination := filepath.Clean(spec)
@@ -45,7 +55,7 @@ func (daemon *Daemon) createContainerPlatformSpecificSettings(container *contain
 			return fmt.Errorf("cannot mount volume over existing file, file exists %s", path)
 		}
 
-		v, err := daemon.volumes.CreateWithRef(name, hostConfig.VolumeDriver, container.ID, nil, nil)
+		v, err := daemon.volumes.CreateWithRef(name, hostConfig.VolumeDriver, container.ID, nil, daemon.anonymousVolumeLabels(config))
 		if err != nil {
 			return err
 		}
@@ -59,6 +69,48 @@ func (daemon *Daemon) createContainerPlatformSpecificSettings(container *contain
 	return daemon.populateVolumes(container)
 }
 
+// setTmpTmpfs adds a tmpfs of the size set in the daemon configuration on
+// /tmp to the tmpfs mounts of a container with one of the TmpTmpfsLabels,
+// so that what it writes there doesn't end up in its writable layer. It is
+// skipped if a tmpfs, a bind mount or a volume is already mounted on /tmp.
+func (daemon *Daemon) setTmpTmpfs(container *container.Container, config *containertypes.Config, hostConfig *containertypes.HostConfig) error {
+	if daemon.configStore == nil || !hasLabel(config.Labels, daemon.configStore.TmpTmpfsLabels) {
+		return nil
+	}
+	for dest := range hostConfig.Tmpfs {
+		if filepath.Clean(dest) == tmpTmpfsDestination {
+			return nil
+		}
+	}
+	for spec := range config.Volumes {
+		if filepath.Clean(spec) == tmpTmpfsDestination {
+			return nil
+		}
+	}
+	if container.IsDestinationMounted(tmpTmpfsDestination) {
+		return nil
+	}
+
+	size, err := units.RAMInBytes(daemon.configStore.TmpTmpfsSize)
+	if err != nil {
+		return err
+	}
+	if hostConfig.Tmpfs == nil {
+		hostConfig.Tmpfs = make(map[string]string)
+	}
+	hostConfig.Tmpfs[tmpTmpfsDestination] = fmt.Sprintf("size=%d", size)
+	return nil
+}
+
+// ValidateTmpTmpfsLabel validates a label of the containers getting a tmpfs
+// mounted on /tmp, as key or key=value.
+func ValidateTmpTmpfsLabel(val string) (string, error) {
+	if val == "" || strings.HasPrefix(val, "=") {
+		return "", fmt.Errorf("invalid tmpfs label %q, the format is key or key=value", val)
+	}
+	return val, nil
+}
+
 // populateVolumes copies data from the container's rootfs into the volume for non-binds.
 // this is only called when the container is created.
 func (daemon *Daemon) populateVolumes(c *container.Container) error {
@@ -14,6 +14,7 @@ import (
 	"path"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"syscall"
@@ -22,10 +23,12 @@ import (
 	"github.com/Sirupsen/logrus"
 	containerd "github.com/docker/containerd/api/grpc/types"
 	"github.com/docker/docker/api"
+	"github.com/docker/docker/api/types/backend"
 	"github.com/docker/docker/builder"
 	"github.com/docker/docker/container"
 	"github.com/docker/docker/daemon/events"
 	"github.com/docker/docker/daemon/exec"
+	"github.com/docker/docker/daemon/graphdriver"
 	"github.com/docker/docker/errors"
 	"github.com/docker/engine-api/types"
 	containertypes "github.com/docker/engine-api/types/container"
@@ -51,7 +54,6 @@ import (
 	"github.com/docker/docker/pkg/fileutils"
 	"github.com/docker/docker/pkg/graphdb"
 	"github.com/docker/docker/pkg/idtools"
-	"github.com/docker/docker/pkg/namesgenerator"
 	"github.com/docker/docker/pkg/progress"
 	"github.com/docker/docker/pkg/registrar"
 	"github.com/docker/docker/pkg/signal"
@@ -68,6 +70,7 @@ import (
 	"github.com/docker/docker/volume/local"
 	"github.com/docker/docker/volume/store"
 	"github.com/docker/go-connections/nat"
+	"github.com/docker/go-units"
 	"github.com/docker/libnetwork"
 	nwconfig "github.com/docker/libnetwork/config"
 	"github.com/docker/libtrust"
@@ -122,14 +125,34 @@ type Daemon struct {
 	root                      string
 	seccompEnabled            bool
 	shutdown                  bool
+	drained                   int32 // accessed atomically, see SetDrainMode
 	uidMaps                   []idtools.IDMap
 	gidMaps                   []idtools.IDMap
 	layerStore                layer.Store
 	imageStore                image.Store
 	nameIndex                 *registrar.Registrar
+	hostnameIndex             *registrar.Registrar // hostnames generated from the hostname template
 	linkIndex                 *linkIndex
 	containerd                libcontainerd.Client
 	defaultIsolation          containertypes.Isolation // Default isolation mode on Windows
+	createInterceptors        []CreateInterceptor
+	quotaMu                   sync.Mutex // serializes the creations checked against the container quotas
+	idMapsMu                  sync.Mutex // serializes the creations checked against the ID maps of the other containers
+	freezeMu                  sync.Mutex // serializes FreezeContainers and ThawContainers
+	configRestartMu           sync.Mutex // serializes the restarts on configuration changes
+	specMutators              []SpecMutator
+	shutdownCtx               context.Context // cancelled when the daemon shuts down
+	cancelShutdownCtx         context.CancelFunc
+	startLimiter              *startLimiter // caps the number of concurrent container starts
+	stoppingMu                sync.Mutex
+	stopping                  map[string]struct{} // IDs of the containers being stopped on shutdown
+	reloadMu                  sync.Mutex
+	reloadStatus              *backend.ReloadStatus
+	reloadedValues            map[string]interface{} // configuration file values last applied by Reload
+	eventsWebhookMu           sync.Mutex
+	eventsWebhook             *eventsWebhook // posts the container events to --events-webhook
+	readyMu                   sync.Mutex
+	readyProbe                chan struct{} // closed when the in-flight probe of the storage driver returns
 }
 
 // GetContainer looks for a container using the provided information, which could be
@@ -265,7 +288,7 @@ func (daemon *Daemon) restore() error {
 		// Ignore the container if it does not support the current driver being used by the graph
 		if (container.Driver == "" && currentDriver == "aufs") || container.Driver == currentDriver {
 			rwlayer, err := daemon.layerStore.GetRWLayer(container.ID)
-			if err != nil {
+			if err != nil && !(err == layer.ErrMountDoesNotExist && container.DeferredRWLayer) {
 				logrus.Errorf("Failed to load container mount %v: %v", id, err)
 				continue
 			}
@@ -302,7 +325,7 @@ func (daemon *Daemon) restore() error {
 					logrus.Errorf("Failed to ReinitRWLayer for %s due to %s", c.ID, err)
 					return
 				}
-				if err := daemon.containerd.Restore(c.ID, libcontainerd.WithRestartManager(c.RestartManager(true))); err != nil {
+				if err := daemon.containerd.Restore(c.ID, daemon.containerdOptions(c)...); err != nil {
 					logrus.Errorf("Failed to restore with containerd: %q", err)
 					return
 				}
@@ -323,6 +346,8 @@ func (daemon *Daemon) restore() error {
 	}
 	wg.Wait()
 
+	daemon.cleanupContainerdOrphans()
+
 	// migrate any legacy links from sqlite
 	linkdbFile := filepath.Join(daemon.root, "linkgraph.db")
 	var legacyLinkDB *graphdb.Database
@@ -370,7 +395,7 @@ func (daemon *Daemon) restore() error {
 
 			// Make sure networks are available before starting
 			daemon.waitForNetworks(c)
-			if err := daemon.containerStart(c); err != nil {
+			if err := daemon.containerStart(context.Background(), c, false); err != nil {
 				logrus.Errorf("Failed to start container %s: %s", c.ID, err)
 			}
 			close(chNotify)
@@ -498,10 +523,17 @@ func (daemon *Daemon) releaseName(name string) {
 	daemon.nameIndex.Release(name)
 }
 
+// generateNewName reserves a name generated by the name generator of the
+// daemon for the container id, or its truncated ID if the generated names
+// are in use.
 func (daemon *Daemon) generateNewName(id string) (string, error) {
+	generator, err := daemon.nameGenerator(NameGeneratorFriendly)
+	if err != nil {
+		return "", err
+	}
 	var name string
-	for i := 0; i < 6; i++ {
-		name = namesgenerator.GetRandomName(i)
+	for i := 0; i < maxGeneratedNameAttempts; i++ {
+		name = generator.GenerateName(i)
 		if name[0] != '/' {
 			name = "/" + name
 		}
@@ -522,11 +554,21 @@ func (daemon *Daemon) generateNewName(id string) (string, error) {
 	return name, nil
 }
 
-func (daemon *Daemon) generateHostname(id string, config *containertypes.Config) {
-	// Generate default hostname
-	if config.Hostname == "" {
-		config.Hostname = id[:12]
+func (daemon *Daemon) generateHostname(id, name string, config *containertypes.Config) error {
+	if config.Hostname != "" {
+		return nil
+	}
+	if daemon.configStore != nil && daemon.configStore.HostnameTemplate != "" {
+		hostname, err := daemon.templateHostname(daemon.configStore.HostnameTemplate, id, name, config)
+		if err != nil {
+			return err
+		}
+		config.Hostname = hostname
+		return nil
 	}
+	// Generate default hostname
+	config.Hostname = id[:12]
+	return nil
 }
 
 func (daemon *Daemon) getEntrypointAndArgs(configEntrypoint strslice.StrSlice, configCmd strslice.StrSlice) (string, []string) {
@@ -548,7 +590,10 @@ func (daemon *Daemon) newContainer(name string, config *containertypes.Config, i
 	}
 
 	//根据id生成hostname，并且写到config中
-	daemon.generateHostname(id, config)
+	if err := daemon.generateHostname(id, name, config); err != nil {
+		daemon.releaseName(name)
+		return nil, err
+	}
 	entrypoint, args := daemon.getEntrypointAndArgs(config.Entrypoint, config.Cmd)
 
 	/*
@@ -714,6 +759,8 @@ func NewDaemon(config *Config, registryService *registry.Service, containerdRemo
 	os.Setenv("TMPDIR", realTmp)
 
 	d := &Daemon{configStore: config}
+	d.startLimiter = newStartLimiter(config.MaxConcurrentStarts)
+	d.shutdownCtx, d.cancelShutdownCtx = context.WithCancel(context.Background())
 	// Ensure the daemon is properly shutdown if there is a failure during
 	// initialization
 	defer func() {
@@ -764,6 +811,10 @@ func NewDaemon(config *Config, registryService *registry.Service, containerdRemo
 	}
 
 	graphDriver := d.layerStore.DriverName()
+	if err := checkRequiredGraphDriver(config, graphDriver, uidMaps, gidMaps); err != nil {
+		d.layerStore.Cleanup()
+		return nil, err
+	}
 	imageRoot := filepath.Join(config.Root, "image", graphDriver)
 
 	// Configure and validate the kernels security support
@@ -856,6 +907,9 @@ func NewDaemon(config *Config, registryService *registry.Service, containerdRemo
 	}
 	d.RegistryService = registryService
 	d.EventsService = eventsService
+	if err := d.setEventsWebhook(config.EventsWebhook); err != nil {
+		return nil, err
+	}
 	d.volumes = volStore
 	d.root = config.Root
 	d.uidMaps = uidMaps
@@ -863,6 +917,7 @@ func NewDaemon(config *Config, registryService *registry.Service, containerdRemo
 	d.seccompEnabled = sysInfo.Seccomp
 
 	d.nameIndex = registrar.NewRegistrar()
+	d.hostnameIndex = registrar.NewRegistrar()
 	d.linkIndex = newLinkIndex()
 
 	go d.execCommandGC()
@@ -879,7 +934,7 @@ func NewDaemon(config *Config, registryService *registry.Service, containerdRemo
 	return d, nil
 }
 
-func (daemon *Daemon) shutdownContainer(c *container.Container) error {
+func (daemon *Daemon) shutdownContainer(c *container.Container, timeout int) error {
 	// TODO(windows): Handle docker restart with paused containers
 	if c.IsPaused() {
 		// To terminate a process in freezer cgroup, we should send
@@ -896,8 +951,8 @@ func (daemon *Daemon) shutdownContainer(c *container.Container) error {
 		if err := daemon.containerUnpause(c); err != nil {
 			return fmt.Errorf("Failed to unpause container %s with error: %v", c.ID, err)
 		}
-		if _, err := c.WaitStop(10 * time.Second); err != nil {
-			logrus.Debugf("container %s failed to exit in 10 second of SIGTERM, sending SIGKILL to force", c.ID)
+		if _, err := c.WaitStop(time.Duration(timeout) * time.Second); err != nil {
+			logrus.Debugf("container %s failed to exit in %d second of SIGTERM, sending SIGKILL to force", c.ID, timeout)
 			sig, ok := signal.SignalMap["KILL"]
 			if !ok {
 				return fmt.Errorf("System does not support SIGKILL")
@@ -909,8 +964,8 @@ func (daemon *Daemon) shutdownContainer(c *container.Container) error {
 			return err
 		}
 	}
-	// If container failed to exit in 10 seconds of SIGTERM, then using the force
-	if err := daemon.containerStop(c, 10); err != nil {
+	// If container failed to exit in timeout seconds of SIGTERM, then using the force
+	if err := daemon.containerStop(c, timeout); err != nil {
 		return fmt.Errorf("Stop container %s with error: %v", c.ID, err)
 	}
 
@@ -918,9 +973,61 @@ func (daemon *Daemon) shutdownContainer(c *container.Container) error {
 	return nil
 }
 
+// shutdownStopTimeout returns the number of seconds to wait for c to stop
+// on shutdown before killing it. It doesn't go past deadline, when the
+// shutdown is forced, so that the container is killed rather than left
+// running.
+func shutdownStopTimeout(c *container.Container, defaultTimeout int, deadline time.Time) int {
+	timeout := c.StopTimeout(defaultTimeout)
+	if remaining := int(deadline.Sub(time.Now()) / time.Second); remaining < timeout {
+		timeout = remaining
+	}
+	if timeout < 0 {
+		return 0
+	}
+	return timeout
+}
+
+// setStopping records whether the container id is being stopped on
+// shutdown.
+func (daemon *Daemon) setStopping(id string, stopping bool) {
+	daemon.stoppingMu.Lock()
+	defer daemon.stoppingMu.Unlock()
+	if daemon.stopping == nil {
+		daemon.stopping = make(map[string]struct{})
+	}
+	if stopping {
+		daemon.stopping[id] = struct{}{}
+	} else {
+		delete(daemon.stopping, id)
+	}
+}
+
+// StoppingContainers returns the sorted IDs of the containers Shutdown is
+// still stopping.
+func (daemon *Daemon) StoppingContainers() []string {
+	daemon.stoppingMu.Lock()
+	defer daemon.stoppingMu.Unlock()
+	ids := make([]string, 0, len(daemon.stopping))
+	for id := range daemon.stopping {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
 // Shutdown stops the daemon.
 func (daemon *Daemon) Shutdown() error {
 	daemon.shutdown = true
+	stopTimeout, deadline := defaultShutdownStopTimeout, time.Now().Add(defaultShutdownTimeout*time.Second)
+	if daemon.configStore != nil {
+		stopTimeout = daemon.configStore.ShutdownStopTimeout
+		deadline = time.Now().Add(time.Duration(daemon.configStore.ShutdownTimeout) * time.Second)
+	}
+	if daemon.cancelShutdownCtx != nil {
+		// abort the containers being started
+		daemon.cancelShutdownCtx()
+	}
 	if daemon.containers != nil {
 		logrus.Debug("starting clean shutdown of all containers...")
 		daemon.containers.ApplyAll(func(c *container.Container) {
@@ -928,7 +1035,9 @@ func (daemon *Daemon) Shutdown() error {
 				return
 			}
 			logrus.Debugf("stopping %s", c.ID)
-			if err := daemon.shutdownContainer(c); err != nil {
+			daemon.setStopping(c.ID, true)
+			defer daemon.setStopping(c.ID, false)
+			if err := daemon.shutdownContainer(c, shutdownStopTimeout(c, stopTimeout, deadline)); err != nil {
 				logrus.Errorf("Stop container error: %v", err)
 				return
 			}
@@ -939,6 +1048,9 @@ func (daemon *Daemon) Shutdown() error {
 		})
 	}
 
+	// post the exit events of the stopped containers
+	daemon.setEventsWebhook("")
+
 	// trigger libnetwork Stop only if it's initialized
 	if daemon.netController != nil {
 		daemon.netController.Stop()
@@ -959,7 +1071,14 @@ func (daemon *Daemon) Shutdown() error {
 
 // Mount sets container.BaseFS
 // (is it not set coming in? why is it unset?)
+// The writable layer of a container created with DeferRWLayer is created
+// when it is first mounted.
 func (daemon *Daemon) Mount(container *container.Container) error {
+	if container.RWLayer == nil && container.DeferredRWLayer {
+		if err := daemon.setRWLayer(container); err != nil {
+			return err
+		}
+	}
 	dir, err := container.RWLayer.Mount(container.GetMountLabel())
 	if err != nil {
 		return err
@@ -982,6 +1101,10 @@ func (daemon *Daemon) Mount(container *container.Container) error {
 
 // Unmount unsets the container base filesystem
 func (daemon *Daemon) Unmount(container *container.Container) error {
+	if container.RWLayer == nil {
+		// the writable layer is deferred and was never mounted
+		return nil
+	}
 	if err := container.RWLayer.Unmount(); err != nil {
 		logrus.Errorf("Error unmounting container %s: %s", container.ID, err)
 		return err
@@ -1002,6 +1125,10 @@ func (daemon *Daemon) unsubscribeToContainerStats(c *container.Container, ch cha
 }
 
 func (daemon *Daemon) changes(container *container.Container) ([]archive.Change, error) {
+	if container.RWLayer == nil {
+		// the writable layer is deferred, nothing changed yet
+		return nil, nil
+	}
 	return container.RWLayer.Changes()
 }
 
@@ -1084,6 +1211,29 @@ func (daemon *Daemon) PullImage(ctx context.Context, ref reference.Named, metaHe
 	return err
 }
 
+// PullImageIfMissing pulls the image referenced by name unless it is present
+// locally. Images referenced by digest are pulled by digest, and images
+// referenced by ID are never pulled. The pull progress is written to
+// outStream.
+func (daemon *Daemon) PullImageIfMissing(ctx context.Context, name string, metaHeaders map[string][]string, authConfig *types.AuthConfig, outStream io.Writer) error {
+	_, err := daemon.GetImageID(name)
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(ErrImageDoesNotExist); !ok {
+		return err
+	}
+
+	id, ref, err := reference.ParseIDOrReference(name)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		return nil
+	}
+	return daemon.PullImage(ctx, reference.WithDefaultTag(ref), metaHeaders, authConfig, outStream)
+}
+
 // PullOnBuild tells Docker to pull image referenced by `name`.
 func (daemon *Daemon) PullOnBuild(ctx context.Context, name string, authConfigs map[string]types.AuthConfig, output io.Writer) (builder.Image, error) {
 	ref, err := reference.ParseNamed(name)
@@ -1363,6 +1513,29 @@ func (daemon *Daemon) GetImageOnBuild(name string) (builder.Image, error) {
 	return img, nil
 }
 
+// checkRequiredGraphDriver returns an error if the storage driver selected
+// isn't the one required by the configuration. The required driver is
+// initialized to report why it wasn't selected.
+func checkRequiredGraphDriver(config *Config, selected string, uidMaps, gidMaps []idtools.IDMap) error {
+	required := config.RequiredGraphDriver
+	if required == "" || required == selected {
+		return nil
+	}
+	// don't leave the state of the required driver behind, it would be
+	// detected as prior state on the next start
+	home := filepath.Join(config.Root, required)
+	_, statErr := os.Stat(home)
+	driver, err := graphdriver.GetDriver(required, config.Root, config.GraphOptions, uidMaps, gidMaps)
+	if err != nil {
+		return fmt.Errorf("The storage driver %q was selected but %q is required, it is unavailable: %v", selected, required, err)
+	}
+	driver.Cleanup()
+	if os.IsNotExist(statErr) {
+		os.RemoveAll(home)
+	}
+	return fmt.Errorf("The storage driver %q was selected but %q is required, it is available and can be selected with --storage-driver", selected, required)
+}
+
 // GraphDriverName returns the name of the graph driver used by the layer.Store
 func (daemon *Daemon) GraphDriverName() string {
 	return daemon.layerStore.DriverName()
@@ -1490,9 +1663,17 @@ func (daemon *Daemon) setHostConfig(container *container.Container, hostConfig *
 	return container.ToDisk()
 }
 
-func (daemon *Daemon) setupInitLayer(initPath string) error {
-	rootUID, rootGID := daemon.GetRemappedUIDGID()
-	return setupInitLayer(initPath, rootUID, rootGID)
+// initLayerFunc returns the function setting up the init layer of container
+// c, owned by the root of the container, and verifying it if the daemon is
+// configured to.
+func (daemon *Daemon) initLayerFunc(c *container.Container) layer.MountInit {
+	rootUID, rootGID := daemon.containerRootUIDGID(c)
+	return func(initPath string) error {
+		if err := setupInitLayer(initPath, rootUID, rootGID); err != nil {
+			return err
+		}
+		return daemon.verifyInitLayer(initPath, rootUID, rootGID)
+	}
 }
 
 func setDefaultMtu(config *Config) {
@@ -1505,7 +1686,7 @@ func setDefaultMtu(config *Config) {
 
 // verifyContainerSettings performs validation of the hostconfig and config
 // structures.
-func (daemon *Daemon) verifyContainerSettings(hostConfig *containertypes.HostConfig, config *containertypes.Config, update bool) ([]string, error) {
+func (daemon *Daemon) verifyContainerSettings(hostConfig *containertypes.HostConfig, config *containertypes.Config, update bool) (containerWarnings, error) {
 
 	// First perform verification of settings common across all platforms.
 	if config != nil {
@@ -1528,11 +1709,19 @@ func (daemon *Daemon) verifyContainerSettings(hostConfig *containertypes.HostCon
 		return nil, nil
 	}
 
+	if err := daemon.verifyBindSources(hostConfig); err != nil {
+		return nil, err
+	}
+
 	logCfg := daemon.getLogConfig(hostConfig.LogConfig)
 	if err := logger.ValidateLogOpts(logCfg.Type, logCfg.Config); err != nil {
 		return nil, err
 	}
 
+	if err := verifyStorageOpt(hostConfig.StorageOpt); err != nil {
+		return nil, err
+	}
+
 	for port := range hostConfig.PortBindings {
 		_, portStr := nat.SplitProtoPort(string(port))
 		if _, err := nat.ParsePort(portStr); err != nil {
@@ -1550,6 +1739,62 @@ func (daemon *Daemon) verifyContainerSettings(hostConfig *containertypes.HostCon
 	return verifyPlatformContainerSettings(daemon, hostConfig, config, update)
 }
 
+// verifyContainerLabels checks the settings carried in the labels of a
+// container being created, once the labels of its image are merged in.
+func (daemon *Daemon) verifyContainerLabels(hostConfig *containertypes.HostConfig, config *containertypes.Config) error {
+	if _, err := runconfig.StopTimeoutFromLabels(config.Labels, 0); err != nil {
+		return err
+	}
+	if _, _, err := runconfig.RestartBackoffFromLabels(config.Labels); err != nil {
+		return err
+	}
+	if _, err := runconfig.ProcessGroupSignalsFromLabels(config.Labels); err != nil {
+		return err
+	}
+	if err := verifyLiveRestore(hostConfig, config); err != nil {
+		return err
+	}
+	if err := verifyEphemeralVolumeLabel(config.Labels); err != nil {
+		return err
+	}
+	if err := verifyPlatformContainerLabels(daemon, hostConfig, config); err != nil {
+		return err
+	}
+	return daemon.verifyIDMaps(hostConfig, config)
+}
+
+// verifyStorageOpt checks the per-container storage options. Only "size",
+// the size limit of the writable layer, is supported. Whether the graph
+// driver can enforce it is checked when the layer is created.
+func verifyStorageOpt(storageOpt map[string]string) error {
+	for k, v := range storageOpt {
+		if strings.ToLower(k) != "size" {
+			return fmt.Errorf("Unknown storage option %q", k)
+		}
+		size, err := units.RAMInBytes(v)
+		if err != nil {
+			return fmt.Errorf("Invalid storage size %q: %v", v, err)
+		}
+		if size <= 0 {
+			return fmt.Errorf("Invalid storage size %q: the size must be positive", v)
+		}
+	}
+	return nil
+}
+
+// verifyLiveRestore checks that a container kept running when the daemon
+// restarts has a restart policy, by which it remains supervised.
+func verifyLiveRestore(hostConfig *containertypes.HostConfig, config *containertypes.Config) error {
+	live, err := runconfig.LiveRestoreFromLabels(config.Labels)
+	if err != nil || !live {
+		return err
+	}
+	if hostConfig.RestartPolicy.Name == "" || hostConfig.RestartPolicy.IsNone() {
+		return fmt.Errorf("Live restore requires a restart policy, the container would not be supervised when the daemon restarts")
+	}
+	return nil
+}
+
 // Checks if the client set configurations for more than one network while creating a container
 func (daemon *Daemon) verifyNetworkingConfig(nwConfig *networktypes.NetworkingConfig) error {
 	if nwConfig == nil || len(nwConfig.EndpointsConfig) <= 1 {
@@ -1664,20 +1909,147 @@ func (daemon *Daemon) initDiscovery(config *Config) error {
 // daemon according to those changes.
 // This are the settings that Reload changes:
 // - Daemon labels.
+// - Default log driver and log options.
+// - Daemon shutdown timeout.
 // - Cluster discovery (reconfigure and restart).
-func (daemon *Daemon) Reload(config *Config) error {
+//
+// The whole configuration is validated before any of it is applied, so an
+// invalid configuration leaves the current one untouched. The cluster
+// discovery, whose reconfiguration can still fail, is applied first.
+// The running containers labeled with RestartOnConfigChangeLabel and using
+// a changed default are then restarted.
+// The result of the reload is recorded, see ReloadStatus.
+func (daemon *Daemon) Reload(config *Config) (err error) {
 	daemon.configStore.reloadLock.Lock()
 	defer daemon.configStore.reloadLock.Unlock()
+	defer func() {
+		daemon.recordReload(config, err)
+	}()
+	logConfig, dnsSearch := daemon.defaultLogConfig, daemon.configStore.DNSSearch
+
+	// validate everything before applying anything, so that an invalid
+	// configuration leaves the current one untouched
+	newLogConfig, err := daemon.reloadedLogConfig(config)
+	if err != nil {
+		return err
+	}
+	if config.IsValueSet("events-webhook") && config.EventsWebhook != "" {
+		if err := validateEventsWebhook(config.EventsWebhook); err != nil {
+			return err
+		}
+	}
+	discovery, err := daemon.reloadedClusterDiscovery(config)
+	if err != nil {
+		return err
+	}
+
+	// the discovery is reconfigured first, as it can still fail
+	if discovery != nil {
+		if err := daemon.reloadClusterDiscovery(config, discovery); err != nil {
+			return err
+		}
+	}
+	if config.IsValueSet("events-webhook") {
+		if err := daemon.setEventsWebhook(config.EventsWebhook); err != nil {
+			return err
+		}
+		daemon.configStore.EventsWebhook = config.EventsWebhook
+	}
+	if newLogConfig != nil {
+		daemon.defaultLogConfig = *newLogConfig
+		daemon.configStore.LogConfig = LogConfig{
+			Type:   newLogConfig.Type,
+			Config: newLogConfig.Config,
+		}
+		logrus.Debugf("Using default logging driver %s", newLogConfig.Type)
+	}
 	if config.IsValueSet("labels") {
 		daemon.configStore.Labels = config.Labels
 	}
 	if config.IsValueSet("debug") {
 		daemon.configStore.Debug = config.Debug
 	}
-	return daemon.reloadClusterDiscovery(config)
+	if config.IsValueSet("shutdown-timeout") {
+		daemon.configStore.ShutdownTimeout = config.ShutdownTimeout
+	}
+	if config.IsValueSet("shutdown-stop-timeout") {
+		daemon.configStore.ShutdownStopTimeout = config.ShutdownStopTimeout
+	}
+	if config.IsValueSet("dns-search") {
+		daemon.configStore.DNSSearch = config.DNSSearch
+	}
+	if config.IsValueSet("network-timeout") {
+		daemon.configStore.NetworkTimeout = config.NetworkTimeout
+	}
+	if config.IsValueSet("require-resource-limits") {
+		daemon.configStore.RequireResourceLimits = config.RequireResourceLimits
+	}
+	if config.IsValueSet("resource-limits-exempt-labels") {
+		daemon.configStore.ResourceLimitsExemptLabels = config.ResourceLimitsExemptLabels
+	}
+	if config.IsValueSet("container-quotas") {
+		daemon.configStore.ContainerQuotas = config.ContainerQuotas
+	}
+	if config.IsValueSet("allow-platform-mismatch") {
+		daemon.configStore.AllowPlatformMismatch = config.AllowPlatformMismatch
+	}
+	if config.IsValueSet("hostname-template") {
+		daemon.configStore.HostnameTemplate = config.HostnameTemplate
+	}
+	if config.IsValueSet("ephemeral-anonymous-volumes") {
+		daemon.configStore.EphemeralAnonymousVolumes = config.EphemeralAnonymousVolumes
+	}
+	if config.IsValueSet("log-buffer-size") {
+		daemon.configStore.LogBufferSize = config.LogBufferSize
+	}
+	daemon.reloadPlatform(config)
+	daemon.restartOnConfigChange(daemon.newConfigChange(logConfig, dnsSearch))
+	return nil
+}
+
+// reloadedLogConfig returns the validated default log configuration used by
+// containers created after the reload, or nil if config does not change it.
+// Containers that already exist keep the log configuration they were
+// created with.
+func (daemon *Daemon) reloadedLogConfig(config *Config) (*containertypes.LogConfig, error) {
+	if !config.IsValueSet("log-driver") && !config.IsValueSet("log-opts") {
+		return nil, nil
+	}
+
+	logConfig := daemon.defaultLogConfig
+	if config.IsValueSet("log-driver") && config.LogConfig.Type != logConfig.Type {
+		// log options are driver specific, don't carry them over to a new driver
+		logConfig.Type = config.LogConfig.Type
+		logConfig.Config = nil
+	}
+	if config.IsValueSet("log-opts") {
+		logConfig.Config = config.LogConfig.Config
+	}
+
+	if logConfig.Type != "none" {
+		if _, err := logger.GetLogDriver(logConfig.Type); err != nil {
+			return nil, fmt.Errorf("error finding the logging driver: %v", err)
+		}
+	}
+	if err := logger.ValidateLogOpts(logConfig.Type, logConfig.Config); err != nil {
+		return nil, fmt.Errorf("failed to set log opts: %v", err)
+	}
+	return &logConfig, nil
+}
+
+// clusterDiscovery is the cluster discovery configuration a reload changes
+// to.
+type clusterDiscovery struct {
+	store     string
+	advertise string
+	opts      map[string]string
+	// disabled is set when the reload disables the discovery
+	disabled bool
 }
 
-func (daemon *Daemon) reloadClusterDiscovery(config *Config) error {
+// reloadedClusterDiscovery returns the validated cluster discovery
+// configuration of config, or nil if config does not change it.
+func (daemon *Daemon) reloadedClusterDiscovery(config *Config) (*clusterDiscovery, error) {
 	var err error
 	newAdvertise := daemon.configStore.ClusterAdvertise
 	newClusterStore := daemon.configStore.ClusterStore
@@ -1687,37 +2059,47 @@ func (daemon *Daemon) reloadClusterDiscovery(config *Config) error {
 		}
 		newAdvertise, err = parseClusterAdvertiseSettings(newClusterStore, config.ClusterAdvertise)
 		if err != nil && err != errDiscoveryDisabled {
-			return err
+			return nil, err
 		}
 	}
 
 	// check discovery modifications
 	if !modifiedDiscoverySettings(daemon.configStore, newAdvertise, newClusterStore, config.ClusterOpts) {
-		return nil
+		return nil, nil
 	}
+	return &clusterDiscovery{
+		store:     newClusterStore,
+		advertise: newAdvertise,
+		opts:      config.ClusterOpts,
+		disabled:  err == errDiscoveryDisabled,
+	}, nil
+}
 
+// reloadClusterDiscovery reconfigures and restarts the cluster discovery
+// with the configuration d, validated by reloadedClusterDiscovery.
+func (daemon *Daemon) reloadClusterDiscovery(config *Config, d *clusterDiscovery) error {
 	// enable discovery for the first time if it was not previously enabled
 	if daemon.discoveryWatcher == nil {
-		discoveryWatcher, err := initDiscovery(newClusterStore, newAdvertise, config.ClusterOpts)
+		discoveryWatcher, err := initDiscovery(d.store, d.advertise, d.opts)
 		if err != nil {
 			return fmt.Errorf("discovery initialization failed (%v)", err)
 		}
 		daemon.discoveryWatcher = discoveryWatcher
 	} else {
-		if err == errDiscoveryDisabled {
+		if d.disabled {
 			// disable discovery if it was previously enabled and it's disabled now
 			daemon.discoveryWatcher.Stop()
 		} else {
 			// reload discovery
-			if err = daemon.discoveryWatcher.Reload(config.ClusterStore, newAdvertise, config.ClusterOpts); err != nil {
+			if err := daemon.discoveryWatcher.Reload(config.ClusterStore, d.advertise, d.opts); err != nil {
 				return err
 			}
 		}
 	}
 
-	daemon.configStore.ClusterStore = newClusterStore
-	daemon.configStore.ClusterOpts = config.ClusterOpts
-	daemon.configStore.ClusterAdvertise = newAdvertise
+	daemon.configStore.ClusterStore = d.store
+	daemon.configStore.ClusterOpts = d.opts
+	daemon.configStore.ClusterAdvertise = d.advertise
 
 	if daemon.netController == nil {
 		return nil
@@ -2,8 +2,25 @@
 
 package daemon
 
-import "github.com/docker/engine-api/types/container"
+import (
+	"runtime"
 
-func (daemon *Daemon) verifyExperimentalContainerSettings(hostConfig *container.HostConfig, config *container.Config) ([]string, error) {
-	return nil, nil
+	"github.com/docker/engine-api/types/container"
+)
+
+// lazyNetworking tells whether the containers labeled with LazyNetworkLabel
+// set up their network when it is first needed.
+const lazyNetworking = runtime.GOOS == "linux"
+
+func (daemon *Daemon) verifyExperimentalContainerSettings(hostConfig *container.HostConfig, config *container.Config) (containerWarnings, error) {
+	var warnings containerWarnings
+	if config != nil && config.Labels[LazyNetworkLabel] == "true" {
+		mode := hostConfig.NetworkMode
+		if config.NetworkDisabled || mode.IsHost() || mode.IsContainer() || mode.IsNone() {
+			warnings.add(WarningNetwork, "Lazy networking only applies to the containers with their own network, the label is ignored.")
+		} else {
+			warnings.add(WarningNetwork, "The network of the container is only set up by docker exec, docker network connect or disconnect, or a container joining its network namespace. Its processes have no network until then.")
+		}
+	}
+	return warnings, nil
 }
@@ -23,6 +23,34 @@ func (daemon *Daemon) cleanupMountsByID(id string) error {
 	return daemon.cleanupMountsFromReaderByID(f, id, mount.Unmount)
 }
 
+// detachMountsByID lazily unmounts the mounts of the layer id which are
+// still busy, as a last resort once cleanupMountsByID failed. The mounts are
+// detached from the mount namespace and released by the kernel once nothing
+// holds them anymore. It does nothing unless enabled in the configuration.
+func (daemon *Daemon) detachMountsByID(id string) error {
+	if !daemon.configStore.LazyUnmountFallback {
+		return nil
+	}
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return daemon.cleanupMountsFromReaderByID(f, id, lazyUnmount(detachMounted))
+}
+
+// lazyUnmount wraps detach, logging the mounts it detached.
+func lazyUnmount(detach func(target string) error) func(target string) error {
+	return func(target string) error {
+		if err := detach(target); err != nil {
+			return err
+		}
+		logrus.Warnf("Mount %s was still busy and has been lazily unmounted", target)
+		return nil
+	}
+}
+
 func (daemon *Daemon) cleanupMountsFromReaderByID(reader io.Reader, id string, unmount func(target string) error) error {
 	if daemon.root == "" {
 		return nil
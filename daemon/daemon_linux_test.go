@@ -3,8 +3,19 @@
 package daemon
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/libcontainerd"
+	"github.com/docker/docker/pkg/stringutils"
+	"github.com/docker/docker/runconfig"
+	containertypes "github.com/docker/engine-api/types/container"
+	"github.com/opencontainers/specs/specs-go"
 )
 
 const mountsFixture = `142 78 0:38 / / rw,relatime - aufs none rw,si=573b861da0b3a05b,dio
@@ -102,3 +113,229 @@ func TestNotCleanupMounts(t *testing.T) {
 		t.Fatalf("Expected not to clean up /dev/shm")
 	}
 }
+
+func TestSetInit(t *testing.T) {
+	daemon := &Daemon{configStore: &Config{InitPath: "/usr/local/bin/docker-init"}}
+	c := &container.Container{}
+	c.Config = &containertypes.Config{}
+	c.HostConfig = &containertypes.HostConfig{}
+
+	s := &specs.Spec{Process: specs.Process{Args: []string{"sh", "-c", "sleep 10"}}}
+	if err := daemon.setInit(s, c); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"sh", "-c", "sleep 10"}; !reflect.DeepEqual(s.Process.Args, expected) || len(s.Mounts) != 0 {
+		t.Fatalf("Expected the spec to be unchanged without --init, got %v and %v", s.Process.Args, s.Mounts)
+	}
+
+	c.Config = &containertypes.Config{Labels: map[string]string{runconfig.InitLabel: "true"}}
+	if err := daemon.setInit(s, c); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"/dev/init", "--", "sh", "-c", "sleep 10"}; !reflect.DeepEqual(s.Process.Args, expected) {
+		t.Fatalf("Expected args %v, got %v", expected, s.Process.Args)
+	}
+	if len(s.Mounts) != 1 || s.Mounts[0].Source != "/usr/local/bin/docker-init" || s.Mounts[0].Destination != "/dev/init" {
+		t.Fatalf("Expected the init binary to be mounted on /dev/init, got %v", s.Mounts)
+	}
+}
+
+func TestMutateSpec(t *testing.T) {
+	source, err := ioutil.TempDir("", "docker-mutator-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(source)
+
+	newSpec := func() *libcontainerd.Spec {
+		return &libcontainerd.Spec{Mounts: []specs.Mount{{Destination: "/proc", Type: "proc", Source: "proc"}}}
+	}
+	c := &container.Container{}
+	c.ID = "mutated"
+
+	var calls []string
+	daemon := &Daemon{}
+	daemon.AddSpecMutator(func(c *container.Container, s *libcontainerd.Spec) error {
+		calls = append(calls, "agent")
+		s.Mounts = append(s.Mounts, specs.Mount{Destination: "/var/run/agent/", Type: "bind", Source: source, Options: []string{"ro"}})
+		return nil
+	})
+	daemon.AddSpecMutator(func(c *container.Container, s *libcontainerd.Spec) error {
+		calls = append(calls, "env")
+		s.Process.Env = append(s.Process.Env, "AGENT_SOCKET=/var/run/agent/agent.sock")
+		return nil
+	})
+
+	s := newSpec()
+	if err := daemon.mutateSpec(c, s); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"agent", "env"}; !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("Expected the mutators to be called in order %v, got %v", expected, calls)
+	}
+	expected := specs.Mount{Destination: "/var/run/agent", Type: "bind", Source: source, Options: []string{"rbind", "ro"}}
+	if len(s.Mounts) != 2 || !reflect.DeepEqual(s.Mounts[1], expected) {
+		t.Fatalf("Expected mount %v, got %v", expected, s.Mounts)
+	}
+	if len(s.Process.Env) != 1 {
+		t.Fatalf("Expected the environment to be mutated, got %v", s.Process.Env)
+	}
+
+	invalid := []specs.Mount{
+		{Destination: "relative", Type: "bind", Source: source},
+		{Destination: "/", Type: "bind", Source: source},
+		{Destination: "/proc", Type: "bind", Source: source},
+		{Destination: "/agent", Type: "bind", Source: "agent-volume"},
+		{Destination: "/agent", Type: "bind", Source: source + "/missing"},
+		{Destination: "/agent", Type: "tmpfs", Source: "tmpfs"},
+		{Destination: "/agent", Type: "bind", Source: source, Options: []string{"exec"}},
+	}
+	for _, m := range invalid {
+		daemon := &Daemon{}
+		m := m
+		daemon.AddSpecMutator(func(c *container.Container, s *libcontainerd.Spec) error {
+			s.Mounts = append(s.Mounts, m)
+			return nil
+		})
+		if err := daemon.mutateSpec(c, newSpec()); err == nil {
+			t.Fatalf("Expected mount %v to be rejected", m)
+		}
+	}
+
+	for _, mutate := range []SpecMutator{
+		func(c *container.Container, s *libcontainerd.Spec) error {
+			s.Mounts[0].Options = append(s.Mounts[0].Options, "rw")
+			return nil
+		},
+		func(c *container.Container, s *libcontainerd.Spec) error {
+			s.Mounts[0] = specs.Mount{Destination: "/proc", Type: "bind", Source: source}
+			return nil
+		},
+		func(c *container.Container, s *libcontainerd.Spec) error {
+			s.Mounts = append(s.Mounts[1:], specs.Mount{Destination: "/agent", Type: "bind", Source: source})
+			return nil
+		},
+	} {
+		daemon := &Daemon{}
+		daemon.AddSpecMutator(mutate)
+		if err := daemon.mutateSpec(c, newSpec()); err == nil {
+			t.Fatal("Expected a mutator changing the existing mounts to be rejected")
+		}
+	}
+
+	calls = nil
+	daemon.specMutators = append([]SpecMutator{func(c *container.Container, s *libcontainerd.Spec) error {
+		return fmt.Errorf("agent unavailable")
+	}}, daemon.specMutators...)
+	if err := daemon.mutateSpec(c, newSpec()); err == nil || err.Error() != "agent unavailable" {
+		t.Fatalf("Expected the mutator error to abort the start, got %v", err)
+	}
+	if len(calls) != 0 {
+		t.Fatalf("Expected the mutators after a failing one not to be called, got %v", calls)
+	}
+}
+
+func TestSetInitSignalForwarding(t *testing.T) {
+	daemon := &Daemon{configStore: &Config{InitPath: "/usr/local/bin/docker-init"}}
+	c := &container.Container{}
+	c.Config = &containertypes.Config{Labels: map[string]string{runconfig.InitLabel: "true", runconfig.SignalForwardingLabel: "group"}}
+	c.HostConfig = &containertypes.HostConfig{}
+
+	s := &specs.Spec{Process: specs.Process{Args: []string{"sh", "-c", "sleep 10"}}}
+	if err := daemon.setInit(s, c); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"/dev/init", "-g", "--", "sh", "-c", "sleep 10"}; !reflect.DeepEqual(s.Process.Args, expected) {
+		t.Fatalf("Expected args %v, got %v", expected, s.Process.Args)
+	}
+}
+
+func TestDetachMountsByID(t *testing.T) {
+	d := &Daemon{
+		root:        "/var/lib/docker/",
+		configStore: &Config{},
+	}
+	if err := d.detachMountsByID("03ca4b49e71f1e49a41108829f4d5c70ac95934526e2af8984a1f65f1de0715d"); err != nil {
+		t.Fatalf("Expected no error when the fallback is disabled, got %v", err)
+	}
+
+	expected := "/var/lib/docker/aufs/mnt/03ca4b49e71f1e49a41108829f4d5c70ac95934526e2af8984a1f65f1de0715d"
+	var detached []string
+	detach := lazyUnmount(func(target string) error {
+		detached = append(detached, target)
+		return nil
+	})
+
+	d.cleanupMountsFromReaderByID(strings.NewReader(mountsFixture), "03ca4b49e71f1e49a41108829f4d5c70ac95934526e2af8984a1f65f1de0715d", detach)
+
+	if len(detached) != 1 || detached[0] != expected {
+		t.Fatalf("Expected to detach the aufs root (and that only), got %v", detached)
+	}
+}
+
+func TestSecurityReport(t *testing.T) {
+	daemon := &Daemon{seccompEnabled: true}
+	c := &container.Container{
+		CommonContainer: container.CommonContainer{
+			HostConfig: &containertypes.HostConfig{
+				CapAdd:  []string{"SYS_ADMIN"},
+				CapDrop: []string{"NET_RAW"},
+			},
+		},
+	}
+	report, err := daemon.securityReport(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(report.CapabilitiesAdded, []string{"CAP_SYS_ADMIN"}) {
+		t.Fatalf("Expected CAP_SYS_ADMIN to be added, got %v", report.CapabilitiesAdded)
+	}
+	if !reflect.DeepEqual(report.CapabilitiesDropped, []string{"CAP_NET_RAW"}) {
+		t.Fatalf("Expected CAP_NET_RAW to be dropped, got %v", report.CapabilitiesDropped)
+	}
+	for _, cap := range []string{"CAP_SYS_ADMIN", "CAP_CHOWN"} {
+		if !stringutils.InSlice(report.Capabilities, cap) {
+			t.Fatalf("Expected %s in the capabilities, got %v", cap, report.Capabilities)
+		}
+	}
+	if stringutils.InSlice(report.Capabilities, "CAP_NET_RAW") {
+		t.Fatalf("Expected CAP_NET_RAW not to be in the capabilities, got %v", report.Capabilities)
+	}
+	expected := seccompDefault
+	if !supportsSeccomp {
+		expected = seccompUnconfined
+	}
+	if report.Seccomp != expected {
+		t.Fatalf("Expected the %s seccomp profile, got %s", expected, report.Seccomp)
+	}
+
+	c.HostConfig = &containertypes.HostConfig{Privileged: true}
+	report, err = daemon.securityReport(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Privileged || report.Seccomp != seccompUnconfined || len(report.CapabilitiesDropped) != 0 {
+		t.Fatalf("Expected all the capabilities and no seccomp profile when privileged, got %+v", report)
+	}
+}
+
+func TestSeccompProfileKind(t *testing.T) {
+	c := &container.Container{
+		CommonContainer: container.CommonContainer{HostConfig: &containertypes.HostConfig{}},
+	}
+	for _, tc := range []struct {
+		profile  string
+		enabled  bool
+		expected string
+	}{
+		{"", true, seccompDefault},
+		{"", false, seccompUnconfined},
+		{"unconfined", true, seccompUnconfined},
+		{`{"defaultAction":"SCMP_ACT_ALLOW"}`, true, seccompCustom},
+	} {
+		c.SeccompProfile = tc.profile
+		if kind := seccompProfileKind(c, tc.enabled); kind != tc.expected {
+			t.Fatalf("Expected %s for profile %q (enabled %v), got %s", tc.expected, tc.profile, tc.enabled, kind)
+		}
+	}
+}
@@ -4,6 +4,14 @@ package daemon
 
 import "github.com/docker/engine-api/types/container"
 
-func (daemon *Daemon) verifyExperimentalContainerSettings(hostConfig *container.HostConfig, config *container.Config) ([]string, error) {
-	return nil, nil
+// lazyNetworking tells whether the containers labeled with LazyNetworkLabel
+// set up their network when it is first needed.
+const lazyNetworking = false
+
+func (daemon *Daemon) verifyExperimentalContainerSettings(hostConfig *container.HostConfig, config *container.Config) (containerWarnings, error) {
+	var warnings containerWarnings
+	if config != nil && config.Labels[LazyNetworkLabel] == "true" {
+		warnings.add(WarningNetwork, "Lazy networking is only available in experimental builds, the network of the container is set up when it starts.")
+	}
+	return warnings, nil
 }
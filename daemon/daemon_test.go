@@ -1,18 +1,25 @@
 package daemon
 
 import (
+	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/network"
 	"github.com/docker/docker/pkg/discovery"
 	_ "github.com/docker/docker/pkg/discovery/memory"
 	"github.com/docker/docker/pkg/registrar"
 	"github.com/docker/docker/pkg/truncindex"
+	"github.com/docker/docker/runconfig"
 	"github.com/docker/docker/volume"
 	volumedrivers "github.com/docker/docker/volume/drivers"
 	"github.com/docker/docker/volume/local"
@@ -154,6 +161,61 @@ func TestValidContainerNames(t *testing.T) {
 	}
 }
 
+func TestVerifyStorageOpt(t *testing.T) {
+	valid := []map[string]string{
+		nil,
+		{"size": "10G"},
+		{"Size": "512m"},
+	}
+	invalid := []map[string]string{
+		{"size": "ten"},
+		{"size": "0"},
+		{"dm.basesize": "10G"},
+	}
+
+	for _, opt := range valid {
+		if err := verifyStorageOpt(opt); err != nil {
+			t.Fatalf("Expected %v to be valid, got %v", opt, err)
+		}
+	}
+	for _, opt := range invalid {
+		if err := verifyStorageOpt(opt); err == nil {
+			t.Fatalf("Expected %v to be invalid", opt)
+		}
+	}
+}
+
+func TestVerifyLiveRestore(t *testing.T) {
+	live := &containertypes.Config{Labels: map[string]string{runconfig.LiveRestoreLabel: "true"}}
+	valid := []struct {
+		hostConfig *containertypes.HostConfig
+		config     *containertypes.Config
+	}{
+		{&containertypes.HostConfig{}, &containertypes.Config{}},
+		{&containertypes.HostConfig{}, &containertypes.Config{Labels: map[string]string{runconfig.LiveRestoreLabel: "false"}}},
+		{&containertypes.HostConfig{RestartPolicy: containertypes.RestartPolicy{Name: "always"}}, live},
+		{&containertypes.HostConfig{RestartPolicy: containertypes.RestartPolicy{Name: "on-failure", MaximumRetryCount: 3}}, live},
+	}
+	for _, c := range valid {
+		if err := verifyLiveRestore(c.hostConfig, c.config); err != nil {
+			t.Fatalf("Expected %+v to be valid, got %v", c.config.Labels, err)
+		}
+	}
+	invalid := []*containertypes.HostConfig{
+		{},
+		{RestartPolicy: containertypes.RestartPolicy{Name: "no"}},
+	}
+	for _, hostConfig := range invalid {
+		if err := verifyLiveRestore(hostConfig, live); err == nil || !strings.Contains(err.Error(), "restart policy") {
+			t.Fatalf("Expected %+v to require a restart policy, got %v", hostConfig, err)
+		}
+	}
+	bogus := &containertypes.Config{Labels: map[string]string{runconfig.LiveRestoreLabel: "maybe"}}
+	if err := verifyLiveRestore(&containertypes.HostConfig{}, bogus); err == nil {
+		t.Fatal("Expected an invalid label value to be rejected")
+	}
+}
+
 func TestContainerInitDNS(t *testing.T) {
 	tmp, err := ioutil.TempDir("", "docker-container-test-")
 	if err != nil {
@@ -364,6 +426,124 @@ func TestDaemonReloadNotAffectOthers(t *testing.T) {
 	}
 }
 
+func TestDaemonReloadLogConfig(t *testing.T) {
+	daemon := &Daemon{containers: container.NewMemoryStore()}
+	daemon.configStore = &Config{}
+	daemon.defaultLogConfig = containertypes.LogConfig{
+		Type:   "json-file",
+		Config: map[string]string{"max-size": "1k"},
+	}
+
+	valuesSets := make(map[string]interface{})
+	valuesSets["log-opts"] = map[string]interface{}{"max-file": "2"}
+	newConfig := &Config{
+		CommonConfig: CommonConfig{
+			LogConfig: LogConfig{
+				Config: map[string]string{"max-file": "2"},
+			},
+			valuesSet: valuesSets,
+		},
+	}
+
+	if err := daemon.Reload(newConfig); err != nil {
+		t.Fatal(err)
+	}
+	if daemon.defaultLogConfig.Type != "json-file" {
+		t.Fatalf("Expected log driver `json-file`, got %s", daemon.defaultLogConfig.Type)
+	}
+	expected := map[string]string{"max-file": "2"}
+	if !reflect.DeepEqual(daemon.defaultLogConfig.Config, expected) {
+		t.Fatalf("Expected log opts %v, got %v", expected, daemon.defaultLogConfig.Config)
+	}
+	if !reflect.DeepEqual(daemon.configStore.LogConfig.Config, expected) {
+		t.Fatalf("Expected daemon configuration log opts %v, got %v", expected, daemon.configStore.LogConfig.Config)
+	}
+}
+
+func TestDaemonReloadInvalidLogConfig(t *testing.T) {
+	daemon := &Daemon{}
+	daemon.configStore = &Config{}
+	daemon.defaultLogConfig = containertypes.LogConfig{
+		Type:   "json-file",
+		Config: map[string]string{"max-size": "1k"},
+	}
+
+	valuesSets := make(map[string]interface{})
+	valuesSets["log-opts"] = map[string]interface{}{"foo": "bar"}
+	valuesSets["labels"] = "foo=baz"
+	newConfig := &Config{
+		CommonConfig: CommonConfig{
+			Labels: []string{"foo=baz"},
+			LogConfig: LogConfig{
+				Config: map[string]string{"foo": "bar"},
+			},
+			valuesSet: valuesSets,
+		},
+	}
+
+	if err := daemon.Reload(newConfig); err == nil {
+		t.Fatal("Expected error reloading an invalid log option, got nil")
+	}
+	expected := map[string]string{"max-size": "1k"}
+	if !reflect.DeepEqual(daemon.defaultLogConfig.Config, expected) {
+		t.Fatalf("Expected log opts to be kept as %v, got %v", expected, daemon.defaultLogConfig.Config)
+	}
+	if len(daemon.configStore.Labels) != 0 {
+		t.Fatalf("Expected the labels not to be reloaded, got %v", daemon.configStore.Labels)
+	}
+}
+
+func TestDaemonReloadInvalidLogConfigKeepsDiscovery(t *testing.T) {
+	daemon := &Daemon{}
+	daemon.configStore = &Config{}
+
+	valuesSets := make(map[string]interface{})
+	valuesSets["cluster-store"] = "memory://127.0.0.1"
+	valuesSets["cluster-advertise"] = "127.0.0.1:3333"
+	valuesSets["log-driver"] = "no-such-driver"
+	newConfig := &Config{
+		CommonConfig: CommonConfig{
+			ClusterStore:     "memory://127.0.0.1",
+			ClusterAdvertise: "127.0.0.1:3333",
+			LogConfig:        LogConfig{Type: "no-such-driver"},
+			valuesSet:        valuesSets,
+		},
+	}
+
+	if err := daemon.Reload(newConfig); err == nil {
+		t.Fatal("Expected error reloading an unknown log driver, got nil")
+	}
+	if daemon.discoveryWatcher != nil || daemon.configStore.ClusterStore != "" {
+		t.Fatalf("Expected the discovery not to be reloaded, got the cluster store %q", daemon.configStore.ClusterStore)
+	}
+}
+
+func TestDaemonReloadInvalidEventsWebhook(t *testing.T) {
+	daemon := &Daemon{}
+	daemon.configStore = &Config{}
+
+	valuesSets := make(map[string]interface{})
+	valuesSets["labels"] = "foo=baz"
+	valuesSets["events-webhook"] = "ftp://example.com"
+	newConfig := &Config{
+		CommonConfig: CommonConfig{
+			Labels:        []string{"foo=baz"},
+			EventsWebhook: "ftp://example.com",
+			valuesSet:     valuesSets,
+		},
+	}
+
+	if err := daemon.Reload(newConfig); err == nil {
+		t.Fatal("Expected error reloading an invalid events webhook, got nil")
+	}
+	if len(daemon.configStore.Labels) != 0 {
+		t.Fatalf("Expected the labels not to be reloaded, got %v", daemon.configStore.Labels)
+	}
+	if daemon.configStore.EventsWebhook != "" {
+		t.Fatalf("Expected the events webhook not to be reloaded, got %q", daemon.configStore.EventsWebhook)
+	}
+}
+
 func TestDaemonDiscoveryReload(t *testing.T) {
 	daemon := &Daemon{}
 	daemon.configStore = &Config{
@@ -530,3 +710,421 @@ func TestDaemonDiscoveryReloadOnlyClusterAdvertise(t *testing.T) {
 	}
 
 }
+
+func TestCheckRequiredGraphDriver(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-daemon-graphdriver-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	config := &Config{}
+	config.Root = root
+	if err := checkRequiredGraphDriver(config, "aufs", nil, nil); err != nil {
+		t.Fatalf("Expected no error without a required driver, got %v", err)
+	}
+
+	config.RequiredGraphDriver = "vfs"
+	if err := checkRequiredGraphDriver(config, "vfs", nil, nil); err != nil {
+		t.Fatalf("Expected no error when the required driver is selected, got %v", err)
+	}
+
+	err = checkRequiredGraphDriver(config, "aufs", nil, nil)
+	if err == nil || !strings.Contains(err.Error(), `"aufs" was selected`) || !strings.Contains(err.Error(), "is available") {
+		t.Fatalf("Expected an error reporting the available required driver, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "vfs")); !os.IsNotExist(err) {
+		t.Fatalf("Expected the state of the required driver to be removed, got %v", err)
+	}
+
+	config.RequiredGraphDriver = "nonexistent"
+	err = checkRequiredGraphDriver(config, "vfs", nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "unavailable") {
+		t.Fatalf("Expected an error reporting the unavailable required driver, got %v", err)
+	}
+}
+
+func TestShutdownStopTimeout(t *testing.T) {
+	deadline := time.Now().Add(30 * time.Second)
+	c := &container.Container{CommonContainer: container.CommonContainer{Config: &containertypes.Config{}}}
+	if timeout := shutdownStopTimeout(c, 10, deadline); timeout != 10 {
+		t.Fatalf("Expected the default stop timeout 10, got %d", timeout)
+	}
+
+	c.Config.Labels = map[string]string{runconfig.StopTimeoutLabel: "20"}
+	if timeout := shutdownStopTimeout(c, 10, deadline); timeout != 20 {
+		t.Fatalf("Expected the stop timeout of the container 20, got %d", timeout)
+	}
+
+	c.Config.Labels[runconfig.StopTimeoutLabel] = "60"
+	if timeout := shutdownStopTimeout(c, 10, deadline); timeout > 30 {
+		t.Fatalf("Expected the stop timeout to be bounded by the deadline, got %d", timeout)
+	}
+
+	if timeout := shutdownStopTimeout(c, 10, time.Now().Add(-time.Second)); timeout != 0 {
+		t.Fatalf("Expected no stop timeout past the deadline, got %d", timeout)
+	}
+}
+
+func TestStoppingContainers(t *testing.T) {
+	daemon := &Daemon{}
+	if ids := daemon.StoppingContainers(); len(ids) != 0 {
+		t.Fatalf("Expected no stopping containers, got %v", ids)
+	}
+
+	daemon.setStopping("b", true)
+	daemon.setStopping("a", true)
+	daemon.setStopping("c", true)
+	daemon.setStopping("c", false)
+	if ids := daemon.StoppingContainers(); !reflect.DeepEqual(ids, []string{"a", "b"}) {
+		t.Fatalf("Expected the stopping containers [a b], got %v", ids)
+	}
+}
+
+func TestVerifyResourceLimits(t *testing.T) {
+	daemon := &Daemon{configStore: &Config{}}
+	hostConfig := &containertypes.HostConfig{}
+	config := &containertypes.Config{}
+	if err := daemon.verifyResourceLimits(hostConfig, config); err != nil {
+		t.Fatalf("Expected no resource limits to be required by default, got %v", err)
+	}
+
+	daemon.configStore.RequireResourceLimits = true
+	err := daemon.verifyResourceLimits(hostConfig, config)
+	if err == nil || !strings.Contains(err.Error(), "memory") || !strings.Contains(err.Error(), "CPU") {
+		t.Fatalf("Expected an error reporting the missing memory and CPU limits, got %v", err)
+	}
+
+	hostConfig.Memory = 512 * 1024 * 1024
+	hostConfig.CPUShares = 512
+	err = daemon.verifyResourceLimits(hostConfig, config)
+	if err == nil || strings.Contains(err.Error(), "memory") || !strings.Contains(err.Error(), "CPU") {
+		t.Fatalf("Expected an error reporting the missing CPU limit, got %v", err)
+	}
+
+	hostConfig.CpusetCpus = "0-1"
+	if err := daemon.verifyResourceLimits(hostConfig, config); err != nil {
+		t.Fatalf("Expected a container with memory and CPU limits to be accepted, got %v", err)
+	}
+
+	// the default resources of the daemon count as limits
+	daemon.configStore.DefaultResources = []string{"tier=batch:memory=1g,cpu-quota=50000"}
+	hostConfig = &containertypes.HostConfig{}
+	config.Labels = map[string]string{"tier": "batch"}
+	if err := daemon.verifyResourceLimits(hostConfig, config); err != nil {
+		t.Fatalf("Expected the default resources to satisfy the limits, got %v", err)
+	}
+	if hostConfig.Memory != 0 {
+		t.Fatalf("Expected the host config to be left unchanged, got memory %d", hostConfig.Memory)
+	}
+}
+
+func TestVerifyResourceLimitsExemptLabels(t *testing.T) {
+	daemon := &Daemon{configStore: &Config{
+		CommonConfig: CommonConfig{
+			RequireResourceLimits:      true,
+			ResourceLimitsExemptLabels: []string{"com.example.system", "tier=infra"},
+		},
+	}}
+	hostConfig := &containertypes.HostConfig{}
+
+	for _, labels := range []map[string]string{
+		{"com.example.system": ""},
+		{"com.example.system": "true"},
+		{"tier": "infra"},
+	} {
+		if err := daemon.verifyResourceLimits(hostConfig, &containertypes.Config{Labels: labels}); err != nil {
+			t.Fatalf("Expected a container labeled %v to be exempt, got %v", labels, err)
+		}
+	}
+	for _, labels := range []map[string]string{
+		nil,
+		{"tier": "web"},
+		{"com.example": "system"},
+	} {
+		if err := daemon.verifyResourceLimits(hostConfig, &containertypes.Config{Labels: labels}); err == nil {
+			t.Fatalf("Expected a container labeled %v to require resource limits", labels)
+		}
+	}
+}
+
+func TestDaemonReloadResourceLimits(t *testing.T) {
+	daemon := &Daemon{configStore: &Config{}}
+	newConfig := &Config{
+		CommonConfig: CommonConfig{
+			RequireResourceLimits:      true,
+			ResourceLimitsExemptLabels: []string{"tier=infra"},
+			valuesSet: map[string]interface{}{
+				"require-resource-limits":       true,
+				"resource-limits-exempt-labels": []string{"tier=infra"},
+			},
+		},
+	}
+	if err := daemon.Reload(newConfig); err != nil {
+		t.Fatal(err)
+	}
+	if !daemon.configStore.RequireResourceLimits || !reflect.DeepEqual(daemon.configStore.ResourceLimitsExemptLabels, []string{"tier=infra"}) {
+		t.Fatalf("Expected the resource limits requirement to be reloaded, got %v %v", daemon.configStore.RequireResourceLimits, daemon.configStore.ResourceLimitsExemptLabels)
+	}
+}
+
+func TestDaemonReloadStatus(t *testing.T) {
+	daemon := &Daemon{configStore: &Config{}}
+	daemon.configStore.valuesSet = map[string]interface{}{
+		"debug":  false,
+		"labels": []interface{}{"foo=bar"},
+	}
+	if status := daemon.ReloadStatus(); status != nil {
+		t.Fatalf("Expected no reload status before the first reload, got %+v", status)
+	}
+
+	newConfig := &Config{
+		CommonConfig: CommonConfig{
+			Labels: []string{"foo=baz"},
+			valuesSet: map[string]interface{}{
+				"debug":           false,
+				"labels":          []interface{}{"foo=baz"},
+				"network-timeout": float64(30),
+			},
+		},
+	}
+	if err := daemon.Reload(newConfig); err != nil {
+		t.Fatal(err)
+	}
+	status := daemon.ReloadStatus()
+	if status == nil || !status.Success || status.Error != "" || status.Time.IsZero() {
+		t.Fatalf("Expected a successful reload, got %+v", status)
+	}
+	if expected := []string{"labels", "network-timeout"}; !reflect.DeepEqual(status.Changed, expected) {
+		t.Fatalf("Expected the changed keys %v, got %v", expected, status.Changed)
+	}
+
+	// the keys are compared with the last applied configuration, so a
+	// failing reload doesn't change the reference
+	failing := &Config{
+		CommonConfig: CommonConfig{
+			LogConfig: LogConfig{Type: "no-such-driver"},
+			valuesSet: map[string]interface{}{
+				"labels":     []interface{}{"foo=baz"},
+				"log-driver": "no-such-driver",
+			},
+		},
+	}
+	if err := daemon.Reload(failing); err == nil {
+		t.Fatal("Expected the reload to fail with an unknown log driver")
+	}
+	status = daemon.ReloadStatus()
+	if status.Success || !strings.Contains(status.Error, "no-such-driver") {
+		t.Fatalf("Expected a failed reload, got %+v", status)
+	}
+	if expected := []string{"debug", "log-driver", "network-timeout"}; !reflect.DeepEqual(status.Changed, expected) {
+		t.Fatalf("Expected the changed keys %v, got %v", expected, status.Changed)
+	}
+
+	daemon.ReloadFailed(errors.New("invalid character in the configuration file"))
+	status = daemon.ReloadStatus()
+	if status.Success || status.Error != "invalid character in the configuration file" || len(status.Changed) != 0 {
+		t.Fatalf("Expected a reload which failed before it applied the configuration, got %+v", status)
+	}
+}
+
+func TestConfigChangeRestarts(t *testing.T) {
+	daemon := &Daemon{containers: container.NewMemoryStore()}
+	add := func(id string, labels map[string]string, running bool, hostConfig *containertypes.HostConfig) {
+		c := container.NewBaseContainer(id, "")
+		c.Config = &containertypes.Config{Labels: labels}
+		c.HostConfig = hostConfig
+		if running {
+			c.SetRunning(1234, true)
+		}
+		daemon.containers.Add(id, c)
+	}
+	optIn := map[string]string{RestartOnConfigChangeLabel: "true"}
+	add("opted-in", optIn, true, &containertypes.HostConfig{})
+	add("not-opted-in", nil, true, &containertypes.HostConfig{})
+	add("stopped", optIn, false, &containertypes.HostConfig{})
+	add("own-log-driver", optIn, true, &containertypes.HostConfig{LogConfig: containertypes.LogConfig{Type: "syslog"}})
+	add("own-dns-search", optIn, true, &containertypes.HostConfig{DNSSearch: []string{"example.com"}})
+
+	restarted := func(change configChange) []string {
+		var ids []string
+		for _, c := range daemon.configChangeRestarts(change) {
+			ids = append(ids, c.ID)
+		}
+		sort.Strings(ids)
+		return ids
+	}
+	if ids := restarted(configChange{logConfig: true}); !reflect.DeepEqual(ids, []string{"opted-in", "own-dns-search"}) {
+		t.Fatalf("Expected the opted in containers using the default log driver to be restarted, got %v", ids)
+	}
+	if ids := restarted(configChange{dnsSearch: true}); !reflect.DeepEqual(ids, []string{"opted-in", "own-log-driver"}) {
+		t.Fatalf("Expected the opted in containers using the default DNS search domains to be restarted, got %v", ids)
+	}
+
+	daemon.configStore = &Config{}
+	daemon.defaultLogConfig = containertypes.LogConfig{Type: "json-file"}
+	if change := daemon.newConfigChange(containertypes.LogConfig{Type: "json-file"}, nil); change != (configChange{}) {
+		t.Fatalf("Expected no change, got %+v", change)
+	}
+	if change := daemon.newConfigChange(containertypes.LogConfig{Type: "syslog"}, []string{"example.com"}); !change.logConfig || !change.dnsSearch {
+		t.Fatalf("Expected the log driver and DNS search domains to be changed, got %+v", change)
+	}
+}
+
+func TestLazyNetwork(t *testing.T) {
+	lazy := map[string]string{LazyNetworkLabel: "true"}
+	for _, c := range []struct {
+		labels   map[string]string
+		mode     containertypes.NetworkMode
+		disabled bool
+		lazy     bool
+	}{
+		{lazy, "default", false, true},
+		{lazy, "mynet", false, true},
+		{nil, "default", false, false},
+		{map[string]string{LazyNetworkLabel: "false"}, "default", false, false},
+		{lazy, "host", false, false},
+		{lazy, "none", false, false},
+		{lazy, "container:web", false, false},
+		{lazy, "default", true, false},
+	} {
+		ctr := container.NewBaseContainer("lazy", "")
+		ctr.Config = &containertypes.Config{Labels: c.labels, NetworkDisabled: c.disabled}
+		ctr.HostConfig = &containertypes.HostConfig{NetworkMode: c.mode}
+		if expected := lazyNetworking && c.lazy; isLazyNetwork(ctr) != expected {
+			t.Fatalf("Expected lazy networking to be %v for labels %v and network mode %s", expected, c.labels, c.mode)
+		}
+	}
+
+	daemon := &Daemon{}
+	ctr := container.NewBaseContainer("pending", "")
+	ctr.HostConfig = &containertypes.HostConfig{}
+	ctr.Config = &containertypes.Config{}
+	ctr.NetworkSettings = &network.Settings{}
+	ctr.NetworkPending = true
+	// saved, for a restored container not to release its network
+	if b, err := json.Marshal(ctr); err != nil || !strings.Contains(string(b), `"NetworkPending":true`) {
+		t.Fatalf("Expected the deferred network to be saved with the container, got %s: %v", b, err)
+	}
+	if err := daemon.setupLazyNetwork(ctr); err == nil {
+		t.Fatal("Expected the network of a stopped container not to be set up")
+	}
+	daemon.releaseLazyNetwork(ctr)
+	if ctr.NetworkPending {
+		t.Fatal("Expected the deferred network to be released")
+	}
+	if err := daemon.setupLazyNetwork(ctr); err != nil {
+		t.Fatalf("Expected nothing to set up once the network was released, got %v", err)
+	}
+}
+
+func TestEphemeralAnonymousVolumes(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-daemon-ephemeral-volumes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	daemon, err := initDaemonWithVolumeStore(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer volumedrivers.Unregister(volume.DefaultDriverName)
+	daemon.configStore = &Config{}
+
+	if labels := daemon.anonymousVolumeLabels(&containertypes.Config{}); labels != nil {
+		t.Fatalf("Expected the anonymous volumes to be kept by default, got labels %v", labels)
+	}
+	config := &containertypes.Config{Labels: map[string]string{EphemeralVolumeLabel: "true"}}
+	ephemeral := daemon.anonymousVolumeLabels(config)
+	if ephemeral[EphemeralVolumeLabel] != "true" {
+		t.Fatalf("Expected the container label to make the anonymous volumes ephemeral, got labels %v", ephemeral)
+	}
+	daemon.configStore.EphemeralAnonymousVolumes = true
+	if labels := daemon.anonymousVolumeLabels(&containertypes.Config{Labels: map[string]string{EphemeralVolumeLabel: "false"}}); labels != nil {
+		t.Fatalf("Expected the container label to keep the anonymous volumes, got labels %v", labels)
+	}
+	if err := verifyEphemeralVolumeLabel(map[string]string{EphemeralVolumeLabel: "sometimes"}); err == nil {
+		t.Fatal("Expected an invalid ephemeral volume label to be rejected")
+	}
+
+	// c1 and c2 mount the ephemeral volume, e.g. with --volumes-from, and
+	// c1 the volume which is kept
+	withVolumes := func(id string, names ...string) *container.Container {
+		c := container.NewBaseContainer(id, "")
+		for _, name := range names {
+			v, err := daemon.volumes.GetWithRef(name, volume.DefaultDriverName, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.AddMountPointWithVolume("/"+name, v, true)
+		}
+		return c
+	}
+	if _, err := daemon.volumes.Create("ephemeral", volume.DefaultDriverName, nil, ephemeral); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := daemon.volumes.Create("kept", volume.DefaultDriverName, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	c1 := withVolumes("c1", "ephemeral", "kept")
+	c2 := withVolumes("c2", "ephemeral")
+
+	if err := daemon.removeMountPoints(c1, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := daemon.volumes.Get("ephemeral"); err != nil {
+		t.Fatalf("Expected the ephemeral volume to be kept while c2 references it, got %v", err)
+	}
+	if _, err := daemon.volumes.Get("kept"); err != nil {
+		t.Fatalf("Expected the volume which isn't ephemeral to be kept, got %v", err)
+	}
+
+	if err := daemon.removeMountPoints(c2, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := daemon.volumes.Get("ephemeral"); err == nil {
+		t.Fatal("Expected the ephemeral volume to be removed with the last container referencing it")
+	}
+}
+
+func TestReadLogsFromBuffer(t *testing.T) {
+	daemon := &Daemon{configStore: &Config{}}
+	c := container.NewBaseContainer("logs", "")
+	c.HostConfig = &containertypes.HostConfig{LogConfig: containertypes.LogConfig{Type: "none"}}
+	daemon.configStore.LogBufferSize = "64k"
+	buf := daemon.logBuffer(c)
+	if buf == nil || buf.Size() != 64*1024 {
+		t.Fatalf("Expected a 64k log buffer, got %v", buf)
+	}
+	for i := 0; i < 5; i++ {
+		buf.Add(&logger.Message{Line: []byte{byte('a' + i)}, Source: "stdout", Timestamp: time.Now()})
+	}
+
+	read := func(tail int) string {
+		logs, err := daemon.readLogs(c, logger.ReadConfig{Tail: tail})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var lines string
+		for msg := range logs.Msg {
+			lines += string(msg.Line)
+		}
+		return lines
+	}
+	if lines := read(2); lines != "de" {
+		t.Fatalf("Expected the last 2 lines from the buffer, got %q", lines)
+	}
+	// the "none" driver can't read the logs, all the buffered ones are read
+	if lines := read(10); lines != "abcde" {
+		t.Fatalf("Expected the buffered lines, got %q", lines)
+	}
+
+	daemon.configStore.LogBufferSize = "0"
+	if daemon.logBuffer(c) != nil || c.LogBuffer != nil {
+		t.Fatal("Expected the log buffer to be dropped when disabled")
+	}
+	if _, err := daemon.readLogs(c, logger.ReadConfig{Tail: 2}); err == nil {
+		t.Fatal("Expected reading the logs of the none driver without a buffer to fail")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"net"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
 	"runtime/debug"
@@ -16,6 +17,7 @@ import (
 	"time"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/docker/distribution/digest"
 	"github.com/docker/docker/container"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/layer"
@@ -29,6 +31,7 @@ import (
 	"github.com/docker/engine-api/types"
 	pblkiodev "github.com/docker/engine-api/types/blkiodev"
 	containertypes "github.com/docker/engine-api/types/container"
+	"github.com/docker/go-units"
 	"github.com/docker/libnetwork"
 	nwconfig "github.com/docker/libnetwork/config"
 	"github.com/docker/libnetwork/drivers/bridge"
@@ -55,6 +58,12 @@ const (
 	// constant for cgroup drivers
 	cgroupFsDriver      = "cgroupfs"
 	cgroupSystemdDriver = "systemd"
+
+	// DefaultInitBinary is the name of the init binary injected in containers
+	// started with --init, looked up in the PATH of the daemon.
+	DefaultInitBinary = "docker-init"
+	// containerInitPath is where the init binary is mounted in containers.
+	containerInitPath = "/dev/init"
 )
 
 func getMemoryResources(config containertypes.Resources) *specs.Memory {
@@ -163,8 +172,19 @@ func parseSecurityOpt(container *container.Container, config *containertypes.Hos
 			case "label":
 				labelOpts = append(labelOpts, con[1])
 			case "apparmor":
+				// a profile can be given by the absolute path of its file
+				if filepath.IsAbs(con[1]) {
+					if err := validateAppArmorProfileFile(con[1]); err != nil {
+						return fmt.Errorf("Invalid AppArmor profile %s: %v", con[1], err)
+					}
+				}
 				container.AppArmorProfile = con[1]
 			case "seccomp":
+				// the profile is the JSON body of the file given to the
+				// client, or given inline
+				if err := validateSeccompProfile(con[1]); err != nil {
+					return err
+				}
 				container.SeccompProfile = con[1]
 			default:
 				return fmt.Errorf("Invalid --security-opt 2: %q", opt)
@@ -278,14 +298,19 @@ func checkKernel() error {
 
 // adaptContainerSettings is called during container creation to modify any
 // settings necessary in the HostConfig structure.
-func (daemon *Daemon) adaptContainerSettings(hostConfig *containertypes.HostConfig, adjustCPUShares bool) error {
+func (daemon *Daemon) adaptContainerSettings(hostConfig *containertypes.HostConfig, adjustCPUShares bool) (containerWarnings, error) {
+	var warnings containerWarnings
 	if adjustCPUShares && hostConfig.CPUShares > 0 {
 		// Handle unsupported CPUShares
 		if hostConfig.CPUShares < linuxMinCPUShares {
-			logrus.Warnf("Changing requested CPUShares of %d to minimum allowed of %d", hostConfig.CPUShares, linuxMinCPUShares)
+			msg := fmt.Sprintf("Changing requested CPUShares of %d to minimum allowed of %d", hostConfig.CPUShares, linuxMinCPUShares)
+			warnings.add(WarningResource, msg)
+			logrus.Warn(msg)
 			hostConfig.CPUShares = linuxMinCPUShares
 		} else if hostConfig.CPUShares > linuxMaxCPUShares {
-			logrus.Warnf("Changing requested CPUShares of %d to maximum allowed of %d", hostConfig.CPUShares, linuxMaxCPUShares)
+			msg := fmt.Sprintf("Changing requested CPUShares of %d to maximum allowed of %d", hostConfig.CPUShares, linuxMaxCPUShares)
+			warnings.add(WarningResource, msg)
+			logrus.Warn(msg)
 			hostConfig.CPUShares = linuxMaxCPUShares
 		}
 	}
@@ -300,7 +325,7 @@ func (daemon *Daemon) adaptContainerSettings(hostConfig *containertypes.HostConf
 	if hostConfig.SecurityOpt == nil {
 		hostConfig.SecurityOpt, err = daemon.generateSecurityOpt(hostConfig.IpcMode, hostConfig.PidMode)
 		if err != nil {
-			return err
+			return warnings, err
 		}
 	}
 	if hostConfig.MemorySwappiness == nil {
@@ -312,24 +337,24 @@ func (daemon *Daemon) adaptContainerSettings(hostConfig *containertypes.HostConf
 		hostConfig.OomKillDisable = &defaultOomKillDisable
 	}
 
-	return nil
+	return warnings, nil
 }
 
-func verifyContainerResources(resources *containertypes.Resources, sysInfo *sysinfo.SysInfo, update bool) ([]string, error) {
-	warnings := []string{}
+func verifyContainerResources(resources *containertypes.Resources, sysInfo *sysinfo.SysInfo, update bool) (containerWarnings, error) {
+	warnings := containerWarnings{}
 
 	// memory subsystem checks and adjustments
 	if resources.Memory != 0 && resources.Memory < linuxMinMemory {
 		return warnings, fmt.Errorf("Minimum memory limit allowed is 4MB")
 	}
 	if resources.Memory > 0 && !sysInfo.MemoryLimit {
-		warnings = append(warnings, "Your kernel does not support memory limit capabilities. Limitation discarded.")
+		warnings.add(WarningResource, "Your kernel does not support memory limit capabilities. Limitation discarded.")
 		logrus.Warnf("Your kernel does not support memory limit capabilities. Limitation discarded.")
 		resources.Memory = 0
 		resources.MemorySwap = -1
 	}
 	if resources.Memory > 0 && resources.MemorySwap != -1 && !sysInfo.SwapLimit {
-		warnings = append(warnings, "Your kernel does not support swap limit capabilities, memory limited without swap.")
+		warnings.add(WarningResource, "Your kernel does not support swap limit capabilities, memory limited without swap.")
 		logrus.Warnf("Your kernel does not support swap limit capabilities, memory limited without swap.")
 		resources.MemorySwap = -1
 	}
@@ -340,7 +365,7 @@ func verifyContainerResources(resources *containertypes.Resources, sysInfo *sysi
 		return warnings, fmt.Errorf("You should always set the Memory limit when using Memoryswap limit, see usage.")
 	}
 	if resources.MemorySwappiness != nil && *resources.MemorySwappiness != -1 && !sysInfo.MemorySwappiness {
-		warnings = append(warnings, "Your kernel does not support memory swappiness capabilities, memory swappiness discarded.")
+		warnings.add(WarningResource, "Your kernel does not support memory swappiness capabilities, memory swappiness discarded.")
 		logrus.Warnf("Your kernel does not support memory swappiness capabilities, memory swappiness discarded.")
 		resources.MemorySwappiness = nil
 	}
@@ -351,7 +376,7 @@ func verifyContainerResources(resources *containertypes.Resources, sysInfo *sysi
 		}
 	}
 	if resources.MemoryReservation > 0 && !sysInfo.MemoryReservation {
-		warnings = append(warnings, "Your kernel does not support memory soft limit capabilities. Limitation discarded.")
+		warnings.add(WarningResource, "Your kernel does not support memory soft limit capabilities. Limitation discarded.")
 		logrus.Warnf("Your kernel does not support memory soft limit capabilities. Limitation discarded.")
 		resources.MemoryReservation = 0
 	}
@@ -359,7 +384,7 @@ func verifyContainerResources(resources *containertypes.Resources, sysInfo *sysi
 		return warnings, fmt.Errorf("Minimum memory limit should be larger than memory reservation limit, see usage.")
 	}
 	if resources.KernelMemory > 0 && !sysInfo.KernelMemory {
-		warnings = append(warnings, "Your kernel does not support kernel memory limit capabilities. Limitation discarded.")
+		warnings.add(WarningResource, "Your kernel does not support kernel memory limit capabilities. Limitation discarded.")
 		logrus.Warnf("Your kernel does not support kernel memory limit capabilities. Limitation discarded.")
 		resources.KernelMemory = 0
 	}
@@ -367,45 +392,45 @@ func verifyContainerResources(resources *containertypes.Resources, sysInfo *sysi
 		return warnings, fmt.Errorf("Minimum kernel memory limit allowed is 4MB")
 	}
 	if resources.KernelMemory > 0 && !checkKernelVersion(4, 0, 0) {
-		warnings = append(warnings, "You specified a kernel memory limit on a kernel older than 4.0. Kernel memory limits are experimental on older kernels, it won't work as expected and can cause your system to be unstable.")
+		warnings.add(WarningResource, "You specified a kernel memory limit on a kernel older than 4.0. Kernel memory limits are experimental on older kernels, it won't work as expected and can cause your system to be unstable.")
 		logrus.Warnf("You specified a kernel memory limit on a kernel older than 4.0. Kernel memory limits are experimental on older kernels, it won't work as expected and can cause your system to be unstable.")
 	}
 	if resources.OomKillDisable != nil && !sysInfo.OomKillDisable {
 		// only produce warnings if the setting wasn't to *disable* the OOM Kill; no point
 		// warning the caller if they already wanted the feature to be off
 		if *resources.OomKillDisable {
-			warnings = append(warnings, "Your kernel does not support OomKillDisable, OomKillDisable discarded.")
+			warnings.add(WarningResource, "Your kernel does not support OomKillDisable, OomKillDisable discarded.")
 			logrus.Warnf("Your kernel does not support OomKillDisable, OomKillDisable discarded.")
 		}
 		resources.OomKillDisable = nil
 	}
 
 	if resources.PidsLimit != 0 && !sysInfo.PidsLimit {
-		warnings = append(warnings, "Your kernel does not support pids limit capabilities, pids limit discarded.")
+		warnings.add(WarningResource, "Your kernel does not support pids limit capabilities, pids limit discarded.")
 		logrus.Warnf("Your kernel does not support pids limit capabilities, pids limit discarded.")
 		resources.PidsLimit = 0
 	}
 
 	// cpu subsystem checks and adjustments
 	if resources.CPUShares > 0 && !sysInfo.CPUShares {
-		warnings = append(warnings, "Your kernel does not support CPU shares. Shares discarded.")
+		warnings.add(WarningResource, "Your kernel does not support CPU shares. Shares discarded.")
 		logrus.Warnf("Your kernel does not support CPU shares. Shares discarded.")
 		resources.CPUShares = 0
 	}
 	if resources.CPUPeriod > 0 && !sysInfo.CPUCfsPeriod {
-		warnings = append(warnings, "Your kernel does not support CPU cfs period. Period discarded.")
+		warnings.add(WarningResource, "Your kernel does not support CPU cfs period. Period discarded.")
 		logrus.Warnf("Your kernel does not support CPU cfs period. Period discarded.")
 		resources.CPUPeriod = 0
 	}
 	if resources.CPUQuota > 0 && !sysInfo.CPUCfsQuota {
-		warnings = append(warnings, "Your kernel does not support CPU cfs quota. Quota discarded.")
+		warnings.add(WarningResource, "Your kernel does not support CPU cfs quota. Quota discarded.")
 		logrus.Warnf("Your kernel does not support CPU cfs quota. Quota discarded.")
 		resources.CPUQuota = 0
 	}
 
 	// cpuset subsystem checks and adjustments
 	if (resources.CpusetCpus != "" || resources.CpusetMems != "") && !sysInfo.Cpuset {
-		warnings = append(warnings, "Your kernel does not support cpuset. Cpuset discarded.")
+		warnings.add(WarningResource, "Your kernel does not support cpuset. Cpuset discarded.")
 		logrus.Warnf("Your kernel does not support cpuset. Cpuset discarded.")
 		resources.CpusetCpus = ""
 		resources.CpusetMems = ""
@@ -427,7 +452,7 @@ func verifyContainerResources(resources *containertypes.Resources, sysInfo *sysi
 
 	// blkio subsystem checks and adjustments
 	if resources.BlkioWeight > 0 && !sysInfo.BlkioWeight {
-		warnings = append(warnings, "Your kernel does not support Block I/O weight. Weight discarded.")
+		warnings.add(WarningResource, "Your kernel does not support Block I/O weight. Weight discarded.")
 		logrus.Warnf("Your kernel does not support Block I/O weight. Weight discarded.")
 		resources.BlkioWeight = 0
 	}
@@ -435,27 +460,27 @@ func verifyContainerResources(resources *containertypes.Resources, sysInfo *sysi
 		return warnings, fmt.Errorf("Range of blkio weight is from 10 to 1000.")
 	}
 	if len(resources.BlkioWeightDevice) > 0 && !sysInfo.BlkioWeightDevice {
-		warnings = append(warnings, "Your kernel does not support Block I/O weight_device.")
+		warnings.add(WarningResource, "Your kernel does not support Block I/O weight_device.")
 		logrus.Warnf("Your kernel does not support Block I/O weight_device. Weight-device discarded.")
 		resources.BlkioWeightDevice = []*pblkiodev.WeightDevice{}
 	}
 	if len(resources.BlkioDeviceReadBps) > 0 && !sysInfo.BlkioReadBpsDevice {
-		warnings = append(warnings, "Your kernel does not support Block read limit in bytes per second.")
+		warnings.add(WarningResource, "Your kernel does not support Block read limit in bytes per second.")
 		logrus.Warnf("Your kernel does not support Block I/O read limit in bytes per second. --device-read-bps discarded.")
 		resources.BlkioDeviceReadBps = []*pblkiodev.ThrottleDevice{}
 	}
 	if len(resources.BlkioDeviceWriteBps) > 0 && !sysInfo.BlkioWriteBpsDevice {
-		warnings = append(warnings, "Your kernel does not support Block write limit in bytes per second.")
+		warnings.add(WarningResource, "Your kernel does not support Block write limit in bytes per second.")
 		logrus.Warnf("Your kernel does not support Block I/O write limit in bytes per second. --device-write-bps discarded.")
 		resources.BlkioDeviceWriteBps = []*pblkiodev.ThrottleDevice{}
 	}
 	if len(resources.BlkioDeviceReadIOps) > 0 && !sysInfo.BlkioReadIOpsDevice {
-		warnings = append(warnings, "Your kernel does not support Block read limit in IO per second.")
+		warnings.add(WarningResource, "Your kernel does not support Block read limit in IO per second.")
 		logrus.Warnf("Your kernel does not support Block I/O read limit in IO per second. -device-read-iops discarded.")
 		resources.BlkioDeviceReadIOps = []*pblkiodev.ThrottleDevice{}
 	}
 	if len(resources.BlkioDeviceWriteIOps) > 0 && !sysInfo.BlkioWriteIOpsDevice {
-		warnings = append(warnings, "Your kernel does not support Block write limit in IO per second.")
+		warnings.add(WarningResource, "Your kernel does not support Block write limit in IO per second.")
 		logrus.Warnf("Your kernel does not support Block I/O write limit in IO per second. --device-write-iops discarded.")
 		resources.BlkioDeviceWriteIOps = []*pblkiodev.ThrottleDevice{}
 	}
@@ -498,10 +523,27 @@ func UsingSystemd(config *Config) bool {
 	return getCD(config) == cgroupSystemdDriver
 }
 
+// verifyPlatformContainerLabels performs platform-specific validation of the
+// settings carried in the labels of a container.
+func verifyPlatformContainerLabels(daemon *Daemon, hostConfig *containertypes.HostConfig, config *containertypes.Config) error {
+	withInit, err := runconfig.InitFromLabels(config.Labels)
+	if err != nil {
+		return err
+	}
+	if withInit {
+		if !hostConfig.PidMode.IsPrivate() {
+			return fmt.Errorf("Cannot run an init process when sharing a PID namespace")
+		}
+		if _, err := daemon.initPath(); err != nil {
+			return fmt.Errorf("Cannot find the init binary: %v", err)
+		}
+	}
+	return nil
+}
+
 // verifyPlatformContainerSettings performs platform-specific validation of the
 // hostconfig and config structures.
-func verifyPlatformContainerSettings(daemon *Daemon, hostConfig *containertypes.HostConfig, config *containertypes.Config, update bool) ([]string, error) {
-	warnings := []string{}
+func verifyPlatformContainerSettings(daemon *Daemon, hostConfig *containertypes.HostConfig, config *containertypes.Config, update bool) (containerWarnings, error) {
 	sysInfo := sysinfo.New(true)
 
 	warnings, err := daemon.verifyExperimentalContainerSettings(hostConfig, config)
@@ -519,11 +561,15 @@ func verifyPlatformContainerSettings(daemon *Daemon, hostConfig *containertypes.
 		return warnings, fmt.Errorf("SHM size must be greater then 0")
 	}
 
+	if err := verifyUlimits(hostConfig.Ulimits, daemon.configStore.MaxUlimits); err != nil {
+		return warnings, err
+	}
+
 	if hostConfig.OomScoreAdj < -1000 || hostConfig.OomScoreAdj > 1000 {
 		return warnings, fmt.Errorf("Invalid value %d, range for oom score adj is [-1000, 1000].", hostConfig.OomScoreAdj)
 	}
 	if sysInfo.IPv4ForwardingDisabled {
-		warnings = append(warnings, "IPv4 forwarding is disabled. Networking will not work.")
+		warnings.add(WarningNetwork, "IPv4 forwarding is disabled. Networking will not work.")
 		logrus.Warnf("IPv4 forwarding is disabled. Networking will not work")
 	}
 	// check for various conflicting options with user namespaces
@@ -550,6 +596,40 @@ func verifyPlatformContainerSettings(daemon *Daemon, hostConfig *containertypes.
 	return warnings, nil
 }
 
+// verifyUlimits checks that the requested ulimits don't exceed the maximums
+// configured on the daemon. Ulimits without a configured maximum are allowed.
+func verifyUlimits(ulimits []*units.Ulimit, max map[string]*units.Ulimit) error {
+	for _, ul := range ulimits {
+		m, ok := max[ul.Name]
+		if !ok {
+			continue
+		}
+		if ul.Soft > m.Soft {
+			return fmt.Errorf("Soft limit %d for ulimit %s exceeds the maximum %d allowed by the daemon", ul.Soft, ul.Name, m.Soft)
+		}
+		if ul.Hard > m.Hard {
+			return fmt.Errorf("Hard limit %d for ulimit %s exceeds the maximum %d allowed by the daemon", ul.Hard, ul.Name, m.Hard)
+		}
+	}
+	return nil
+}
+
+// initPath returns the path on the host of the init binary injected in
+// containers started with --init.
+func (daemon *Daemon) initPath() (string, error) {
+	if daemon.configStore.InitPath != "" {
+		return daemon.configStore.InitPath, nil
+	}
+	return exec.LookPath(DefaultInitBinary)
+}
+
+// reloadPlatform updates the platform specific configuration of the daemon.
+func (daemon *Daemon) reloadPlatform(config *Config) {
+	if config.IsValueSet("max-ulimits") {
+		daemon.configStore.MaxUlimits = config.MaxUlimits
+	}
+}
+
 // verifyDaemonSettings performs validation of daemon config struct
 func verifyDaemonSettings(config *Config) error {
 	// Check for mutually incompatible config options
@@ -570,6 +650,20 @@ func verifyDaemonSettings(config *Config) error {
 			return fmt.Errorf("cgroup-parent for systemd cgroup should be a valid slice named as \"xxx.slice\"")
 		}
 	}
+	if config.FifoTimeout < 0 {
+		return fmt.Errorf("invalid containerd fifo timeout %d, it must not be negative", config.FifoTimeout)
+	}
+	if size, err := units.RAMInBytes(config.TmpTmpfsSize); err != nil || size <= 0 {
+		return fmt.Errorf("invalid tmpfs size %q for --tmp-tmpfs-size", config.TmpTmpfsSize)
+	}
+	if config.InitLayerDigest != "" {
+		if !config.VerifyInitLayer {
+			return fmt.Errorf("--init-layer-digest requires --verify-init-layer")
+		}
+		if _, err := digest.ParseDigest(config.InitLayerDigest); err != nil {
+			return fmt.Errorf("invalid init layer digest %q for --init-layer-digest: %v", config.InitLayerDigest, err)
+		}
+	}
 	return nil
 }
 
@@ -788,24 +882,28 @@ func initBridgeDriver(controller libnetwork.NetworkController, config *Config) e
 	return nil
 }
 
+// initLayerPaths are the paths setupInitLayer creates in the init layer:
+// directories, empty files, or symbolic links to the given targets.
+var initLayerPaths = map[string]string{
+	"/dev/pts":         "dir",
+	"/dev/shm":         "dir",
+	"/proc":            "dir",
+	"/sys":             "dir",
+	"/.dockerenv":      "file",
+	"/etc/resolv.conf": "file",
+	"/etc/hosts":       "file",
+	"/etc/hostname":    "file",
+	"/dev/console":     "file",
+	"/etc/mtab":        "/proc/mounts",
+}
+
 // setupInitLayer populates a directory with mountpoints suitable
 // for bind-mounting things into the container.
 //
 // This extra layer is used by all containers as the top-most ro layer. It protects
 // the container from unwanted side-effects on the rw layer.
 func setupInitLayer(initLayer string, rootUID, rootGID int) error {
-	for pth, typ := range map[string]string{
-		"/dev/pts":         "dir",
-		"/dev/shm":         "dir",
-		"/proc":            "dir",
-		"/sys":             "dir",
-		"/.dockerenv":      "file",
-		"/etc/resolv.conf": "file",
-		"/etc/hosts":       "file",
-		"/etc/hostname":    "file",
-		"/dev/console":     "file",
-		"/etc/mtab":        "/proc/mounts",
-	} {
+	for pth, typ := range initLayerPaths {
 		parts := strings.Split(pth, "/")
 		prev := "/"
 		for _, p := range parts[1:] {
@@ -846,15 +944,16 @@ func setupInitLayer(initLayer string, rootUID, rootGID int) error {
 }
 
 // Parse the remapped root (user namespace) option, which can be one of:
-//   username            - valid username from /etc/passwd
-//   username:groupname  - valid username; valid groupname from /etc/group
-//   uid                 - 32-bit unsigned int valid Linux UID value
-//   uid:gid             - uid value; 32-bit unsigned int Linux GID value
 //
-//  If no groupname is specified, and a username is specified, an attempt
-//  will be made to lookup a gid for that username as a groupname
+//	 username            - valid username from /etc/passwd
+//	 username:groupname  - valid username; valid groupname from /etc/group
+//	 uid                 - 32-bit unsigned int valid Linux UID value
+//	 uid:gid             - uid value; 32-bit unsigned int Linux GID value
+//
+//	If no groupname is specified, and a username is specified, an attempt
+//	will be made to lookup a gid for that username as a groupname
 //
-//  If names are used, they are verified to exist in passwd/group
+//	If names are used, they are verified to exist in passwd/group
 func parseRemappedRoot(usergrp string) (string, string, error) {
 
 	var (
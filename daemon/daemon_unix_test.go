@@ -5,10 +5,17 @@ package daemon
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/docker/docker/api/types/backend"
 	"github.com/docker/docker/container"
+	"github.com/docker/docker/volume"
+	"github.com/docker/engine-api/types"
 	containertypes "github.com/docker/engine-api/types/container"
+	"github.com/docker/go-units"
+	"golang.org/x/net/context"
 )
 
 // Unix test as uses settings which are not available on Windows
@@ -26,10 +33,13 @@ func TestAdjustCPUShares(t *testing.T) {
 	hostConfig := &containertypes.HostConfig{
 		Resources: containertypes.Resources{CPUShares: linuxMinCPUShares - 1},
 	}
-	daemon.adaptContainerSettings(hostConfig, true)
+	warnings, _ := daemon.adaptContainerSettings(hostConfig, true)
 	if hostConfig.CPUShares != linuxMinCPUShares {
 		t.Errorf("Expected CPUShares to be %d", linuxMinCPUShares)
 	}
+	if len(warnings) != 1 || warnings[0].Category != string(WarningResource) || warnings.messages()[0] != warnings[0].Message {
+		t.Errorf("Expected a resource warning about the adjusted CPUShares, got %v", warnings)
+	}
 
 	hostConfig.CPUShares = linuxMaxCPUShares + 1
 	daemon.adaptContainerSettings(hostConfig, true)
@@ -44,10 +54,13 @@ func TestAdjustCPUShares(t *testing.T) {
 	}
 
 	hostConfig.CPUShares = 1024
-	daemon.adaptContainerSettings(hostConfig, true)
+	warnings, _ = daemon.adaptContainerSettings(hostConfig, true)
 	if hostConfig.CPUShares != 1024 {
 		t.Error("Expected CPUShares to be unchanged")
 	}
+	if len(warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", warnings)
+	}
 }
 
 // Unix test as uses settings which are not available on Windows
@@ -89,6 +102,94 @@ func TestAdjustCPUSharesNoAdjustment(t *testing.T) {
 	}
 }
 
+func TestApplyDefaultResources(t *testing.T) {
+	daemon := &Daemon{configStore: &Config{}}
+	daemon.configStore.DefaultResources = []string{
+		"tier=batch:memory=512m,cpu-shares=512",
+		"tier=batch:memory=1g,pids-limit=100",
+	}
+
+	batch := &containertypes.Config{Labels: map[string]string{"tier": "batch"}}
+	hostConfig := &containertypes.HostConfig{}
+	if err := daemon.applyDefaultResources(hostConfig, batch); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := daemon.adaptContainerSettings(hostConfig, false); err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.Memory != 512*units.MiB || hostConfig.CPUShares != 512 || hostConfig.PidsLimit != 100 {
+		t.Fatalf("Expected the default resources of the first matching rules, got %+v", hostConfig.Resources)
+	}
+	if hostConfig.MemorySwap != 1024*units.MiB {
+		t.Fatalf("Expected the default swap to be computed from the default memory, got %d", hostConfig.MemorySwap)
+	}
+
+	hostConfig = &containertypes.HostConfig{Resources: containertypes.Resources{Memory: 64 * units.MiB}}
+	if err := daemon.applyDefaultResources(hostConfig, batch); err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.Memory != 64*units.MiB || hostConfig.CPUShares != 512 {
+		t.Fatalf("Expected the explicit memory to be preserved, got %+v", hostConfig.Resources)
+	}
+
+	web := &containertypes.Config{Labels: map[string]string{"tier": "web"}}
+	hostConfig = &containertypes.HostConfig{}
+	if err := daemon.applyDefaultResources(hostConfig, web); err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.Memory != 0 || hostConfig.CPUShares != 0 {
+		t.Fatalf("Expected no default resources for unmatched labels, got %+v", hostConfig.Resources)
+	}
+}
+
+func TestContainerCreateVerifiesDefaultResources(t *testing.T) {
+	daemon := &Daemon{configStore: &Config{}}
+	daemon.configStore.DefaultResources = []string{"tier=batch:memory=1m"}
+
+	config := &containertypes.Config{Labels: map[string]string{"tier": "batch"}}
+	_, err := daemon.ContainerCreate(context.Background(), backend.ContainerCreateConfig{ContainerCreateConfig: types.ContainerCreateConfig{Config: config}})
+	if err == nil || !strings.Contains(err.Error(), "Minimum memory limit") {
+		t.Fatalf("Expected the default memory limit to be verified, got %v", err)
+	}
+}
+
+func TestParseDefaultResourcesLabelValueWithColon(t *testing.T) {
+	d, err := parseDefaultResources("endpoint=tcp://db:5432:memory=512m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.label != "endpoint" || d.value != "tcp://db:5432" || d.resources.Memory != 512*units.MiB {
+		t.Fatalf("Expected the limits to follow the last colon, got %+v", d)
+	}
+}
+
+func TestValidateDefaultResources(t *testing.T) {
+	valid := []string{
+		"tier=batch:memory=512m",
+		"tier=:cpu-shares=512,cpuset-cpus=0-1",
+		"io=low:blkio-weight=10,memory-swap=-1",
+		"endpoint=tcp://db:5432:memory=512m",
+	}
+	for _, rule := range valid {
+		if _, err := ValidateDefaultResources(rule); err != nil {
+			t.Fatalf("Expected %q to be valid, got %v", rule, err)
+		}
+	}
+	invalid := []string{
+		"tier=batch",
+		"tier:memory=512m",
+		"tier=batch:memory",
+		"tier=batch:memory=lots",
+		"tier=batch:gpus=1",
+		"tier=batch:blkio-weight=100000",
+	}
+	for _, rule := range invalid {
+		if _, err := ValidateDefaultResources(rule); err == nil {
+			t.Fatalf("Expected %q to be invalid", rule)
+		}
+	}
+}
+
 // Unix test as uses settings which are not available on Windows
 func TestParseSecurityOptWithDeprecatedColon(t *testing.T) {
 	container := &container.Container{}
@@ -104,7 +205,7 @@ func TestParseSecurityOptWithDeprecatedColon(t *testing.T) {
 	}
 
 	// test seccomp
-	sp := "/path/to/seccomp_test.json"
+	sp := `{"defaultAction":"SCMP_ACT_ALLOW"}`
 	config.SecurityOpt = []string{"seccomp=" + sp}
 	if err := parseSecurityOpt(container, config); err != nil {
 		t.Fatalf("Unexpected parseSecurityOpt error: %v", err)
@@ -146,7 +247,7 @@ func TestParseSecurityOpt(t *testing.T) {
 	}
 
 	// test seccomp
-	sp := "/path/to/seccomp_test.json"
+	sp := `{"defaultAction":"SCMP_ACT_ALLOW"}`
 	config.SecurityOpt = []string{"seccomp=" + sp}
 	if err := parseSecurityOpt(container, config); err != nil {
 		t.Fatalf("Unexpected parseSecurityOpt error: %v", err)
@@ -174,6 +275,39 @@ func TestParseSecurityOpt(t *testing.T) {
 	}
 }
 
+func TestParseSecurityOptAppArmorProfileFile(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-daemon-unix-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	valid := filepath.Join(tmp, "docker-app")
+	if err := ioutil.WriteFile(valid, []byte("#include <tunables/global>\n\nprofile docker-app flags=(attach_disconnected) {\n  #include <abstractions/base>\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(tmp, "no-profile")
+	if err := ioutil.WriteFile(invalid, []byte("#include <tunables/global>\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	container := &container.Container{}
+	config := &containertypes.HostConfig{SecurityOpt: []string{"apparmor=" + valid}}
+	if err := parseSecurityOpt(container, config); err != nil {
+		t.Fatalf("Unexpected parseSecurityOpt error: %v", err)
+	}
+	if container.AppArmorProfile != valid {
+		t.Fatalf("Unexpected AppArmorProfile, expected: %q, got %q", valid, container.AppArmorProfile)
+	}
+
+	for _, path := range []string{invalid, filepath.Join(tmp, "missing")} {
+		config.SecurityOpt = []string{"apparmor=" + path}
+		if err := parseSecurityOpt(container, config); err == nil || !strings.Contains(err.Error(), "Invalid AppArmor profile") {
+			t.Fatalf("Expected an invalid AppArmor profile error for %s, got %v", path, err)
+		}
+	}
+}
+
 func TestNetworkOptions(t *testing.T) {
 	daemon := &Daemon{}
 	dconfigCorrect := &Config{
@@ -197,3 +331,217 @@ func TestNetworkOptions(t *testing.T) {
 		t.Fatalf("Expected networkOptions error, got nil")
 	}
 }
+
+func TestVerifyUlimits(t *testing.T) {
+	max := map[string]*units.Ulimit{
+		"nofile": {Name: "nofile", Soft: 1024, Hard: 2048},
+	}
+
+	valid := []*units.Ulimit{
+		{Name: "nofile", Soft: 1024, Hard: 2048},
+		{Name: "nofile", Soft: 512, Hard: 1024},
+		{Name: "nproc", Soft: 65535, Hard: 65535},
+	}
+	if err := verifyUlimits(valid, max); err != nil {
+		t.Fatalf("Expected ulimits to be accepted, got %v", err)
+	}
+
+	invalid := map[*units.Ulimit]string{
+		{Name: "nofile", Soft: 2048, Hard: 2048}: "Soft limit 2048 for ulimit nofile exceeds the maximum 1024 allowed by the daemon",
+		{Name: "nofile", Soft: 1024, Hard: 4096}: "Hard limit 4096 for ulimit nofile exceeds the maximum 2048 allowed by the daemon",
+	}
+	for ul, expected := range invalid {
+		err := verifyUlimits([]*units.Ulimit{ul}, max)
+		if err == nil || err.Error() != expected {
+			t.Fatalf("Expected error %q for %v, got %v", expected, ul, err)
+		}
+	}
+}
+
+func TestDaemonReloadMaxUlimits(t *testing.T) {
+	daemon := &Daemon{}
+	daemon.configStore = &Config{
+		MaxUlimits: map[string]*units.Ulimit{
+			"nofile": {Name: "nofile", Soft: 1024, Hard: 1024},
+		},
+	}
+
+	valuesSets := make(map[string]interface{})
+	valuesSets["max-ulimits"] = map[string]interface{}{}
+	newConfig := &Config{
+		CommonConfig: CommonConfig{
+			valuesSet: valuesSets,
+		},
+		MaxUlimits: map[string]*units.Ulimit{
+			"nofile": {Name: "nofile", Soft: 4096, Hard: 4096},
+		},
+	}
+
+	if err := daemon.Reload(newConfig); err != nil {
+		t.Fatal(err)
+	}
+	ul := []*units.Ulimit{{Name: "nofile", Soft: 2048, Hard: 2048}}
+	if err := verifyUlimits(ul, daemon.configStore.MaxUlimits); err != nil {
+		t.Fatalf("Expected the reloaded maximums to accept %v, got %v", ul[0], err)
+	}
+}
+
+func TestVerifyBindSources(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-daemon-unix-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	missing := filepath.Join(tmp, "missing")
+	hostConfig := &containertypes.HostConfig{
+		Binds: []string{tmp + ":/existing", "named:/named", missing + ":/missing:ro"},
+	}
+
+	daemon := &Daemon{configStore: &Config{CommonConfig: CommonConfig{MissingBindSource: MissingBindSourceReject}}}
+	err = daemon.verifyBindSources(hostConfig)
+	if err == nil || !strings.Contains(err.Error(), "source path "+missing+" does not exist") {
+		t.Fatalf("Expected an error naming the missing source, got %v", err)
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Fatalf("Expected the missing source not to be created, got %v", err)
+	}
+
+	daemon.configStore.MissingBindSource = MissingBindSourceCreate
+	if err := daemon.verifyBindSources(hostConfig); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(missing); err != nil || !fi.IsDir() {
+		t.Fatalf("Expected the missing source to be created, got %v", err)
+	}
+}
+
+func TestSetTmpTmpfs(t *testing.T) {
+	daemon := &Daemon{
+		configStore: &Config{
+			TmpTmpfsLabels: []string{"tmpfs", "app=web"},
+			TmpTmpfsSize:   "16m",
+		},
+	}
+
+	cases := []struct {
+		labels   map[string]string
+		tmpfs    map[string]string
+		volumes  map[string]struct{}
+		mounted  bool
+		expected string
+	}{
+		{labels: map[string]string{"tmpfs": ""}, expected: "size=16777216"},
+		{labels: map[string]string{"app": "web"}, expected: "size=16777216"},
+		{labels: map[string]string{"app": "db"}},
+		{},
+		{labels: map[string]string{"tmpfs": ""}, tmpfs: map[string]string{"/tmp/": "size=1m"}},
+		{labels: map[string]string{"tmpfs": ""}, volumes: map[string]struct{}{"/tmp": {}}},
+		{labels: map[string]string{"tmpfs": ""}, mounted: true},
+	}
+	for _, c := range cases {
+		ctr := &container.Container{MountPoints: make(map[string]*volume.MountPoint)}
+		if c.mounted {
+			ctr.MountPoints["/tmp"] = &volume.MountPoint{Destination: "/tmp"}
+		}
+		config := &containertypes.Config{Labels: c.labels, Volumes: c.volumes}
+		hostConfig := &containertypes.HostConfig{Tmpfs: c.tmpfs}
+		if err := daemon.setTmpTmpfs(ctr, config, hostConfig); err != nil {
+			t.Fatal(err)
+		}
+		if data := hostConfig.Tmpfs["/tmp"]; data != c.expected {
+			t.Fatalf("expected the tmpfs on /tmp of %v to be %q, got %q", c, c.expected, data)
+		}
+	}
+}
+
+func TestPreviewMounts(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-daemon-unix-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	hosts := filepath.Join(tmp, "hosts")
+	if err := ioutil.WriteFile(hosts, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(tmp, "missing")
+	userHosts := filepath.Join(tmp, "user-hosts")
+
+	ctr := &container.Container{
+		CommonContainer: container.CommonContainer{
+			HostConfig: &containertypes.HostConfig{},
+			MountPoints: map[string]*volume.MountPoint{
+				"/data":      {Source: missing, Destination: "/data", RW: true},
+				"/etc/hosts": {Source: userHosts, Destination: "/etc/hosts"},
+			},
+		},
+		HostsPath: hosts,
+	}
+	daemon := &Daemon{}
+	mounts, err := daemon.previewMounts(ctr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mounts) != 2 {
+		t.Fatalf("expected 2 mounts, got %v", mounts)
+	}
+	if mounts[0].Source != missing || mounts[0].Destination != "/data" || !mounts[0].Writable {
+		t.Fatalf("unexpected mount for /data: %v", mounts[0])
+	}
+	if mounts[1].Source != userHosts || mounts[1].Destination != "/etc/hosts" || mounts[1].Writable {
+		t.Fatalf("unexpected mount for /etc/hosts: %v", mounts[1])
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Fatalf("expected the missing source not to be created, got %v", err)
+	}
+	if ctr.HostsPath != hosts {
+		t.Fatalf("expected the hosts path of the container to be left untouched, got %s", ctr.HostsPath)
+	}
+}
+
+func TestVerifyInitLayer(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-init-layer-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	uid, gid := os.Getuid(), os.Getgid()
+	// the directories of the image are kept as they are
+	if err := os.MkdirAll(filepath.Join(tmp, "proc"), 0555); err != nil {
+		t.Fatal(err)
+	}
+	if err := setupInitLayer(tmp, uid, gid); err != nil {
+		t.Fatal(err)
+	}
+
+	d := &Daemon{configStore: &Config{}}
+	if err := d.verifyInitLayer(tmp, uid, gid); err != nil {
+		t.Fatalf("Expected no verification without --verify-init-layer, got %v", err)
+	}
+	d.configStore.VerifyInitLayer = true
+	if err := d.verifyInitLayer(tmp, uid, gid); err != nil {
+		t.Fatalf("Expected the init layer to match the reference one, got %v", err)
+	}
+	actual, err := initLayerDigest(tmp, uid, gid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.configStore.InitLayerDigest = actual.String()
+	if err := d.verifyInitLayer(tmp, uid, gid); err != nil {
+		t.Fatalf("Expected the init layer to match the pinned digest, got %v", err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(tmp, "etc", "hosts"), []byte("10.0.0.1 registry\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.verifyInitLayer(tmp, uid, gid); err == nil || !strings.Contains(err.Error(), "doesn't match") {
+		t.Fatalf("Expected the tampered init layer to be rejected, got %v", err)
+	}
+	d.configStore.InitLayerDigest = ""
+	if err := d.verifyInitLayer(tmp, uid, gid); err == nil {
+		t.Fatal("Expected the tampered init layer not to match the reference one")
+	}
+}
@@ -69,6 +69,12 @@ func setupInitLayer(initLayer string, rootUID, rootGID int) error {
 	return nil
 }
 
+// verifyInitLayer has nothing to verify on Windows, which has no init layer
+// contents.
+func (daemon *Daemon) verifyInitLayer(initPath string, rootUID, rootGID int) error {
+	return nil
+}
+
 func checkKernel() error {
 	return nil
 }
@@ -79,28 +85,54 @@ func (daemon *Daemon) getCgroupDriver() string {
 
 // adaptContainerSettings is called during container creation to modify any
 // settings necessary in the HostConfig structure.
-func (daemon *Daemon) adaptContainerSettings(hostConfig *containertypes.HostConfig, adjustCPUShares bool) error {
+func (daemon *Daemon) adaptContainerSettings(hostConfig *containertypes.HostConfig, adjustCPUShares bool) (containerWarnings, error) {
 	if hostConfig == nil {
-		return nil
+		return nil, nil
 	}
 
+	var warnings containerWarnings
 	if hostConfig.CPUShares < 0 {
-		logrus.Warnf("Changing requested CPUShares of %d to minimum allowed of %d", hostConfig.CPUShares, windowsMinCPUShares)
+		msg := fmt.Sprintf("Changing requested CPUShares of %d to minimum allowed of %d", hostConfig.CPUShares, windowsMinCPUShares)
+		warnings.add(WarningResource, msg)
+		logrus.Warn(msg)
 		hostConfig.CPUShares = windowsMinCPUShares
 	} else if hostConfig.CPUShares > windowsMaxCPUShares {
-		logrus.Warnf("Changing requested CPUShares of %d to maximum allowed of %d", hostConfig.CPUShares, windowsMaxCPUShares)
+		msg := fmt.Sprintf("Changing requested CPUShares of %d to maximum allowed of %d", hostConfig.CPUShares, windowsMaxCPUShares)
+		warnings.add(WarningResource, msg)
+		logrus.Warn(msg)
 		hostConfig.CPUShares = windowsMaxCPUShares
 	}
 
+	return warnings, nil
+}
+
+// verifyPlatformContainerLabels performs platform-specific validation of the
+// settings carried in the labels of a container.
+func verifyPlatformContainerLabels(daemon *Daemon, hostConfig *containertypes.HostConfig, config *containertypes.Config) error {
+	if withInit, err := runconfig.InitFromLabels(config.Labels); err != nil {
+		return err
+	} else if withInit {
+		return fmt.Errorf("Windows does not support running an init process in containers")
+	}
+	if group, _ := runconfig.ProcessGroupSignalsFromLabels(config.Labels); group {
+		return fmt.Errorf("Windows does not support forwarding signals to process groups")
+	}
+	if live, _ := runconfig.LiveRestoreFromLabels(config.Labels); live {
+		return fmt.Errorf("Windows does not support keeping containers running across daemon restarts")
+	}
 	return nil
 }
 
 // verifyPlatformContainerSettings performs platform-specific validation of the
 // hostconfig and config structures.
-func verifyPlatformContainerSettings(daemon *Daemon, hostConfig *containertypes.HostConfig, config *containertypes.Config, update bool) ([]string, error) {
+func verifyPlatformContainerSettings(daemon *Daemon, hostConfig *containertypes.HostConfig, config *containertypes.Config, update bool) (containerWarnings, error) {
 	return nil, nil
 }
 
+// reloadPlatform updates the platform specific configuration of the daemon.
+func (daemon *Daemon) reloadPlatform(config *Config) {
+}
+
 // verifyDaemonSettings performs validation of daemon config struct
 func verifyDaemonSettings(config *Config) error {
 	return nil
@@ -293,6 +325,10 @@ func (daemon *Daemon) cleanupMountsByID(in string) error {
 	return nil
 }
 
+func (daemon *Daemon) detachMountsByID(in string) error {
+	return nil
+}
+
 func (daemon *Daemon) cleanupMounts() error {
 	return nil
 }
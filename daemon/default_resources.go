@@ -0,0 +1,194 @@
+package daemon
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	containertypes "github.com/docker/engine-api/types/container"
+	"github.com/docker/go-units"
+)
+
+// defaultResources are the resource limits applied to the containers
+// labeled with label=value which don't set them.
+type defaultResources struct {
+	label     string
+	value     string
+	resources containertypes.Resources
+}
+
+// parseDefaultResources parses a default resources rule, in the form
+// label=value:resource=limit[,resource=limit...], e.g.
+// tier=batch:memory=512m,cpu-shares=512.
+func parseDefaultResources(rule string) (defaultResources, error) {
+	var d defaultResources
+	selector, limits := rule, ""
+	if i := strings.LastIndex(rule, ":"); i >= 0 {
+		selector, limits = rule[:i], rule[i+1:]
+	}
+	kv := strings.SplitN(selector, "=", 2)
+	if len(kv) != 2 || kv[0] == "" || limits == "" {
+		return d, fmt.Errorf("invalid default resources %q, the format is label=value:resource=limit[,resource=limit...]", rule)
+	}
+	d.label, d.value = kv[0], kv[1]
+
+	for _, limit := range strings.Split(limits, ",") {
+		kv := strings.SplitN(limit, "=", 2)
+		if len(kv) != 2 {
+			return d, fmt.Errorf("invalid resource limit %q in default resources %q", limit, rule)
+		}
+		if err := setDefaultResource(&d.resources, kv[0], kv[1]); err != nil {
+			return d, fmt.Errorf("invalid resource limit %q in default resources %q: %v", limit, rule, err)
+		}
+	}
+	return d, nil
+}
+
+func setDefaultResource(r *containertypes.Resources, name, value string) error {
+	var err error
+	switch name {
+	case "memory":
+		r.Memory, err = units.RAMInBytes(value)
+	case "memory-reservation":
+		r.MemoryReservation, err = units.RAMInBytes(value)
+	case "memory-swap":
+		if value == "-1" {
+			r.MemorySwap = -1
+			return nil
+		}
+		r.MemorySwap, err = units.RAMInBytes(value)
+	case "kernel-memory":
+		r.KernelMemory, err = units.RAMInBytes(value)
+	case "cpu-shares":
+		r.CPUShares, err = strconv.ParseInt(value, 10, 64)
+	case "cpu-period":
+		r.CPUPeriod, err = strconv.ParseInt(value, 10, 64)
+	case "cpu-quota":
+		r.CPUQuota, err = strconv.ParseInt(value, 10, 64)
+	case "cpuset-cpus":
+		r.CpusetCpus = value
+	case "pids-limit":
+		r.PidsLimit, err = strconv.ParseInt(value, 10, 64)
+	case "blkio-weight":
+		var w uint64
+		w, err = strconv.ParseUint(value, 10, 16)
+		r.BlkioWeight = uint16(w)
+	default:
+		return fmt.Errorf("unknown resource %s", name)
+	}
+	return err
+}
+
+// ValidateDefaultResources validates a default resources rule of the daemon.
+func ValidateDefaultResources(val string) (string, error) {
+	if _, err := parseDefaultResources(val); err != nil {
+		return "", err
+	}
+	return val, nil
+}
+
+// applyDefaultResources sets the resource limits of the default resources
+// rules of the daemon matching the labels in config, in the order of the
+// rules. The limits set in hostConfig, by the user or by a previous rule,
+// are kept.
+func (daemon *Daemon) applyDefaultResources(hostConfig *containertypes.HostConfig, config *containertypes.Config) error {
+	if daemon.configStore == nil || config == nil {
+		return nil
+	}
+	for _, rule := range daemon.configStore.DefaultResources {
+		d, err := parseDefaultResources(rule)
+		if err != nil {
+			return err
+		}
+		if v, ok := config.Labels[d.label]; !ok || v != d.value {
+			continue
+		}
+		r, def := &hostConfig.Resources, d.resources
+		if r.Memory == 0 {
+			r.Memory = def.Memory
+		}
+		if r.MemoryReservation == 0 {
+			r.MemoryReservation = def.MemoryReservation
+		}
+		if r.MemorySwap == 0 {
+			r.MemorySwap = def.MemorySwap
+		}
+		if r.KernelMemory == 0 {
+			r.KernelMemory = def.KernelMemory
+		}
+		if r.CPUShares == 0 {
+			r.CPUShares = def.CPUShares
+		}
+		if r.CPUPeriod == 0 {
+			r.CPUPeriod = def.CPUPeriod
+		}
+		if r.CPUQuota == 0 {
+			r.CPUQuota = def.CPUQuota
+		}
+		if r.CpusetCpus == "" {
+			r.CpusetCpus = def.CpusetCpus
+		}
+		if r.PidsLimit == 0 {
+			r.PidsLimit = def.PidsLimit
+		}
+		if r.BlkioWeight == 0 {
+			r.BlkioWeight = def.BlkioWeight
+		}
+	}
+	return nil
+}
+
+// ValidateExemptLabel validates a label exempting containers from the
+// resource limits requirement, as key or key=value.
+func ValidateExemptLabel(val string) (string, error) {
+	if val == "" || strings.HasPrefix(val, "=") {
+		return "", fmt.Errorf("invalid exempt label %q, the format is key or key=value", val)
+	}
+	return val, nil
+}
+
+// hasLabel reports whether labels have one of the given labels, as key or
+// key=value, a key alone matching any value.
+func hasLabel(labels map[string]string, selectors []string) bool {
+	for _, selector := range selectors {
+		kv := strings.SplitN(selector, "=", 2)
+		if v, ok := labels[kv[0]]; ok && (len(kv) == 1 || v == kv[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyResourceLimits checks that a container has memory and CPU limits,
+// once the default resources of the daemon are applied, when the daemon
+// requires them. A CPU limit is a CPU quota or a set of CPUs, the CPU
+// shares are only a relative weight. The exempt labels are matched against
+// the labels of the container once the ones of its image are merged in.
+func (daemon *Daemon) verifyResourceLimits(hostConfig *containertypes.HostConfig, config *containertypes.Config) error {
+	if daemon.configStore == nil || !daemon.configStore.RequireResourceLimits {
+		return nil
+	}
+	var labels map[string]string
+	if config != nil {
+		labels = config.Labels
+	}
+	if hasLabel(labels, daemon.configStore.ResourceLimitsExemptLabels) {
+		return nil
+	}
+
+	effective := *hostConfig
+	if err := daemon.applyDefaultResources(&effective, config); err != nil {
+		return err
+	}
+	var missing []string
+	if effective.Memory == 0 {
+		missing = append(missing, "memory (--memory)")
+	}
+	if effective.CPUQuota <= 0 && effective.CpusetCpus == "" {
+		missing = append(missing, "CPU (--cpu-quota or --cpuset-cpus)")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("The daemon requires resource limits, set the %s limits of the container", strings.Join(missing, " and "))
+	}
+	return nil
+}
@@ -111,6 +111,7 @@ func (daemon *Daemon) cleanupContainer(container *container.Container, forceRemo
 	defer func() {
 		if err == nil || forceRemove {
 			daemon.nameIndex.Delete(container.ID)
+			daemon.hostnameIndex.Delete(container.ID)
 			daemon.linkIndex.delete(container)
 			selinuxFreeLxcContexts(container.ProcessLabel)
 			daemon.idIndex.Delete(container.ID)
@@ -0,0 +1,32 @@
+package daemon
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/errors"
+)
+
+// errDaemonDrained is returned when a container is created or started
+// while the daemon is in drain mode.
+var errDaemonDrained = errors.NewErrorWithStatusCode(fmt.Errorf("The daemon is in drain mode and does not accept new containers"), http.StatusServiceUnavailable)
+
+// SetDrainMode enables or disables drain mode. While the daemon is drained
+// containers that are already running keep running, but new containers
+// cannot be created or started.
+func (daemon *Daemon) SetDrainMode(drain bool) {
+	var v int32
+	if drain {
+		v = 1
+	}
+	if atomic.SwapInt32(&daemon.drained, v) != v {
+		logrus.Infof("Drain mode set to %t", drain)
+	}
+}
+
+// IsDrained returns true if the daemon is in drain mode.
+func (daemon *Daemon) IsDrained() bool {
+	return atomic.LoadInt32(&daemon.drained) == 1
+}
@@ -28,6 +28,16 @@ func (daemon *Daemon) LogContainerEventWithAttributes(container *container.Conta
 	daemon.EventsService.Log(action, events.ContainerEventType, actor)
 }
 
+// logContainerStartEvent generates the start event of a container, which
+// includes the ID of the API request starting the container, if any.
+func (daemon *Daemon) logContainerStartEvent(container *container.Container) {
+	attributes := map[string]string{}
+	if container.StartRequestID != "" {
+		attributes["requestID"] = container.StartRequestID
+	}
+	daemon.LogContainerEventWithAttributes(container, "start", attributes)
+}
+
 // LogImageEvent generates an event related to a container with only the default attributes.
 func (daemon *Daemon) LogImageEvent(imageID, refName, action string) {
 	daemon.LogImageEventWithAttributes(imageID, refName, action, map[string]string{})
@@ -4,10 +4,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/docker/docker/api/server/httputils"
 	"github.com/docker/docker/container"
 	"github.com/docker/docker/daemon/events"
+	"github.com/docker/docker/image"
 	containertypes "github.com/docker/engine-api/types/container"
 	eventtypes "github.com/docker/engine-api/types/events"
+	"golang.org/x/net/context"
 )
 
 func TestLogContainerEventCopyLabels(t *testing.T) {
@@ -75,6 +78,51 @@ func TestLogContainerEventWithAttributes(t *testing.T) {
 	})
 }
 
+func TestLogContainerCreateEventAttributes(t *testing.T) {
+	e := events.New()
+	_, l, _ := e.Subscribe()
+	defer e.Evict(l)
+
+	container := &container.Container{
+		CommonContainer: container.CommonContainer{
+			ID:      "container_id",
+			Name:    "container_name",
+			ImageID: image.ID("sha256:4d3e6b2a8f5c9d1e7a0b3c6f9e2d5a8b1c4f7e0d3a6b9c2e5f8a1d4b7c0e3f6a"),
+			Config: &containertypes.Config{
+				Image:  "busybox",
+				Env:    []string{"PASSWORD=secret"},
+				Labels: map[string]string{"team": "web"},
+			},
+			HostConfig: &containertypes.HostConfig{
+				Resources: containertypes.Resources{
+					Memory:    67108864,
+					CPUShares: 512,
+				},
+			},
+		},
+	}
+	daemon := &Daemon{
+		EventsService: e,
+	}
+	ctx := context.WithValue(context.Background(), httputils.RequestIDKey, "deploy-42")
+	attributes := createEventAttributes(ctx, container)
+	daemon.LogContainerEventWithAttributes(container, "create", attributes)
+
+	validateTestAttributes(t, l, map[string]string{
+		"image":     "busybox",
+		"imageID":   "sha256:4d3e6b2a8f5c9d1e7a0b3c6f9e2d5a8b1c4f7e0d3a6b9c2e5f8a1d4b7c0e3f6a",
+		"memory":    "67108864",
+		"cpuShares": "512",
+		"team":      "web",
+		"requestID": "deploy-42",
+	})
+	for _, v := range attributes {
+		if v == "PASSWORD=secret" || v == "secret" {
+			t.Fatalf("Expected the environment not to be logged, got %v", attributes)
+		}
+	}
+}
+
 func validateTestAttributes(t *testing.T, l chan interface{}, expectedAttributesToTest map[string]string) {
 	select {
 	case ev := <-l:
@@ -0,0 +1,244 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+const (
+	// eventsWebhookQueueSize is the number of events the webhook buffers
+	// before dropping them, so that a slow webhook doesn't slow the daemon
+	// down.
+	eventsWebhookQueueSize = 256
+	// eventsWebhookAttempts is the number of times an event is posted to
+	// the webhook before giving up on it.
+	eventsWebhookAttempts = 5
+	// eventsWebhookCloseTimeout is how long closing the webhook waits for
+	// the queued events to be posted.
+	eventsWebhookCloseTimeout = 5 * time.Second
+	// statusTooManyRequests is the status of the webhook asking to post
+	// the event again later, which net/http has no constant for.
+	statusTooManyRequests = 429
+)
+
+var (
+	// eventsWebhookRetryDelay is the delay before posting an event again,
+	// doubled after each failure.
+	eventsWebhookRetryDelay = time.Second
+	// eventsWebhookTimeout is how long the webhook has to answer.
+	eventsWebhookTimeout = 10 * time.Second
+)
+
+// webhookEvent is the JSON object posted to the webhook for each container
+// event.
+type webhookEvent struct {
+	Action     string            `json:"action"`
+	ID         string            `json:"id"`
+	Name       string            `json:"name,omitempty"`
+	Image      string            `json:"image,omitempty"`
+	Time       int64             `json:"time"`
+	TimeNano   int64             `json:"timeNano"`
+	ExitCode   *int              `json:"exitCode,omitempty"`
+	OOM        *bool             `json:"oomKilled,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// eventsWebhook posts the lifecycle events of the containers, e.g. their
+// exits with their exit code, to the URL set with --events-webhook.
+type eventsWebhook struct {
+	url    string
+	client *http.Client
+
+	queue   chan webhookEvent
+	dropped int64
+	stop    chan struct{}
+	done    chan struct{}
+	cancel  func()
+
+	// oomKilled are the IDs of the containers which ran out of memory and
+	// haven't exited yet.
+	oomKilled map[string]bool
+}
+
+// validateEventsWebhook checks the URL of the events webhook is an HTTP or
+// HTTPS URL.
+func validateEventsWebhook(rawurl string) error {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return fmt.Errorf("invalid events webhook %q: %v", rawurl, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid events webhook %q, it must be an http:// or https:// URL", rawurl)
+	}
+	return nil
+}
+
+// newEventsWebhook subscribes to the events of es and starts posting the
+// container events to rawurl.
+func newEventsWebhook(rawurl string, es *events.Events) (*eventsWebhook, error) {
+	if err := validateEventsWebhook(rawurl); err != nil {
+		return nil, err
+	}
+	w := &eventsWebhook{
+		url:       rawurl,
+		client:    &http.Client{Timeout: eventsWebhookTimeout},
+		queue:     make(chan webhookEvent, eventsWebhookQueueSize),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+		oomKilled: make(map[string]bool),
+	}
+	_, l, cancel := es.Subscribe()
+	w.cancel = cancel
+	go w.receive(l)
+	go w.deliver()
+	return w, nil
+}
+
+// receive queues the container events received on l, dropping them when
+// the queue is full, until l is closed.
+func (w *eventsWebhook) receive(l chan interface{}) {
+	defer close(w.queue)
+	for m := range l {
+		msg, ok := m.(eventtypes.Message)
+		if !ok || msg.Type != eventtypes.ContainerEventType {
+			continue
+		}
+		select {
+		case w.queue <- w.webhookEvent(msg):
+		default:
+			if atomic.AddInt64(&w.dropped, 1) == 1 {
+				logrus.Warnf("The events webhook %s is too slow, dropping events", w.url)
+			}
+		}
+	}
+}
+
+// webhookEvent converts a container event to the object posted to the
+// webhook. The exit events tell whether the container ran out of memory.
+func (w *eventsWebhook) webhookEvent(msg eventtypes.Message) webhookEvent {
+	e := webhookEvent{
+		Action:     msg.Action,
+		ID:         msg.Actor.ID,
+		Name:       msg.Actor.Attributes["name"],
+		Image:      msg.Actor.Attributes["image"],
+		Time:       msg.Time,
+		TimeNano:   msg.TimeNano,
+		Attributes: msg.Actor.Attributes,
+	}
+	switch msg.Action {
+	case "oom":
+		w.oomKilled[e.ID] = true
+	case "die":
+		if exitCode, err := strconv.Atoi(msg.Actor.Attributes["exitCode"]); err == nil {
+			e.ExitCode = &exitCode
+		}
+		oomKilled := w.oomKilled[e.ID]
+		e.OOM = &oomKilled
+		delete(w.oomKilled, e.ID)
+	case "destroy":
+		delete(w.oomKilled, e.ID)
+	}
+	return e
+}
+
+// deliver posts the queued events to the webhook, in order.
+func (w *eventsWebhook) deliver() {
+	defer close(w.done)
+	for e := range w.queue {
+		if err := w.post(e); err != nil {
+			logrus.Errorf("Failed to post the %s event of container %s to the events webhook %s: %v", e.Action, e.ID, w.url, err)
+		}
+		if dropped := atomic.SwapInt64(&w.dropped, 0); dropped > 0 {
+			logrus.Warnf("Dropped %d events for the events webhook %s", dropped, w.url)
+		}
+	}
+}
+
+// post posts e to the webhook, trying again with an increasing delay when
+// the webhook can't be reached or fails, until it was tried
+// eventsWebhookAttempts times or the webhook is closed.
+func (w *eventsWebhook) post(e webhookEvent) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	delay := eventsWebhookRetryDelay
+	for attempt := 1; ; attempt++ {
+		retry, err := w.postOnce(body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == eventsWebhookAttempts {
+			return err
+		}
+		logrus.Debugf("Failed to post an event to the events webhook %s, retrying in %s: %v", w.url, delay, err)
+		select {
+		case <-time.After(delay):
+		case <-w.stop:
+			return fmt.Errorf("%v, giving up as the daemon is shutting down", err)
+		}
+		delay *= 2
+	}
+}
+
+// postOnce posts body to the webhook, and returns whether to try again when
+// it fails. The client errors of the webhook, other than 429, are final.
+func (w *eventsWebhook) postOnce(body []byte) (bool, error) {
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == statusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("the webhook answered %s", resp.Status)
+	default:
+		return false, fmt.Errorf("the webhook answered %s", resp.Status)
+	}
+}
+
+// Close stops receiving events, and waits for the queued ones to be posted
+// at most eventsWebhookCloseTimeout, without retrying the failed ones.
+func (w *eventsWebhook) Close() {
+	w.cancel()
+	close(w.stop)
+	select {
+	case <-w.done:
+	case <-time.After(eventsWebhookCloseTimeout):
+		logrus.Warnf("Timed out posting the queued events to the events webhook %s", w.url)
+	}
+}
+
+// setEventsWebhook replaces the events webhook of the daemon by one posting
+// to rawurl, or by none if rawurl is empty.
+func (daemon *Daemon) setEventsWebhook(rawurl string) error {
+	daemon.eventsWebhookMu.Lock()
+	defer daemon.eventsWebhookMu.Unlock()
+	if daemon.eventsWebhook != nil && daemon.eventsWebhook.url == rawurl {
+		return nil
+	}
+	var w *eventsWebhook
+	if rawurl != "" {
+		var err error
+		if w, err = newEventsWebhook(rawurl, daemon.EventsService); err != nil {
+			return err
+		}
+	}
+	if daemon.eventsWebhook != nil {
+		daemon.eventsWebhook.Close()
+	}
+	daemon.eventsWebhook = w
+	return nil
+}
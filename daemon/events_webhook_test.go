@@ -0,0 +1,158 @@
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+)
+
+func TestValidateEventsWebhook(t *testing.T) {
+	for url, valid := range map[string]bool{
+		"http://localhost:8080/events": true,
+		"https://hooks.example.com/":   true,
+		"localhost:8080":               false,
+		"ftp://example.com/":           false,
+		"http://":                      false,
+		"http://[::1":                  false,
+	} {
+		if err := validateEventsWebhook(url); (err == nil) != valid {
+			t.Fatalf("Expected %q to be valid: %v, got %v", url, valid, err)
+		}
+	}
+}
+
+// newWebhookServer returns a server answering the posts with the status
+// returned by status, and sending the events posted with a 2xx status to the
+// returned channel.
+func newWebhookServer(t *testing.T, status func() int) (*httptest.Server, chan webhookEvent) {
+	posted := make(chan webhookEvent, 10)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e webhookEvent
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			t.Errorf("Error decoding the posted event: %v", err)
+		}
+		code := status()
+		if code < 300 {
+			posted <- e
+		}
+		w.WriteHeader(code)
+	}))
+	return s, posted
+}
+
+func receiveWebhookEvent(t *testing.T, posted chan webhookEvent) webhookEvent {
+	select {
+	case e := <-posted:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the webhook to receive an event")
+	}
+	return webhookEvent{}
+}
+
+func TestEventsWebhookPostsExits(t *testing.T) {
+	s, posted := newWebhookServer(t, func() int { return http.StatusOK })
+	defer s.Close()
+
+	es := events.New()
+	w, err := newEventsWebhook(s.URL, es)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	actor := eventtypes.Actor{ID: "c1", Attributes: map[string]string{"name": "web", "image": "nginx"}}
+	es.Log("pull", eventtypes.ImageEventType, eventtypes.Actor{ID: "nginx"})
+	es.Log("oom", eventtypes.ContainerEventType, actor)
+	es.Log("die", eventtypes.ContainerEventType, eventtypes.Actor{ID: "c1", Attributes: map[string]string{"name": "web", "exitCode": "137"}})
+	es.Log("die", eventtypes.ContainerEventType, eventtypes.Actor{ID: "c1", Attributes: map[string]string{"name": "web", "exitCode": "0"}})
+
+	e := receiveWebhookEvent(t, posted)
+	if e.Action != "oom" || e.ID != "c1" || e.Name != "web" || e.Image != "nginx" || e.ExitCode != nil {
+		t.Fatalf("Unexpected event %+v", e)
+	}
+	e = receiveWebhookEvent(t, posted)
+	if e.Action != "die" || e.ExitCode == nil || *e.ExitCode != 137 || e.OOM == nil || !*e.OOM {
+		t.Fatalf("Expected the exit of the container killed by the OOM killer, got %+v", e)
+	}
+	e = receiveWebhookEvent(t, posted)
+	if e.Action != "die" || e.ExitCode == nil || *e.ExitCode != 0 || e.OOM == nil || *e.OOM {
+		t.Fatalf("Expected the exit of the container, got %+v", e)
+	}
+}
+
+func TestEventsWebhookRetries(t *testing.T) {
+	defer func(delay time.Duration) { eventsWebhookRetryDelay = delay }(eventsWebhookRetryDelay)
+	eventsWebhookRetryDelay = time.Millisecond
+
+	for _, c := range []struct {
+		status   int
+		failures int32
+		posted   string
+	}{
+		// the event is posted again until it succeeds
+		{http.StatusServiceUnavailable, 2, "c1"},
+		{statusTooManyRequests, 2, "c1"},
+		// the event is given up on, and the next one is posted
+		{http.StatusBadRequest, 1, "c2"},
+		{http.StatusServiceUnavailable, eventsWebhookAttempts, "c2"},
+	} {
+		var attempts int32
+		s, posted := newWebhookServer(t, func() int {
+			if atomic.AddInt32(&attempts, 1) <= c.failures {
+				return c.status
+			}
+			return http.StatusOK
+		})
+
+		es := events.New()
+		w, err := newEventsWebhook(s.URL, es)
+		if err != nil {
+			t.Fatal(err)
+		}
+		es.Log("die", eventtypes.ContainerEventType, eventtypes.Actor{ID: "c1", Attributes: map[string]string{"exitCode": "1"}})
+		es.Log("start", eventtypes.ContainerEventType, eventtypes.Actor{ID: "c2"})
+
+		if e := receiveWebhookEvent(t, posted); e.ID != c.posted {
+			t.Fatalf("Expected %s to be posted after %d failures with %d, got %+v", c.posted, c.failures, c.status, e)
+		}
+		if n := atomic.LoadInt32(&attempts); n != c.failures+1 {
+			t.Fatalf("Expected %d attempts after %d failures with %d, got %d", c.failures+1, c.failures, c.status, n)
+		}
+		w.Close()
+		s.Close()
+	}
+}
+
+func TestEventsWebhookDropsWhenFull(t *testing.T) {
+	block := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	}))
+	defer s.Close()
+
+	es := events.New()
+	w, err := newEventsWebhook(s.URL, es)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < eventsWebhookQueueSize+10; i++ {
+		es.Log("start", eventtypes.ContainerEventType, eventtypes.Actor{ID: "c1"})
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt64(&w.dropped) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the events to be dropped when the queue is full")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(block)
+	w.Close()
+}
@@ -93,6 +93,9 @@ func (d *Daemon) ContainerExecCreate(config *types.ExecConfig) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if err := d.setupLazyNetwork(container); err != nil {
+		return "", err
+	}
 
 	cmd := strslice.StrSlice(config.Cmd)
 	entrypoint, args := d.getEntrypointAndArgs(strslice.StrSlice{}, cmd)
@@ -35,7 +35,7 @@ func (daemon *Daemon) containerExport(container *container.Container) (archive.A
 		return nil, err
 	}
 
-	uidMaps, gidMaps := daemon.GetUIDGIDMaps()
+	uidMaps, gidMaps := daemon.containerIDMaps(container.Config)
 	archive, err := archive.TarWithOptions(container.BaseFS, &archive.TarOptions{
 		Compression: archive.Uncompressed,
 		UIDMaps:     uidMaps,
@@ -0,0 +1,140 @@
+package daemon
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types/backend"
+	"github.com/docker/docker/container"
+)
+
+// FreezeContainers pauses all the running containers which are not paused
+// yet, e.g. to snapshot their filesystems consistently, and returns the ones
+// it paused, to be passed to ThawContainers. The containers which fail to
+// pause are reported and the others are paused anyway, unless atomic is set:
+// then the containers paused so far are unpaused, and an error is returned.
+// The containers started meanwhile are not paused.
+func (daemon *Daemon) FreezeContainers(atomic bool) (*backend.FreezeResult, error) {
+	daemon.freezeMu.Lock()
+	defer daemon.freezeMu.Unlock()
+
+	var containers []*container.Container
+	for _, c := range daemon.List() {
+		if c.IsRunning() && !c.IsPaused() && !c.IsRestarting() {
+			containers = append(containers, c)
+		}
+	}
+	result := daemon.forEachFrozen(containers, daemon.containerPause)
+	if atomic && len(result.Errors) > 0 {
+		rollback := daemon.forEachFrozen(daemon.getContainers(result.Containers), daemon.resumeFrozen)
+		for id, err := range rollback.Errors {
+			logrus.Errorf("Failed to unpause container %s after freezing the containers failed: %s", id, err)
+		}
+		return nil, fmt.Errorf("Cannot freeze the containers, the %d containers paused were unpaused: %s", len(result.Containers), formatFreezeErrors(result.Errors))
+	}
+	logrus.Infof("Froze %d containers", len(result.Containers))
+	return result, nil
+}
+
+// ThawContainers unpauses the containers ids, as returned by
+// FreezeContainers, or all the paused containers when ids is empty, and
+// returns the ones it unpaused. The containers which fail to unpause are
+// reported and the others are unpaused anyway.
+func (daemon *Daemon) ThawContainers(ids []string) (*backend.FreezeResult, error) {
+	daemon.freezeMu.Lock()
+	defer daemon.freezeMu.Unlock()
+
+	var containers []*container.Container
+	if len(ids) == 0 {
+		for _, c := range daemon.List() {
+			if c.IsPaused() {
+				containers = append(containers, c)
+			}
+		}
+	} else {
+		for _, id := range ids {
+			c, err := daemon.GetContainer(id)
+			if err != nil {
+				return nil, err
+			}
+			containers = append(containers, c)
+		}
+	}
+	result := daemon.forEachFrozen(containers, daemon.resumeFrozen)
+	logrus.Infof("Thawed %d containers", len(result.Containers))
+	return result, nil
+}
+
+// forEachFrozen calls fn for the containers concurrently, and returns the
+// ones it succeeded for along with the errors of the others. The containers
+// which stopped meanwhile are left out.
+func (daemon *Daemon) forEachFrozen(containers []*container.Container, fn func(*container.Container) error) *backend.FreezeResult {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		result = &backend.FreezeResult{Containers: []string{}}
+	)
+	for _, c := range containers {
+		wg.Add(1)
+		go func(c *container.Container) {
+			defer wg.Done()
+			err := fn(c)
+			if _, ok := err.(errNotRunning); ok {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if result.Errors == nil {
+					result.Errors = make(map[string]string)
+				}
+				result.Errors[c.ID] = err.Error()
+				return
+			}
+			result.Containers = append(result.Containers, c.ID)
+		}(c)
+	}
+	wg.Wait()
+	sort.Strings(result.Containers)
+	return result
+}
+
+// getContainers returns the containers ids which still exist.
+func (daemon *Daemon) getContainers(ids []string) []*container.Container {
+	var containers []*container.Container
+	for _, id := range ids {
+		if c := daemon.containers.Get(id); c != nil {
+			containers = append(containers, c)
+		}
+	}
+	return containers
+}
+
+// resumeFrozen unpauses a container paused by FreezeContainers. Unlike
+// containerUnpause, it doesn't wait for the pause to be reported by
+// containerd, which happens asynchronously.
+func (daemon *Daemon) resumeFrozen(c *container.Container) error {
+	c.Lock()
+	defer c.Unlock()
+
+	if !c.Running {
+		return errNotRunning{c.ID}
+	}
+	if err := daemon.containerd.Resume(c.ID); err != nil {
+		return fmt.Errorf("Cannot unpause container %s: %s", c.ID, err)
+	}
+	return nil
+}
+
+// formatFreezeErrors formats the errors of a FreezeResult, sorted by ID.
+func formatFreezeErrors(errs map[string]string) string {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err)
+	}
+	sort.Strings(msgs)
+	return strings.Join(msgs, "; ")
+}
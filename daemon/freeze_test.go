@@ -0,0 +1,122 @@
+package daemon
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/libcontainerd"
+)
+
+// pauseClient is a containerd client pausing the containers which are not
+// failing.
+type pauseClient struct {
+	libcontainerd.Client
+	mu      sync.Mutex
+	failing map[string]bool
+	paused  map[string]bool
+}
+
+func (c *pauseClient) Pause(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failing[id] {
+		return fmt.Errorf("freezer failed")
+	}
+	c.paused[id] = true
+	return nil
+}
+
+func (c *pauseClient) Resume(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.paused[id] {
+		return fmt.Errorf("not paused")
+	}
+	delete(c.paused, id)
+	return nil
+}
+
+func newFreezeTestDaemon(failing ...string) (*Daemon, *pauseClient) {
+	client := &pauseClient{failing: make(map[string]bool), paused: make(map[string]bool)}
+	for _, id := range failing {
+		client.failing[id] = true
+	}
+	daemon := &Daemon{containers: container.NewMemoryStore(), containerd: client}
+	for _, id := range []string{"c1", "c2", "c3", "stopped"} {
+		c := container.NewBaseContainer(id, "")
+		if id != "stopped" {
+			c.SetRunning(1, true)
+		}
+		daemon.containers.Add(id, c)
+	}
+	return daemon, client
+}
+
+func TestFreezeContainers(t *testing.T) {
+	daemon, client := newFreezeTestDaemon("c2")
+	// a container paused by the user is not frozen, nor thawed
+	daemon.containers.Get("c3").Paused = true
+	client.paused["c3"] = true
+
+	result, err := daemon.FreezeContainers(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result.Containers, []string{"c1"}) {
+		t.Fatalf("Expected c1 to be paused, got %v", result.Containers)
+	}
+	if len(result.Errors) != 1 || !strings.Contains(result.Errors["c2"], "freezer failed") {
+		t.Fatalf("Expected the failure of c2 to be reported, got %v", result.Errors)
+	}
+
+	result, err = daemon.ThawContainers(result.Containers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result.Containers, []string{"c1"}) || len(result.Errors) != 0 {
+		t.Fatalf("Expected c1 to be unpaused, got %+v", result)
+	}
+	if !reflect.DeepEqual(client.paused, map[string]bool{"c3": true}) {
+		t.Fatalf("Expected only c3 to be left paused, got %v", client.paused)
+	}
+}
+
+func TestFreezeContainersAtomic(t *testing.T) {
+	daemon, client := newFreezeTestDaemon("c2")
+
+	if _, err := daemon.FreezeContainers(true); err == nil || !strings.Contains(err.Error(), "freezer failed") {
+		t.Fatalf("Expected the freeze to fail, got %v", err)
+	}
+	if len(client.paused) != 0 {
+		t.Fatalf("Expected the paused containers to be unpaused, got %v", client.paused)
+	}
+
+	delete(client.failing, "c2")
+	result, err := daemon.FreezeContainers(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result.Containers, []string{"c1", "c2", "c3"}) {
+		t.Fatalf("Expected all the running containers to be paused, got %v", result.Containers)
+	}
+}
+
+func TestThawAllPausedContainers(t *testing.T) {
+	daemon, client := newFreezeTestDaemon()
+	for _, id := range []string{"c1", "c3"} {
+		daemon.containers.Get(id).Paused = true
+		client.paused[id] = true
+	}
+
+	result, err := daemon.ThawContainers(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result.Containers, []string{"c1", "c3"}) || len(client.paused) != 0 {
+		t.Fatalf("Expected all the paused containers to be unpaused, got %+v", result)
+	}
+}
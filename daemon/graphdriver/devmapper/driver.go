@@ -120,7 +120,11 @@ func (d *Driver) Cleanup() error {
 }
 
 // Create adds a device with a given id and the parent.
-func (d *Driver) Create(id, parent, mountLabel string) error {
+func (d *Driver) Create(id, parent, mountLabel string, storageOpt map[string]string) error {
+	if len(storageOpt) != 0 {
+		return fmt.Errorf("--storage-opt is not supported for %s", d.String())
+	}
+
 	if err := d.DeviceSet.AddDevice(id, parent); err != nil {
 		return err
 	}
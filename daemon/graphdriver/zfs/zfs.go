@@ -18,6 +18,7 @@ import (
 	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/mount"
 	"github.com/docker/docker/pkg/parsers"
+	"github.com/docker/go-units"
 	zfs "github.com/mistifyio/go-zfs"
 	"github.com/opencontainers/runc/libcontainer/label"
 )
@@ -243,8 +244,13 @@ func (d *Driver) mountPath(id string) string {
 }
 
 // Create prepares the dataset and filesystem for the ZFS driver for the given id under the parent.
-func (d *Driver) Create(id string, parent string, mountLabel string) error {
-	err := d.create(id, parent)
+func (d *Driver) Create(id string, parent string, mountLabel string, storageOpt map[string]string) error {
+	quota, err := parseStorageOpt(storageOpt)
+	if err != nil {
+		return err
+	}
+
+	err = d.create(id, parent, quota)
 	if err == nil {
 		return nil
 	}
@@ -263,22 +269,61 @@ func (d *Driver) Create(id string, parent string, mountLabel string) error {
 	}
 
 	// retry
-	return d.create(id, parent)
+	return d.create(id, parent, quota)
 }
 
-func (d *Driver) create(id, parent string) error {
+func (d *Driver) create(id, parent, quota string) error {
 	name := d.zfsPath(id)
 	if parent == "" {
 		mountoptions := map[string]string{"mountpoint": "legacy"}
 		fs, err := zfs.CreateFilesystem(name, mountoptions)
 		if err == nil {
-			d.Lock()
-			d.filesystemsCache[fs.Name] = true
-			d.Unlock()
+			err = setQuota(name, quota)
+			if err == nil {
+				d.Lock()
+				d.filesystemsCache[fs.Name] = true
+				d.Unlock()
+			}
 		}
 		return err
 	}
-	return d.cloneFilesystem(name, d.zfsPath(parent))
+	err := d.cloneFilesystem(name, d.zfsPath(parent))
+	if err == nil {
+		err = setQuota(name, quota)
+	}
+	return err
+}
+
+// parseStorageOpt returns the quota requested with the "size" storage
+// option, or an empty string if no quota is requested.
+func parseStorageOpt(storageOpt map[string]string) (string, error) {
+	var quota string
+	for k, v := range storageOpt {
+		switch strings.ToLower(k) {
+		case "size":
+			size, err := units.RAMInBytes(v)
+			if err != nil {
+				return "", err
+			}
+			quota = strconv.FormatInt(size, 10)
+		default:
+			return "", fmt.Errorf("Unknown storage option %s for zfs", k)
+		}
+	}
+	return quota, nil
+}
+
+// setQuota limits the space the dataset and its descendants can
+// reference, not counting the data shared with its origin snapshot.
+func setQuota(name, quota string) error {
+	if quota == "" {
+		return nil
+	}
+	fs, err := zfs.GetDataset(name)
+	if err != nil {
+		return err
+	}
+	return fs.SetProperty("refquota", quota)
 }
 
 // Remove deletes the dataset, filesystem and the cache for the given id.
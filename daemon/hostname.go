@@ -0,0 +1,134 @@
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/docker/docker/pkg/registrar"
+	containertypes "github.com/docker/engine-api/types/container"
+)
+
+// maxHostnameLength is the maximum length of a DNS label.
+const maxHostnameLength = 63
+
+var validHostname = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?$`)
+
+// hostnameTemplateData is the data the hostname template of the daemon is
+// executed with.
+type hostnameTemplateData struct {
+	// ID is the truncated ID of the container.
+	ID string
+	// Name is the name of the container, without the leading slash.
+	Name string
+	// Image is the image the container is created from, as requested.
+	Image string
+	// Labels are the labels of the container.
+	Labels map[string]string
+	// Index is the lowest index, from 1, giving a hostname which isn't used
+	// by another container, nor being generated for one.
+	Index templateIndex
+}
+
+// templateIndex is the index of a hostname, which the template outputs
+// between markers, for a truncated hostname to keep it.
+type templateIndex int
+
+// indexMarker delimits the index in the output of the hostname template.
+const indexMarker = "\x00"
+
+func (i templateIndex) String() string {
+	return fmt.Sprintf("%s%d%s", indexMarker, int(i), indexMarker)
+}
+
+// parseHostnameTemplate parses the hostname template set with
+// --hostname-template. Missing labels are errors instead of "<no value>".
+func parseHostnameTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("hostname").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hostname template %q: %v", text, err)
+	}
+	return tmpl, nil
+}
+
+// executeHostnameTemplate executes the hostname template with data, and
+// validates the result is a DNS label, truncating it to 63 characters. The
+// text before the last index is truncated, so that the index is kept. The
+// underscores, which container names may contain, are replaced by hyphens.
+func executeHostnameTemplate(tmpl *template.Template, data hostnameTemplateData) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("cannot generate the hostname of the container: %v", err)
+	}
+	parts := strings.Split(strings.Replace(strings.TrimSpace(buf.String()), "_", "-", -1), indexMarker)
+	if excess := len(strings.Join(parts, "")) - maxHostnameLength; excess > 0 {
+		// the parts alternate text and indexes, the last index being the
+		// part before the last one
+		if i := len(parts) - 3; i >= 0 && len(strings.TrimRight(parts[i], "-")) > excess {
+			text := strings.TrimRight(parts[i], "-")
+			sep := parts[i][len(text):]
+			parts[i] = strings.TrimRight(text[:len(text)-excess], "-") + sep
+		}
+	}
+	hostname := strings.Join(parts, "")
+	if len(hostname) > maxHostnameLength {
+		hostname = strings.TrimRight(hostname[:maxHostnameLength], "-")
+	}
+	if !validHostname.MatchString(hostname) {
+		return "", fmt.Errorf("the hostname template generated %q, which is not a valid hostname", hostname)
+	}
+	return hostname, nil
+}
+
+// templateHostname generates the hostname of a container from the hostname
+// template of the daemon, picking the lowest index giving a hostname which
+// isn't used by another container. The hostname is reserved for the
+// container id in the hostname index until it is removed, so that the
+// containers created concurrently are given different indexes.
+func (daemon *Daemon) templateHostname(text, id, name string, config *containertypes.Config) (string, error) {
+	tmpl, err := parseHostnameTemplate(text)
+	if err != nil {
+		return "", err
+	}
+
+	used := make(map[string]bool)
+	for _, c := range daemon.List() {
+		if c.Config != nil {
+			used[c.Config.Hostname] = true
+		}
+	}
+
+	data := hostnameTemplateData{
+		ID:     id[:12],
+		Name:   strings.TrimPrefix(name, "/"),
+		Image:  config.Image,
+		Labels: config.Labels,
+	}
+	if data.Labels == nil {
+		data.Labels = map[string]string{}
+	}
+
+	var previous string
+	for data.Index = 1; ; data.Index++ {
+		hostname, err := executeHostnameTemplate(tmpl, data)
+		if err != nil {
+			return "", err
+		}
+		// The hostname doesn't depend on the index, and is in use.
+		if hostname == previous {
+			return "", fmt.Errorf("the hostname template generated %q, which is already used by another container", hostname)
+		}
+		if !used[hostname] {
+			err := daemon.hostnameIndex.Reserve(hostname, id)
+			if err == nil {
+				return hostname, nil
+			}
+			if err != registrar.ErrNameReserved {
+				return "", err
+			}
+		}
+		previous = hostname
+	}
+}
@@ -0,0 +1,169 @@
+package daemon
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/docker/runconfig"
+	containertypes "github.com/docker/engine-api/types/container"
+)
+
+// containerIDMaps returns the user namespace UID and GID maps of a
+// container: its own when its labels set some, the daemon's otherwise.
+func (daemon *Daemon) containerIDMaps(config *containertypes.Config) ([]idtools.IDMap, []idtools.IDMap) {
+	if config != nil {
+		// the labels are validated when the container is created, once the
+		// labels of its image are merged in
+		if uidMaps, gidMaps, err := runconfig.IDMapsFromLabels(config.Labels); err == nil && len(uidMaps) > 0 {
+			return uidMaps, gidMaps
+		}
+	}
+	return daemon.uidMaps, daemon.gidMaps
+}
+
+// hasOwnIDMaps returns whether the labels of a container set its own UID
+// and GID maps.
+func hasOwnIDMaps(config *containertypes.Config) bool {
+	if config == nil {
+		return false
+	}
+	_, ok := config.Labels[runconfig.UIDMapsLabel]
+	return ok
+}
+
+// containerRootUIDGID returns the host UID and GID the root of container c
+// is mapped to.
+func (daemon *Daemon) containerRootUIDGID(c *container.Container) (int, int) {
+	uidMaps, gidMaps := daemon.containerIDMaps(c.Config)
+	uid, gid, _ := idtools.GetRootUIDGID(uidMaps, gidMaps)
+	return uid, gid
+}
+
+// verifyIDMaps checks the UID and GID maps a container is created with are
+// valid, and within the subordinate ranges the daemon remaps users to.
+func (daemon *Daemon) verifyIDMaps(hostConfig *containertypes.HostConfig, config *containertypes.Config) error {
+	uidMaps, gidMaps, err := runconfig.IDMapsFromLabels(config.Labels)
+	if err != nil {
+		return err
+	}
+	if len(uidMaps) == 0 && len(gidMaps) == 0 {
+		return nil
+	}
+	if daemon.uidMaps == nil {
+		return fmt.Errorf("Cannot set the UID and GID maps of a container when the daemon doesn't remap users with --userns-remap")
+	}
+	if !hostConfig.UsernsMode.IsPrivate() {
+		return fmt.Errorf("Cannot set the UID and GID maps of a container sharing the host's user namespace")
+	}
+	if len(uidMaps) == 0 || len(gidMaps) == 0 {
+		return fmt.Errorf("Both the UID and GID maps of a container must be set")
+	}
+	if err := checkIDMaps("UID", uidMaps, daemon.uidMaps); err != nil {
+		return err
+	}
+	return checkIDMaps("GID", gidMaps, daemon.gidMaps)
+}
+
+// checkIDMaps checks the maps of kind, UID or GID, don't overlap, map the
+// root of the container, and fit in the available host ranges.
+func checkIDMaps(kind string, maps []idtools.IDMap, available []idtools.IDMap) error {
+	root := false
+	for i, m := range maps {
+		if m.Size <= 0 {
+			return fmt.Errorf("Invalid %s map %s", kind, formatIDMap(m))
+		}
+		if m.ContainerID == 0 {
+			root = true
+		}
+		for _, o := range maps[:i] {
+			if rangesOverlap(m.ContainerID, m.Size, o.ContainerID, o.Size) || rangesOverlap(m.HostID, m.Size, o.HostID, o.Size) {
+				return fmt.Errorf("The %s maps %s and %s overlap", kind, formatIDMap(o), formatIDMap(m))
+			}
+		}
+		if !withinIDMaps(m.HostID, m.Size, available) {
+			return fmt.Errorf("The %s map %s is not within the subordinate %ss available to the daemon: %s", kind, formatIDMap(m), kind, formatHostRanges(available))
+		}
+	}
+	if !root {
+		return fmt.Errorf("The %s maps must map the root of the container, %s 0", kind, kind)
+	}
+	return nil
+}
+
+// verifyIDMapsOverlap rejects the UID and GID maps of a container whose
+// host ranges overlap the ones of another container created with its own
+// maps. The containers without their own maps share the daemon's ranges.
+func (daemon *Daemon) verifyIDMapsOverlap(config *containertypes.Config) error {
+	uidMaps, gidMaps, err := runconfig.IDMapsFromLabels(config.Labels)
+	if err != nil || len(uidMaps) == 0 {
+		return err
+	}
+	for _, c := range daemon.List() {
+		if !hasOwnIDMaps(c.Config) {
+			continue
+		}
+		otherUIDMaps, otherGIDMaps := daemon.containerIDMaps(c.Config)
+		if m, o, ok := hostRangesOverlap(uidMaps, otherUIDMaps); ok {
+			return fmt.Errorf("The UID map %s overlaps the UID map %s of container %s", formatIDMap(m), formatIDMap(o), c.ID)
+		}
+		if m, o, ok := hostRangesOverlap(gidMaps, otherGIDMaps); ok {
+			return fmt.Errorf("The GID map %s overlaps the GID map %s of container %s", formatIDMap(m), formatIDMap(o), c.ID)
+		}
+	}
+	return nil
+}
+
+func hostRangesOverlap(maps, others []idtools.IDMap) (idtools.IDMap, idtools.IDMap, bool) {
+	for _, m := range maps {
+		for _, o := range others {
+			if rangesOverlap(m.HostID, m.Size, o.HostID, o.Size) {
+				return m, o, true
+			}
+		}
+	}
+	return idtools.IDMap{}, idtools.IDMap{}, false
+}
+
+func rangesOverlap(start, size, otherStart, otherSize int) bool {
+	return start < otherStart+otherSize && otherStart < start+size
+}
+
+// withinIDMaps returns whether the host IDs from start to start+size are
+// all in one of the host ranges of maps.
+func withinIDMaps(start, size int, maps []idtools.IDMap) bool {
+	for _, m := range maps {
+		if start >= m.HostID && int64(start)+int64(size) <= int64(m.HostID)+int64(m.Size) {
+			return true
+		}
+	}
+	return false
+}
+
+// shiftID returns the host ID the maps to remap the same ID of the container
+// to as the host ID id of the maps from. The IDs which either maps don't
+// map are kept.
+func shiftID(id int, from, to []idtools.IDMap) int {
+	containerID, err := idtools.ToContainer(id, from)
+	if err != nil {
+		return id
+	}
+	hostID, err := idtools.ToHost(containerID, to)
+	if err != nil {
+		return id
+	}
+	return hostID
+}
+
+func formatIDMap(m idtools.IDMap) string {
+	return fmt.Sprintf("%d:%d:%d", m.ContainerID, m.HostID, m.Size)
+}
+
+func formatHostRanges(maps []idtools.IDMap) string {
+	var ranges []string
+	for _, m := range maps {
+		ranges = append(ranges, fmt.Sprintf("%d-%d", m.HostID, m.HostID+m.Size-1))
+	}
+	return strings.Join(ranges, ", ")
+}
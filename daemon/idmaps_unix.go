@@ -0,0 +1,55 @@
+//go:build !windows
+// +build !windows
+
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/layer"
+)
+
+// shiftRootfsIDs changes the owner of the files of the writable layer
+// rwLayer of container c, created with its own UID and GID maps, from the
+// host IDs the daemon remaps the users of the image to, to the ones the maps
+// of the container remap them to. The files of the image are copied up into
+// the writable layer, which takes as long and as much space as cloning the
+// image.
+func (daemon *Daemon) shiftRootfsIDs(c *container.Container, rwLayer layer.RWLayer) error {
+	if !hasOwnIDMaps(c.Config) {
+		return nil
+	}
+	uidMaps, gidMaps := daemon.containerIDMaps(c.Config)
+
+	root, err := rwLayer.Mount(c.MountLabel)
+	if err != nil {
+		return err
+	}
+	defer rwLayer.Unmount()
+
+	return filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		stat, ok := fi.Sys().(*syscall.Stat_t)
+		if !ok {
+			return nil
+		}
+		uid := shiftID(int(stat.Uid), daemon.uidMaps, uidMaps)
+		gid := shiftID(int(stat.Gid), daemon.gidMaps, gidMaps)
+		if uid == int(stat.Uid) && gid == int(stat.Gid) {
+			return nil
+		}
+		if err := os.Lchown(path, uid, gid); err != nil {
+			return err
+		}
+		// changing the owner clears the setuid and setgid bits
+		if fi.Mode()&os.ModeSymlink == 0 && fi.Mode()&(os.ModeSetuid|os.ModeSetgid) != 0 {
+			return os.Chmod(path, fi.Mode())
+		}
+		return nil
+	})
+}
@@ -0,0 +1,12 @@
+package daemon
+
+import (
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/layer"
+)
+
+// shiftRootfsIDs does nothing, the containers on Windows have no user
+// namespace.
+func (daemon *Daemon) shiftRootfsIDs(c *container.Container, rwLayer layer.RWLayer) error {
+	return nil
+}
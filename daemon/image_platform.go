@@ -0,0 +1,54 @@
+package daemon
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/docker/docker/image"
+)
+
+// archAliases maps the other names of the architectures found in images to
+// their names in Go.
+var archAliases = map[string]string{
+	"x86_64":  "amd64",
+	"x86-64":  "amd64",
+	"i386":    "386",
+	"i686":    "386",
+	"aarch64": "arm64",
+	"armhf":   "arm",
+	"armel":   "arm",
+}
+
+// normalizeArch returns the name of the architecture arch in Go.
+func normalizeArch(arch string) string {
+	if a, ok := archAliases[arch]; ok {
+		return a
+	}
+	return arch
+}
+
+// verifyImagePlatform checks that img was built for the OS and architecture
+// of the host, unless the daemon allows mismatches, e.g. to run images with
+// emulation.
+func (daemon *Daemon) verifyImagePlatform(img *image.Image) error {
+	if img == nil || (daemon.configStore != nil && daemon.configStore.AllowPlatformMismatch) {
+		return nil
+	}
+	return checkImagePlatform(img, runtime.GOOS, runtime.GOARCH)
+}
+
+// checkImagePlatform checks that img was built for os and arch. The images
+// which don't record their OS or architecture are accepted.
+func checkImagePlatform(img *image.Image, os, arch string) error {
+	imgOS, imgArch := img.OS, normalizeArch(img.Architecture)
+	if (imgOS == "" || imgOS == os) && (imgArch == "" || imgArch == arch) {
+		return nil
+	}
+	if imgOS == "" {
+		imgOS = os
+	}
+	if imgArch == "" {
+		imgArch = arch
+	}
+	return fmt.Errorf("Image %s was built for %s/%s, the host is %s/%s", img.ID(), imgOS, imgArch, os, arch)
+}
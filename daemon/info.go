@@ -3,6 +3,7 @@ package daemon
 import (
 	"os"
 	"runtime"
+	"strconv"
 	"sync/atomic"
 	"time"
 
@@ -125,6 +126,8 @@ func (daemon *Daemon) SystemInfo() (*types.Info, error) {
 		v.Name = hostname
 	}
 
+	v.SystemStatus = append(v.SystemStatus, [2]string{"Drain Mode", strconv.FormatBool(daemon.IsDrained())})
+
 	return v, nil
 }
 
@@ -0,0 +1,138 @@
+// +build linux freebsd
+
+package daemon
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/distribution/digest"
+)
+
+// initLayerReference is the digest of an init layer set up by the daemon in
+// a temporary directory, which the init layers of the containers are
+// verified against when --init-layer-digest isn't set.
+var initLayerReference struct {
+	sync.Once
+	digest digest.Digest
+	err    error
+}
+
+// verifyInitLayer checks the init layer set up in initPath matches the
+// expected digest when --verify-init-layer is set, so that the creation of a
+// container with a tampered init layer, e.g. with an /etc/hosts which isn't
+// empty, fails.
+func (daemon *Daemon) verifyInitLayer(initPath string, rootUID, rootGID int) error {
+	if daemon.configStore == nil || !daemon.configStore.VerifyInitLayer {
+		return nil
+	}
+	expected, err := daemon.expectedInitLayerDigest()
+	if err != nil {
+		return err
+	}
+	actual, err := initLayerDigest(initPath, rootUID, rootGID)
+	if err != nil {
+		return fmt.Errorf("Error computing the digest of the init layer: %v", err)
+	}
+	if actual != expected {
+		return fmt.Errorf("The init layer doesn't match the expected digest %s, its digest is %s", expected, actual)
+	}
+	return nil
+}
+
+// expectedInitLayerDigest returns the digest set with --init-layer-digest,
+// or the digest of the reference init layer.
+func (daemon *Daemon) expectedInitLayerDigest() (digest.Digest, error) {
+	if daemon.configStore.InitLayerDigest != "" {
+		return digest.Digest(daemon.configStore.InitLayerDigest), nil
+	}
+	initLayerReference.Do(func() {
+		initLayerReference.digest, initLayerReference.err = referenceInitLayerDigest()
+		if initLayerReference.err == nil {
+			logrus.Infof("Verifying the init layers of the containers against digest %s", initLayerReference.digest)
+		}
+	})
+	return initLayerReference.digest, initLayerReference.err
+}
+
+// referenceInitLayerDigest sets up an init layer in a temporary directory and
+// returns its digest.
+func referenceInitLayerDigest() (digest.Digest, error) {
+	dir, err := ioutil.TempDir("", "docker-init-layer-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	uid, gid := os.Getuid(), os.Getgid()
+	if err := setupInitLayer(dir, uid, gid); err != nil {
+		return "", fmt.Errorf("Error setting up the reference init layer: %v", err)
+	}
+	d, err := initLayerDigest(dir, uid, gid)
+	if err != nil {
+		return "", fmt.Errorf("Error computing the digest of the reference init layer: %v", err)
+	}
+	return d, nil
+}
+
+// initLayerDigest returns the digest of the paths setupInitLayer creates in
+// initLayer: the types of the paths, the contents, modes and owners of the
+// files, and the targets of the links. The owners are hashed relatively to
+// the root of the container, rootUID and rootGID. The directories, which may
+// come from the image, only count by their type.
+func initLayerDigest(initLayer string, rootUID, rootGID int) (digest.Digest, error) {
+	var paths []string
+	for pth := range initLayerPaths {
+		paths = append(paths, pth)
+	}
+	sort.Strings(paths)
+
+	digester := digest.Canonical.New()
+	h := digester.Hash()
+	for _, pth := range paths {
+		p := filepath.Join(initLayer, pth)
+		fi, err := os.Lstat(p)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return "", err
+			}
+			fmt.Fprintf(h, "%s missing\n", pth)
+			continue
+		}
+		switch {
+		case fi.IsDir():
+			fmt.Fprintf(h, "%s dir\n", pth)
+		case fi.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(p)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(h, "%s link %s\n", pth, target)
+		case fi.Mode().IsRegular():
+			stat, ok := fi.Sys().(*syscall.Stat_t)
+			if !ok {
+				return "", fmt.Errorf("cannot get the owner of %s", pth)
+			}
+			fmt.Fprintf(h, "%s file %o %d:%d %d\n", pth, fi.Mode().Perm(), int(stat.Uid)-rootUID, int(stat.Gid)-rootGID, fi.Size())
+			f, err := os.Open(p)
+			if err != nil {
+				return "", err
+			}
+			_, err = io.Copy(h, f)
+			f.Close()
+			if err != nil {
+				return "", err
+			}
+		default:
+			fmt.Fprintf(h, "%s %s\n", pth, fi.Mode())
+		}
+	}
+	return digester.Digest(), nil
+}
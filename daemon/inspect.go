@@ -164,6 +164,12 @@ func (daemon *Daemon) getInspectData(container *container.Container, size bool)
 
 	contJSONBase.GraphDriver.Name = container.Driver
 
+	if container.RWLayer == nil {
+		if container.DeferredRWLayer {
+			contJSONBase.GraphDriver.Data = map[string]string{"Deferred": "true"}
+		}
+		return contJSONBase, nil
+	}
 	graphDriverData, err := container.RWLayer.Metadata()
 	if err != nil {
 		return nil, err
@@ -0,0 +1,107 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+// messageOverhead approximates the memory used by a message besides its
+// line, so that buffering many short lines stays within the buffer size.
+const messageOverhead = 64
+
+// RingBuffer keeps the most recent messages logged by a container in
+// memory, up to a total size, evicting the oldest ones. The messages can be
+// read back without going through the log driver, which may be remote or not
+// support reading at all.
+type RingBuffer struct {
+	mu   sync.Mutex
+	size int64
+	used int64
+	msgs []*Message
+}
+
+// NewRingBuffer returns a buffer retaining at most size bytes of messages.
+func NewRingBuffer(size int64) *RingBuffer {
+	return &RingBuffer{size: size}
+}
+
+// Size returns the maximum number of bytes of messages the buffer retains.
+func (r *RingBuffer) Size() int64 {
+	return r.size
+}
+
+// Add adds a copy of msg to the buffer, evicting the oldest messages if it
+// is full. A message bigger than the whole buffer is not retained.
+func (r *RingBuffer) Add(msg *Message) {
+	m := *msg
+	m.Line = append([]byte(nil), msg.Line...)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msgs = append(r.msgs, &m)
+	r.used += messageSize(&m)
+	for r.used > r.size && len(r.msgs) > 0 {
+		r.used -= messageSize(r.msgs[0])
+		r.msgs[0] = nil
+		r.msgs = r.msgs[1:]
+	}
+}
+
+// Tail returns the last tail messages logged since since, or all of them if
+// tail is negative. A zero since matches all the messages.
+func (r *RingBuffer) Tail(since time.Time, tail int) []*Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var msgs []*Message
+	for i := len(r.msgs) - 1; i >= 0 && (tail < 0 || len(msgs) < tail); i-- {
+		m := r.msgs[i]
+		if !since.IsZero() && m.Timestamp.Before(since) {
+			break
+		}
+		msgs = append(msgs, m)
+	}
+	for i, j := 0, len(msgs)-1; i < j; i, j = i+1, j-1 {
+		msgs[i], msgs[j] = msgs[j], msgs[i]
+	}
+	return msgs
+}
+
+func messageSize(m *Message) int64 {
+	return int64(len(m.Line)) + messageOverhead
+}
+
+// bufferedLogger adds the messages to a ring buffer before passing them to
+// the log driver, if any.
+type bufferedLogger struct {
+	l   Logger
+	buf *RingBuffer
+}
+
+// NewBufferedLogger returns a logger adding the messages to buf and logging
+// them with l. l may be nil for the containers logging with the "none"
+// driver, whose messages are only buffered.
+func NewBufferedLogger(l Logger, buf *RingBuffer) Logger {
+	return &bufferedLogger{l: l, buf: buf}
+}
+
+func (b *bufferedLogger) Log(msg *Message) error {
+	b.buf.Add(msg)
+	if b.l == nil {
+		return nil
+	}
+	return b.l.Log(msg)
+}
+
+func (b *bufferedLogger) Name() string {
+	if b.l == nil {
+		return "none"
+	}
+	return b.l.Name()
+}
+
+func (b *bufferedLogger) Close() error {
+	if b.l == nil {
+		return nil
+	}
+	return b.l.Close()
+}
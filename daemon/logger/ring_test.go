@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRingBufferEvicts(t *testing.T) {
+	r := NewRingBuffer(10 * (messageOverhead + 4))
+	start := time.Now()
+	for i := 0; i < 25; i++ {
+		r.Add(&Message{Line: []byte(fmt.Sprintf("%04d", i)), Source: "stdout", Timestamp: start.Add(time.Duration(i) * time.Second)})
+	}
+
+	msgs := r.Tail(time.Time{}, -1)
+	if len(msgs) != 10 {
+		t.Fatalf("Expected the buffer to retain 10 messages, got %d", len(msgs))
+	}
+	for i, m := range msgs {
+		if expected := fmt.Sprintf("%04d", 15+i); string(m.Line) != expected {
+			t.Fatalf("Expected message %d to be %q, got %q", i, expected, m.Line)
+		}
+	}
+	if msgs := r.Tail(time.Time{}, 3); len(msgs) != 3 || string(msgs[0].Line) != "0022" {
+		t.Fatalf("Expected the last 3 messages, got %d", len(msgs))
+	}
+	if msgs := r.Tail(start.Add(20*time.Second), 100); len(msgs) != 5 || string(msgs[0].Line) != "0020" {
+		t.Fatalf("Expected the 5 messages logged since the 20th, got %d", len(msgs))
+	}
+	if msgs := r.Tail(time.Time{}, 0); len(msgs) != 0 {
+		t.Fatalf("Expected no message, got %d", len(msgs))
+	}
+
+	r.Add(&Message{Line: make([]byte, 20*messageOverhead), Source: "stderr"})
+	if msgs := r.Tail(time.Time{}, -1); len(msgs) != 0 {
+		t.Fatalf("Expected a message bigger than the buffer to evict all the others, got %d", len(msgs))
+	}
+}
+
+func TestBufferedLogger(t *testing.T) {
+	r := NewRingBuffer(1024)
+	l := NewBufferedLogger(nil, r)
+	line := []byte("hello")
+	if err := l.Log(&Message{Line: line, Source: "stdout"}); err != nil {
+		t.Fatal(err)
+	}
+	// the copier may reuse the line of the messages it logs
+	copy(line, "HELLO")
+	if msgs := r.Tail(time.Time{}, -1); len(msgs) != 1 || string(msgs[0].Line) != "hello" {
+		t.Fatalf("Expected the buffer to hold a copy of the message, got %v", msgs)
+	}
+	if l.Name() != "none" {
+		t.Fatalf("Expected the buffered logger without a driver to be named none, got %s", l.Name())
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/docker/docker/pkg/stdcopy"
 	containertypes "github.com/docker/engine-api/types/container"
 	timetypes "github.com/docker/engine-api/types/time"
+	"github.com/docker/go-units"
 )
 
 // ContainerLogs hooks up a container's stdout and stderr streams
@@ -29,15 +30,6 @@ func (daemon *Daemon) ContainerLogs(containerName string, config *backend.Contai
 		return fmt.Errorf("You must choose at least one stream")
 	}
 
-	cLog, err := daemon.getLogger(container)
-	if err != nil {
-		return err
-	}
-	logReader, ok := cLog.(logger.LogReader)
-	if !ok {
-		return logger.ErrReadLogsNotSupported
-	}
-
 	follow := config.Follow && container.IsRunning()
 	tailLines, err := strconv.Atoi(config.Tail)
 	if err != nil {
@@ -59,7 +51,10 @@ func (daemon *Daemon) ContainerLogs(containerName string, config *backend.Contai
 		Tail:   tailLines,
 		Follow: follow,
 	}
-	logs := logReader.ReadLogs(readConfig)
+	logs, err := daemon.readLogs(container, readConfig)
+	if err != nil {
+		return err
+	}
 
 	wf := ioutils.NewWriteFlusher(config.OutStream)
 	defer wf.Close()
@@ -101,6 +96,47 @@ func (daemon *Daemon) ContainerLogs(containerName string, config *backend.Contai
 	}
 }
 
+// readLogs reads the logs of container from its log buffer when it holds
+// all the messages asked for, e.g. the last 100 lines without following, and
+// from its log driver otherwise. When the log driver can't read the logs, as
+// the remote ones, the messages in the log buffer are read instead.
+func (daemon *Daemon) readLogs(container *container.Container, config logger.ReadConfig) (*logger.LogWatcher, error) {
+	buf := container.LogBuffer
+	if buf != nil && !config.Follow && config.Tail >= 0 {
+		if msgs := buf.Tail(config.Since, config.Tail); len(msgs) >= config.Tail {
+			return bufferedLogs(msgs), nil
+		}
+	}
+
+	cLog, err := daemon.getLogger(container)
+	if err == nil {
+		if logReader, ok := cLog.(logger.LogReader); ok {
+			return logReader.ReadLogs(config), nil
+		}
+		err = logger.ErrReadLogsNotSupported
+	}
+	if buf == nil {
+		return nil, err
+	}
+	return bufferedLogs(buf.Tail(config.Since, config.Tail)), nil
+}
+
+// bufferedLogs returns a watcher sending msgs, read from a log buffer.
+func bufferedLogs(msgs []*logger.Message) *logger.LogWatcher {
+	logs := logger.NewLogWatcher()
+	go func() {
+		defer close(logs.Msg)
+		for _, msg := range msgs {
+			select {
+			case logs.Msg <- msg:
+			case <-logs.WatchClose():
+				return
+			}
+		}
+	}()
+	return logs
+}
+
 func (daemon *Daemon) getLogger(container *container.Container) (logger.Logger, error) {
 	if container.LogDriver != nil && container.IsRunning() {
 		return container.LogDriver, nil
@@ -115,21 +151,35 @@ func (daemon *Daemon) getLogger(container *container.Container) (logger.Logger,
 // StartLogging initializes and starts the container logging stream.
 func (daemon *Daemon) StartLogging(container *container.Container) error {
 	cfg := daemon.getLogConfig(container.HostConfig.LogConfig)
-	if cfg.Type == "none" {
+	buf := daemon.logBuffer(container)
+	if cfg.Type == "none" && buf == nil {
 		return nil // do not start logging routines
 	}
 
-	if err := logger.ValidateLogOpts(cfg.Type, cfg.Config); err != nil {
-		return err
-	}
-	l, err := container.StartLogger(cfg)
-	if err != nil {
-		return fmt.Errorf("Failed to initialize logging driver: %v", err)
+	var l logger.Logger
+	if cfg.Type != "none" {
+		if err := logger.ValidateLogOpts(cfg.Type, cfg.Config); err != nil {
+			return err
+		}
+		var err error
+		l, err = container.StartLogger(cfg)
+		if err != nil {
+			return fmt.Errorf("Failed to initialize logging driver: %v", err)
+		}
 	}
 
-	copier := logger.NewCopier(container.ID, map[string]io.Reader{"stdout": container.StdoutPipe(), "stderr": container.StderrPipe()}, l)
+	dst := l
+	if buf != nil {
+		dst = logger.NewBufferedLogger(l, buf)
+	}
+	copier := logger.NewCopier(container.ID, map[string]io.Reader{"stdout": container.StdoutPipe(), "stderr": container.StderrPipe()}, dst)
 	container.LogCopier = copier
 	copier.Run()
+	// the log driver is kept to read the logs from, the messages of the
+	// containers logging with "none" are only buffered
+	if l == nil {
+		l = dst
+	}
 	container.LogDriver = l
 
 	// set LogPath field only for json-file logdriver
@@ -140,6 +190,35 @@ func (daemon *Daemon) StartLogging(container *container.Container) error {
 	return nil
 }
 
+// logBuffer returns the buffer retaining the last messages logged by
+// container, or nil if the daemon doesn't set a log buffer size. The buffer
+// is kept across the restarts of the container, unless the size changed.
+func (daemon *Daemon) logBuffer(container *container.Container) *logger.RingBuffer {
+	var size int64
+	if daemon.configStore != nil {
+		size, _ = parseLogBufferSize(daemon.configStore.LogBufferSize)
+	}
+	if size == 0 {
+		container.LogBuffer = nil
+	} else if container.LogBuffer == nil || container.LogBuffer.Size() != size {
+		container.LogBuffer = logger.NewRingBuffer(size)
+	}
+	return container.LogBuffer
+}
+
+// parseLogBufferSize parses the size of the log buffers of the containers,
+// e.g. 256k. They are disabled when it is empty or 0.
+func parseLogBufferSize(size string) (int64, error) {
+	if size == "" {
+		return 0, nil
+	}
+	n, err := units.RAMInBytes(size)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid log buffer size %q for --log-buffer-size", size)
+	}
+	return n, nil
+}
+
 // getLogConfig returns the log configuration for the container.
 func (daemon *Daemon) getLogConfig(cfg containertypes.LogConfig) containertypes.LogConfig {
 	if cfg.Type != "" || len(cfg.Config) > 0 { // container has log driver configured
@@ -0,0 +1,49 @@
+package daemon
+
+import (
+	"time"
+
+	"github.com/docker/docker/pkg/metrics"
+	"github.com/docker/docker/utils"
+)
+
+// Phases of a container start recorded in containerStartDuration.
+const (
+	startPhaseMount   = "mount"
+	startPhaseNetwork = "network"
+	startPhaseSpec    = "spec"
+	startPhaseCreate  = "create"
+	startPhaseTotal   = "total"
+)
+
+var containerStartDuration = metrics.NewHistogramVec(
+	"docker_container_start_duration_seconds",
+	"Time taken by the phases of successful container starts.",
+	"phase",
+	metrics.DefaultBuckets,
+)
+
+// containerRestartDelay is partitioned by restart policy, its count being the
+// number of restarts scheduled, so that crash-looping containers can be
+// alerted on before they exhaust their restart retries.
+var containerRestartDelay = metrics.NewHistogramVec(
+	"docker_container_restart_delay_seconds",
+	"Delay before the restarts scheduled by the restart policies of containers.",
+	"policy",
+	[]float64{.1, .5, 1, 5, 10, 30, 60, 300, 600, 1800},
+)
+
+func init() {
+	metrics.Register(containerStartDuration)
+	metrics.Register(containerRestartDelay)
+}
+
+// observeStartPhase records the time elapsed since start for the given
+// phase of a container start. Timings are only collected while the
+// profiler is enabled, that is in debug mode.
+func observeStartPhase(phase string, start time.Time) {
+	if !utils.IsDebugEnabled() {
+		return
+	}
+	containerStartDuration.Observe(phase, time.Since(start).Seconds())
+}
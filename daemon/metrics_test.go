@@ -0,0 +1,37 @@
+package daemon
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/utils"
+)
+
+func TestObserveStartPhaseOnlyInDebug(t *testing.T) {
+	count := func() string {
+		var buf bytes.Buffer
+		if err := containerStartDuration.Collect(&buf); err != nil {
+			t.Fatal(err)
+		}
+		for _, l := range strings.Split(buf.String(), "\n") {
+			if strings.HasPrefix(l, `docker_container_start_duration_seconds_count{phase="test"}`) {
+				return l
+			}
+		}
+		return ""
+	}
+
+	observeStartPhase("test", time.Now())
+	if c := count(); c != "" {
+		t.Fatalf("expected no sample outside of debug mode, got %s", c)
+	}
+
+	utils.EnableDebug()
+	defer utils.DisableDebug()
+	observeStartPhase("test", time.Now())
+	if c := count(); !strings.HasSuffix(c, " 1") {
+		t.Fatalf("expected one sample in debug mode, got %q", c)
+	}
+}
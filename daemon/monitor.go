@@ -6,6 +6,7 @@ import (
 	"io"
 	"runtime"
 	"strconv"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/libcontainerd"
@@ -20,12 +21,6 @@ func (daemon *Daemon) StateChanged(id string, e libcontainerd.StateInfo) error {
 	}
 
 	switch e.State {
-	case libcontainerd.StateOOM:
-		// StateOOM is Linux specific and should never be hit on Windows
-		if runtime.GOOS == "windows" {
-			return errors.New("Received StateOOM from libcontainerd on Windows. This should never happen.")
-		}
-		daemon.LogContainerEvent(c, "oom")
 	case libcontainerd.StateExit:
 		c.Lock()
 		defer c.Unlock()
@@ -77,7 +72,7 @@ func (daemon *Daemon) StateChanged(id string, e libcontainerd.StateInfo) error {
 			c.Reset(false)
 			return err
 		}
-		daemon.LogContainerEvent(c, "start")
+		daemon.logContainerStartEvent(c)
 	case libcontainerd.StatePause:
 		c.Paused = true
 		daemon.LogContainerEvent(c, "pause")
@@ -89,6 +84,61 @@ func (daemon *Daemon) StateChanged(id string, e libcontainerd.StateInfo) error {
 	return nil
 }
 
+// OOM is called by libcontainerd as soon as a container runs out of memory.
+func (daemon *Daemon) OOM(id string) error {
+	// OOM notifications are Linux specific and should never be hit on Windows
+	if runtime.GOOS == "windows" {
+		return errors.New("Received OOM notification from libcontainerd on Windows. This should never happen.")
+	}
+	c := daemon.containers.Get(id)
+	if c == nil {
+		return fmt.Errorf("no such container: %s", id)
+	}
+	daemon.LogContainerEvent(c, "oom")
+	return nil
+}
+
+// RestartScheduled is called by libcontainerd when the restart policy of a
+// container schedules its restart, before the container is restarted.
+func (daemon *Daemon) RestartScheduled(id string, attempt int, delay time.Duration) error {
+	c := daemon.containers.Get(id)
+	if c == nil {
+		return fmt.Errorf("no such container: %s", id)
+	}
+	containerRestartDelay.Observe(c.HostConfig.RestartPolicy.Name, delay.Seconds())
+	attributes := map[string]string{
+		"attempt": strconv.Itoa(attempt),
+		"delay":   delay.String(),
+	}
+	daemon.LogContainerEventWithAttributes(c, "restarting", attributes)
+	return nil
+}
+
+// ContainerdReconnected is called by libcontainerd when the connection to
+// containerd is recovered. The containers which are still running for the
+// daemon but are not tracked by libcontainerd anymore are marked as exited,
+// their exit code is unknown.
+func (daemon *Daemon) ContainerdReconnected() error {
+	processes, err := daemon.containerd.ListProcesses()
+	if err != nil {
+		return fmt.Errorf("failed to reconcile the containers after reconnecting to containerd: %v", err)
+	}
+	for _, c := range daemon.List() {
+		if !c.IsRunning() || c.IsRestarting() {
+			continue
+		}
+		if _, ok := processes[c.ID]; ok {
+			continue
+		}
+		logrus.Warnf("Container %s is not tracked by containerd anymore, marking it as exited", c.ID)
+		if err := daemon.StateChanged(c.ID, libcontainerd.StateInfo{State: libcontainerd.StateExit, ExitCode: 255}); err != nil {
+			logrus.Error(err)
+		}
+	}
+	logrus.Info("Reconnected to containerd")
+	return nil
+}
+
 // AttachStreams is called by libcontainerd to connect the stdio.
 func (daemon *Daemon) AttachStreams(id string, iop libcontainerd.IOPipe) error {
 	var s *runconfig.StreamConfig
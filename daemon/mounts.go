@@ -2,12 +2,41 @@ package daemon
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/docker/docker/api/types/backend"
 	"github.com/docker/docker/container"
 	volumestore "github.com/docker/docker/volume/store"
 )
 
+// LayerMounts returns the reference counts of the read-write layers in the
+// layer store, along with the container using each layer.
+func (daemon *Daemon) LayerMounts() []backend.LayerMount {
+	var mounts []backend.LayerMount
+	for _, m := range daemon.layerStore.Mounts() {
+		mount := backend.LayerMount{
+			Name:       m.Name,
+			MountID:    m.MountID,
+			References: m.References,
+			Mounts:     m.Mounts,
+		}
+		// read-write layers are named after the container using them
+		if c := daemon.containers.Get(m.Name); c != nil {
+			mount.Container = c.ID
+		}
+		mounts = append(mounts, mount)
+	}
+	sort.Sort(byLayerMountName(mounts))
+	return mounts
+}
+
+type byLayerMountName []backend.LayerMount
+
+func (m byLayerMountName) Len() int           { return len(m) }
+func (m byLayerMountName) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
+func (m byLayerMountName) Less(i, j int) bool { return m[i].Name < m[j].Name }
+
 func (daemon *Daemon) prepareMountPoints(container *container.Container) error {
 	for _, config := range container.MountPoints {
 		if err := daemon.lazyInitializeVolume(container.ID, config); err != nil {
@@ -24,21 +53,21 @@ func (daemon *Daemon) removeMountPoints(container *container.Container, rm bool)
 			continue
 		}
 		daemon.volumes.Dereference(m.Volume, container.ID)
-		if rm {
-			// Do not remove named mountpoints
-			// these are mountpoints specified like `docker run -v <name>:/foo`
-			if m.Named {
-				continue
-			}
-			err := daemon.volumes.Remove(m.Volume)
-			// Ignore volume in use errors because having this
-			// volume being referenced by other container is
-			// not an error, but an implementation detail.
-			// This prevents docker from logging "ERROR: Volume in use"
-			// where there is another container using the volume.
-			if err != nil && !volumestore.IsInUse(err) {
-				rmErrors = append(rmErrors, err.Error())
-			}
+		// Do not remove named mountpoints
+		// these are mountpoints specified like `docker run -v <name>:/foo`
+		// Ephemeral volumes are removed even without rm, once they are not
+		// referenced anymore.
+		if (!rm || m.Named) && !isEphemeralVolume(m.Volume) {
+			continue
+		}
+		err := daemon.volumes.Remove(m.Volume)
+		// Ignore volume in use errors because having this
+		// volume being referenced by other container is
+		// not an error, but an implementation detail.
+		// This prevents docker from logging "ERROR: Volume in use"
+		// where there is another container using the volume.
+		if err != nil && !volumestore.IsInUse(err) {
+			rmErrors = append(rmErrors, err.Error())
 		}
 	}
 	if len(rmErrors) > 0 {
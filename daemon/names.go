@@ -0,0 +1,77 @@
+package daemon
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/pkg/namesgenerator"
+	"github.com/docker/docker/pkg/stringid"
+)
+
+// NameGenerator generates the names of the volumes and containers created
+// without a name.
+type NameGenerator interface {
+	// GenerateName returns a name, attempt being the number of names
+	// generated before which were already in use.
+	GenerateName(attempt int) string
+}
+
+// The name generators registered by default.
+const (
+	// NameGeneratorRandom generates random IDs.
+	NameGeneratorRandom = "random"
+	// NameGeneratorFriendly generates names formatted as
+	// "adjective_surname", such as "focused_turing".
+	NameGeneratorFriendly = "friendly"
+)
+
+// maxGeneratedNameAttempts is the number of names generated for a volume or
+// a container before falling back to a random ID, when the generated names
+// are already in use.
+const maxGeneratedNameAttempts = 6
+
+type randomNameGenerator struct{}
+
+func (randomNameGenerator) GenerateName(attempt int) string {
+	return stringid.GenerateNonCryptoID()
+}
+
+type friendlyNameGenerator struct{}
+
+func (friendlyNameGenerator) GenerateName(attempt int) string {
+	return namesgenerator.GetRandomName(attempt)
+}
+
+// nameGenerators are the name generators which can be set in the daemon
+// configuration.
+var nameGenerators = map[string]NameGenerator{
+	NameGeneratorRandom:   randomNameGenerator{},
+	NameGeneratorFriendly: friendlyNameGenerator{},
+}
+
+// lookupNameGenerator returns the name generator name.
+func lookupNameGenerator(name string) (NameGenerator, error) {
+	g, ok := nameGenerators[name]
+	if !ok {
+		var names []string
+		for n := range nameGenerators {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown name generator %q, it must be one of %s", name, strings.Join(names, ", "))
+	}
+	return g, nil
+}
+
+// nameGenerator returns the generator of the names of the containers and
+// volumes created without a name, set in the daemon configuration. When none
+// is set, the generator defaultName is used, so that the containers keep
+// getting friendly names and the volumes random IDs.
+func (daemon *Daemon) nameGenerator(defaultName string) (NameGenerator, error) {
+	name := defaultName
+	if daemon.configStore != nil && daemon.configStore.NameGenerator != "" {
+		name = daemon.configStore.NameGenerator
+	}
+	return lookupNameGenerator(name)
+}
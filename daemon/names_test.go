@@ -0,0 +1,102 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/daemon/events"
+	"github.com/docker/docker/pkg/registrar"
+)
+
+type sequenceNameGenerator []string
+
+func (s sequenceNameGenerator) GenerateName(attempt int) string {
+	return s[attempt]
+}
+
+func TestVolumeCreateGeneratedName(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-daemon-names-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	daemon, err := initDaemonWithVolumeStore(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	daemon.EventsService = events.New()
+	daemon.configStore = &Config{}
+
+	v, err := daemon.VolumeCreate("", "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{64}$`).MatchString(v.Name) {
+		t.Fatalf("Expected a random ID by default, got %s", v.Name)
+	}
+
+	daemon.configStore.NameGenerator = NameGeneratorFriendly
+	v, err = daemon.VolumeCreate("", "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^[a-z]+_[a-z]+$`).MatchString(v.Name) {
+		t.Fatalf("Expected a friendly name, got %s", v.Name)
+	}
+
+	nameGenerators["test-sequence"] = sequenceNameGenerator{"taken", "free"}
+	defer delete(nameGenerators, "test-sequence")
+	if _, err := daemon.VolumeCreate("taken", "", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	daemon.configStore.NameGenerator = "test-sequence"
+	v, err = daemon.VolumeCreate("", "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "free" {
+		t.Fatalf("Expected the name in use to be generated again, got %s", v.Name)
+	}
+}
+
+func TestGenerateNewName(t *testing.T) {
+	daemon := &Daemon{
+		configStore: &Config{},
+		nameIndex:   registrar.NewRegistrar(),
+	}
+	name, err := daemon.generateNewName("0123456789ab0123456789ab0123456789ab0123456789ab0123456789abcdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^/[a-z]+_[a-z]+$`).MatchString(name) {
+		t.Fatalf("Expected a friendly name by default, got %s", name)
+	}
+
+	nameGenerators["test-sequence"] = sequenceNameGenerator{"taken", "free"}
+	defer delete(nameGenerators, "test-sequence")
+	if err := daemon.nameIndex.Reserve("/taken", "other"); err != nil {
+		t.Fatal(err)
+	}
+	daemon.configStore.NameGenerator = "test-sequence"
+	if name, err := daemon.generateNewName("0123456789ab"); err != nil || name != "/free" {
+		t.Fatalf("Expected the configured generator to name the container, got %s: %v", name, err)
+	}
+
+	daemon.configStore.NameGenerator = "bogus"
+	if _, err := daemon.generateNewName("0123456789ab"); err == nil {
+		t.Fatal("Expected an unknown name generator to be rejected")
+	}
+}
+
+func TestValidateNameGenerator(t *testing.T) {
+	if err := ValidateConfiguration(&Config{CommonConfig: CommonConfig{NameGenerator: NameGeneratorFriendly}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateConfiguration(&Config{CommonConfig: CommonConfig{NameGenerator: "frendly"}}); err == nil || !strings.Contains(err.Error(), "unknown name generator") {
+		t.Fatalf("Expected an unknown name generator to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package daemon
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/container"
+	"github.com/docker/libnetwork/etchosts"
+)
+
+// LazyNetworkLabel is the label opting a container in to lazy networking,
+// its value being "true": the network of the container is set up when the
+// daemon first needs it instead of when the container starts. Nothing
+// triggers it from inside the container: the traffic of its processes isn't
+// watched, so the network is only set up by exec, network connect and
+// disconnect, and the containers joining its network namespace, which the
+// creation of the container warns about. It is only honored by experimental
+// builds.
+const LazyNetworkLabel = "com.docker.network.lazy"
+
+// isLazyNetwork returns whether the network of container c is set up when
+// it is first needed. Only the containers with their own network namespace
+// can defer it.
+func isLazyNetwork(c *container.Container) bool {
+	if !lazyNetworking || c.Config.Labels[LazyNetworkLabel] != "true" || c.Config.NetworkDisabled {
+		return false
+	}
+	mode := c.HostConfig.NetworkMode
+	return !mode.IsHost() && !mode.IsContainer() && !mode.IsNone()
+}
+
+// deferNetwork starts container c with only a loopback interface. The
+// networks the container is connected to are recorded, and the files of its
+// network mounts created, but nothing is allocated until setupLazyNetwork.
+func (daemon *Daemon) deferNetwork(c *container.Container) error {
+	if len(c.NetworkSettings.Networks) == 0 {
+		if err := daemon.updateContainerNetworkSettings(c, nil); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if c.HostsPath, err = c.GetRootResourcePath("hosts"); err != nil {
+		return err
+	}
+	if err := etchosts.Build(c.HostsPath, "", c.Config.Hostname, c.Config.Domainname, nil); err != nil {
+		return err
+	}
+	if c.ResolvConfPath, err = c.GetRootResourcePath("resolv.conf"); err != nil {
+		return err
+	}
+	// filled in by libnetwork when the network is set up
+	if err := ioutil.WriteFile(c.ResolvConfPath, nil, 0644); err != nil {
+		return err
+	}
+
+	c.NetworkMu.Lock()
+	c.NetworkPending = true
+	c.NetworkMu.Unlock()
+	logrus.Debugf("Deferring the network of container %s until it is needed", c.ID)
+	return nil
+}
+
+// setupLazyNetwork sets up the network of running container c if it was
+// deferred, moving its interfaces into the network namespace of the
+// container as the libnetwork-setkey prestart hook does when it starts.
+//
+// The state of the container is read before locking its NetworkMu, which
+// Cleanup locks while holding the lock of the container: a container which
+// exited in between isn't pending anymore, or fails to join the namespace.
+// For the same reason, the container is saved once NetworkMu is unlocked.
+func (daemon *Daemon) setupLazyNetwork(c *container.Container) error {
+	running, pid := c.IsRunning(), c.State.GetPID()
+
+	c.NetworkMu.Lock()
+	if !c.NetworkPending {
+		c.NetworkMu.Unlock()
+		return nil
+	}
+	err := daemon.setupPendingNetwork(c, running, pid)
+	c.NetworkMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := c.ToDiskLocking(); err != nil {
+		logrus.Warnf("Error saving container %s with its network set up: %v", c.ID, err)
+	}
+	return nil
+}
+
+// setupPendingNetwork sets up the deferred network of container c, running
+// as process pid, with its NetworkMu locked.
+func (daemon *Daemon) setupPendingNetwork(c *container.Container, running bool, pid int) error {
+	if !running || pid == 0 {
+		return fmt.Errorf("Cannot set up the network of container %s, it is not running", c.ID)
+	}
+
+	logrus.Debugf("Setting up the deferred network of container %s", c.ID)
+	err := daemon.allocateNetworkWithTimeout(c)
+	if err == nil {
+		err = daemon.setNetworkNamespaceKey(c.ID, pid)
+	}
+	if err != nil {
+		// the network stays deferred, to be set up again when needed
+		daemon.releaseNetwork(c)
+		return fmt.Errorf("Error setting up the network of container %s: %v", c.ID, err)
+	}
+	c.NetworkPending = false
+	return nil
+}
+
+// releaseLazyNetwork releases the network of container c, unless it was
+// never set up.
+func (daemon *Daemon) releaseLazyNetwork(c *container.Container) {
+	c.NetworkMu.Lock()
+	defer c.NetworkMu.Unlock()
+	if c.NetworkPending {
+		c.NetworkPending = false
+		c.NetworkSettings.Ports = nil
+		return
+	}
+	daemon.releaseNetwork(c)
+}
@@ -0,0 +1,42 @@
+package daemon
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/libcontainerd"
+)
+
+// SpecMutator is called with the OCI spec of a container once it has been
+// built, before the container is started. It can add mounts or environment
+// variables, or adjust the process, or return an error to abort the start.
+// The mounts it adds are validated like the mounts supplied by the user, the
+// mounts already in the spec must be left as they are.
+type SpecMutator func(c *container.Container, s *libcontainerd.Spec) error
+
+// AddSpecMutator adds fn to the mutators called before a container starts.
+// Mutators are called in the order they are added. They must be added when
+// the daemon is initialized, before it serves the API.
+func (daemon *Daemon) AddSpecMutator(fn SpecMutator) {
+	daemon.specMutators = append(daemon.specMutators, fn)
+}
+
+// mutateSpec calls the spec mutators in turn, checking that each of them
+// left the existing mounts untouched and validating the mounts it added
+// before calling the next one.
+func (daemon *Daemon) mutateSpec(c *container.Container, s *libcontainerd.Spec) error {
+	for _, mutate := range daemon.specMutators {
+		mounts := copyMounts(s.Mounts)
+		if err := mutate(c, s); err != nil {
+			return err
+		}
+		if len(s.Mounts) < len(mounts) || !reflect.DeepEqual(copyMounts(s.Mounts[:len(mounts)]), mounts) {
+			return fmt.Errorf("spec mutator changed or removed mounts of container %s", c.ID)
+		}
+		if err := validateAddedMounts(s, len(mounts)); err != nil {
+			return fmt.Errorf("spec mutator added an invalid mount: %v", err)
+		}
+	}
+	return nil
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/docker/docker/pkg/mount"
 	"github.com/docker/docker/pkg/stringutils"
 	"github.com/docker/docker/pkg/symlink"
+	"github.com/docker/docker/runconfig"
 	"github.com/docker/docker/volume"
 	containertypes "github.com/docker/engine-api/types/container"
 	"github.com/opencontainers/runc/libcontainer/apparmor"
@@ -244,7 +245,7 @@ func setNamespaces(daemon *Daemon, s *specs.Spec, c *container.Container) error
 	userNS := false
 	// user
 	if c.HostConfig.UsernsMode.IsPrivate() {
-		uidMap, gidMap := daemon.GetUIDGIDMaps()
+		uidMap, gidMap := daemon.containerIDMaps(c.Config)
 		if uidMap != nil {
 			userNS = true
 			//这个会生成随机数吗？
@@ -479,30 +480,9 @@ func setMounts(daemon *Daemon, s *specs.Spec, c *container.Container, mounts []c
 
 		mt := specs.Mount{Destination: m.Destination, Source: m.Source, Type: "bind"}
 
-		// Determine property of RootPropagation based on volume
-		// properties. If a volume is shared, then keep root propagation
-		// shared. This should work for slave and private volumes too.
-		//
-		// For slave volumes, it can be either [r]shared/[r]slave.
-		//
-		// For private volumes any root propagation value should work.
 		pFlag := mountPropagationMap[m.Propagation]
-		if pFlag == mount.SHARED || pFlag == mount.RSHARED {
-			if err := ensureShared(m.Source); err != nil {
-				return err
-			}
-			rootpg := mountPropagationMap[s.Linux.RootfsPropagation]
-			if rootpg != mount.SHARED && rootpg != mount.RSHARED {
-				s.Linux.RootfsPropagation = mountPropagationReverseMap[mount.SHARED]
-			}
-		} else if pFlag == mount.SLAVE || pFlag == mount.RSLAVE {
-			if err := ensureSharedOrSlave(m.Source); err != nil {
-				return err
-			}
-			rootpg := mountPropagationMap[s.Linux.RootfsPropagation]
-			if rootpg != mount.SHARED && rootpg != mount.RSHARED && rootpg != mount.SLAVE && rootpg != mount.RSLAVE {
-				s.Linux.RootfsPropagation = mountPropagationReverseMap[mount.RSLAVE]
-			}
+		if err := setRootfsPropagation(s, m.Source, pFlag); err != nil {
+			return err
 		}
 
 		opts := []string{"rbind"}
@@ -546,7 +526,7 @@ func setMounts(daemon *Daemon, s *specs.Spec, c *container.Container, mounts []c
 
 	// TODO: until a kernel/mount solution exists for handling remount in a user namespace,
 	// we must clear the readonly flag for the cgroups mount (@mrunalp concurs)
-	if uidMap, _ := daemon.GetUIDGIDMaps(); uidMap != nil || c.HostConfig.Privileged {
+	if uidMap, _ := daemon.containerIDMaps(c.Config); uidMap != nil || c.HostConfig.Privileged {
 		for i, m := range s.Mounts {
 			if m.Type == "cgroup" {
 				clearReadOnly(&s.Mounts[i])
@@ -557,6 +537,100 @@ func setMounts(daemon *Daemon, s *specs.Spec, c *container.Container, mounts []c
 	return nil
 }
 
+// setRootfsPropagation makes sure the propagation of the root filesystem of
+// the container allows a bind mount of source with the propagation pFlag.
+func setRootfsPropagation(s *specs.Spec, source string, pFlag int) error {
+	// Determine property of RootPropagation based on volume
+	// properties. If a volume is shared, then keep root propagation
+	// shared. This should work for slave and private volumes too.
+	//
+	// For slave volumes, it can be either [r]shared/[r]slave.
+	//
+	// For private volumes any root propagation value should work.
+	if pFlag == mount.SHARED || pFlag == mount.RSHARED {
+		if err := ensureShared(source); err != nil {
+			return err
+		}
+		rootpg := mountPropagationMap[s.Linux.RootfsPropagation]
+		if rootpg != mount.SHARED && rootpg != mount.RSHARED {
+			s.Linux.RootfsPropagation = mountPropagationReverseMap[mount.SHARED]
+		}
+	} else if pFlag == mount.SLAVE || pFlag == mount.RSLAVE {
+		if err := ensureSharedOrSlave(source); err != nil {
+			return err
+		}
+		rootpg := mountPropagationMap[s.Linux.RootfsPropagation]
+		if rootpg != mount.SHARED && rootpg != mount.RSHARED && rootpg != mount.SLAVE && rootpg != mount.RSLAVE {
+			s.Linux.RootfsPropagation = mountPropagationReverseMap[mount.RSLAVE]
+		}
+	}
+	return nil
+}
+
+// copyMounts returns a deep copy of mounts, so that they can be compared
+// once a spec mutator had access to them.
+func copyMounts(mounts []specs.Mount) []specs.Mount {
+	cp := make([]specs.Mount, len(mounts))
+	for i, m := range mounts {
+		cp[i] = m
+		if m.Options != nil {
+			cp[i].Options = append([]string{}, m.Options...)
+		}
+	}
+	return cp
+}
+
+// validateAddedMounts validates the mounts appended to s from index from on,
+// with the checks applied to the binds supplied by the user. Only bind
+// mounts of existing host paths can be added.
+func validateAddedMounts(s *libcontainerd.Spec, from int) error {
+	for i := from; i < len(s.Mounts); i++ {
+		m := &s.Mounts[i]
+		if m.Type != "bind" {
+			return fmt.Errorf("mount type %q of %s is not supported, only bind mounts can be added", m.Type, m.Destination)
+		}
+		mode := "rw"
+		propagation := ""
+		for _, o := range m.Options {
+			switch {
+			case o == "bind" || o == "rbind":
+			case o == "ro" || o == "rw":
+				mode = o
+			case mountPropagationMap[o] != 0:
+				propagation = o
+			default:
+				return fmt.Errorf("mount option %q of %s is not supported", o, m.Destination)
+			}
+		}
+		if propagation != "" {
+			mode += "," + propagation
+		}
+		mp, err := volume.ParseMountSpec(m.Source+":"+m.Destination+":"+mode, "")
+		if err != nil {
+			return err
+		}
+		if mp.Source == "" {
+			return fmt.Errorf("source of %s must be an absolute host path", m.Destination)
+		}
+		if _, err := os.Stat(mp.Source); err != nil {
+			return err
+		}
+		for j := range s.Mounts {
+			if j != i && filepath.Clean(s.Mounts[j].Destination) == mp.Destination {
+				return fmt.Errorf("Duplicate mount point '%s'", mp.Destination)
+			}
+		}
+		m.Source, m.Destination = mp.Source, mp.Destination
+		if !stringutils.InSlice(m.Options, "bind") && !stringutils.InSlice(m.Options, "rbind") {
+			m.Options = append([]string{"rbind"}, m.Options...)
+		}
+		if err := setRootfsPropagation((*specs.Spec)(s), mp.Source, mountPropagationMap[propagation]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (daemon *Daemon) populateCommonSpec(s *specs.Spec, c *container.Container) error {
 	//将通过--link相连的容器中的信息获取过来，然后将其中的信息转成环境变量(是[]string数组的形式，每一个元素类似于"NAME=xxxx")的形式
 	linkedEnv, err := daemon.setupLinkedContainers(c)
@@ -569,7 +643,7 @@ func (daemon *Daemon) populateCommonSpec(s *specs.Spec, c *container.Container)
 		Readonly: c.HostConfig.ReadonlyRootfs,
 	}
 	//设置目录的权限。
-	rootUID, rootGID := daemon.GetRemappedUIDGID()
+	rootUID, rootGID := daemon.containerRootUIDGID(c)
 	if err := c.SetupWorkingDirectory(rootUID, rootGID); err != nil {
 		return err
 	}
@@ -589,6 +663,19 @@ func (daemon *Daemon) populateCommonSpec(s *specs.Spec, c *container.Container)
 
 //创建容器的namespace以及cgroups等相关。
 func (daemon *Daemon) createSpec(c *container.Container) (*libcontainerd.Spec, error) {
+	return daemon.buildSpec(c, false)
+}
+
+// previewSpec returns the spec createSpec would create for the container c,
+// without mounting its shm and volumes, creating the missing sources of its
+// bind mounts or loading its AppArmor profile.
+func (daemon *Daemon) previewSpec(c *container.Container) (*libcontainerd.Spec, error) {
+	return daemon.buildSpec(c, true)
+}
+
+// buildSpec creates the spec of the container c. When preview is set, the
+// host resources referenced by the spec are left untouched.
+func (daemon *Daemon) buildSpec(c *container.Container, preview bool) (*libcontainerd.Spec, error) {
 	s := oci.DefaultSpec()
 	//populateCommand(container, env) 主要是为container的execdriver(最终启动容器的)
 	//设置网络模式、设置namespace(pid,ipc,uts)等、资源(resources)限制等，并且设置
@@ -647,27 +734,49 @@ func (daemon *Daemon) createSpec(c *container.Container) (*libcontainerd.Spec, e
 		return nil, fmt.Errorf("linux seccomp: %v", err)
 	}
 
-	//?
-	if err := daemon.setupIpcDirs(c); err != nil {
-		return nil, err
-	}
+	var mounts []container.Mount
+	if preview {
+		shmPath, err := daemon.ipcShmPath(c)
+		if err != nil {
+			return nil, err
+		}
+		if mounts, err = daemon.previewMounts(c); err != nil {
+			return nil, err
+		}
+		if !c.HasMountFor("/dev/shm") {
+			mounts = append(mounts, container.Mount{
+				Source:      shmPath,
+				Destination: "/dev/shm",
+				Writable:    true,
+				Propagation: volume.DefaultPropagationMode,
+			})
+		}
+	} else {
+		//?
+		if err := daemon.setupIpcDirs(c); err != nil {
+			return nil, err
+		}
 
-	//container.setupMounts() 返回container的所有挂载点；
-	mounts, err := daemon.setupMounts(c)
-	if err != nil {
-		return nil, err
+		//container.setupMounts() 返回container的所有挂载点；
+		var err error
+		if mounts, err = daemon.setupMounts(c); err != nil {
+			return nil, err
+		}
+		mounts = append(mounts, c.IpcMounts()...)
 	}
-	mounts = append(mounts, c.IpcMounts()...)
 	mounts = append(mounts, c.TmpfsMounts()...)
 	//设置容器的所有挂载点。
 	if err := setMounts(daemon, &s, c, mounts); err != nil {
 		return nil, fmt.Errorf("linux mounts: %v", err)
 	}
+	if err := daemon.setInit(&s, c); err != nil {
+		return nil, fmt.Errorf("linux init: %v", err)
+	}
 
 	//和网络的设置有关，但是这一点究竟是干什么的呢？
 	//设置到进程的钩子，通过进程/proc/XXX/exe的链接文件获取到。
 	for _, ns := range s.Linux.Namespaces {
-		if ns.Type == "network" && ns.Path == "" && !c.Config.NetworkDisabled {
+		if ns.Type == "network" && ns.Path == "" && !c.Config.NetworkDisabled && !c.NetworkPending {
 			target, err := os.Readlink(filepath.Join("/proc", strconv.Itoa(os.Getpid()), "exe"))
 			if err != nil {
 				return nil, err
@@ -686,7 +795,17 @@ func (daemon *Daemon) createSpec(c *container.Container) (*libcontainerd.Spec, e
 	//apparmor相关配置
 	if apparmor.IsEnabled() {
 		appArmorProfile := "docker-default"
-		if len(c.AppArmorProfile) > 0 {
+		if filepath.IsAbs(c.AppArmorProfile) {
+			load := daemon.loadAppArmorProfileFile
+			if preview {
+				load = appArmorProfileFileName
+			}
+			name, err := load(c.AppArmorProfile)
+			if err != nil {
+				return nil, fmt.Errorf("Unable to load the AppArmor profile %s: %v", c.AppArmorProfile, err)
+			}
+			appArmorProfile = name
+		} else if len(c.AppArmorProfile) > 0 {
 			appArmorProfile = c.AppArmorProfile
 		} else if c.HostConfig.Privileged {
 			appArmorProfile = "unconfined"
@@ -698,9 +817,41 @@ func (daemon *Daemon) createSpec(c *container.Container) (*libcontainerd.Spec, e
 	s.Process.NoNewPrivileges = c.NoNewPrivileges
 	s.Linux.MountLabel = c.MountLabel
 
+	if err := daemon.mutateSpec(c, (*libcontainerd.Spec)(&s)); err != nil {
+		return nil, err
+	}
+
 	return (*libcontainerd.Spec)(&s), nil
 }
 
+// setInit runs the init binary of the daemon as the first process of the
+// container when it was created with --init, which sets the InitLabel. The
+// init reaps the zombie processes and forwards the signals it receives to the
+// container command, or to its process group when the signals are forwarded
+// to process groups.
+func (daemon *Daemon) setInit(s *specs.Spec, c *container.Container) error {
+	if withInit, _ := runconfig.InitFromLabels(c.Config.Labels); !withInit || !c.HostConfig.PidMode.IsPrivate() {
+		return nil
+	}
+	path, err := daemon.initPath()
+	if err != nil {
+		return err
+	}
+	args := []string{containerInitPath}
+	if group, _ := runconfig.ProcessGroupSignalsFromLabels(c.Config.Labels); group {
+		// forward the signals to the process group of the command too
+		args = append(args, "-g")
+	}
+	s.Process.Args = append(append(args, "--"), s.Process.Args...)
+	s.Mounts = append(s.Mounts, specs.Mount{
+		Destination: containerInitPath,
+		Type:        "bind",
+		Source:      path,
+		Options:     []string{"bind", "ro"},
+	})
+	return nil
+}
+
 func clearReadOnly(m *specs.Mount) {
 	var opt []string
 	for _, o := range m.Options {
@@ -2,6 +2,8 @@ package daemon
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"syscall"
 
@@ -10,6 +12,7 @@ import (
 	"github.com/docker/docker/libcontainerd"
 	"github.com/docker/docker/libcontainerd/windowsoci"
 	"github.com/docker/docker/oci"
+	"github.com/docker/docker/volume"
 )
 
 func (daemon *Daemon) createSpec(c *container.Container) (*libcontainerd.Spec, error) {
@@ -175,9 +178,56 @@ func (daemon *Daemon) createSpec(c *container.Container) (*libcontainerd.Spec, e
 		//TODO SandboxSize: ...,
 		},
 	}
+
+	if err := daemon.mutateSpec(c, (*libcontainerd.Spec)(&s)); err != nil {
+		return nil, err
+	}
+
 	return (*libcontainerd.Spec)(&s), nil
 }
 
+// previewSpec returns the spec createSpec would create for the container c.
+// Creating the spec leaves the host resources untouched on Windows.
+func (daemon *Daemon) previewSpec(c *container.Container) (*libcontainerd.Spec, error) {
+	return daemon.createSpec(c)
+}
+
+// copyMounts returns a copy of mounts, so that they can be compared once a
+// spec mutator had access to them.
+func copyMounts(mounts []windowsoci.Mount) []windowsoci.Mount {
+	return append([]windowsoci.Mount{}, mounts...)
+}
+
+// validateAddedMounts validates the mounts appended to s from index from on,
+// with the checks applied to the binds supplied by the user. Only mounts of
+// existing host paths can be added.
+func validateAddedMounts(s *libcontainerd.Spec, from int) error {
+	for i := from; i < len(s.Mounts); i++ {
+		m := &s.Mounts[i]
+		mode := "rw"
+		if m.Readonly {
+			mode = "ro"
+		}
+		mp, err := volume.ParseMountSpec(m.Source+":"+m.Destination+":"+mode, "")
+		if err != nil {
+			return err
+		}
+		if mp.Source == "" {
+			return fmt.Errorf("source of %s must be an absolute host path", m.Destination)
+		}
+		if _, err := os.Stat(mp.Source); err != nil {
+			return err
+		}
+		for j := range s.Mounts {
+			if j != i && strings.EqualFold(filepath.Clean(s.Mounts[j].Destination), mp.Destination) {
+				return fmt.Errorf("Duplicate mount point '%s'", mp.Destination)
+			}
+		}
+		m.Source, m.Destination = mp.Source, mp.Destination
+	}
+	return nil
+}
+
 func escapeArgs(args []string) []string {
 	escapedArgs := make([]string, len(args))
 	for i, a := range args {
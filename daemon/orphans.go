@@ -0,0 +1,62 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"sort"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// knownContainerIDs returns the IDs of all the containers stored in the
+// daemon repository, including the ones that could not be loaded.
+func (daemon *Daemon) knownContainerIDs() (map[string]bool, error) {
+	dir, err := ioutil.ReadDir(daemon.repository)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(dir))
+	for _, v := range dir {
+		known[v.Name()] = true
+	}
+	return known, nil
+}
+
+// cleanupContainerdOrphans removes the libcontainerd container directories
+// that do not belong to any container of the daemon. They are only
+// reported when LIBCONTAINERD_NOCLEAN is set.
+func (daemon *Daemon) cleanupContainerdOrphans() {
+	known, err := daemon.knownContainerIDs()
+	if err != nil {
+		logrus.Errorf("Failed to list containers for containerd cleanup: %v", err)
+		return
+	}
+	orphans, err := daemon.containerd.CleanupOrphanedDirs(known)
+	if err != nil {
+		logrus.Errorf("Failed to clean up orphaned containerd directories: %v", err)
+		return
+	}
+	for _, dir := range orphans {
+		logrus.Warnf("Found orphaned containerd container directory %s", dir)
+	}
+}
+
+// ContainerdOrphans returns the libcontainerd container directories that
+// do not belong to any container of the daemon.
+func (daemon *Daemon) ContainerdOrphans() ([]string, error) {
+	known, err := daemon.knownContainerIDs()
+	if err != nil {
+		return nil, err
+	}
+	orphans, err := daemon.containerd.OrphanedDirs(known)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(orphans)
+	return orphans, nil
+}
+
+// RetainedContainerdBundles returns the libcontainerd bundle directories
+// retained for the containers that exited with an error, oldest first.
+func (daemon *Daemon) RetainedContainerdBundles() ([]string, error) {
+	return daemon.containerd.RetainedBundles()
+}
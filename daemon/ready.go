@@ -0,0 +1,51 @@
+package daemon
+
+import (
+	"fmt"
+	"time"
+)
+
+// readyTimeout is how long Ready waits for the storage driver to respond.
+var readyTimeout = 5 * time.Second
+
+// Ready returns an error if the daemon is not ready to serve requests,
+// because its network controller isn't initialized or its storage driver
+// doesn't respond. Concurrent calls share the same probe of the storage
+// driver, so a driver which hangs is not probed again until it returns.
+func (daemon *Daemon) Ready() error {
+	if !daemon.NetworkControllerEnabled() {
+		return fmt.Errorf("The network controller is not initialized")
+	}
+	if daemon.layerStore == nil {
+		return fmt.Errorf("The layer store is not initialized")
+	}
+
+	timer := time.NewTimer(readyTimeout)
+	defer timer.Stop()
+	select {
+	case <-daemon.probeStorageDriver():
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("The storage driver %s is not responding", daemon.GraphDriverName())
+	}
+}
+
+// probeStorageDriver returns a channel closed once the storage driver
+// responds, starting a probe unless one is already in flight.
+func (daemon *Daemon) probeStorageDriver() <-chan struct{} {
+	daemon.readyMu.Lock()
+	defer daemon.readyMu.Unlock()
+	if daemon.readyProbe != nil {
+		return daemon.readyProbe
+	}
+	probe := make(chan struct{})
+	daemon.readyProbe = probe
+	go func() {
+		daemon.layerStore.DriverStatus()
+		daemon.readyMu.Lock()
+		daemon.readyProbe = nil
+		daemon.readyMu.Unlock()
+		close(probe)
+	}()
+	return probe
+}
@@ -0,0 +1,87 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/layer"
+	"github.com/docker/libnetwork"
+)
+
+type readyNetworkController struct {
+	libnetwork.NetworkController
+}
+
+type readyLayerStore struct {
+	layer.Store
+}
+
+func (readyLayerStore) DriverStatus() [][2]string {
+	return nil
+}
+
+// hangingLayerStore is a layer store whose storage driver doesn't respond
+// until release is closed.
+type hangingLayerStore struct {
+	layer.Store
+	probes  chan struct{}
+	release chan struct{}
+}
+
+func (s hangingLayerStore) DriverStatus() [][2]string {
+	s.probes <- struct{}{}
+	<-s.release
+	return nil
+}
+
+func (hangingLayerStore) DriverName() string {
+	return "hanging"
+}
+
+func TestReadySharesProbe(t *testing.T) {
+	defer func(timeout time.Duration) {
+		readyTimeout = timeout
+	}(readyTimeout)
+	readyTimeout = 10 * time.Millisecond
+
+	ls := hangingLayerStore{
+		probes:  make(chan struct{}, 10),
+		release: make(chan struct{}),
+	}
+	daemon := &Daemon{
+		netController: readyNetworkController{},
+		layerStore:    ls,
+	}
+	for i := 0; i < 3; i++ {
+		if err := daemon.Ready(); err == nil {
+			t.Fatal("Expected an error while the storage driver doesn't respond")
+		}
+	}
+	close(ls.release)
+	<-ls.probes
+	if n := len(ls.probes); n != 0 {
+		t.Fatalf("Expected a single probe of the storage driver, got %d more", n)
+	}
+
+	readyTimeout = 5 * time.Second
+	if err := daemon.Ready(); err != nil {
+		t.Fatalf("Expected the daemon to be ready once the storage driver responds, got %v", err)
+	}
+}
+
+func TestReady(t *testing.T) {
+	daemon := &Daemon{}
+	if err := daemon.Ready(); err == nil {
+		t.Fatal("Expected an error without a network controller")
+	}
+
+	daemon.netController = readyNetworkController{}
+	if err := daemon.Ready(); err == nil {
+		t.Fatal("Expected an error without a layer store")
+	}
+
+	daemon.layerStore = readyLayerStore{}
+	if err := daemon.Ready(); err != nil {
+		t.Fatalf("Expected the daemon to be ready, got %v", err)
+	}
+}
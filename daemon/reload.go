@@ -0,0 +1,81 @@
+package daemon
+
+import (
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/docker/docker/api/types/backend"
+)
+
+// ReloadStatus returns the result of the most recent reload of the daemon
+// configuration, nil if the configuration was never reloaded.
+func (daemon *Daemon) ReloadStatus() *backend.ReloadStatus {
+	daemon.reloadMu.Lock()
+	defer daemon.reloadMu.Unlock()
+	if daemon.reloadStatus == nil {
+		return nil
+	}
+	status := *daemon.reloadStatus
+	return &status
+}
+
+// ReloadFailed records a reload of the daemon configuration which failed
+// before the configuration could be applied, because it couldn't be read or
+// isn't valid.
+func (daemon *Daemon) ReloadFailed(err error) {
+	daemon.setReloadStatus(&backend.ReloadStatus{
+		Time:    time.Now().UTC(),
+		Error:   err.Error(),
+		Changed: []string{},
+	})
+}
+
+// recordReload records the result of the reload of config by Reload, err
+// being nil if it succeeded. The values of the configuration file are
+// compared with those of the configuration previously applied: the one
+// the daemon started with, or the one of the last successful reload.
+func (daemon *Daemon) recordReload(config *Config, err error) {
+	previous := daemon.reloadedValues
+	if previous == nil {
+		previous = daemon.configStore.valuesSet
+	}
+	status := &backend.ReloadStatus{
+		Time:    time.Now().UTC(),
+		Success: err == nil,
+		Changed: changedConfigKeys(previous, config.valuesSet),
+	}
+	if err != nil {
+		status.Error = err.Error()
+	} else {
+		daemon.reloadedValues = config.valuesSet
+		if daemon.reloadedValues == nil {
+			daemon.reloadedValues = map[string]interface{}{}
+		}
+	}
+	daemon.setReloadStatus(status)
+}
+
+func (daemon *Daemon) setReloadStatus(status *backend.ReloadStatus) {
+	daemon.reloadMu.Lock()
+	daemon.reloadStatus = status
+	daemon.reloadMu.Unlock()
+}
+
+// changedConfigKeys returns the sorted keys of the configuration file which
+// were added, removed or whose value changed between previous and current.
+func changedConfigKeys(previous, current map[string]interface{}) []string {
+	changed := []string{}
+	for key, value := range current {
+		if old, ok := previous[key]; !ok || !reflect.DeepEqual(old, value) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range previous {
+		if _, ok := current[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,101 @@
+package daemon
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/container"
+	containertypes "github.com/docker/engine-api/types/container"
+)
+
+// RestartOnConfigChangeLabel is the label opting a container in to be
+// restarted when the configuration of the daemon is reloaded with changes
+// to the defaults the container uses, its value being "true".
+const RestartOnConfigChangeLabel = "com.docker.restart-on-config-change"
+
+// maxConcurrentConfigRestarts is the number of containers restarted at the
+// same time after a configuration change.
+const maxConcurrentConfigRestarts = 2
+
+// configChange records which of the defaults used by the containers are
+// changed by a configuration reload.
+type configChange struct {
+	logConfig bool
+	dnsSearch bool
+}
+
+// newConfigChange compares the defaults of the daemon after a reload with
+// the ones before it, logConfig and dnsSearch.
+func (daemon *Daemon) newConfigChange(logConfig containertypes.LogConfig, dnsSearch []string) configChange {
+	return configChange{
+		logConfig: logConfig.Type != daemon.defaultLogConfig.Type || !reflect.DeepEqual(logConfig.Config, daemon.defaultLogConfig.Config),
+		dnsSearch: strings.Join(dnsSearch, " ") != strings.Join(daemon.configStore.DNSSearch, " "),
+	}
+}
+
+// affects returns whether container c uses one of the changed defaults.
+func (change configChange) affects(c *container.Container) bool {
+	return (change.logConfig && c.HostConfig.LogConfig.Type == "") ||
+		(change.dnsSearch && len(c.HostConfig.DNSSearch) == 0)
+}
+
+// configChangeRestarts returns the running containers opted in to be
+// restarted on configuration changes, and affected by change.
+func (daemon *Daemon) configChangeRestarts(change configChange) []*container.Container {
+	var restarts []*container.Container
+	for _, c := range daemon.List() {
+		if c.Config.Labels[RestartOnConfigChangeLabel] != "true" {
+			continue
+		}
+		if !c.IsRunning() || c.IsRestarting() || c.IsPaused() {
+			continue
+		}
+		if change.affects(c) {
+			restarts = append(restarts, c)
+		}
+	}
+	return restarts
+}
+
+// restartOnConfigChange restarts, in the background and a few at a time,
+// the containers opted in to be restarted on configuration changes which
+// are affected by change, so that they run with the new defaults.
+func (daemon *Daemon) restartOnConfigChange(change configChange) {
+	if change == (configChange{}) {
+		return
+	}
+	restarts := daemon.configChangeRestarts(change)
+	if len(restarts) == 0 {
+		return
+	}
+	go func() {
+		daemon.configRestartMu.Lock()
+		defer daemon.configRestartMu.Unlock()
+
+		var (
+			wg  sync.WaitGroup
+			sem = make(chan struct{}, maxConcurrentConfigRestarts)
+		)
+		for _, c := range restarts {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(c *container.Container) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				// the container may have been stopped in the meantime
+				if !c.IsRunning() {
+					return
+				}
+				logrus.Infof("Restarting container %s on the configuration change", c.ID)
+				if err := daemon.containerRestart(c, c.StopTimeout(defaultShutdownStopTimeout)); err != nil {
+					logrus.Errorf("Error restarting container %s on the configuration change: %v", c.ID, err)
+				}
+			}(c)
+		}
+		wg.Wait()
+	}()
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/docker/docker/container"
+	"golang.org/x/net/context"
 )
 
 // ContainerRestart stops and starts a container. It attempts to
@@ -39,7 +40,7 @@ func (daemon *Daemon) containerRestart(container *container.Container, seconds i
 		return err
 	}
 
-	if err := daemon.containerStart(container); err != nil {
+	if err := daemon.containerStart(context.Background(), container, false); err != nil {
 		return err
 	}
 
@@ -7,6 +7,12 @@ import (
 	"github.com/opencontainers/specs/specs-go"
 )
 
+const supportsSeccomp = false
+
 func setSeccomp(daemon *Daemon, rs *specs.Spec, c *container.Container) error {
 	return nil
 }
+
+func validateSeccompProfile(profile string) error {
+	return nil
+}
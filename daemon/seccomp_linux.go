@@ -11,6 +11,8 @@ import (
 	"github.com/opencontainers/specs/specs-go"
 )
 
+const supportsSeccomp = true
+
 func setSeccomp(daemon *Daemon, rs *specs.Spec, c *container.Container) error {
 	var profile *specs.Seccomp
 	var err error
@@ -44,3 +46,13 @@ func setSeccomp(daemon *Daemon, rs *specs.Spec, c *container.Container) error {
 	rs.Linux.Seccomp = profile
 	return nil
 }
+
+// validateSeccompProfile checks the seccomp profile given in the security
+// options of a container when it is created, rather than when it starts.
+func validateSeccompProfile(profile string) error {
+	if profile == "unconfined" {
+		return nil
+	}
+	_, err := seccomp.LoadProfile(profile)
+	return err
+}
@@ -0,0 +1,65 @@
+package daemon
+
+import (
+	"sort"
+
+	"github.com/docker/docker/api/types/backend"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/caps"
+	"github.com/docker/docker/oci"
+	"github.com/docker/docker/pkg/stringutils"
+)
+
+// The seccomp profiles reported in the security settings of a container.
+const (
+	seccompDefault    = "default"
+	seccompUnconfined = "unconfined"
+	seccompCustom     = "custom"
+)
+
+// securityReport returns the effective security settings container c will
+// run with, computed as when its spec is generated on start.
+func (daemon *Daemon) securityReport(c *container.Container) (*backend.ContainerSecurity, error) {
+	defaults := oci.DefaultSpec().Process.Capabilities
+	report := &backend.ContainerSecurity{
+		Privileged: c.HostConfig.Privileged,
+		Seccomp:    seccompProfileKind(c, supportsSeccomp && daemon.seccompEnabled),
+	}
+
+	if c.HostConfig.Privileged {
+		report.Capabilities = caps.GetAllCapabilities()
+	} else {
+		capabilities, err := caps.TweakCapabilities(defaults, c.HostConfig.CapAdd, c.HostConfig.CapDrop)
+		if err != nil {
+			return nil, err
+		}
+		report.Capabilities = capabilities
+	}
+	sort.Strings(report.Capabilities)
+
+	for _, cap := range report.Capabilities {
+		if !stringutils.InSlice(defaults, cap) {
+			report.CapabilitiesAdded = append(report.CapabilitiesAdded, cap)
+		}
+	}
+	for _, cap := range defaults {
+		if !stringutils.InSlice(report.Capabilities, cap) {
+			report.CapabilitiesDropped = append(report.CapabilitiesDropped, cap)
+		}
+	}
+	sort.Strings(report.CapabilitiesDropped)
+	return report, nil
+}
+
+// seccompProfileKind returns whether container c runs with the default
+// seccomp profile, a custom one, or unconfined.
+func seccompProfileKind(c *container.Container, enabled bool) string {
+	switch {
+	case !enabled || c.HostConfig.Privileged || c.SeccompProfile == "unconfined":
+		return seccompUnconfined
+	case c.SeccompProfile != "":
+		return seccompCustom
+	default:
+		return seccompDefault
+	}
+}
@@ -0,0 +1,14 @@
+// +build !linux
+
+package daemon
+
+import (
+	"github.com/docker/docker/api/types/backend"
+	"github.com/docker/docker/container"
+)
+
+// securityReport returns no security settings, capabilities and seccomp
+// are only supported on Linux.
+func (daemon *Daemon) securityReport(c *container.Container) (*backend.ContainerSecurity, error) {
+	return nil, nil
+}
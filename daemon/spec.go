@@ -0,0 +1,34 @@
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/errors"
+)
+
+// ContainerSpec returns the runtime spec the container would be started
+// with, without starting it. The spec is built from the current
+// configuration of the container and the daemon, but leaves the volumes,
+// the shm and the AppArmor profiles untouched, so its mount sources may not
+// exist yet. An error is returned if the container is running.
+func (daemon *Daemon) ContainerSpec(name string) (interface{}, error) {
+	container, err := daemon.GetContainer(name)
+	if err != nil {
+		return nil, err
+	}
+
+	container.Lock()
+	defer container.Unlock()
+
+	if container.IsRunning() {
+		err := fmt.Errorf("Container %s is running, stop the container to get its spec", container.ID)
+		return nil, errors.NewRequestConflictError(err)
+	}
+
+	if err := daemon.Mount(container); err != nil {
+		return nil, err
+	}
+	defer daemon.Unmount(container)
+
+	return daemon.previewSpec(container)
+}
@@ -6,18 +6,36 @@ import (
 	"runtime"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/server/httputils"
 	"github.com/docker/docker/container"
 	"github.com/docker/docker/errors"
 	"github.com/docker/docker/libcontainerd"
 	"github.com/docker/docker/runconfig"
 	containertypes "github.com/docker/engine-api/types/container"
+	"golang.org/x/net/context"
+)
+
+const (
+	// mountRetryCount is the number of times a mount failing with a
+	// transient error is retried when starting a container.
+	mountRetryCount = 5
+	// mountRetryDelay is the delay before the first retry, it doubles
+	// with every subsequent retry.
+	mountRetryDelay = 100 * time.Millisecond
 )
 
 // ContainerStart starts a container.
 //该方法主要做一些检查工作，具体的创建请见containerStart()。
-func (daemon *Daemon) ContainerStart(name string, hostConfig *containertypes.HostConfig) error {
+// Cancelling ctx aborts the start if the container is not running yet.
+// If paused is set, the container is frozen right after its process is
+// started and stays paused until it is unpaused.
+func (daemon *Daemon) ContainerStart(ctx context.Context, name string, hostConfig *containertypes.HostConfig, paused bool) error {
+	if daemon.IsDrained() {
+		return errDaemonDrained
+	}
 
 	//根据传入的容器名称查找容器是否存在。
 	container, err := daemon.GetContainer(name)
@@ -36,6 +54,10 @@ func (daemon *Daemon) ContainerStart(name string, hostConfig *containertypes.Hos
 		return errors.NewErrorWithStatusCode(err, http.StatusNotModified)
 	}
 
+	if paused && runtime.GOOS == "windows" {
+		return fmt.Errorf("Starting a container paused is not supported on Windows")
+	}
+
 	// Windows does not have the backwards compatibility issue here.
 	//以后将不可以在容器启动的时候设置hostConfig。包括网络模式等。
 	//在进行一些参数验证(端口映射的设置、验证exec driver、验证内核是否支持cpu share，IO weight等)后
@@ -74,33 +96,51 @@ func (daemon *Daemon) ContainerStart(name string, hostConfig *containertypes.Hos
 	if _, err = daemon.verifyContainerSettings(container.HostConfig, nil, false); err != nil {
 		return err
 	}
+	if err := daemon.verifyResourceLimits(container.HostConfig, container.Config); err != nil {
+		return err
+	}
 	// Adapt for old containers in case we have updates in this function and
 	// old containers never have chance to call the new function in create stage.
 	//匹配旧版本的容器。
-	if err := daemon.adaptContainerSettings(container.HostConfig, false); err != nil {
+	if _, err := daemon.adaptContainerSettings(container.HostConfig, false); err != nil {
 		return err
 	}
 
 	//真正启动容器的方法，请参考containerStart()方法。
-	return daemon.containerStart(container)
+	return daemon.containerStart(ctx, container, paused)
 }
 
 // Start starts a container
-func (daemon *Daemon) Start(container *container.Container) error {
-	return daemon.containerStart(container)
+func (daemon *Daemon) Start(ctx context.Context, container *container.Container) error {
+	return daemon.containerStart(ctx, container, false)
 }
 
 // containerStart prepares the container to run by setting up everything the
 // container needs, such as storage and networking, as well as links
 // between containers. The container is left waiting for a signal to
-// begin running.
+// begin running. The start is aborted, and the setup done so far undone,
+// if ctx is cancelled or the daemon shuts down before the container runs.
+// If paused is set, the container is paused right after its process is started.
 //容器启动的核心方法。
-func (daemon *Daemon) containerStart(container *container.Container) (err error) {
+func (daemon *Daemon) containerStart(ctx context.Context, container *container.Container, paused bool) (err error) {
+	ctx, cancel := daemon.withShutdown(ctx)
+	defer cancel()
+
+	// wait for a start slot before locking the container, so that queued
+	// starts don't block the other operations on it
+	if err := daemon.startLimiter.Acquire(ctx); err != nil {
+		return err
+	}
+	defer daemon.startLimiter.Release()
 
 	//这里的锁是干什么的？
 	container.Lock()
 	defer container.Unlock()
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	if container.Running {
 		return nil
 	}
@@ -131,7 +171,20 @@ func (daemon *Daemon) containerStart(container *container.Container) (err error)
 	//挂载容器的文件系统。会调用daemon/daemon.go中的Mount()方法。
 	//不过那个方法比较奇怪，感觉正常情况下并不会做什么事情。
 
-	if err := daemon.conditionalMountOnStart(container); err != nil {
+	if err := daemon.verifyContainerImage(container); err != nil {
+		return err
+	}
+
+	startTime := time.Now()
+	phaseStart := startTime
+	if err := daemon.materializeRWLayer(container); err != nil {
+		return err
+	}
+	if err := daemon.mountOnStart(ctx, container); err != nil {
+		return err
+	}
+	observeStartPhase(startPhaseMount, phaseStart)
+	if err := ctx.Err(); err != nil {
 		return err
 	}
 
@@ -146,48 +199,167 @@ func (daemon *Daemon) containerStart(container *container.Container) (err error)
 		（与其他容器共用一个网络栈，猜测kubernate中的pod所用的模式）；
 		根据config和hostConfig中的参数来确定容器的网络模式，然后调动libnetwork包来建立网络
 	*/
+	phaseStart = time.Now()
 	if err := daemon.initializeNetworking(container); err != nil {
 		return err
 	}
+	observeStartPhase(startPhaseNetwork, phaseStart)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
 	//创建容器关于namespace和cgroup等的运行环境。在daemon/oci_linux.go中。
 	//Spec中包括了容器的最基本的信息。
+	phaseStart = time.Now()
 	spec, err := daemon.createSpec(container)
 	if err != nil {
 		return err
 	}
+	observeStartPhase(startPhaseSpec, phaseStart)
+
+	container.StartRequestID = httputils.RequestIDFromContext(ctx)
+	defer func() { container.StartRequestID = "" }()
 
+	phaseStart = time.Now()
 	//运行容器，详细请见libcontainerd/client_linux.go中Create()方法。
-	if err := daemon.containerd.Create(container.ID, *spec, libcontainerd.WithRestartManager(container.RestartManager(true))); err != nil {
+	createOptions := daemon.containerdOptions(container)
+	if paused {
+		createOptions = append(createOptions, libcontainerd.WithStartPaused())
+	}
+	if err := daemon.containerd.Create(ctx, container.ID, *spec, createOptions...); err != nil {
 		// if we receive an internal error from the initial start of a container then lets
 		// return it instead of entering the restart loop
-		// set to 127 for container cmd not found/does not exist)
-		if strings.Contains(err.Error(), "executable file not found") ||
-			strings.Contains(err.Error(), "no such file or directory") ||
-			strings.Contains(err.Error(), "system cannot find the file specified") {
-			container.ExitCode = 127
-			err = fmt.Errorf("Container command '%s' not found or does not exist.", container.Path)
-		}
-		// set to 126 for container cmd can't be invoked errors
-		if strings.Contains(err.Error(), syscall.EACCES.Error()) {
-			container.ExitCode = 126
-			err = fmt.Errorf("Container command '%s' could not be invoked.", container.Path)
+		if exitCode, execErr := startErrorExitCode(err, container.Path); exitCode != 0 {
+			container.ExitCode = exitCode
+			err = execErr
 		}
 
 		container.Reset(false)
 
 		// start event is logged even on error
-		daemon.LogContainerEvent(container, "start")
+		daemon.logContainerStartEvent(container)
+
+		if container.ExitCode == 0 {
+			container.ExitCode = 128
+		}
+		attributes := map[string]string{
+			"exitCode": fmt.Sprintf("%d", container.ExitCode),
+			"error":    err.Error(),
+		}
+		daemon.LogContainerEventWithAttributes(container, "start_failed", attributes)
 		return err
 	}
+	observeStartPhase(startPhaseCreate, phaseStart)
+	observeStartPhase(startPhaseTotal, startTime)
+
+	return nil
+}
+
+// withShutdown returns a copy of ctx which is also cancelled when the
+// daemon shuts down.
+func (daemon *Daemon) withShutdown(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	if daemon.shutdownCtx == nil {
+		return ctx, cancel
+	}
+	if daemon.shutdownCtx.Err() != nil {
+		cancel()
+		return ctx, cancel
+	}
+	go func() {
+		select {
+		case <-daemon.shutdownCtx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// startErrorExitCode returns the exit code of a container whose command
+// failed to start with err, along with the error to report. It returns 0 if
+// err isn't caused by the command of the container.
+func startErrorExitCode(err error, path string) (int, error) {
+	var notFound, notPermitted bool
+	if execErr, ok := err.(*libcontainerd.ExecError); ok {
+		notFound = execErr.Err == libcontainerd.ErrExecNotFound
+		notPermitted = execErr.Err == libcontainerd.ErrExecNotPermitted
+	} else {
+		// last resort for errors libcontainerd didn't classify
+		msg := err.Error()
+		notFound = strings.Contains(msg, "executable file not found") ||
+			strings.Contains(msg, "no such file or directory") ||
+			strings.Contains(msg, "system cannot find the file specified")
+		notPermitted = strings.Contains(msg, syscall.EACCES.Error())
+	}
+
+	switch {
+	case notFound:
+		// set to 127 for container cmd not found/does not exist
+		return 127, fmt.Errorf("Container command '%s' not found or does not exist.", path)
+	case notPermitted:
+		// set to 126 for container cmd can't be invoked errors
+		return 126, fmt.Errorf("Container command '%s' could not be invoked.", path)
+	}
+	return 0, err
+}
+
+// mountOnStart mounts the container filesystem. Mounts failing because a
+// previous unmount hasn't fully settled yet are retried with a backoff.
+func (daemon *Daemon) mountOnStart(ctx context.Context, container *container.Container) error {
+	delay := mountRetryDelay
+	for i := 0; ; i++ {
+		err := daemon.conditionalMountOnStart(container)
+		if err == nil || i == mountRetryCount || !isTransientMountError(err) {
+			return err
+		}
+		logrus.Warnf("Failed to mount container %s, retrying in %v: %v", container.ID, delay, err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		delay *= 2
+	}
+}
 
+// isTransientMountError returns true if the mount failed with EBUSY or
+// EAGAIN. Graph drivers don't always return the errno itself, so the
+// error message is checked too.
+func isTransientMountError(err error) bool {
+	for _, errno := range []syscall.Errno{syscall.EBUSY, syscall.EAGAIN} {
+		if err == errno || strings.HasSuffix(err.Error(), errno.Error()) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyContainerImage checks, when enabled in the daemon configuration,
+// that the image a container was created from still exists, and that the
+// image reference it was created with still refers to it, so that the
+// container doesn't silently run another image after the tag was moved.
+func (daemon *Daemon) verifyContainerImage(container *container.Container) error {
+	if daemon.configStore == nil || !daemon.configStore.VerifyImageOnStart {
+		return nil
+	}
+	if _, err := daemon.imageStore.Get(container.ImageID); err != nil {
+		return fmt.Errorf("Cannot start container %s: its image %s was deleted", container.ID, container.ImageID)
+	}
+	img, err := daemon.GetImage(container.Config.Image)
+	if err != nil {
+		return fmt.Errorf("Cannot start container %s: image %s no longer exists", container.ID, container.Config.Image)
+	}
+	if img.ID() != container.ImageID {
+		return fmt.Errorf("Cannot start container %s: image %s now refers to %s, not to %s the container was created from", container.ID, container.Config.Image, img.ID(), container.ImageID)
+	}
 	return nil
 }
 
 // Cleanup releases any network resources allocated to the container along with any rules
 // around how containers are linked together.  It also unmounts the container's root filesystem.
 func (daemon *Daemon) Cleanup(container *container.Container) {
-	daemon.releaseNetwork(container)
+	daemon.releaseLazyNetwork(container)
 
 	container.UnmountIpcMounts(detachMounted)
 
@@ -195,7 +367,9 @@ func (daemon *Daemon) Cleanup(container *container.Container) {
 		// FIXME: remove once reference counting for graphdrivers has been refactored
 		// Ensure that all the mounts are gone
 		if mountid, err := daemon.layerStore.GetMountID(container.ID); err == nil {
-			daemon.cleanupMountsByID(mountid)
+			if err := daemon.cleanupMountsByID(mountid); err != nil {
+				daemon.detachMountsByID(mountid)
+			}
 		}
 	}
 
@@ -210,3 +384,19 @@ func (daemon *Daemon) Cleanup(container *container.Container) {
 	}
 	container.CancelAttachContext()
 }
+
+// containerdOptions returns the options of container with containerd, both
+// when it is started and when it is restored.
+func (daemon *Daemon) containerdOptions(container *container.Container) []libcontainerd.CreateOption {
+	options := []libcontainerd.CreateOption{
+		libcontainerd.WithRestartManager(container.RestartManager(true)),
+		libcontainerd.WithStartLimiter(daemon.startLimiter),
+	}
+	if group, _ := runconfig.ProcessGroupSignalsFromLabels(container.Config.Labels); group {
+		options = append(options, libcontainerd.WithProcessGroupSignals())
+	}
+	if live, _ := runconfig.LiveRestoreFromLabels(container.Config.Labels); live {
+		options = append(options, libcontainerd.WithLiveRestore())
+	}
+	return options
+}
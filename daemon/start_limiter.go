@@ -0,0 +1,45 @@
+package daemon
+
+import (
+	"runtime"
+
+	"golang.org/x/net/context"
+)
+
+// startLimiter caps the number of containers starting at the same time, so
+// that restarting a large number of containers does not saturate the host.
+// It implements libcontainerd.StartLimiter. A nil limiter does not limit
+// the starts.
+type startLimiter struct {
+	slots chan struct{}
+}
+
+// newStartLimiter creates a limiter allowing max concurrent starts. Zero
+// allows as many starts as there are CPUs.
+func newStartLimiter(max int) *startLimiter {
+	if max <= 0 {
+		max = runtime.NumCPU()
+	}
+	return &startLimiter{slots: make(chan struct{}, max)}
+}
+
+// Acquire blocks until a start can proceed, or ctx is done.
+func (l *startLimiter) Acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release frees the slot taken by a call to Acquire.
+func (l *startLimiter) Release() {
+	if l == nil {
+		return
+	}
+	<-l.slots
+}
@@ -0,0 +1,71 @@
+package daemon
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestStartLimiterCapsConcurrency(t *testing.T) {
+	const max = 3
+	l := newStartLimiter(max)
+
+	var (
+		mu      sync.Mutex
+		running int
+		peak    int
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.Acquire(context.Background()); err != nil {
+				t.Error(err)
+				return
+			}
+			defer l.Release()
+
+			mu.Lock()
+			running++
+			if running > peak {
+				peak = running
+			}
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if peak > max {
+		t.Fatalf("expected at most %d concurrent starts, got %d", max, peak)
+	}
+	if peak < max {
+		t.Fatalf("expected the starts to use the %d slots, got %d", max, peak)
+	}
+}
+
+func TestStartLimiterAcquireCancelled(t *testing.T) {
+	l := newStartLimiter(1)
+	if err := l.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Acquire(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected the queued start to time out, got %v", err)
+	}
+
+	l.Release()
+	if err := l.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
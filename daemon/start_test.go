@@ -0,0 +1,188 @@
+package daemon
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/layer"
+	"github.com/docker/docker/libcontainerd"
+	"github.com/docker/docker/reference"
+	containertypes "github.com/docker/engine-api/types/container"
+	"golang.org/x/net/context"
+)
+
+func TestContainerStartCancelled(t *testing.T) {
+	daemon := &Daemon{}
+	c := container.NewBaseContainer("start-cancelled", "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := daemon.containerStart(ctx, c, false); err != context.Canceled {
+		t.Fatalf("Expected the start to be cancelled, got %v", err)
+	}
+	if c.IsRunning() {
+		t.Fatal("Expected the container not to be running")
+	}
+}
+
+func TestContainerStartCancelledOnShutdown(t *testing.T) {
+	daemon := &Daemon{}
+	daemon.shutdownCtx, daemon.cancelShutdownCtx = context.WithCancel(context.Background())
+	daemon.cancelShutdownCtx()
+
+	c := container.NewBaseContainer("start-shutdown", "")
+
+	if err := daemon.containerStart(context.Background(), c, false); err != context.Canceled {
+		t.Fatalf("Expected the start to be cancelled, got %v", err)
+	}
+}
+
+// failingRWLayer is a layer.RWLayer whose Mount fails with the queued
+// errors before succeeding.
+type failingRWLayer struct {
+	layer.RWLayer
+	errs   []error
+	mounts int
+}
+
+func (l *failingRWLayer) Mount(mountLabel string) (string, error) {
+	l.mounts++
+	if len(l.errs) > 0 {
+		err := l.errs[0]
+		l.errs = l.errs[1:]
+		return "", err
+	}
+	return "/var/lib/docker/rootfs", nil
+}
+
+func TestMountOnStartRetriesTransientErrors(t *testing.T) {
+	daemon := &Daemon{}
+	c := container.NewBaseContainer("mount-busy", "")
+	rwLayer := &failingRWLayer{errs: []error{
+		&os.PathError{Op: "mount", Path: "/merged", Err: syscall.EBUSY},
+		fmt.Errorf("error creating overlay mount to /merged: %v", syscall.EAGAIN),
+	}}
+	c.HostConfig = &containertypes.HostConfig{}
+	c.RWLayer = rwLayer
+
+	if err := daemon.mountOnStart(context.Background(), c); err != nil {
+		t.Fatal(err)
+	}
+	if rwLayer.mounts != 3 {
+		t.Fatalf("Expected 3 mount attempts, got %d", rwLayer.mounts)
+	}
+	if c.BaseFS != "/var/lib/docker/rootfs" {
+		t.Fatalf("Expected the container to be mounted, got %q", c.BaseFS)
+	}
+}
+
+func TestMountOnStartNonTransientError(t *testing.T) {
+	daemon := &Daemon{}
+	c := container.NewBaseContainer("mount-fail", "")
+	rwLayer := &failingRWLayer{errs: []error{syscall.ENOENT}}
+	c.HostConfig = &containertypes.HostConfig{}
+	c.RWLayer = rwLayer
+
+	if err := daemon.mountOnStart(context.Background(), c); err != syscall.ENOENT {
+		t.Fatalf("Expected the mount error, got %v", err)
+	}
+	if rwLayer.mounts != 1 {
+		t.Fatalf("Expected a single mount attempt, got %d", rwLayer.mounts)
+	}
+}
+
+func TestStartErrorExitCode(t *testing.T) {
+	cases := []struct {
+		err      error
+		exitCode int
+		msg      string
+	}{
+		{&libcontainerd.ExecError{Err: libcontainerd.ErrExecNotFound, Detail: "exec: \"foo\": executable file not found in $PATH"}, 127, "Container command 'foo' not found or does not exist."},
+		{&libcontainerd.ExecError{Err: libcontainerd.ErrExecNotPermitted, Detail: "exec: \"foo\": permission denied"}, 126, "Container command 'foo' could not be invoked."},
+		{fmt.Errorf("oci runtime error: exec: \"foo\": stat foo: no such file or directory"), 127, "Container command 'foo' not found or does not exist."},
+		{fmt.Errorf("oci runtime error: exec: \"foo\": permission denied"), 126, "Container command 'foo' could not be invoked."},
+		{fmt.Errorf("oci runtime error: cgroup path is not writable"), 0, "oci runtime error: cgroup path is not writable"},
+	}
+
+	for _, c := range cases {
+		exitCode, err := startErrorExitCode(c.err, "foo")
+		if exitCode != c.exitCode || err.Error() != c.msg {
+			t.Fatalf("Expected exit code %d and error %q for %v, got %d and %q", c.exitCode, c.msg, c.err, exitCode, err)
+		}
+	}
+}
+
+func TestVerifyContainerImage(t *testing.T) {
+	is, cleanup := newTestImageStore(t)
+	defer cleanup()
+	tmp, err := ioutil.TempDir("", "docker-daemon-start-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	rs, err := reference.NewReferenceStore(filepath.Join(tmp, "repositories.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := is.Create([]byte(`{"comment": "created", "rootfs": {"type": "layers"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replacement, err := is.Create([]byte(`{"comment": "replacement", "rootfs": {"type": "layers"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := reference.ParseNamed("foo:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.AddTag(ref, created, false); err != nil {
+		t.Fatal(err)
+	}
+
+	daemon := &Daemon{
+		configStore:    &Config{},
+		imageStore:     is,
+		referenceStore: rs,
+	}
+	c := container.NewBaseContainer("verify-image", "")
+	c.Config = &containertypes.Config{Image: "foo:latest"}
+	c.ImageID = created
+
+	if err := daemon.verifyContainerImage(c); err != nil {
+		t.Fatalf("Expected the image not to be verified when disabled, got %v", err)
+	}
+	daemon.configStore.VerifyImageOnStart = true
+	if err := daemon.verifyContainerImage(c); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rs.AddTag(ref, replacement, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := daemon.verifyContainerImage(c); err == nil || !strings.Contains(err.Error(), "now refers to") {
+		t.Fatalf("Expected the start to fail as the tag moved, got %v", err)
+	}
+
+	if _, err := rs.Delete(ref); err != nil {
+		t.Fatal(err)
+	}
+	if err := daemon.verifyContainerImage(c); err == nil || !strings.Contains(err.Error(), "no longer exists") {
+		t.Fatalf("Expected the start to fail as the tag was removed, got %v", err)
+	}
+
+	if _, err := is.Delete(created); err != nil {
+		t.Fatal(err)
+	}
+	if err := daemon.verifyContainerImage(c); err == nil || !strings.Contains(err.Error(), "was deleted") {
+		t.Fatalf("Expected the start to fail as the image was deleted, got %v", err)
+	}
+}
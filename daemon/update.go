@@ -9,18 +9,16 @@ import (
 
 // ContainerUpdate updates configuration of the container
 func (daemon *Daemon) ContainerUpdate(name string, hostConfig *container.HostConfig) ([]string, error) {
-	var warnings []string
-
 	warnings, err := daemon.verifyContainerSettings(hostConfig, nil, true)
 	if err != nil {
-		return warnings, err
+		return warnings.messages(), err
 	}
 
 	if err := daemon.update(name, hostConfig); err != nil {
-		return warnings, err
+		return warnings.messages(), err
 	}
 
-	return warnings, nil
+	return warnings.messages(), nil
 }
 
 // ContainerUpdateCmdOnBuild updates Path and Args for the container with ID cID.
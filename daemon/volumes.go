@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/docker/docker/container"
@@ -20,6 +22,20 @@ var (
 	ErrVolumeReadonly = errors.New("mounted volume is marked read-only")
 )
 
+// Policies for the bind mounts whose source path does not exist.
+const (
+	// MissingBindSourceCreate creates the missing source directories.
+	MissingBindSourceCreate = "create"
+	// MissingBindSourceReject rejects the containers with a missing source.
+	MissingBindSourceReject = "reject"
+)
+
+// EphemeralVolumeLabel is the label of the volumes removed along with the
+// last container referencing them, its value being "true". On a container,
+// "true" or "false" overrides --ephemeral-anonymous-volumes for the anonymous
+// volumes created for it.
+const EphemeralVolumeLabel = "com.docker.volume.ephemeral"
+
 type mounts []container.Mount
 
 // volumeToAPIType converts a volume.Volume to the type used by the remote API
@@ -164,6 +180,36 @@ func (daemon *Daemon) registerMountPoints(container *container.Container, hostCo
 	return nil
 }
 
+// verifyBindSources checks that the source of each bind mount of hostConfig
+// exists. Missing sources are created if the daemon policy allows it, and
+// rejected otherwise. Windows never creates them.
+func (daemon *Daemon) verifyBindSources(hostConfig *containertypes.HostConfig) error {
+	policy := MissingBindSourceCreate
+	if daemon.configStore != nil && daemon.configStore.MissingBindSource != "" {
+		policy = daemon.configStore.MissingBindSource
+	}
+	for _, spec := range hostConfig.Binds {
+		bind, err := volume.ParseMountSpec(spec, hostConfig.VolumeDriver)
+		if err != nil {
+			return err
+		}
+		if bind.Source == "" {
+			// a named volume
+			continue
+		}
+		if _, err := os.Stat(bind.Source); err == nil || !os.IsNotExist(err) {
+			continue
+		}
+		if policy == MissingBindSourceReject || runtime.GOOS == "windows" {
+			return fmt.Errorf("Invalid bind mount %s: source path %s does not exist", spec, bind.Source)
+		}
+		if err := os.MkdirAll(bind.Source, 0755); err != nil {
+			return fmt.Errorf("Invalid bind mount %s: failed to create source path %s: %v", spec, bind.Source, err)
+		}
+	}
+	return nil
+}
+
 // lazyInitializeVolume initializes a mountpoint's volume if needed.
 // This happens after a daemon restart.
 func (daemon *Daemon) lazyInitializeVolume(containerID string, m *volume.MountPoint) error {
@@ -176,3 +222,37 @@ func (daemon *Daemon) lazyInitializeVolume(containerID string, m *volume.MountPo
 	}
 	return nil
 }
+
+// verifyEphemeralVolumeLabel checks the EphemeralVolumeLabel of a container
+// is a boolean.
+func verifyEphemeralVolumeLabel(labels map[string]string) error {
+	if v, ok := labels[EphemeralVolumeLabel]; ok {
+		if _, err := strconv.ParseBool(v); err != nil {
+			return fmt.Errorf("Invalid value %q for the label %s, it must be true or false", v, EphemeralVolumeLabel)
+		}
+	}
+	return nil
+}
+
+// anonymousVolumeLabels returns the labels of the anonymous volumes created
+// for a container with config: EphemeralVolumeLabel if they are removed
+// along with the last container referencing them.
+func (daemon *Daemon) anonymousVolumeLabels(config *containertypes.Config) map[string]string {
+	ephemeral := daemon.configStore != nil && daemon.configStore.EphemeralAnonymousVolumes
+	if v, ok := config.Labels[EphemeralVolumeLabel]; ok {
+		ephemeral, _ = strconv.ParseBool(v)
+	}
+	if !ephemeral {
+		return nil
+	}
+	return map[string]string{EphemeralVolumeLabel: "true"}
+}
+
+// isEphemeralVolume returns whether v is removed along with the last
+// container referencing it.
+func isEphemeralVolume(v volume.Volume) bool {
+	lv, ok := v.(interface {
+		Labels() map[string]string
+	})
+	return ok && lv.Labels()[EphemeralVolumeLabel] == "true"
+}
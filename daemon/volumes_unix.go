@@ -50,7 +50,7 @@ func (daemon *Daemon) setupMounts(c *container.Container) ([]container.Mount, er
 	// if we are going to mount any of the network files from container
 	// metadata, the ownership must be set properly for potential container
 	// remapped root (user namespaces)
-	rootUID, rootGID := daemon.GetRemappedUIDGID()
+	rootUID, rootGID := daemon.containerRootUIDGID(c)
 	for _, mount := range netMounts {
 		if err := os.Chown(mount.Source, rootUID, rootGID); err != nil {
 			return nil, err
@@ -59,6 +59,37 @@ func (daemon *Daemon) setupMounts(c *container.Container) ([]container.Mount, er
 	return append(mounts, netMounts...), nil
 }
 
+// previewMounts returns the mounts setupMounts would return for the container
+// c. The volumes are not mounted and the missing sources of the bind mounts
+// are not created, so the sources of the mounts may not exist yet.
+func (daemon *Daemon) previewMounts(c *container.Container) ([]container.Mount, error) {
+	var mounts []container.Mount
+	for _, m := range c.MountPoints {
+		if err := daemon.lazyInitializeVolume(c.ID, m); err != nil {
+			return nil, err
+		}
+		path := m.Source
+		if m.Volume != nil {
+			path = m.Volume.Path()
+		}
+		mounts = append(mounts, container.Mount{
+			Source:      path,
+			Destination: m.Destination,
+			Writable:    m.RW,
+			Propagation: m.Propagation,
+		})
+	}
+
+	mounts = sortMounts(mounts)
+	for _, mount := range c.NetworkMounts() {
+		// the mount points for the network files replace them
+		if !c.HasMountFor(mount.Destination) {
+			mounts = append(mounts, mount)
+		}
+	}
+	return mounts, nil
+}
+
 // sortMounts sorts an array of mounts in lexicographic order. This ensure that
 // when mounting, the mounts don't shadow other mounts. For example, if mounting
 // /etc and /etc/resolv.conf, /etc/resolv.conf must not be mounted first.
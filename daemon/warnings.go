@@ -0,0 +1,37 @@
+package daemon
+
+import "github.com/docker/docker/api/types/backend"
+
+// WarningCategory groups the warnings about the configuration of a
+// container, so that clients can present them together.
+type WarningCategory string
+
+const (
+	// WarningResource is the category of the warnings about resource
+	// limits that are discarded or adjusted.
+	WarningResource WarningCategory = "resource"
+	// WarningNetwork is the category of the warnings about the networking
+	// of the container.
+	WarningNetwork WarningCategory = "network"
+)
+
+// containerWarnings accumulates the warnings about the configuration of a
+// container along with their category.
+type containerWarnings []backend.ContainerWarning
+
+// add appends the warning msg of the given category.
+func (w *containerWarnings) add(category WarningCategory, msg string) {
+	*w = append(*w, backend.ContainerWarning{Category: string(category), Message: msg})
+}
+
+// messages returns the messages of the warnings, without their category.
+func (w containerWarnings) messages() []string {
+	if w == nil {
+		return nil
+	}
+	messages := make([]string, 0, len(w))
+	for _, warning := range w {
+		messages = append(messages, warning.Message)
+	}
+	return messages
+}
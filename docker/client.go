@@ -14,6 +14,7 @@ var clientFlags = &cli.ClientFlags{FlagSet: new(flag.FlagSet), Common: commonFla
 func init() {
 	client := clientFlags.FlagSet
 	client.StringVar(&clientFlags.ConfigDir, []string{"-config"}, cliconfig.ConfigDir(), "Location of client config files")
+	client.StringVar(&clientFlags.RequestID, []string{"-request-id"}, "", "Request ID sent to the daemon and recorded in the events it produces")
 
 	clientFlags.PostParse = func() {
 		clientFlags.Common.PostParse()
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/docker/docker/api/client"
+	"github.com/docker/docker/cli"
+	"github.com/docker/docker/opts"
+	flag "github.com/docker/docker/pkg/mflag"
+	runconfigopts "github.com/docker/docker/runconfig/opts"
+)
+
+// completeCommand is the hidden command printing the commands and flags of
+// the CLI, from which the shell completion scripts are generated.
+const completeCommand = "__complete"
+
+// completion is the output of `docker __complete`.
+type completion struct {
+	Flags    []completionFlag    `json:"flags"`
+	Commands []completionCommand `json:"commands"`
+}
+
+type completionCommand struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Flags       []completionFlag `json:"flags"`
+}
+
+type completionFlag struct {
+	Names       []string `json:"names"`
+	Description string   `json:"description"`
+	Default     string   `json:"default,omitempty"`
+	// Value is true if the flag takes a value, false for a boolean flag.
+	Value bool `json:"value"`
+}
+
+// completeCli prints the commands of a Cli for the completion scripts.
+type completeCli struct {
+	cli *cli.Cli
+	out io.Writer
+}
+
+// CmdComplete prints the global flags, the commands and their flags as JSON.
+//
+// Usage: docker __complete
+func (c *completeCli) CmdComplete(args ...string) error {
+	cmd := cli.Subcmd(completeCommand, nil, "Print the commands and flags for the completion scripts", true)
+	cmd.Require(flag.Exact, 0)
+	cmd.ParseFlags(args, true)
+
+	comp := completion{
+		Flags:    completionFlags(flag.CommandLine),
+		Commands: []completionCommand{},
+	}
+	for _, command := range dockerCommands(c.cli) {
+		cc := completionCommand{
+			Name:        command.Name,
+			Description: command.Description,
+			Flags:       []completionFlag{},
+		}
+		if addFlags, ok := commandFlags[command.Name]; ok {
+			flags := flag.NewFlagSet(command.Name, flag.ContinueOnError)
+			flags.SetOutput(ioutil.Discard)
+			addFlags(flags)
+			// added by ParseFlags when the command runs
+			if flags.Lookup("-help") == nil {
+				flags.Bool([]string{"#help", "-help"}, false, "Print usage")
+			}
+			cc.Flags = completionFlags(flags)
+		}
+		comp.Commands = append(comp.Commands, cc)
+	}
+	return json.NewEncoder(c.out).Encode(comp)
+}
+
+// commandFlags adds the flags of each command of cli.DockerCommands to a
+// FlagSet. The flags of create, run and exec are added by the parsers of
+// their configuration, the others are copied from api/client, but for the
+// deprecated ones, and must be updated with it.
+var commandFlags = map[string]func(cmd *flag.FlagSet){
+	"attach": func(cmd *flag.FlagSet) {
+		cmd.Bool([]string{"-no-stdin"}, false, "Do not attach STDIN")
+		cmd.Bool([]string{"-sig-proxy"}, true, "Proxy all received signals to the process")
+		cmd.String([]string{"-detach-keys"}, "", "Override the key sequence for detaching a container")
+	},
+	"build": func(cmd *flag.FlagSet) {
+		listVar(cmd, []string{"t", "-tag"}, "Name and optionally a tag in the 'name:tag' format")
+		cmd.Bool([]string{"q", "-quiet"}, false, "Suppress the build output and print image ID on success")
+		cmd.Bool([]string{"-no-cache"}, false, "Do not use cache when building the image")
+		cmd.Bool([]string{"-rm"}, true, "Remove intermediate containers after a successful build")
+		cmd.Bool([]string{"-force-rm"}, false, "Always remove intermediate containers")
+		cmd.Bool([]string{"-pull"}, false, "Always attempt to pull a newer version of the image")
+		cmd.String([]string{"f", "-file"}, "", "Name of the Dockerfile (Default is 'PATH/Dockerfile')")
+		cmd.String([]string{"m", "-memory"}, "", "Memory limit")
+		cmd.String([]string{"-memory-swap"}, "", "Swap limit equal to memory plus swap: '-1' to enable unlimited swap")
+		cmd.String([]string{"-shm-size"}, "", "Size of /dev/shm, default value is 64MB")
+		cmd.Int64([]string{"#c", "-cpu-shares"}, 0, "CPU shares (relative weight)")
+		cmd.Int64([]string{"-cpu-period"}, 0, "Limit the CPU CFS (Completely Fair Scheduler) period")
+		cmd.Int64([]string{"-cpu-quota"}, 0, "Limit the CPU CFS (Completely Fair Scheduler) quota")
+		cmd.String([]string{"-cpuset-cpus"}, "", "CPUs in which to allow execution (0-3, 0,1)")
+		cmd.String([]string{"-cpuset-mems"}, "", "MEMs in which to allow execution (0-3, 0,1)")
+		cmd.String([]string{"-cgroup-parent"}, "", "Optional parent cgroup for the container")
+		listVar(cmd, []string{"-build-arg"}, "Set build-time variables")
+		cmd.String([]string{"-isolation"}, "", "Container isolation technology")
+		listVar(cmd, []string{"-label"}, "Set metadata for an image")
+		cmd.Var(runconfigopts.NewUlimitOpt(nil), []string{"-ulimit"}, "Ulimit options")
+	},
+	"commit": func(cmd *flag.FlagSet) {
+		cmd.Bool([]string{"p", "-pause"}, true, "Pause container during commit")
+		cmd.String([]string{"m", "-message"}, "", "Commit message")
+		cmd.String([]string{"a", "-author"}, "", "Author (e.g., \"John Hannibal Smith <hannibal@a-team.com>\")")
+		listVar(cmd, []string{"c", "-change"}, "Apply Dockerfile instruction to the created image")
+	},
+	"cp": func(cmd *flag.FlagSet) {
+		cmd.Bool([]string{"L", "-follow-link"}, false, "Always follow symbol link in SRC_PATH")
+	},
+	"create": func(cmd *flag.FlagSet) {
+		cmd.String([]string{"-name"}, "", "Assign a name to the container")
+		containerFlags(cmd)
+	},
+	"diff": noFlags,
+	"events": func(cmd *flag.FlagSet) {
+		cmd.String([]string{"-since"}, "", "Show all events created since timestamp")
+		cmd.String([]string{"-until"}, "", "Stream events until this timestamp")
+		listVar(cmd, []string{"f", "-filter"}, "Filter output based on conditions provided")
+	},
+	"exec": func(cmd *flag.FlagSet) {
+		cmd.String([]string{"-detach-keys"}, "", "Override the key sequence for detaching a container")
+		// the arguments only satisfy the parser
+		client.ParseExec(cmd, []string{"CONTAINER", "COMMAND"})
+	},
+	"export": func(cmd *flag.FlagSet) {
+		cmd.String([]string{"o", "-output"}, "", "Write to a file, instead of STDOUT")
+	},
+	"history": func(cmd *flag.FlagSet) {
+		cmd.Bool([]string{"H", "-human"}, true, "Print sizes and dates in human readable format")
+		cmd.Bool([]string{"q", "-quiet"}, false, "Only show numeric IDs")
+		cmd.Bool([]string{"-no-trunc"}, false, "Don't truncate output")
+	},
+	"images": func(cmd *flag.FlagSet) {
+		cmd.Bool([]string{"q", "-quiet"}, false, "Only show numeric IDs")
+		cmd.Bool([]string{"a", "-all"}, false, "Show all images (default hides intermediate images)")
+		cmd.Bool([]string{"-no-trunc"}, false, "Don't truncate output")
+		cmd.Bool([]string{"-digests"}, false, "Show digests")
+		cmd.String([]string{"-format"}, "", "Pretty-print images using a Go template")
+		listVar(cmd, []string{"f", "-filter"}, "Filter output based on conditions provided")
+	},
+	"import": func(cmd *flag.FlagSet) {
+		listVar(cmd, []string{"c", "-change"}, "Apply Dockerfile instruction to the created image")
+		cmd.String([]string{"m", "-message"}, "", "Set commit message for imported image")
+	},
+	"info": noFlags,
+	"inspect": func(cmd *flag.FlagSet) {
+		cmd.String([]string{"f", "-format"}, "", "Format the output using the given go template")
+		cmd.String([]string{"-type"}, "", "Return JSON for specified type, (e.g image or container)")
+		cmd.Bool([]string{"s", "-size"}, false, "Display total file sizes if the type is container")
+	},
+	"kill": func(cmd *flag.FlagSet) {
+		cmd.String([]string{"s", "-signal"}, "KILL", "Signal to send to the container")
+	},
+	"load": func(cmd *flag.FlagSet) {
+		cmd.String([]string{"i", "-input"}, "", "Read from a tar archive file, instead of STDIN")
+		cmd.Bool([]string{"q", "-quiet"}, false, "Suppress the load output")
+	},
+	"login": func(cmd *flag.FlagSet) {
+		cmd.String([]string{"u", "-username"}, "", "Username")
+		cmd.String([]string{"p", "-password"}, "", "Password")
+	},
+	"logout": noFlags,
+	"logs": func(cmd *flag.FlagSet) {
+		cmd.Bool([]string{"f", "-follow"}, false, "Follow log output")
+		cmd.String([]string{"-since"}, "", "Show logs since timestamp")
+		cmd.Bool([]string{"t", "-timestamps"}, false, "Show timestamps")
+		cmd.String([]string{"-tail"}, "all", "Number of lines to show from the end of the logs")
+	},
+	"network": noFlags,
+	"pause":   noFlags,
+	"port":    noFlags,
+	"ps": func(cmd *flag.FlagSet) {
+		cmd.Bool([]string{"q", "-quiet"}, false, "Only display numeric IDs")
+		cmd.Bool([]string{"s", "-size"}, false, "Display total file sizes")
+		cmd.Bool([]string{"a", "-all"}, false, "Show all containers (default shows just running)")
+		cmd.Bool([]string{"-no-trunc"}, false, "Don't truncate output")
+		cmd.Bool([]string{"l", "-latest"}, false, "Show the latest created container (includes all states)")
+		cmd.Int([]string{"n"}, -1, "Show n last created containers (includes all states)")
+		cmd.String([]string{"-format"}, "", "Pretty-print containers using a Go template")
+		listVar(cmd, []string{"f", "-filter"}, "Filter output based on conditions provided")
+	},
+	"pull": func(cmd *flag.FlagSet) {
+		cmd.Bool([]string{"a", "-all-tags"}, false, "Download all tagged images in the repository")
+	},
+	"push":   noFlags,
+	"rename": noFlags,
+	"restart": func(cmd *flag.FlagSet) {
+		cmd.Int([]string{"t", "-time"}, 10, "Seconds to wait for stop before killing the container")
+	},
+	"rm": func(cmd *flag.FlagSet) {
+		cmd.Bool([]string{"v", "-volumes"}, false, "Remove the volumes associated with the container")
+		cmd.Bool([]string{"l", "-link"}, false, "Remove the specified link")
+		cmd.Bool([]string{"f", "-force"}, false, "Force the removal of a running container (uses SIGKILL)")
+	},
+	"rmi": func(cmd *flag.FlagSet) {
+		cmd.Bool([]string{"f", "-force"}, false, "Force removal of the image")
+		cmd.Bool([]string{"-no-prune"}, false, "Do not delete untagged parents")
+	},
+	"run": func(cmd *flag.FlagSet) {
+		cmd.Bool([]string{"-rm"}, false, "Automatically remove the container when it exits")
+		cmd.Bool([]string{"d", "-detach"}, false, "Run container in background and print container ID")
+		cmd.Bool([]string{"-sig-proxy"}, true, "Proxy received signals to the process")
+		cmd.String([]string{"-name"}, "", "Assign a name to the container")
+		cmd.String([]string{"-detach-keys"}, "", "Override the key sequence for detaching a container")
+		containerFlags(cmd)
+	},
+	"save": func(cmd *flag.FlagSet) {
+		cmd.String([]string{"o", "-output"}, "", "Write to a file, instead of STDOUT")
+	},
+	"search": func(cmd *flag.FlagSet) {
+		cmd.Bool([]string{"-no-trunc"}, false, "Don't truncate output")
+		cmd.Bool([]string{"-automated"}, false, "Only show automated builds")
+		cmd.Uint([]string{"s", "-stars"}, 0, "Only displays with at least x stars")
+	},
+	"start": func(cmd *flag.FlagSet) {
+		cmd.Bool([]string{"a", "-attach"}, false, "Attach STDOUT/STDERR and forward signals")
+		cmd.Bool([]string{"i", "-interactive"}, false, "Attach container's STDIN")
+		cmd.String([]string{"-detach-keys"}, "", "Override the key sequence for detaching a container")
+	},
+	"stats": func(cmd *flag.FlagSet) {
+		cmd.Bool([]string{"a", "-all"}, false, "Show all containers (default shows just running)")
+		cmd.Bool([]string{"-no-stream"}, false, "Disable streaming stats and only pull the first result")
+	},
+	"stop": func(cmd *flag.FlagSet) {
+		cmd.Int([]string{"t", "-time"}, 10, "Seconds to wait for stop before killing it")
+	},
+	"tag":     noFlags,
+	"top":     noFlags,
+	"unpause": noFlags,
+	"update": func(cmd *flag.FlagSet) {
+		cmd.Uint16([]string{"-blkio-weight"}, 0, "Block IO (relative weight), between 10 and 1000")
+		cmd.Int64([]string{"-cpu-period"}, 0, "Limit CPU CFS (Completely Fair Scheduler) period")
+		cmd.Int64([]string{"-cpu-quota"}, 0, "Limit CPU CFS (Completely Fair Scheduler) quota")
+		cmd.String([]string{"-cpuset-cpus"}, "", "CPUs in which to allow execution (0-3, 0,1)")
+		cmd.String([]string{"-cpuset-mems"}, "", "MEMs in which to allow execution (0-3, 0,1)")
+		cmd.Int64([]string{"#c", "-cpu-shares"}, 0, "CPU shares (relative weight)")
+		cmd.String([]string{"m", "-memory"}, "", "Memory limit")
+		cmd.String([]string{"-memory-reservation"}, "", "Memory soft limit")
+		cmd.String([]string{"-memory-swap"}, "", "Swap limit equal to memory plus swap: '-1' to enable unlimited swap")
+		cmd.String([]string{"-kernel-memory"}, "", "Kernel memory limit")
+		cmd.String([]string{"-restart"}, "", "Restart policy to apply when a container exits")
+	},
+	"version": func(cmd *flag.FlagSet) {
+		cmd.String([]string{"f", "#format", "-format"}, "", "Format the output using the given go template")
+	},
+	"volume": noFlags,
+	"wait":   noFlags,
+}
+
+func noFlags(cmd *flag.FlagSet) {}
+
+// listVar adds a flag which can be given several times.
+func listVar(cmd *flag.FlagSet, names []string, usage string) {
+	values := opts.NewListOpts(nil)
+	cmd.Var(&values, names, usage)
+}
+
+// containerFlags adds the flags of the container configuration of create
+// and run. The image only satisfies the parser.
+func containerFlags(cmd *flag.FlagSet) {
+	runconfigopts.Parse(cmd, []string{"IMAGE"})
+}
+
+// completionFlags returns the flags of fs shown in its usage, with their
+// names as typed on the command line.
+func completionFlags(fs *flag.FlagSet) []completionFlag {
+	flags := []completionFlag{}
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Usage == "" {
+			return
+		}
+		var names []string
+		for _, name := range f.Names {
+			if strings.HasPrefix(name, "#") {
+				// deprecated
+				continue
+			}
+			names = append(names, "-"+name)
+		}
+		if len(names) == 0 {
+			return
+		}
+		value := true
+		if b, ok := f.Value.(interface {
+			IsBoolFlag() bool
+		}); ok && b.IsBoolFlag() {
+			value = false
+		}
+		flags = append(flags, completionFlag{
+			Names:       names,
+			Description: f.Usage,
+			Default:     f.DefValue,
+			Value:       value,
+		})
+	})
+	return flags
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/cli"
+	flag "github.com/docker/docker/pkg/mflag"
+)
+
+func TestCompletionFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Bool([]string{"f", "#force", "-force"}, false, "Force")
+	fs.String([]string{"-name"}, "foo", "Name")
+	fs.String([]string{"#old"}, "", "Deprecated")
+	fs.String([]string{"-hidden"}, "", "")
+
+	expected := []completionFlag{
+		{Names: []string{"-f", "--force"}, Description: "Force", Default: "false", Value: false},
+		{Names: []string{"--name"}, Description: "Name", Default: "foo", Value: true},
+	}
+	if flags := completionFlags(fs); !reflect.DeepEqual(flags, expected) {
+		t.Fatalf("Expected flags %v, got %v", expected, flags)
+	}
+}
+
+func TestCommandFlags(t *testing.T) {
+	for name := range cli.DockerCommands {
+		if _, ok := commandFlags[name]; !ok {
+			t.Fatalf("Expected the flags of docker %s to be described", name)
+		}
+	}
+
+	for name, expected := range map[string][]string{
+		"attach": {"-no-stdin", "-sig-proxy", "-detach-keys"},
+		"create": {"-name", "-memory", "-uid-map"},
+		"exec":   {"-detach-keys", "i", "-privileged"},
+		"run":    {"-rm", "d", "-name", "-restart"},
+	} {
+		flags := flag.NewFlagSet(name, flag.ContinueOnError)
+		commandFlags[name](flags)
+		for _, f := range expected {
+			if flags.Lookup(f) == nil {
+				t.Fatalf("Expected the flags of docker %s to include %s", name, f)
+			}
+		}
+	}
+}
@@ -4,12 +4,16 @@ package main
 
 import (
 	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Sirupsen/logrus"
@@ -18,6 +22,7 @@ import (
 	"github.com/docker/docker/api/server/router"
 	"github.com/docker/docker/api/server/router/build"
 	"github.com/docker/docker/api/server/router/container"
+	"github.com/docker/docker/api/server/router/health"
 	"github.com/docker/docker/api/server/router/image"
 	"github.com/docker/docker/api/server/router/network"
 	systemrouter "github.com/docker/docker/api/server/router/system"
@@ -44,10 +49,32 @@ import (
 const (
 	daemonUsage          = "       docker daemon [ --help | ... ]\n"
 	daemonConfigFileFlag = "-config-file"
+	// daemonConfigEnv is the environment variable holding the path of the
+	// configuration file, or directory, when --config-file isn't set.
+	daemonConfigEnv = "DOCKER_DAEMON_CONFIG"
+	// stdinConfigFile is the configuration file meaning that the
+	// configuration is read from the standard input.
+	stdinConfigFile = "-"
+)
+
+// Phases of the daemon startup, reported when it fails.
+const (
+	startupPhaseConfig     = "config"
+	startupPhaseSetup      = "setup"
+	startupPhaseListeners  = "listeners"
+	startupPhaseContainerd = "containerd"
+	startupPhaseDaemon     = "daemon"
 )
 
 var (
 	daemonCli cli.Handler = NewDaemonCli()
+
+	// configStdin is where the configuration is read from when the
+	// configuration file is stdinConfigFile.
+	configStdin io.Reader = os.Stdin
+
+	// startupExit exits the daemon when it fails to start.
+	startupExit = os.Exit
 )
 
 // DaemonCli represents the daemon CLI.
@@ -67,6 +94,7 @@ func NewDaemonCli() *DaemonCli {
 	daemonConfig := new(daemon.Config)
 	daemonConfig.LogConfig.Config = make(map[string]string)
 	daemonConfig.ClusterOpts = make(map[string]string)
+	daemonConfig.LogLevels = make(map[string]string)
 
 	if runtime.GOOS != "linux" {
 		daemonConfig.V2Only = true
@@ -124,21 +152,22 @@ func migrateKey() (err error) {
 	return nil
 }
 
-func getGlobalFlag() (globalFlag *flag.Flag) {
-	defer func() {
-		if x := recover(); x != nil {
-			switch f := x.(type) {
-			case *flag.Flag:
-				globalFlag = f
-			default:
-				panic(x)
+func getGlobalFlag() *flag.Flag {
+	return firstSetFlag(commonFlags.FlagSet, clientFlags.FlagSet)
+}
+
+// firstSetFlag returns the first flag that has been set in flagSets,
+// or nil if none has been set.
+func firstSetFlag(flagSets ...*flag.FlagSet) *flag.Flag {
+	var first *flag.Flag
+	for _, fs := range flagSets {
+		fs.Visit(func(f *flag.Flag) {
+			if first == nil {
+				first = f
 			}
-		}
-	}()
-	visitor := func(f *flag.Flag) { panic(f) }
-	commonFlags.FlagSet.Visit(visitor)
-	clientFlags.FlagSet.Visit(visitor)
-	return
+		})
+	}
+	return first
 }
 
 // CmdDaemon is the daemon command, called the raw arguments after `docker daemon`.
@@ -157,7 +186,10 @@ func (cli *DaemonCli) CmdDaemon(args ...string) error {
 		flag.Merge(cli.flags, commonFlags.FlagSet)
 	}
 
-	configFile := cli.flags.String([]string{daemonConfigFileFlag}, defaultDaemonConfigFile, "Daemon configuration file")
+	configFileFlags := opts.NewListOpts(nil)
+	cli.flags.Var(&configFileFlags, []string{daemonConfigFileFlag}, "Daemon configuration files or directories, merged in order (default "+defaultDaemonConfigFile+")")
+	validateConfig := cli.flags.Bool([]string{"-validate-config"}, false, "Validate the daemon configuration and exit")
+	printConfig := cli.flags.Bool([]string{"-print-config"}, false, "Print the effective daemon configuration as JSON and exit")
 
 	//匹配配置参数
 	cli.flags.ParseFlags(args, true)
@@ -167,13 +199,37 @@ func (cli *DaemonCli) CmdDaemon(args ...string) error {
 	if commonFlags.TrustKey == "" {
 		commonFlags.TrustKey = filepath.Join(getDaemonConfDir(), defaultTrustKeyFile)
 	}
-	cliConfig, err := loadDaemonCliConfig(cli.Config, cli.flags, commonFlags, *configFile)
+	configFiles := daemonConfigFiles(cli.flags, configFileFlags.GetAll())
+	cliConfig, err := loadDaemonCliConfig(cli.Config, cli.flags, commonFlags, configFiles...)
 	if err != nil {
+		if cli.Config.LogFormat == daemon.LogFormatJSON {
+			// the configuration file may not be loaded, the format
+			// can only be set by the flag
+			setDaemonLogFormat(daemon.LogFormatJSON, false)
+			startupFailed(cli.Config, startupPhaseConfig, err)
+		}
 		fmt.Fprint(os.Stderr, err)
 		os.Exit(1)
 	}
 	cli.Config = cliConfig
 
+	if *printConfig {
+		if err := printDaemonConfig(os.Stdout, cli.Config); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return nil
+	}
+
+	if *validateConfig {
+		if err := validateDaemonCliConfig(cli.Config); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(os.Stdout, "configuration OK")
+		return nil
+	}
+
 	if cli.Config.Debug {
 		utils.EnableDebug()
 	}
@@ -182,18 +238,26 @@ func (cli *DaemonCli) CmdDaemon(args ...string) error {
 		logrus.Warn("Running experimental build")
 	}
 
-	logrus.SetFormatter(&logrus.TextFormatter{
-		TimestampFormat: jsonlog.RFC3339NanoFixed,
-		DisableColors:   cli.Config.RawLogs,
-	})
+	setDaemonLogFormat(cli.Config.LogFormat, cli.Config.RawLogs)
+	setDaemonLogLevels(cli.Config.LogLevel, cli.Config.LogLevels)
+
+	if cli.Config.StartupProgress != "" {
+		progress, err := openStartupProgress(cli.Config.StartupProgress)
+		if err != nil {
+			startupFailed(cli.Config, startupPhaseSetup, fmt.Errorf("Error opening the startup progress: %v", err))
+		}
+		startupReporter = progress
+		defer progress.Close()
+	}
+	startupReporter.report(startupEventConfigLoaded, "Daemon configuration loaded")
 
 	if err := setDefaultUmask(); err != nil {
-		logrus.Fatalf("Failed to set umask: %v", err)
+		startupFailed(cli.Config, startupPhaseSetup, fmt.Errorf("Failed to set umask: %v", err))
 	}
 
 	if len(cli.LogConfig.Config) > 0 {
 		if err := logger.ValidateLogOpts(cli.LogConfig.Type, cli.LogConfig.Config); err != nil {
-			logrus.Fatalf("Failed to set log opts: %v", err)
+			startupFailed(cli.Config, startupPhaseConfig, fmt.Errorf("Failed to set log opts: %v", err))
 		}
 	}
 
@@ -201,7 +265,7 @@ func (cli *DaemonCli) CmdDaemon(args ...string) error {
 	if cli.Pidfile != "" {
 		pf, err := pidfile.New(cli.Pidfile)
 		if err != nil {
-			logrus.Fatalf("Error starting daemon: %v", err)
+			startupFailed(cli.Config, startupPhaseSetup, fmt.Errorf("Error starting daemon: %v", err))
 		}
 		pfile = pf
 		defer func() {
@@ -217,9 +281,31 @@ func (cli *DaemonCli) CmdDaemon(args ...string) error {
 		Logging:                  true,
 		SocketGroup:              cli.Config.SocketGroup,
 		Version:                  dockerversion.Version,
+		ReadTimeout:              time.Duration(cli.Config.APIReadTimeout) * time.Second,
+		WriteTimeout:             time.Duration(cli.Config.APIWriteTimeout) * time.Second,
+		IdleTimeout:              time.Duration(cli.Config.APIIdleTimeout) * time.Second,
 	}
 	serverConfig = setPlatformServerConfig(serverConfig, cli.Config)
 
+	if cli.Config.AccessLog != "" {
+		accessLog, err := os.OpenFile(cli.Config.AccessLog, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			startupFailed(cli.Config, startupPhaseSetup, fmt.Errorf("Error opening the access log: %v", err))
+		}
+		defer accessLog.Close()
+		serverConfig.AccessLog = &apiserver.AccessLogConfig{
+			Out:     accessLog,
+			Verbose: cli.Config.AccessLogVerbose,
+		}
+	}
+
+	buildLogSinks, err := build.NewLogSinks(cli.Config.BuildLogSinks)
+	if err != nil {
+		startupFailed(cli.Config, startupPhaseSetup, err)
+	}
+	defer buildLogSinks.Close()
+
+	var certReloader *certificateReloader
 	if cli.Config.TLS {
 		tlsOptions := tlsconfig.Options{
 			CAFile:   cli.Config.CommonTLSOptions.CAFile,
@@ -233,7 +319,11 @@ func (cli *DaemonCli) CmdDaemon(args ...string) error {
 		}
 		tlsConfig, err := tlsconfig.Server(tlsOptions)
 		if err != nil {
-			logrus.Fatal(err)
+			startupFailed(cli.Config, startupPhaseListeners, err)
+		}
+		certReloader, err = newCertificateReloader(tlsConfig, cli.Config.CommonTLSOptions)
+		if err != nil {
+			startupFailed(cli.Config, startupPhaseListeners, err)
 		}
 		serverConfig.TLSConfig = tlsConfig
 	}
@@ -255,31 +345,32 @@ func (cli *DaemonCli) CmdDaemon(args ...string) error {
 	*/
 	api := apiserver.New(serverConfig)
 
-	for i := 0; i < len(cli.Config.Hosts); i++ {
-		var err error
-		if cli.Config.Hosts[i], err = opts.ParseHost(cli.Config.TLS, cli.Config.Hosts[i]); err != nil {
-			logrus.Fatalf("error parsin
-			g -H %s : %v", cli.Config.Hosts[i], err)
-		}
-
-		protoAddr := cli.Config.Hosts[i]
-		protoAddrParts := strings.SplitN(protoAddr, "://", 2)
-		if len(protoAddrParts) != 2 {
-			logrus.Fatalf("bad format %s, expected PROTO://ADDR", protoAddr)
-		}
-		l, err := listeners.Init(protoAddrParts[0], protoAddrParts[1], serverConfig.SocketGroup, serverConfig.TLSConfig)
-		if err != nil {
-			logrus.Fatal(err)
-		}
-
-		logrus.Debugf("Listener created for HTTP on %s (%s)", protoAddrParts[0], protoAddrParts[1])
-		
+	hostListeners, err := initListeners(cli.Config.Hosts, cli.Config.TLS, serverConfig.SocketGroup, serverConfig.TLSConfig)
+	if err != nil {
+		startupFailed(cli.Config, startupPhaseListeners, err)
+	}
+	for _, hl := range hostListeners {
 		//初始化api的servers数组，里面放着的都是httpserver类型。此时也没有具体的运行什么
-		api.Accept(protoAddrParts[1], l...)
+		api.Accept(hl.addr, hl.listeners...)
 	}
+	startupReporter.report(startupEventListenersBound, "API listeners bound")
+
+	// serve the health checks while the daemon initializes, the other
+	// routers are added once it is initialized
+	healthBackend := &initializingDaemon{}
+	api.InitRouter(utils.IsDebugEnabled(), health.NewRouter(healthBackend))
+
+	// The serve API routine never exits unless an error occurs
+	// We need to start it as a goroutine and wait on it so
+	// daemon doesn't exit
+	//设置一个传输apiServer状态的通道
+	serveAPIWait := make(chan error)
+	//重新开启一个goroutine作为httpServer。
+	//具体的请查看api/server/server.go中的方法func (s *Server) serveAPI() error 
+	go api.Wait(serveAPIWait)
 
 	if err := migrateKey(); err != nil {
-		logrus.Fatal(err)
+		startupFailed(cli.Config, startupPhaseSetup, err)
 	}
 	cli.TrustKeyPath = commonFlags.TrustKey
 
@@ -288,10 +379,15 @@ func (cli *DaemonCli) CmdDaemon(args ...string) error {
 
 	//初始化libcontainer。比如在linux中，就会调用libcontainerd/remote_linux.go中的New方法。
 	
-	containerdRemote, err := libcontainerd.New(filepath.Join(cli.Config.ExecRoot, "libcontainerd"), cli.getPlatformRemoteOptions()...)
+	remoteOpts, err := cli.getPlatformRemoteOptions()
 	if err != nil {
-		logrus.Fatal(err)
+		startupFailed(cli.Config, startupPhaseContainerd, err)
+	}
+	containerdRemote, err := libcontainerd.New(filepath.Join(cli.Config.ExecRoot, "libcontainerd"), remoteOpts...)
+	if err != nil {
+		startupFailed(cli.Config, startupPhaseContainerd, err)
 	}
+	startupReporter.report(startupEventContainerdConnected, "Connected to containerd")
 
            //初始化守护进程使得能够服务。需要输入仓库服务和libcontainerd服务的参数。
 	//返回的d是Daemon类型：
@@ -335,10 +431,10 @@ func (cli *DaemonCli) CmdDaemon(args ...string) error {
 				logrus.Error(err)
 			}
 		}
-		logrus.Fatalf("Error starting daemon: %v", err)
+		startupFailed(cli.Config, startupPhaseDaemon, fmt.Errorf("Error starting daemon: %v", err))
 	}
 
-	logrus.Info("Daemon has completed initialization")
+	startupReporter.report(startupEventDaemonInitialized, "Daemon has completed initialization")
 
 	logrus.WithFields(logrus.Fields{
 		"version":     dockerversion.Version,
@@ -361,7 +457,8 @@ func (cli *DaemonCli) CmdDaemon(args ...string) error {
 	})
 	 */
 	//其中的ContainerCreate在
-	initRouter(api, d)
+	initRouter(api, d, buildLogSinks)
+	healthBackend.initialized(d)
 
 	reload := func(config *daemon.Config) {
 		if err := d.Reload(config); err != nil {
@@ -380,23 +477,35 @@ func (cli *DaemonCli) CmdDaemon(args ...string) error {
 			}
 
 		}
+		if config.IsValueSet("log-format") {
+			setDaemonLogFormat(config.LogFormat, cli.Config.RawLogs)
+		}
+		if config.IsValueSet("log-levels") || config.IsValueSet("debug") {
+			reloadDaemonLogLevels(cli.Config, config)
+		}
+		if certReloader != nil {
+			certReloader.reload(config)
+		}
+		if config.IsValueSet("build-log-sinks") {
+			if err := buildLogSinks.Reload(config.BuildLogSinks); err != nil {
+				logrus.Errorf("Error reloading the build log sinks: %v", err)
+			}
+		}
 	}
 
-	setupConfigReloadTrap(*configFile, cli.flags, reload)
-
-	// The serve API routine never exits unless an error occurs
-	// We need to start it as a goroutine and wait on it so
-	// daemon doesn't exit
-	//设置一个传输apiServer状态的通道
-	serveAPIWait := make(chan error)
-	//重新开启一个goroutine作为httpServer。
-	//具体的请查看api/server/server.go中的方法func (s *Server) serveAPI() error 
-	go api.Wait(serveAPIWait)
+	setupConfigReloadTrap(func() {
+		// the daemon records the failures to apply the configuration,
+		// these are the failures to read or validate it
+		if err := reloadConfiguration(configFiles, cli.flags, reload); err != nil {
+			logrus.Error(err)
+			d.ReloadFailed(err)
+		}
+	})
 
 	signal.Trap(func() {
 		api.Close()
 		<-serveAPIWait
-		shutdownDaemon(d, 15)
+		shutdownDaemon(d, time.Duration(cli.Config.ShutdownTimeout))
 		if pfile != nil {
 			if err := pfile.Remove(); err != nil {
 				logrus.Error(err)
@@ -406,6 +515,8 @@ func (cli *DaemonCli) CmdDaemon(args ...string) error {
 
 	// after the daemon is done setting up we can notify systemd api
 	notifySystem()
+	startupReporter.report(startupEventServing, "Daemon is serving the API")
+	startupReporter.Close()
 
 	// Daemon is fully initialized and handling API traffic
 	// Wait for serve API to complete
@@ -413,7 +524,7 @@ func (cli *DaemonCli) CmdDaemon(args ...string) error {
 	//也就是说主线程一直在等待api.wait的goroutine启动apiServer之后的返回才会进行。
 	errAPI := <-serveAPIWait
 	//当接收到返回（返回就是错误了），开始清理进程。
-	shutdownDaemon(d, 15)
+	shutdownDaemon(d, time.Duration(cli.Config.ShutdownTimeout))
 	containerdRemote.Cleanup()
 	if errAPI != nil {
 		if pfile != nil {
@@ -426,9 +537,33 @@ func (cli *DaemonCli) CmdDaemon(args ...string) error {
 	return nil
 }
 
+// initializingDaemon is the backend of the health router, served before
+// the daemon is initialized. The daemon is not ready until then.
+type initializingDaemon struct {
+	mu sync.Mutex
+	d  *daemon.Daemon
+}
+
+// initialized makes the readiness that of the initialized daemon d.
+func (i *initializingDaemon) initialized(d *daemon.Daemon) {
+	i.mu.Lock()
+	i.d = d
+	i.mu.Unlock()
+}
+
+func (i *initializingDaemon) Ready() error {
+	i.mu.Lock()
+	d := i.d
+	i.mu.Unlock()
+	if d == nil {
+		return errors.New("The daemon is initializing")
+	}
+	return d.Ready()
+}
+
 // shutdownDaemon just wraps daemon.Shutdown() to handle a timeout in case
 // d.Shutdown() is waiting too long to kill container or worst it's
-// blocked there
+// blocked there. The containers still stopping when it times out are logged.
 func shutdownDaemon(d *daemon.Daemon, timeout time.Duration) {
 	ch := make(chan struct{})
 	go func() {
@@ -439,11 +574,48 @@ func shutdownDaemon(d *daemon.Daemon, timeout time.Duration) {
 	case <-ch:
 		logrus.Debug("Clean shutdown succeeded")
 	case <-time.After(timeout * time.Second):
-		logrus.Error("Force shutdown daemon")
+		stopping := d.StoppingContainers()
+		if len(stopping) == 0 {
+			logrus.Error("Force shutdown daemon")
+			return
+		}
+		for i, id := range stopping {
+			if c, err := d.GetContainer(id); err == nil {
+				stopping[i] = fmt.Sprintf("%s (%s)", id, strings.TrimPrefix(c.Name, "/"))
+			}
+		}
+		logrus.Errorf("Force shutdown daemon, containers still stopping: %s", strings.Join(stopping, ", "))
 	}
 }
 
-func loadDaemonCliConfig(config *daemon.Config, daemonFlags *flag.FlagSet, commonConfig *cli.CommonFlags, configFile string) (*daemon.Config, error) {
+// daemonConfigFiles returns the configuration files of the daemon: the
+// values of the --config-file flag if it is set, otherwise the file in the
+// DOCKER_DAEMON_CONFIG environment variable, otherwise the default file.
+func daemonConfigFiles(flags *flag.FlagSet, flagValues []string) []string {
+	if flags.IsSet(daemonConfigFileFlag) {
+		return flagValues
+	}
+	if configFile := os.Getenv(daemonConfigEnv); configFile != "" {
+		return []string{configFile}
+	}
+	return []string{defaultDaemonConfigFile}
+}
+
+// reloadConfiguration reloads the configuration files of the daemon. A
+// configuration read from the standard input can't be reloaded.
+func reloadConfiguration(configFiles []string, flags *flag.FlagSet, reload func(*daemon.Config)) error {
+	for _, configFile := range configFiles {
+		if configFile == stdinConfigFile {
+			return fmt.Errorf("the configuration was read from the standard input, it can't be reloaded")
+		}
+	}
+	return daemon.ReloadConfiguration(configFiles, flags, reload)
+}
+
+// loadDaemonCliConfig merges the configuration files, in order, with the
+// configuration set by the flags. A configuration file is read from the
+// standard input if it is stdinConfigFile, which must then be the only one.
+func loadDaemonCliConfig(config *daemon.Config, daemonFlags *flag.FlagSet, commonConfig *cli.CommonFlags, configFiles ...string) (*daemon.Config, error) {
 	config.Debug = commonConfig.Debug
 	config.Hosts = commonConfig.Hosts
 	config.LogLevel = commonConfig.LogLevel
@@ -457,11 +629,27 @@ func loadDaemonCliConfig(config *daemon.Config, daemonFlags *flag.FlagSet, commo
 		config.CommonTLSOptions.KeyFile = commonConfig.TLSOptions.KeyFile
 	}
 
-	if configFile != "" {
-		c, err := daemon.MergeDaemonConfigurations(config, daemonFlags, configFile)
+	var files []string
+	for _, configFile := range configFiles {
+		if configFile == stdinConfigFile && len(configFiles) > 1 {
+			return nil, fmt.Errorf("the configuration can't be read from the standard input and from files")
+		}
+		if configFile != "" {
+			files = append(files, configFile)
+		}
+	}
+
+	if len(files) == 1 && files[0] == stdinConfigFile {
+		c, err := daemon.MergeDaemonConfigurationsFromReader(config, daemonFlags, configStdin)
 		if err != nil {
-			if daemonFlags.IsSet(daemonConfigFileFlag) || !os.IsNotExist(err) {
-				return nil, fmt.Errorf("unable to configure the Docker daemon with file %s: %v\n", configFile, err)
+			return nil, fmt.Errorf("unable to configure the Docker daemon from the standard input: %v\n", err)
+		}
+		config = c
+	} else if len(files) > 0 {
+		c, err := daemon.MergeDaemonConfigurations(config, daemonFlags, files...)
+		if err != nil {
+			if daemonFlags.IsSet(daemonConfigFileFlag) || os.Getenv(daemonConfigEnv) != "" || !os.IsNotExist(err) {
+				return nil, fmt.Errorf("unable to configure the Docker daemon with file %s: %v\n", strings.Join(files, ", "), err)
 			}
 		}
 		// the merged configuration can be nil if the config file didn't exist.
@@ -477,19 +665,181 @@ func loadDaemonCliConfig(config *daemon.Config, daemonFlags *flag.FlagSet, commo
 		config.TLS = true
 	}
 
+	if err := daemon.ValidateConfiguration(config); err != nil {
+		return nil, err
+	}
+
 	// ensure that the log level is the one set after merging configurations
 	setDaemonLogLevel(config.LogLevel)
 
 	return config, nil
 }
 
-func initRouter(s *apiserver.Server, d *daemon.Daemon) {
+// startupFailed reports err, a failure of the daemon to start in phase, to
+// the logs and the startup progress, and exits. With the JSON log format,
+// err is reported as a single line with the phase, error and fatal fields,
+// so that supervisors can tell which phase of the startup failed.
+func startupFailed(config *daemon.Config, phase string, err error) {
+	startupReporter.failed(phase, err)
+	if config.LogFormat != daemon.LogFormatJSON {
+		logrus.Fatal(err)
+	}
+	logrus.WithFields(logrus.Fields{
+		"phase": phase,
+		"error": err.Error(),
+		"fatal": true,
+	}).Error("Daemon failed to start")
+	startupExit(1)
+}
+
+// setDaemonLogFormat sets the formatter of the daemon logs. Timestamps
+// use the same format in text and JSON logs.
+func setDaemonLogFormat(logFormat string, rawLogs bool) {
+	if logFormat == daemon.LogFormatJSON {
+		daemonLogLevels.setFormatter(&logrus.JSONFormatter{
+			TimestampFormat: jsonlog.RFC3339NanoFixed,
+		})
+		return
+	}
+	daemonLogLevels.setFormatter(&logrus.TextFormatter{
+		TimestampFormat: jsonlog.RFC3339NanoFixed,
+		DisableColors:   rawLogs,
+	})
+}
+
+// validateDaemonCliConfig runs the checks on the configuration that are
+// otherwise only done when the daemon starts serving the API.
+func validateDaemonCliConfig(config *daemon.Config) error {
+	var errs []string
+	if err := logger.ValidateLogOpts(config.LogConfig.Type, config.LogConfig.Config); err != nil {
+		errs = append(errs, fmt.Sprintf("invalid log opts: %v", err))
+	}
+
+	hosts := config.Hosts
+	if len(hosts) == 0 {
+		hosts = make([]string, 1)
+	}
+	parsed := make([]string, len(hosts))
+	for i, host := range hosts {
+		var err error
+		if parsed[i], err = opts.ParseHost(config.TLS, host); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid -H %s: %v", host, err))
+			parsed[i] = ""
+		}
+	}
+	if err := checkHostConflicts(hosts, parsed); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// printDaemonConfig writes the effective configuration, merged from the
+// flags, the configuration files and the defaults, to w as JSON.
+func printDaemonConfig(w io.Writer, config *daemon.Config) error {
+	b, err := json.MarshalIndent(config.EffectiveValues(), "", "\t")
+	if err != nil {
+		return fmt.Errorf("Error printing the configuration: %v", err)
+	}
+	_, err = fmt.Fprintf(w, "%s\n", b)
+	return err
+}
+
+// maxConcurrentListeners bounds the number of hosts the daemon starts
+// listening on at the same time.
+const maxConcurrentListeners = 8
+
+// hostListeners holds the listeners created for a host.
+type hostListeners struct {
+	addr      string
+	listeners []net.Listener
+}
+
+// initListeners creates the listeners for hosts concurrently, so a host
+// that is slow to set up does not delay the others. Each host is parsed and
+// replaced in hosts by its normalized form, and no listener is created if
+// two hosts conflict. The listeners are returned in
+// the order of hosts. If any host fails, the listeners already created are
+// closed and the returned error reports every failing address.
+func initListeners(hosts []string, tlsEnabled bool, socketGroup string, tlsConfig *tls.Config) ([]hostListeners, error) {
+	var (
+		result = make([]hostListeners, len(hosts))
+		errs   = make([]error, len(hosts))
+		sem    = make(chan struct{}, maxConcurrentListeners)
+		wg     sync.WaitGroup
+	)
+
+	flags := append([]string(nil), hosts...)
+	parsed := make([]string, len(hosts))
+	for i := range hosts {
+		var err error
+		if hosts[i], err = opts.ParseHost(tlsEnabled, hosts[i]); err != nil {
+			errs[i] = fmt.Errorf("error parsing -H %s : %v", hosts[i], err)
+			continue
+		}
+		parsed[i] = hosts[i]
+	}
+	// the conflicting hosts are reported before listening on any host
+	if err := checkHostConflicts(flags, parsed); err != nil {
+		return nil, err
+	}
+
+	for i := range hosts {
+		if errs[i] != nil {
+			continue
+		}
+		protoAddrParts := strings.SplitN(hosts[i], "://", 2)
+		if len(protoAddrParts) != 2 {
+			errs[i] = fmt.Errorf("bad format %s, expected PROTO://ADDR", hosts[i])
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, proto, addr string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			l, err := listeners.Init(proto, addr, socketGroup, tlsConfig)
+			if err != nil {
+				errs[i] = fmt.Errorf("error listening on %s: %v", hosts[i], err)
+				return
+			}
+			logrus.Debugf("Listener created for HTTP on %s (%s)", proto, addr)
+			result[i] = hostListeners{addr: addr, listeners: l}
+		}(i, protoAddrParts[0], protoAddrParts[1])
+	}
+	wg.Wait()
+
+	var msgs []string
+	for _, err := range errs {
+		if err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	if len(msgs) > 0 {
+		for _, hl := range result {
+			for _, l := range hl.listeners {
+				l.Close()
+			}
+		}
+		return nil, errors.New(strings.Join(msgs, "\n"))
+	}
+	return result, nil
+}
+
+// initRouter adds the routers of the initialized daemon d to the health
+// router s already serves.
+func initRouter(s *apiserver.Server, d *daemon.Daemon, buildLogSinks *build.LogSinks) {
 	routers := []router.Router{
 		container.NewRouter(d),
 		image.NewRouter(d),
-		systemrouter.NewRouter(d),
+		systemrouter.NewRouter(d, s),
 		volume.NewRouter(d),
-		build.NewRouter(dockerfile.NewBuildManager(d)),
+		build.NewRouter(dockerfile.NewBuildManager(d), buildLogSinks),
 	}
 	if d.NetworkControllerEnabled() {
 		routers = append(routers, network.NewRouter(d))
@@ -3,6 +3,10 @@
 package main
 
 import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"os"
 	"strings"
@@ -294,3 +298,250 @@ func TestLoadDaemonConfigWithRegistryOptions(t *testing.T) {
 		t.Fatal("expected disable-legacy-registry to be true, got false")
 	}
 }
+
+func TestLoadDaemonCliConfigWithNegativeShutdownTimeout(t *testing.T) {
+	c := &daemon.Config{}
+	c.ShutdownTimeout = -1
+	common := &cli.CommonFlags{}
+
+	flags := mflag.NewFlagSet("test", mflag.ContinueOnError)
+	_, err := loadDaemonCliConfig(c, flags, common, "/tmp/fooobarbaz")
+	if err == nil {
+		t.Fatal("expected configuration error, got nil")
+	}
+	if !strings.Contains(err.Error(), "shutdown timeout") {
+		t.Fatalf("expected shutdown timeout error, got %v", err)
+	}
+}
+
+func TestLoadDaemonCliConfigWithInvalidLogFormat(t *testing.T) {
+	c := &daemon.Config{}
+	c.LogFormat = "xml"
+	common := &cli.CommonFlags{}
+
+	flags := mflag.NewFlagSet("test", mflag.ContinueOnError)
+	_, err := loadDaemonCliConfig(c, flags, common, "/tmp/fooobarbaz")
+	if err == nil {
+		t.Fatal("expected configuration error, got nil")
+	}
+	if !strings.Contains(err.Error(), "log format") {
+		t.Fatalf("expected log format error, got %v", err)
+	}
+}
+
+func TestSetDaemonLogFormat(t *testing.T) {
+	defer setDaemonLogFormat(daemon.LogFormatText, false)
+
+	setDaemonLogFormat(daemon.LogFormatJSON, false)
+	if logrus.StandardLogger().Formatter != daemonLogLevels {
+		t.Fatalf("expected the formatter filtering the subsystem log levels, got %T", logrus.StandardLogger().Formatter)
+	}
+	if _, ok := daemonLogLevels.formatter.(*logrus.JSONFormatter); !ok {
+		t.Fatalf("expected a JSON formatter, got %T", daemonLogLevels.formatter)
+	}
+
+	setDaemonLogFormat(daemon.LogFormatText, true)
+	f, ok := daemonLogLevels.formatter.(*logrus.TextFormatter)
+	if !ok {
+		t.Fatalf("expected a text formatter, got %T", daemonLogLevels.formatter)
+	}
+	if !f.DisableColors {
+		t.Fatal("expected colors to be disabled for raw logs")
+	}
+}
+
+func TestValidateDaemonCliConfig(t *testing.T) {
+	c := &daemon.Config{}
+	c.LogConfig.Type = "json-file"
+	c.LogConfig.Config = map[string]string{"max-size": "1k"}
+	c.Hosts = []string{"tcp://127.0.0.1:2375", "unix:///var/run/docker.sock"}
+
+	if err := validateDaemonCliConfig(c); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateDaemonCliConfigWithErrors(t *testing.T) {
+	c := &daemon.Config{}
+	c.LogConfig.Type = "json-file"
+	c.LogConfig.Config = map[string]string{"foo": "bar"}
+	c.Hosts = []string{"udp://127.0.0.1:2375"}
+
+	err := validateDaemonCliConfig(c)
+	if err == nil {
+		t.Fatal("expected validation error, got nil")
+	}
+	if !strings.Contains(err.Error(), "log opts") {
+		t.Fatalf("expected log opts error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "udp://127.0.0.1:2375") {
+		t.Fatalf("expected host error, got %v", err)
+	}
+
+	c.Hosts = []string{"tcp://0.0.0.0:2375", "tcp://127.0.0.1:2375"}
+	if err := validateDaemonCliConfig(c); err == nil || !strings.Contains(err.Error(), "listen on the same address") {
+		t.Fatalf("expected host conflict error, got %v", err)
+	}
+}
+
+func TestFirstSetFlag(t *testing.T) {
+	common := mflag.NewFlagSet("common", mflag.ContinueOnError)
+	common.Bool([]string{"D", "-debug"}, false, "")
+	client := mflag.NewFlagSet("client", mflag.ContinueOnError)
+	client.String([]string{"-config"}, "", "")
+
+	if f := firstSetFlag(common, client); f != nil {
+		t.Fatalf("Expected no flag, got %v", f.Names)
+	}
+
+	if err := common.Parse([]string{"-D"}); err != nil {
+		t.Fatal(err)
+	}
+	f := firstSetFlag(common, client)
+	if f == nil || f.Names[0] != "D" {
+		t.Fatalf("Expected flag D, got %v", f)
+	}
+}
+
+func TestCertificateReloader(t *testing.T) {
+	const fixtures = "../integration-cli/fixtures/https/"
+	tlsConfig, err := tlsconfig.Server(tlsconfig.Options{
+		CertFile: fixtures + "server-cert.pem",
+		KeyFile:  fixtures + "server-key.pem",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := newCertificateReloader(tlsConfig, daemon.CommonTLSOptions{
+		CertFile: fixtures + "server-cert.pem",
+		KeyFile:  fixtures + "server-key.pem",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tlsConfig.Certificates) != 0 || tlsConfig.GetCertificate == nil {
+		t.Fatal("expected the TLS configuration to get its certificate from the reloader")
+	}
+	original, _ := tlsConfig.GetCertificate(nil)
+
+	reload := func(content string) *tls.Certificate {
+		f, err := ioutil.TempFile("", "docker-config-")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(f.Name())
+		f.Write([]byte(content))
+		f.Close()
+
+		flags := mflag.NewFlagSet("test", mflag.ContinueOnError)
+		flags.String([]string{"-tlscert"}, "", "")
+		flags.String([]string{"-tlskey"}, "", "")
+		if err := daemon.ReloadConfiguration([]string{f.Name()}, flags, r.reload); err != nil {
+			t.Fatal(err)
+		}
+		cert, _ := tlsConfig.GetCertificate(nil)
+		return cert
+	}
+
+	rotated := reload(`{"tlscert": "` + fixtures + `server-rogue-cert.pem", "tlskey": "` + fixtures + `server-rogue-key.pem"}`)
+	if rotated == original {
+		t.Fatal("expected the certificate to be replaced")
+	}
+
+	if cert := reload(`{"tlscert": "/nonexistent/cert.pem"}`); cert != rotated {
+		t.Fatal("expected the current certificate to be kept when the new one is invalid")
+	}
+
+	if cert := reload(`{}`); cert == rotated || r.certFile != fixtures+"server-rogue-cert.pem" {
+		t.Fatal("expected the certificate to be loaded again from the current files")
+	}
+}
+
+func TestLoadDaemonCliConfigFromStdin(t *testing.T) {
+	defer func() { configStdin = os.Stdin }()
+	flags := mflag.NewFlagSet("test", mflag.ContinueOnError)
+	flags.String([]string{daemonConfigFileFlag}, "", "")
+	flags.Var(opts.NewNamedListOptsRef("labels", &[]string{}, opts.ValidateLabel), []string{"-label"}, "")
+	flags.Set(daemonConfigFileFlag, stdinConfigFile)
+
+	configStdin = strings.NewReader(`{"labels": ["l1=foo"]}`)
+	loadedConfig, err := loadDaemonCliConfig(&daemon.Config{}, flags, &cli.CommonFlags{}, stdinConfigFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loadedConfig.Labels) != 1 || loadedConfig.Labels[0] != "l1=foo" {
+		t.Fatalf("expected the labels read from stdin, got %v", loadedConfig.Labels)
+	}
+
+	configStdin = strings.NewReader(`{"labels": [`)
+	_, err = loadDaemonCliConfig(&daemon.Config{}, flags, &cli.CommonFlags{}, stdinConfigFile)
+	if err == nil || !strings.Contains(err.Error(), "standard input") {
+		t.Fatalf("expected an error parsing stdin, got %v", err)
+	}
+
+	if err := reloadConfiguration([]string{stdinConfigFile}, flags, func(*daemon.Config) {}); err == nil {
+		t.Fatal("expected the configuration read from stdin not to be reloadable")
+	}
+
+	configStdin = strings.NewReader(`{"labels": ["l1=foo"]}`)
+	_, err = loadDaemonCliConfig(&daemon.Config{}, flags, &cli.CommonFlags{}, stdinConfigFile, "/tmp/daemon.json")
+	if err == nil || !strings.Contains(err.Error(), "standard input") {
+		t.Fatalf("expected the standard input not to be merged with files, got %v", err)
+	}
+}
+
+func TestDaemonConfigFileFromEnv(t *testing.T) {
+	defer os.Setenv(daemonConfigEnv, os.Getenv(daemonConfigEnv))
+	flags := mflag.NewFlagSet("test", mflag.ContinueOnError)
+	flags.String([]string{daemonConfigFileFlag}, "", "")
+
+	os.Setenv(daemonConfigEnv, "")
+	if configFiles := daemonConfigFiles(flags, nil); len(configFiles) != 1 || configFiles[0] != defaultDaemonConfigFile {
+		t.Fatalf("expected the default configuration file, got %v", configFiles)
+	}
+
+	os.Setenv(daemonConfigEnv, "/run/docker/daemon.json")
+	if configFiles := daemonConfigFiles(flags, nil); len(configFiles) != 1 || configFiles[0] != "/run/docker/daemon.json" {
+		t.Fatalf("expected the configuration file from the environment, got %v", configFiles)
+	}
+	_, err := loadDaemonCliConfig(&daemon.Config{}, flags, &cli.CommonFlags{}, "/run/docker/daemon.json")
+	if err == nil {
+		t.Fatal("expected a missing configuration file from the environment to be an error")
+	}
+
+	flags.Set(daemonConfigFileFlag, "/tmp/daemon.json")
+	if configFiles := daemonConfigFiles(flags, []string{"/tmp/daemon.json", "/tmp/host.json"}); len(configFiles) != 2 || configFiles[1] != "/tmp/host.json" {
+		t.Fatalf("expected the configuration files from the flag, got %v", configFiles)
+	}
+}
+
+func TestStartupFailedJSON(t *testing.T) {
+	out := &bytes.Buffer{}
+	logrus.SetOutput(out)
+	setDaemonLogFormat(daemon.LogFormatJSON, false)
+	exitCode := -1
+	startupExit = func(code int) { exitCode = code }
+	defer func() {
+		logrus.SetOutput(os.Stderr)
+		setDaemonLogFormat(daemon.LogFormatText, false)
+		startupExit = os.Exit
+	}()
+
+	config := &daemon.Config{}
+	config.LogFormat = daemon.LogFormatJSON
+	startupFailed(config, startupPhaseListeners, errors.New("listen tcp: address already in use"))
+
+	if exitCode != 1 {
+		t.Fatalf("Expected the daemon to exit with 1, got %d", exitCode)
+	}
+	if lines := strings.Count(out.String(), "\n"); lines != 1 {
+		t.Fatalf("Expected a single line, got %q", out.String())
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a JSON line, got %q: %v", out.String(), err)
+	}
+	if entry["phase"] != startupPhaseListeners || entry["error"] != "listen tcp: address already in use" || entry["fatal"] != true {
+		t.Fatalf("Expected the phase, error and fatal fields, got %v", entry)
+	}
+}
@@ -0,0 +1,82 @@
+// +build daemon
+
+package main
+
+import (
+	"crypto/tls"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon"
+)
+
+// certificateReloader serves the TLS certificate of the daemon, which can be
+// replaced while the daemon runs without interrupting the connections.
+type certificateReloader struct {
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	certFile string
+	keyFile  string
+	caFile   string
+}
+
+// newCertificateReloader loads the certificate of the daemon and makes the
+// TLS configuration serve it through the reloader.
+func newCertificateReloader(tlsConfig *tls.Config, opts daemon.CommonTLSOptions) (*certificateReloader, error) {
+	r := &certificateReloader{caFile: opts.CAFile}
+	if err := r.load(opts.CertFile, opts.KeyFile); err != nil {
+		return nil, err
+	}
+	// the certificate is only looked up with GetCertificate when the
+	// configuration has no static certificate
+	tlsConfig.Certificates = nil
+	tlsConfig.GetCertificate = r.GetCertificate
+	return r, nil
+}
+
+func (r *certificateReloader) load(certFile, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.cert = &cert
+	r.certFile = certFile
+	r.keyFile = keyFile
+	r.mu.Unlock()
+	return nil
+}
+
+// GetCertificate returns the current certificate of the daemon. It
+// implements tls.Config.GetCertificate.
+func (r *certificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// reload loads the certificate again, from the files set in the new
+// configuration or from the current files, so that certificates renewed in
+// place are picked up. The current certificate is kept when the new one is
+// invalid.
+func (r *certificateReloader) reload(config *daemon.Config) {
+	r.mu.RLock()
+	certFile, keyFile := r.certFile, r.keyFile
+	r.mu.RUnlock()
+
+	if config.IsValueSet("tlscert") {
+		certFile = config.CommonTLSOptions.CertFile
+	}
+	if config.IsValueSet("tlskey") {
+		keyFile = config.CommonTLSOptions.KeyFile
+	}
+	if err := r.load(certFile, keyFile); err != nil {
+		logrus.Errorf("Error reloading the TLS certificate, keeping the current one: %v", err)
+		return
+	}
+	logrus.Infof("Reloaded the TLS certificate from %s", certFile)
+
+	if config.IsValueSet("tlscacert") && config.CommonTLSOptions.CAFile != r.caFile {
+		logrus.Warnf("The TLS CA certificate changed to %s, restart the daemon to use it", config.CommonTLSOptions.CAFile)
+	}
+}
@@ -3,17 +3,19 @@
 package main
 
 import (
+	"crypto/tls"
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
-	"github.com/Sirupsen/logrus"
 	apiserver "github.com/docker/docker/api/server"
 	"github.com/docker/docker/daemon"
 	"github.com/docker/docker/libcontainerd"
-	"github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/system"
+	"github.com/docker/go-connections/tlsconfig"
 )
 
 const defaultDaemonConfigFile = "/etc/docker/daemon.json"
@@ -53,30 +55,70 @@ func getDaemonConfDir() string {
 }
 
 // setupConfigReloadTrap configures the USR2 signal to reload the configuration.
-func setupConfigReloadTrap(configFile string, flags *mflag.FlagSet, reload func(*daemon.Config)) {
+func setupConfigReloadTrap(reload func()) {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGHUP)
 	go func() {
 		for range c {
-			if err := daemon.ReloadConfiguration(configFile, flags, reload); err != nil {
-				logrus.Error(err)
-			}
+			reload()
 		}
 	}()
 }
 
-func (cli *DaemonCli) getPlatformRemoteOptions() []libcontainerd.RemoteOption {
+func (cli *DaemonCli) getPlatformRemoteOptions() ([]libcontainerd.RemoteOption, error) {
 	opts := []libcontainerd.RemoteOption{
 		libcontainerd.WithDebugLog(cli.Config.Debug),
 	}
+	tlsConfig, err := containerdTLSConfig(cli.Config)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		opts = append(opts, libcontainerd.WithRemoteTLS(tlsConfig))
+	}
 	if cli.Config.ContainerdAddr != "" {
 		opts = append(opts, libcontainerd.WithRemoteAddr(cli.Config.ContainerdAddr))
 	} else {
 		opts = append(opts, libcontainerd.WithStartDaemon(true))
 	}
+	if cli.Config.NoPivotRoot {
+		opts = append(opts, libcontainerd.WithNoPivotRoot(true))
+	}
+	if cli.Config.RetainFailedBundles != 0 {
+		opts = append(opts, libcontainerd.WithRetainedBundles(cli.Config.RetainFailedBundles))
+	}
+	if cli.Config.FifoTimeout != 0 {
+		opts = append(opts, libcontainerd.WithFifoTimeout(time.Duration(cli.Config.FifoTimeout)*time.Second))
+	}
 	if daemon.UsingSystemd(cli.Config) {
 		args := []string{"--systemd-cgroup=true"}
 		opts = append(opts, libcontainerd.WithRuntimeArgs(args))
 	}
-	return opts
+	return opts, nil
+}
+
+// containerdTLSConfig returns the TLS configuration to connect to containerd
+// over TCP with, or nil when containerd is reached through its unix socket.
+// The certificate authority, the certificate and the key are all required
+// with a tcp:// address, and rejected with a socket path.
+func containerdTLSConfig(config *daemon.Config) (*tls.Config, error) {
+	tlsSet := config.ContainerdTLSCACert != "" || config.ContainerdTLSCert != "" || config.ContainerdTLSKey != ""
+	if !strings.HasPrefix(config.ContainerdAddr, "tcp://") {
+		if tlsSet {
+			return nil, fmt.Errorf("--containerd-tlscacert, --containerd-tlscert and --containerd-tlskey require a tcp:// address for --containerd")
+		}
+		return nil, nil
+	}
+	if config.ContainerdTLSCACert == "" || config.ContainerdTLSCert == "" || config.ContainerdTLSKey == "" {
+		return nil, fmt.Errorf("connecting to containerd at %s requires --containerd-tlscacert, --containerd-tlscert and --containerd-tlskey", config.ContainerdAddr)
+	}
+	tlsConfig, err := tlsconfig.Client(tlsconfig.Options{
+		CAFile:   config.ContainerdTLSCACert,
+		CertFile: config.ContainerdTLSCert,
+		KeyFile:  config.ContainerdTLSKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid containerd TLS configuration: %v", err)
+	}
+	return tlsConfig, nil
 }
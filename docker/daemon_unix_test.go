@@ -4,6 +4,9 @@ package main
 
 import (
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/docker/docker/cli"
@@ -169,7 +172,7 @@ func TestLoadDaemonConfigWithTrueDefaultValues(t *testing.T) {
 
 	// make sure reloading doesn't generate configuration
 	// conflicts after normalizing boolean values.
-	err = daemon.ReloadConfiguration(configFile, flags, func(reloadedConfig *daemon.Config) {
+	err = daemon.ReloadConfiguration([]string{configFile}, flags, func(reloadedConfig *daemon.Config) {
 		if reloadedConfig.EnableUserlandProxy {
 			t.Fatal("expected userland proxy to be disabled, got enabled")
 		}
@@ -210,3 +213,117 @@ func TestLoadDaemonConfigWithTrueDefaultValuesLeaveDefaults(t *testing.T) {
 		t.Fatal("expected userland proxy to be enabled, got disabled")
 	}
 }
+
+func TestInitListeners(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-listeners-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	sock := filepath.Join(tmp, "docker.sock")
+	hosts := []string{"unix://" + sock, "tcp://127.0.0.1:0"}
+	hls, err := initListeners(hosts, false, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		for _, hl := range hls {
+			for _, l := range hl.listeners {
+				l.Close()
+			}
+		}
+	}()
+
+	if len(hls) != 2 {
+		t.Fatalf("Expected listeners for 2 hosts, got %d", len(hls))
+	}
+	if hls[0].addr != sock || hls[1].addr != "127.0.0.1:0" {
+		t.Fatalf("Expected the listeners in the order of the hosts, got %q and %q", hls[0].addr, hls[1].addr)
+	}
+	for _, hl := range hls {
+		if len(hl.listeners) != 1 {
+			t.Fatalf("Expected one listener for %s, got %d", hl.addr, len(hl.listeners))
+		}
+	}
+}
+
+func TestInitListenersWithErrors(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-listeners-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	badSock := filepath.Join(tmp, "missing", "docker.sock")
+	hosts := []string{
+		"unix://" + filepath.Join(tmp, "docker.sock"),
+		"tcp://127.0.0.1:notaport",
+		"unix://" + badSock,
+	}
+	_, err = initListeners(hosts, false, "", nil)
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "notaport") {
+		t.Fatalf("Expected the invalid host to be reported, got %q", msg)
+	}
+	if !strings.Contains(msg, badSock) {
+		t.Fatalf("Expected the failing address to be reported, got %q", msg)
+	}
+	if strings.Contains(msg, filepath.Join(tmp, "docker.sock")+":") {
+		t.Fatalf("Expected the valid host not to be reported, got %q", msg)
+	}
+}
+
+func TestInitListenersWithConflicts(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-listeners-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	sock := filepath.Join(tmp, "docker.sock")
+	hosts := []string{"unix://" + sock, "tcp://127.0.0.1:0", "unix://" + sock}
+	if _, err := initListeners(hosts, false, "", nil); err == nil || !strings.Contains(err.Error(), "given more than once") {
+		t.Fatalf("Expected the duplicate host to be reported, got %v", err)
+	}
+	if _, err := os.Stat(sock); !os.IsNotExist(err) {
+		t.Fatalf("Expected no listener to be created, got %v", err)
+	}
+}
+
+func TestContainerdTLSConfig(t *testing.T) {
+	const fixtures = "../integration-cli/fixtures/https/"
+	c := &daemon.Config{}
+	c.ContainerdAddr = "/run/containerd.sock"
+	if tlsConfig, err := containerdTLSConfig(c); err != nil || tlsConfig != nil {
+		t.Fatalf("expected no TLS configuration for a socket path, got %v, %v", tlsConfig, err)
+	}
+
+	c.ContainerdTLSCACert = fixtures + "ca.pem"
+	if _, err := containerdTLSConfig(c); err == nil {
+		t.Fatal("expected an error setting TLS material for a socket path")
+	}
+
+	c.ContainerdAddr = "tcp://127.0.0.1:2376"
+	if _, err := containerdTLSConfig(c); err == nil || !strings.Contains(err.Error(), "requires") {
+		t.Fatalf("expected an error for the missing certificate and key, got %v", err)
+	}
+
+	c.ContainerdTLSCert = fixtures + "client-cert.pem"
+	c.ContainerdTLSKey = fixtures + "missing-key.pem"
+	if _, err := containerdTLSConfig(c); err == nil {
+		t.Fatal("expected an error loading a missing key")
+	}
+
+	c.ContainerdTLSKey = fixtures + "client-key.pem"
+	tlsConfig, err := containerdTLSConfig(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tlsConfig.Certificates) != 1 || tlsConfig.RootCAs == nil {
+		t.Fatalf("expected a client certificate and a certificate authority, got %v", tlsConfig)
+	}
+}
@@ -11,7 +11,6 @@ import (
 	apiserver "github.com/docker/docker/api/server"
 	"github.com/docker/docker/daemon"
 	"github.com/docker/docker/libcontainerd"
-	"github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/system"
 )
 
@@ -41,7 +40,7 @@ func notifySystem() {
 }
 
 // setupConfigReloadTrap configures a Win32 event to reload the configuration.
-func setupConfigReloadTrap(configFile string, flags *mflag.FlagSet, reload func(*daemon.Config)) {
+func setupConfigReloadTrap(reload func()) {
 	go func() {
 		sa := syscall.SecurityAttributes{
 			Length: 0,
@@ -51,14 +50,12 @@ func setupConfigReloadTrap(configFile string, flags *mflag.FlagSet, reload func(
 			logrus.Debugf("Config reload - waiting signal at %s", ev)
 			for {
 				syscall.WaitForSingleObject(h, syscall.INFINITE)
-				if err := daemon.ReloadConfiguration(configFile, flags, reload); err != nil {
-					logrus.Error(err)
-				}
+				reload()
 			}
 		}
 	}()
 }
 
-func (cli *DaemonCli) getPlatformRemoteOptions() []libcontainerd.RemoteOption {
-	return nil
+func (cli *DaemonCli) getPlatformRemoteOptions() ([]libcontainerd.RemoteOption, error) {
+	return nil, nil
 }
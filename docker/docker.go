@@ -47,6 +47,23 @@ func main() {
 	//合并参数到flag.CommandLine中。CommandLine中对应的是Options。
 	flag.Merge(flag.CommandLine, clientFlags.FlagSet, commonFlags.FlagSet)
 
+	//创建client模式的docker，详细分析请见api/client/cli.go包中的NewDockerCli函数。
+	clientCli := client.NewDockerCli(stdin, stdout, stderr, clientFlags)
+
+	//合并client模式的docker和daemonCli模式的docker，实际中只会有一个在工作。
+	//daemonCli对象的创建请见docker/daemon.go中的daemonCli cli.Handler = NewDaemonCli()
+	//cli.New接受两个参数，分别是dockercli对象和daemoncli对象，两个对象结构不同。
+	//但是返回的是一个cli对象:
+	/*
+			type Cli struct {
+			    Stderr   io.Writer
+			    handlers []Handler
+			    Usage    func()
+		            }
+	*/
+	//clientClie和daemoncli就放在句柄handlers数组中。
+	c := cli.New(clientCli, daemonCli)
+
 	//打印docker的使用方式。这里其实只是设置Usage这个函数的实现，以便需要打印的时候打印。
 	flag.Usage = func() {
 		//第一行是正常的使用方式；第二行是daemon的使用方式；第三行固定输出
@@ -63,7 +80,7 @@ func main() {
 
 		//循环输出dockerCommands命令中的可选择命令，分别打印他们的名字和描述信息。
 		//dockerCommands最终由cli.DockerCommands中提供。
-		for _, cmd := range dockerCommands {
+		for _, cmd := range dockerCommands(c) {
 			help += fmt.Sprintf("    %-10.10s%s\n", cmd.Name, cmd.Description)
 		}
 
@@ -88,32 +105,33 @@ func main() {
 		return
 	}
 
-	//创建client模式的docker，详细分析请见api/client/cli.go包中的NewDockerCli函数。
-	clientCli := client.NewDockerCli(stdin, stdout, stderr, clientFlags)
-
-	//合并client模式的docker和daemonCli模式的docker，实际中只会有一个在工作。
-	//daemonCli对象的创建请见docker/daemon.go中的daemonCli cli.Handler = NewDaemonCli()
-	//cli.New接受两个参数，分别是dockercli对象和daemoncli对象，两个对象结构不同。
-	//但是返回的是一个cli对象:
-	/*
-			type Cli struct {
-			    Stderr   io.Writer
-			    handlers []Handler
-			    Usage    func()
-		            }
-	*/
-	//clientClie和daemoncli就放在句柄handlers数组中。
-	c := cli.New(clientCli, daemonCli)
+	run := c.Run
+	if args := flag.Args(); len(args) > 0 && args[0] == completeCommand {
+		run = func(args ...string) error {
+			return (&completeCli{cli: c, out: stdout}).CmdComplete(args[1:]...)
+		}
+	}
 	//c.Run函数见cli中的cli.go的func (cli *Cli) Run(args ...string) error
-	if err := c.Run(flag.Args()...); err != nil {
+	if err := run(flag.Args()...); err != nil {
+		// the machine-readable code of the error, if known, follows the
+		// error message for the scripts to branch on
+		code := client.ErrorCode(err)
 		if sterr, ok := err.(cli.StatusError); ok {
 			if sterr.Status != "" {
 				fmt.Fprintln(stderr, sterr.Status)
+			}
+			if code != "" {
+				fmt.Fprintf(stderr, "Reason: %s\n", code)
+			}
+			if sterr.Status != "" {
 				os.Exit(1)
 			}
 			os.Exit(sterr.StatusCode)
 		}
 		fmt.Fprintln(stderr, err)
+		if code != "" {
+			fmt.Fprintf(stderr, "Reason: %s\n", code)
+		}
 		os.Exit(1)
 	}
 }
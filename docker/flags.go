@@ -18,14 +18,13 @@ func (a byName) Len() int           { return len(a) }
 func (a byName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a byName) Less(i, j int) bool { return a[i].Name < a[j].Name }
 
-var dockerCommands []cli.Command
-
 // TODO(tiborvass): do not show 'daemon' on client-only binaries
-//初始化dockerCommands这个变量，但是不要加入daemon的相关信息。
+//返回dockerCommands，但是不要加入daemon的相关信息。
 //这里来源与cli.DockerCommands中的定义，这个定义包含了所有客户端的命令。
-func init() {
-	for _, cmd := range cli.DockerCommands {
-		dockerCommands = append(dockerCommands, cmd)
-	}
-	sort.Sort(byName(dockerCommands))
+// The list is built on each call to include the commands of handlers
+// registered at runtime with c.
+func dockerCommands(c *cli.Cli) []cli.Command {
+	commands := c.Commands()
+	sort.Sort(byName(commands))
+	return commands
 }
@@ -3,11 +3,13 @@ package main
 import (
 	"sort"
 	"testing"
+
+	"github.com/docker/docker/cli"
 )
 
 // Tests if the subcommands of docker are sorted
 func TestDockerSubcommandsAreSorted(t *testing.T) {
-	if !sort.IsSorted(byName(dockerCommands)) {
+	if !sort.IsSorted(byName(dockerCommands(cli.New()))) {
 		t.Fatal("Docker subcommands are not in sorted order")
 	}
 }
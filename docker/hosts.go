@@ -0,0 +1,99 @@
+// +build daemon
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+)
+
+// checkHostConflicts returns an error naming the hosts the daemon would
+// listen on more than once, which otherwise fail with "address already in
+// use" once the first of them is listening. hosts are the -H values given in
+// flags, normalized by opts.ParseHost; the ones that failed to parse are
+// empty. Two hosts conflict when they are the same socket, or bind the same
+// TCP port on overlapping interfaces, such as tcp://0.0.0.0:2375 and
+// tcp://127.0.0.1:2375.
+func checkHostConflicts(flags, hosts []string) error {
+	var msgs []string
+	for i, host := range hosts {
+		for j, other := range hosts[:i] {
+			if msg := hostConflict(flags[j], other, flags[i], host); msg != "" {
+				msgs = append(msgs, msg)
+				break
+			}
+		}
+	}
+	if len(msgs) > 0 {
+		return errors.New(strings.Join(msgs, "\n"))
+	}
+	return nil
+}
+
+// hostConflict describes the conflict of host b, given as -H fb, with host a
+// given as -H fa, or returns an empty string if they don't conflict.
+func hostConflict(fa, a, fb, b string) string {
+	switch {
+	case a == "" || b == "":
+		return ""
+	case a == b && fa == fb:
+		return fmt.Sprintf("-H %s is given more than once", fb)
+	case a == b:
+		return fmt.Sprintf("-H %s and -H %s are the same host %s", fa, fb, b)
+	case hostsConflict(a, b):
+		return fmt.Sprintf("-H %s and -H %s listen on the same address", fa, fb)
+	}
+	return ""
+}
+
+// hostsConflict returns whether the normalized hosts a and b, which differ,
+// listen on the same address.
+func hostsConflict(a, b string) bool {
+	pa, pb := strings.SplitN(a, "://", 2), strings.SplitN(b, "://", 2)
+	if len(pa) != 2 || len(pb) != 2 || pa[0] != pb[0] {
+		return false
+	}
+	switch pa[0] {
+	case "unix":
+		return filepath.Clean(pa[1]) == filepath.Clean(pb[1])
+	case "npipe":
+		return strings.EqualFold(pa[1], pb[1])
+	case "tcp":
+		return tcpAddrsOverlap(pa[1], pb[1])
+	}
+	return false
+}
+
+// tcpAddrsOverlap returns whether the TCP addresses a and b bind the same
+// port on overlapping interfaces. The unspecified IPv4 address overlaps the
+// IPv4 addresses, the unspecified IPv6 one, listening on both stacks, all the
+// addresses. The host names aren't resolved: they only overlap themselves
+// and the unspecified addresses.
+func tcpAddrsOverlap(a, b string) bool {
+	ha, porta, err := net.SplitHostPort(strings.SplitN(a, "/", 2)[0])
+	if err != nil {
+		return false
+	}
+	hb, portb, err := net.SplitHostPort(strings.SplitN(b, "/", 2)[0])
+	if err != nil {
+		return false
+	}
+	// port 0 picks a free port
+	if porta != portb || porta == "0" {
+		return false
+	}
+	ia, ib := net.ParseIP(ha), net.ParseIP(hb)
+	if ia == nil || ib == nil {
+		return strings.EqualFold(ha, hb) || (ia != nil && ia.IsUnspecified()) || (ib != nil && ib.IsUnspecified())
+	}
+	return ia.Equal(ib) || unspecifiedOverlaps(ia, ib) || unspecifiedOverlaps(ib, ia)
+}
+
+// unspecifiedOverlaps returns whether ip is the unspecified address of a
+// stack other covers.
+func unspecifiedOverlaps(ip, other net.IP) bool {
+	return ip.IsUnspecified() && (ip.To4() == nil || other.To4() != nil)
+}
@@ -0,0 +1,49 @@
+// +build daemon
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/opts"
+)
+
+func TestCheckHostConflicts(t *testing.T) {
+	for _, c := range []struct {
+		hosts    []string
+		conflict string
+	}{
+		{[]string{"unix:///var/run/docker.sock", "tcp://127.0.0.1:2375"}, ""},
+		{[]string{"tcp://127.0.0.1:2375", "tcp://127.0.0.1:2376"}, ""},
+		{[]string{"tcp://127.0.0.1:2375", "tcp://10.0.0.1:2375"}, ""},
+		{[]string{"tcp://0.0.0.0:2375", "tcp://[::1]:2375"}, ""},
+		{[]string{"tcp://127.0.0.1:0", "tcp://0.0.0.0:0"}, ""},
+		{[]string{"unix:///var/run/docker.sock", "unix:///var/run/docker.sock"}, "-H unix:///var/run/docker.sock is given more than once"},
+		{[]string{"unix:///var/run/docker.sock", "unix:///var/run//docker.sock"}, "listen on the same address"},
+		{[]string{"tcp://127.0.0.1:2375", "127.0.0.1:2375"}, "-H tcp://127.0.0.1:2375 and -H 127.0.0.1:2375 are the same host tcp://127.0.0.1:2375"},
+		{[]string{"tcp://0.0.0.0:2375", "tcp://127.0.0.1:2375"}, "-H tcp://0.0.0.0:2375 and -H tcp://127.0.0.1:2375 listen on the same address"},
+		{[]string{"tcp://127.0.0.1:2375", "tcp://[::]:2375"}, "listen on the same address"},
+		{[]string{"tcp://0.0.0.0:2375", "tcp://[::]:2375"}, "listen on the same address"},
+		{[]string{"tcp://localhost:2375", "tcp://0.0.0.0:2375"}, "listen on the same address"},
+		{[]string{"tcp://[::1]:2375", "tcp://[0:0::1]:2375"}, "listen on the same address"},
+	} {
+		parsed := make([]string, len(c.hosts))
+		for i, host := range c.hosts {
+			var err error
+			if parsed[i], err = opts.ParseHost(false, host); err != nil {
+				t.Fatal(err)
+			}
+		}
+		err := checkHostConflicts(c.hosts, parsed)
+		if c.conflict == "" {
+			if err != nil {
+				t.Fatalf("Expected %v not to conflict, got %v", c.hosts, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), c.conflict) {
+			t.Fatalf("Expected %v to conflict with %q, got %v", c.hosts, c.conflict, err)
+		}
+	}
+}
@@ -7,8 +7,8 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"sync"
 
-	"github.com/Sirupsen/logrus"
 	"github.com/coreos/go-systemd/activation"
 	"github.com/docker/go-connections/sockets"
 	"github.com/docker/libnetwork/portallocator"
@@ -41,29 +41,63 @@ func Init(proto, addr, socketGroup string, tlsConfig *tls.Config) (ls []net.List
 	return
 }
 
+var (
+	// activatedMu protects activated and claimedFDs, the listeners
+	// can be initialized concurrently for several fd:// hosts.
+	activatedMu sync.Mutex
+	// activated holds the socket activated listeners passed to the
+	// daemon, they are retrieved once for all the fd:// hosts.
+	activated []net.Listener
+	// claimedFDs holds the offsets in activated of the listeners
+	// already returned for a fd:// host.
+	claimedFDs = make(map[int]bool)
+
+	// activatedListeners returns the socket activated listeners, it
+	// is replaced in tests.
+	activatedListeners = func(tlsConfig *tls.Config) ([]net.Listener, error) {
+		if tlsConfig != nil {
+			return activation.TLSListeners(false, tlsConfig)
+		}
+		return activation.Listeners(false)
+	}
+)
+
 // listenFD returns the specified socket activated files as a slice of
 // net.Listeners or all of the activated files if "*" is given.
+// Several fd:// hosts can be used together as long as they do not
+// select the same file.
 func listenFD(addr string, tlsConfig *tls.Config) ([]net.Listener, error) {
-	var (
-		err       error
-		listeners []net.Listener
-	)
+	activatedMu.Lock()
+	defer activatedMu.Unlock()
+
 	// socket activation
-	if tlsConfig != nil {
-		listeners, err = activation.TLSListeners(false, tlsConfig)
-	} else {
-		listeners, err = activation.Listeners(false)
-	}
-	if err != nil {
-		return nil, err
+	if activated == nil {
+		listeners, err := activatedListeners(tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		activated = listeners
 	}
 
-	if len(listeners) == 0 {
+	if len(activated) == 0 {
 		return nil, fmt.Errorf("No sockets found. Make sure the docker daemon was started by systemd.")
 	}
 
 	// default to all fds just like unix:// and tcp://
 	if addr == "" || addr == "*" {
+		var listeners []net.Listener
+		for i, l := range activated {
+			if l == nil {
+				continue
+			}
+			if claimedFDs[i] {
+				return nil, fmt.Errorf("socket activated file at fd %d is used by more than one -H fd://", i+3)
+			}
+			listeners = append(listeners, l)
+		}
+		for i := range activated {
+			claimedFDs[i] = true
+		}
 		return listeners, nil
 	}
 
@@ -72,21 +106,17 @@ func listenFD(addr string, tlsConfig *tls.Config) ([]net.Listener, error) {
 		return nil, fmt.Errorf("failed to parse systemd address, should be number: %v", err)
 	}
 	fdOffset := fdNum - 3
-	if len(listeners) < int(fdOffset)+1 {
+	if fdOffset < 0 || len(activated) < fdOffset+1 {
 		return nil, fmt.Errorf("Too few socket activated files passed in")
 	}
-	if listeners[fdOffset] == nil {
-		return nil, fmt.Errorf("failed to listen on systemd activated file at fd %d", fdOffset+3)
+	if activated[fdOffset] == nil {
+		return nil, fmt.Errorf("failed to listen on systemd activated file at fd %d", fdNum)
 	}
-	for i, ls := range listeners {
-		if i == fdOffset || ls == nil {
-			continue
-		}
-		if err := ls.Close(); err != nil {
-			logrus.Errorf("Failed to close systemd activated file at fd %d: %v", fdOffset+3, err)
-		}
+	if claimedFDs[fdOffset] {
+		return nil, fmt.Errorf("socket activated file at fd %d is used by more than one -H fd://", fdNum)
 	}
-	return []net.Listener{listeners[fdOffset]}, nil
+	claimedFDs[fdOffset] = true
+	return []net.Listener{activated[fdOffset]}, nil
 }
 
 // allocateDaemonPort ensures that there are no containers
@@ -0,0 +1,94 @@
+// +build !windows
+
+package listeners
+
+import (
+	"crypto/tls"
+	"net"
+	"testing"
+)
+
+func setupActivatedListeners(t *testing.T, n int) []net.Listener {
+	var ls []net.Listener
+	for i := 0; i < n; i++ {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ls = append(ls, l)
+	}
+
+	activated = nil
+	claimedFDs = make(map[int]bool)
+	activatedListeners = func(*tls.Config) ([]net.Listener, error) {
+		return ls, nil
+	}
+	return ls
+}
+
+func closeListeners(ls []net.Listener) {
+	for _, l := range ls {
+		l.Close()
+	}
+}
+
+func TestListenFDMultipleHosts(t *testing.T) {
+	ls := setupActivatedListeners(t, 3)
+	defer closeListeners(ls)
+
+	l, err := Init("fd", "4", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l) != 1 || l[0] != ls[1] {
+		t.Fatalf("Expected the listener at fd 4, got %v", l)
+	}
+
+	l, err = Init("fd", "5", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l) != 1 || l[0] != ls[2] {
+		t.Fatalf("Expected the listener at fd 5, got %v", l)
+	}
+
+	if _, err := Init("fd", "4", "", nil); err == nil {
+		t.Fatal("Expected an error using fd 4 twice")
+	}
+	if _, err := Init("fd", "*", "", nil); err == nil {
+		t.Fatal("Expected an error using all fds after fd 4")
+	}
+}
+
+func TestListenFDAll(t *testing.T) {
+	ls := setupActivatedListeners(t, 2)
+	defer closeListeners(ls)
+
+	l, err := Init("fd", "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l) != 2 {
+		t.Fatalf("Expected 2 listeners, got %d", len(l))
+	}
+
+	if _, err := Init("fd", "3", "", nil); err == nil {
+		t.Fatal("Expected an error using fd 3 after all fds")
+	}
+}
+
+func TestListenFDInvalid(t *testing.T) {
+	ls := setupActivatedListeners(t, 1)
+	defer closeListeners(ls)
+
+	for _, addr := range []string{"2", "4", "foo"} {
+		if _, err := Init("fd", addr, "", nil); err == nil {
+			t.Fatalf("Expected an error for fd://%s", addr)
+		}
+	}
+
+	setupActivatedListeners(t, 0)
+	if _, err := Init("fd", "*", "", nil); err == nil {
+		t.Fatal("Expected an error without socket activated files")
+	}
+}
@@ -0,0 +1,168 @@
+// +build daemon
+
+package main
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon"
+	"github.com/docker/docker/utils"
+)
+
+// subsystemField is the field of the log entries naming their subsystem.
+// Entries without it belong to the subsystem of the package logging them.
+const subsystemField = "subsystem"
+
+// daemonLogLevels filters the daemon logs by the level of their subsystem.
+var daemonLogLevels = &subsystemLevelFormatter{}
+
+// subsystemLevelFormatter formats the log entries with the formatter of the
+// daemon, dropping the ones above the level of their subsystem. logrus only
+// has a global level, which is set to the most verbose level so that the
+// entries of every subsystem reach the formatter.
+type subsystemLevelFormatter struct {
+	mu        sync.RWMutex
+	formatter logrus.Formatter
+	base      logrus.Level
+	levels    map[string]logrus.Level
+}
+
+// setFormatter sets the formatter of the entries which are kept.
+func (f *subsystemLevelFormatter) setFormatter(formatter logrus.Formatter) {
+	f.mu.Lock()
+	f.formatter = formatter
+	f.mu.Unlock()
+	logrus.SetFormatter(f)
+}
+
+// setLevels makes the subsystems in levels log at their own level, and the
+// others at base.
+func (f *subsystemLevelFormatter) setLevels(base logrus.Level, levels map[string]logrus.Level) {
+	f.mu.Lock()
+	f.base, f.levels = base, levels
+	f.mu.Unlock()
+
+	max := base
+	for _, level := range levels {
+		if level > max {
+			max = level
+		}
+	}
+	logrus.SetLevel(max)
+}
+
+// Format implements logrus.Formatter.
+func (f *subsystemLevelFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	f.mu.RLock()
+	formatter, base, levels := f.formatter, f.base, f.levels
+	f.mu.RUnlock()
+
+	if len(levels) > 0 {
+		level, ok := levels[entrySubsystem(entry)]
+		if !ok {
+			level = base
+		}
+		if entry.Level > level {
+			return nil, nil
+		}
+	}
+	if formatter == nil {
+		formatter = &logrus.TextFormatter{}
+	}
+	return formatter.Format(entry)
+}
+
+// entrySubsystem returns the subsystem of entry, set in its subsystem field
+// or derived from the package of the function which logged it.
+func entrySubsystem(entry *logrus.Entry) string {
+	if s, ok := entry.Data[subsystemField].(string); ok {
+		return s
+	}
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(3, pcs)
+	for _, pc := range pcs[:n] {
+		fn := runtime.FuncForPC(pc - 1)
+		if fn == nil {
+			continue
+		}
+		pkg := funcPackage(fn.Name())
+		if pkg == "github.com/Sirupsen/logrus" {
+			continue
+		}
+		return packageSubsystem(pkg)
+	}
+	return "docker"
+}
+
+// funcPackage returns the import path of the package of the function name,
+// as reported by runtime.FuncForPC.
+func funcPackage(name string) string {
+	dir, base := "", name
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		dir, base = name[:i+1], name[i+1:]
+	}
+	if i := strings.Index(base, "."); i >= 0 {
+		base = base[:i]
+	}
+	return dir + base
+}
+
+// packageSubsystem returns the subsystem of the package pkg: the top-level
+// directory of the packages of the docker tree, e.g. daemon, layer or
+// distribution, and the repository name of the vendored packages, e.g.
+// libnetwork, containerd or grpc.
+func packageSubsystem(pkg string) string {
+	const dockerPrefix = "github.com/docker/docker/"
+	if pkg == "main" {
+		// the package of the docker/ directory
+		return "docker"
+	}
+	if strings.HasPrefix(pkg, dockerPrefix) {
+		return strings.SplitN(strings.TrimPrefix(pkg, dockerPrefix), "/", 2)[0]
+	}
+	parts := strings.Split(pkg, "/")
+	switch {
+	case len(parts) >= 3 && (parts[0] == "github.com" || parts[0] == "golang.org"):
+		return parts[2]
+	case len(parts) >= 2 && strings.Contains(parts[0], "."):
+		return parts[1]
+	}
+	return parts[0]
+}
+
+// setDaemonLogLevels makes the subsystems in levels log at their own level,
+// and the others at logLevel, or at the debug level in debug mode. The
+// levels are validated with the configuration.
+func setDaemonLogLevels(logLevel string, levels map[string]string) {
+	base := logrus.InfoLevel
+	if utils.IsDebugEnabled() {
+		base = logrus.DebugLevel
+	} else if logLevel != "" {
+		if lvl, err := logrus.ParseLevel(logLevel); err == nil {
+			base = lvl
+		}
+	}
+	parsed := make(map[string]logrus.Level, len(levels))
+	for subsystem, level := range levels {
+		lvl, err := logrus.ParseLevel(level)
+		if err != nil {
+			logrus.Warnf("Ignoring the log level %q of subsystem %s: %v", level, subsystem, err)
+			continue
+		}
+		parsed[subsystem] = lvl
+	}
+	daemonLogLevels.setLevels(base, parsed)
+}
+
+// reloadDaemonLogLevels applies the log levels of the subsystems of a reloaded
+// configuration, when they are set, or the current ones after the debug mode
+// is switched.
+func reloadDaemonLogLevels(cliConfig, config *daemon.Config) {
+	if config.IsValueSet("log-levels") {
+		cliConfig.LogLevels = config.LogLevels
+	}
+	setDaemonLogLevels(cliConfig.LogLevel, cliConfig.LogLevels)
+}
@@ -0,0 +1,90 @@
+// +build daemon
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Sirupsen/logrus"
+)
+
+func TestPackageSubsystem(t *testing.T) {
+	for name, expected := range map[string]string{
+		"github.com/docker/docker/layer.(*layerStore).Register":               "layer",
+		"github.com/docker/docker/daemon/graphdriver/overlay.(*Driver).Get":   "daemon",
+		"github.com/docker/libnetwork/drivers/bridge.(*driver).CreateNetwork": "libnetwork",
+		"github.com/docker/containerd/api/grpc/types.(*aPIClient).Events":     "containerd",
+		"google.golang.org/grpc.(*ClientConn).resetTransport":                 "grpc",
+		"golang.org/x/net/context.WithCancel":                                 "net",
+		"main.(*DaemonCli).CmdDaemon":                                         "docker",
+	} {
+		if subsystem := packageSubsystem(funcPackage(name)); subsystem != expected {
+			t.Fatalf("Expected the subsystem of %s to be %s, got %s", name, expected, subsystem)
+		}
+	}
+}
+
+func TestSubsystemLevelFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &logrus.Logger{
+		Out:       &buf,
+		Formatter: &subsystemLevelFormatter{formatter: &logrus.TextFormatter{DisableColors: true}},
+		Hooks:     make(logrus.LevelHooks),
+	}
+	f := logger.Formatter.(*subsystemLevelFormatter)
+	f.base = logrus.InfoLevel
+	f.levels = map[string]logrus.Level{
+		"libnetwork": logrus.DebugLevel,
+		"layer":      logrus.WarnLevel,
+	}
+	logger.Level = logrus.DebugLevel
+
+	logger.WithField(subsystemField, "libnetwork").Debug("network debug")
+	logger.WithField(subsystemField, "layer").Info("layer info")
+	logger.WithField(subsystemField, "layer").Warn("layer warning")
+	logger.WithField(subsystemField, "daemon").Debug("daemon debug")
+	logger.WithField(subsystemField, "daemon").Info("daemon info")
+
+	out := buf.String()
+	for _, kept := range []string{"network debug", "layer warning", "daemon info"} {
+		if !strings.Contains(out, kept) {
+			t.Fatalf("Expected %q to be logged, got %s", kept, out)
+		}
+	}
+	for _, dropped := range []string{"layer info", "daemon debug"} {
+		if strings.Contains(out, dropped) {
+			t.Fatalf("Expected %q to be dropped, got %s", dropped, out)
+		}
+	}
+
+	// entries without the field belong to the package logging them
+	buf.Reset()
+	logger.Debug("docker debug")
+	if strings.Contains(buf.String(), "docker debug") {
+		t.Fatalf("Expected the debug entries of the docker package to be dropped, got %s", buf.String())
+	}
+	f.levels["docker"] = logrus.DebugLevel
+	logger.Debug("docker debug")
+	if !strings.Contains(buf.String(), "docker debug") {
+		t.Fatalf("Expected the debug entries of the docker package to be logged, got %s", buf.String())
+	}
+}
+
+func TestSetDaemonLogLevels(t *testing.T) {
+	defer setDaemonLogLevels("info", nil)
+
+	setDaemonLogLevels("warn", map[string]string{"libnetwork": "debug", "layer": "error"})
+	if logrus.GetLevel() != logrus.DebugLevel {
+		t.Fatalf("Expected the global level to be the most verbose one, got %v", logrus.GetLevel())
+	}
+	if daemonLogLevels.base != logrus.WarnLevel || daemonLogLevels.levels["layer"] != logrus.ErrorLevel {
+		t.Fatalf("Expected the base level warn and the layer level error, got %v and %v", daemonLogLevels.base, daemonLogLevels.levels)
+	}
+
+	setDaemonLogLevels("warn", nil)
+	if logrus.GetLevel() != logrus.WarnLevel {
+		t.Fatalf("Expected the global level to be the level of the daemon, got %v", logrus.GetLevel())
+	}
+}
@@ -0,0 +1,107 @@
+// +build daemon
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// Events of the daemon startup reported to the startup progress.
+const (
+	startupEventConfigLoaded        = "config-loaded"
+	startupEventListenersBound      = "listeners-bound"
+	startupEventContainerdConnected = "containerd-connected"
+	startupEventDaemonInitialized   = "daemon-initialized"
+	startupEventServing             = "serving"
+	startupEventFailed              = "failed"
+)
+
+// startupReporter is the startup progress of the daemon, if any.
+var startupReporter *startupProgress
+
+// startupProgressEvent is an event of the startup progress.
+type startupProgressEvent struct {
+	Time  time.Time `json:"time"`
+	Event string    `json:"event"`
+	Phase string    `json:"phase,omitempty"`
+	Error string    `json:"error,omitempty"`
+}
+
+// startupProgress reports the progress of the daemon startup to its
+// supervisor, one JSON event per line, so that it can wait for the daemon
+// to serve the API without polling it.
+type startupProgress struct {
+	mu  sync.Mutex
+	out io.WriteCloser
+}
+
+// openStartupProgress opens the startup progress at path: a unix socket is
+// connected to, any other path, such as a regular file or a FIFO, is opened
+// for appending.
+func openStartupProgress(path string) (*startupProgress, error) {
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		conn, err := net.Dial("unix", path)
+		if err != nil {
+			return nil, err
+		}
+		return &startupProgress{out: conn}, nil
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &startupProgress{out: f}, nil
+}
+
+// report logs msg and reports event to the startup progress, if any.
+func (p *startupProgress) report(event, msg string) {
+	logrus.Info(msg)
+	p.write(startupProgressEvent{Event: event})
+}
+
+// failed reports the failure of the startup in phase.
+func (p *startupProgress) failed(phase string, err error) {
+	p.write(startupProgressEvent{Event: startupEventFailed, Phase: phase, Error: err.Error()})
+}
+
+func (p *startupProgress) write(e startupProgressEvent) {
+	if p == nil {
+		return
+	}
+	e.Time = time.Now().UTC()
+	b, err := json.Marshal(e)
+	if err != nil {
+		logrus.Errorf("Error encoding the startup progress event %s: %v", e.Event, err)
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.out == nil {
+		return
+	}
+	if _, err := p.out.Write(append(b, '\n')); err != nil {
+		logrus.Warnf("Error reporting the startup progress: %v", err)
+	}
+}
+
+// Close closes the startup progress, no event is reported afterwards.
+func (p *startupProgress) Close() error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.out == nil {
+		return nil
+	}
+	err := p.out.Close()
+	p.out = nil
+	return err
+}
@@ -0,0 +1,104 @@
+// +build daemon
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/daemon"
+)
+
+func TestStartupProgressFile(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-startup-progress-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	path := filepath.Join(tmp, "progress")
+
+	progress, err := openStartupProgress(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	progress.report(startupEventConfigLoaded, "Daemon configuration loaded")
+	progress.report(startupEventServing, "Daemon is serving the API")
+	if err := progress.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// nothing is reported once closed
+	progress.report(startupEventServing, "Daemon is serving the API")
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 events, got %q", b)
+	}
+	for i, expected := range []string{startupEventConfigLoaded, startupEventServing} {
+		var e startupProgressEvent
+		if err := json.Unmarshal([]byte(lines[i]), &e); err != nil {
+			t.Fatalf("Expected a JSON event, got %q: %v", lines[i], err)
+		}
+		if e.Event != expected || e.Time.IsZero() {
+			t.Fatalf("Expected a timestamped %s event, got %+v", expected, e)
+		}
+	}
+}
+
+func TestStartupProgressSocket(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-startup-progress-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	path := filepath.Join(tmp, "progress.sock")
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	progress, err := openStartupProgress(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer progress.Close()
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	startupReporter = progress
+	startupExit = func(int) {}
+	defer func() {
+		startupReporter = nil
+		startupExit = os.Exit
+	}()
+	config := &daemon.Config{}
+	config.LogFormat = daemon.LogFormatJSON
+	startupFailed(config, startupPhaseContainerd, errors.New("containerd did not start"))
+
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e startupProgressEvent
+	if err := json.Unmarshal(line, &e); err != nil {
+		t.Fatalf("Expected a JSON event, got %q: %v", line, err)
+	}
+	if e.Event != startupEventFailed || e.Phase != startupPhaseContainerd || e.Error != "containerd did not start" {
+		t.Fatalf("Expected the failure of the containerd phase, got %+v", e)
+	}
+}
@@ -306,7 +306,7 @@ copy_containerd() {
         (set -x
         if [ -x /usr/local/bin/docker-runc ]; then
             echo "Copying nested executables into $dir"
-	    for file in containerd containerd-shim containerd-ctr runc; do
+	    for file in containerd containerd-shim containerd-ctr runc init; do
                 cp `which "docker-$file"` "$dir/"
                 if [ "$2" == "hash" ]; then
                     hash_files "$dir/docker-$file"
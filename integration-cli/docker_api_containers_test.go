@@ -988,6 +988,21 @@ func (s *DockerSuite) TestContainerApiStart(c *check.C) {
 	}
 }
 
+func (s *DockerSuite) TestContainerApiStartPaused(c *check.C) {
+	testRequires(c, DaemonIsLinux)
+	name := "testing-start-paused"
+	dockerCmd(c, "create", "--name", name, "busybox", "top")
+
+	status, _, err := sockRequest("POST", "/containers/"+name+"/start?paused=1", nil)
+	c.Assert(err, checker.IsNil)
+	c.Assert(status, checker.Equals, http.StatusNoContent)
+	c.Assert(inspectField(c, name, "State.Running"), checker.Equals, "true")
+	c.Assert(inspectField(c, name, "State.Paused"), checker.Equals, "true")
+
+	dockerCmd(c, "unpause", name)
+	c.Assert(inspectField(c, name, "State.Paused"), checker.Equals, "false")
+}
+
 func (s *DockerSuite) TestContainerApiStop(c *check.C) {
 	name := "test-api-stop"
 	runSleepingContainer(c, "-i", "--name", name)
@@ -145,7 +145,7 @@ func (s *DockerExternalGraphdriverSuite) setUpPlugin(c *check.C, name string, ex
 		if err := decReq(r.Body, &req, w); err != nil {
 			return
 		}
-		if err := driver.Create(req.ID, req.Parent, ""); err != nil {
+		if err := driver.Create(req.ID, req.Parent, "", nil); err != nil {
 			respond(w, err)
 			return
 		}
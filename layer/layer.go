@@ -158,6 +158,24 @@ type Metadata struct {
 	DiffSize int64
 }
 
+// MountInfo holds the reference counts of a
+// read-write layer
+type MountInfo struct {
+	// Name is the name of the read-write layer
+	Name string
+
+	// MountID is the ID of the mount in the graph driver
+	MountID string
+
+	// References is the number of references
+	// held on the layer
+	References int
+
+	// Mounts is the number of times the layer
+	// is mounted through its references
+	Mounts int
+}
+
 // MountInit is a function to initialize a
 // writable mount. Changes made here will
 // not be included in the Tar stream of the
@@ -171,11 +189,12 @@ type Store interface {
 	Get(ChainID) (Layer, error)
 	Release(Layer) ([]Metadata, error)
 
-	CreateRWLayer(id string, parent ChainID, mountLabel string, initFunc MountInit) (RWLayer, error)
+	CreateRWLayer(id string, parent ChainID, mountLabel string, initFunc MountInit, storageOpt map[string]string) (RWLayer, error)
 	GetRWLayer(id string) (RWLayer, error)
 	GetMountID(id string) (string, error)
 	ReinitRWLayer(l RWLayer) error
 	ReleaseRWLayer(RWLayer) ([]Metadata, error)
+	Mounts() []MountInfo
 
 	Cleanup() error
 	DriverStatus() [][2]string
@@ -263,7 +263,7 @@ func (ls *layerStore) Register(ts io.Reader, parent ChainID) (Layer, error) {
 		references:     map[Layer]struct{}{},
 	}
 
-	if err = ls.driver.Create(layer.cacheID, pid, ""); err != nil {
+	if err = ls.driver.Create(layer.cacheID, pid, "", nil); err != nil {
 		return nil, err
 	}
 
@@ -417,7 +417,7 @@ func (ls *layerStore) Release(l Layer) ([]Metadata, error) {
 	return ls.releaseLayer(layer)
 }
 
-func (ls *layerStore) CreateRWLayer(name string, parent ChainID, mountLabel string, initFunc MountInit) (RWLayer, error) {
+func (ls *layerStore) CreateRWLayer(name string, parent ChainID, mountLabel string, initFunc MountInit, storageOpt map[string]string) (RWLayer, error) {
 	ls.mountL.Lock()
 	defer ls.mountL.Unlock()
 	m, ok := ls.mounts[name]
@@ -454,14 +454,14 @@ func (ls *layerStore) CreateRWLayer(name string, parent ChainID, mountLabel stri
 	}
 
 	if initFunc != nil {
-		pid, err = ls.initMount(m.mountID, pid, mountLabel, initFunc)
+		pid, err = ls.initMount(m.mountID, pid, mountLabel, initFunc, storageOpt)
 		if err != nil {
 			return nil, err
 		}
 		m.initID = pid
 	}
 
-	if err = ls.driver.Create(m.mountID, pid, ""); err != nil {
+	if err = ls.driver.Create(m.mountID, pid, "", storageOpt); err != nil {
 		return nil, err
 	}
 
@@ -495,6 +495,30 @@ func (ls *layerStore) GetMountID(id string) (string, error) {
 	return mount.mountID, nil
 }
 
+// Mounts returns the reference counts of all the read-write layers.
+func (ls *layerStore) Mounts() []MountInfo {
+	ls.mountL.Lock()
+	defer ls.mountL.Unlock()
+
+	mounts := make([]MountInfo, 0, len(ls.mounts))
+	for _, m := range ls.mounts {
+		info := MountInfo{
+			Name:       m.name,
+			MountID:    m.mountID,
+			References: len(m.references),
+		}
+		for _, ref := range m.references {
+			ref.activityL.Lock()
+			if ref.activityCount > 0 {
+				info.Mounts += ref.activityCount
+			}
+			ref.activityL.Unlock()
+		}
+		mounts = append(mounts, info)
+	}
+	return mounts
+}
+
 // ReinitRWLayer reinitializes a given mount to the layerstore, specifically
 // initializing the usage count. It should strictly only be used in the
 // daemon's restore path to restore state of live containers.
@@ -583,14 +607,14 @@ func (ls *layerStore) saveMount(mount *mountedLayer) error {
 	return nil
 }
 
-func (ls *layerStore) initMount(graphID, parent, mountLabel string, initFunc MountInit) (string, error) {
+func (ls *layerStore) initMount(graphID, parent, mountLabel string, initFunc MountInit, storageOpt map[string]string) (string, error) {
 	// Use "<graph-id>-init" to maintain compatibility with graph drivers
 	// which are expecting this layer with this special name. If all
 	// graph drivers can be updated to not rely on knowing about this layer
 	// then the initID should be randomly generated.
 	initID := fmt.Sprintf("%s-init", graphID)
 
-	if err := ls.driver.Create(initID, parent, mountLabel); err != nil {
+	if err := ls.driver.Create(initID, parent, mountLabel, storageOpt); err != nil {
 		return "", err
 	}
 	p, err := ls.driver.Get(initID, "")
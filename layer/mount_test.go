@@ -32,7 +32,7 @@ func TestMountInit(t *testing.T) {
 		return initfile.ApplyFile(root)
 	}
 
-	m, err := ls.CreateRWLayer("fun-mount", layer.ChainID(), "", mountInit)
+	m, err := ls.CreateRWLayer("fun-mount", layer.ChainID(), "", mountInit, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -89,7 +89,7 @@ func TestMountSize(t *testing.T) {
 		return newTestFile("file-init", contentInit, 0777).ApplyFile(root)
 	}
 
-	m, err := ls.CreateRWLayer("mount-size", layer.ChainID(), "", mountInit)
+	m, err := ls.CreateRWLayer("mount-size", layer.ChainID(), "", mountInit, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -138,7 +138,7 @@ func TestMountChanges(t *testing.T) {
 		return initfile.ApplyFile(root)
 	}
 
-	m, err := ls.CreateRWLayer("mount-changes", layer.ChainID(), "", mountInit)
+	m, err := ls.CreateRWLayer("mount-changes", layer.ChainID(), "", mountInit, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -228,3 +228,57 @@ func (cs *changeSorter) Swap(i, j int) {
 func (cs *changeSorter) Less(i, j int) bool {
 	return cs.changes[i].Path < cs.changes[j].Path
 }
+
+func TestMounts(t *testing.T) {
+	// TODO Windows: Figure out why this is failing
+	if runtime.GOOS == "windows" {
+		t.Skip("Failing on Windows")
+	}
+	ls, _, cleanup := newTestStore(t)
+	defer cleanup()
+
+	li := initWithFiles(newTestFile("testfile.txt", []byte("base data!"), 0644))
+	layer, err := createLayer(ls, "", li)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := ls.CreateRWLayer("counted-mount", layer.ChainID(), "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Mount(""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Mount(""); err != nil {
+		t.Fatal(err)
+	}
+
+	mounts := ls.Mounts()
+	if len(mounts) != 1 {
+		t.Fatalf("Expected 1 mount, got %d", len(mounts))
+	}
+	if mounts[0].Name != "counted-mount" || mounts[0].MountID == "" {
+		t.Fatalf("Unexpected mount %+v", mounts[0])
+	}
+	if mounts[0].References != 1 || mounts[0].Mounts != 2 {
+		t.Fatalf("Expected 1 reference mounted twice, got %+v", mounts[0])
+	}
+
+	if err := m.Unmount(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Unmount(); err != nil {
+		t.Fatal(err)
+	}
+	if mounts := ls.Mounts(); mounts[0].Mounts != 0 {
+		t.Fatalf("Expected the layer to be unmounted, got %+v", mounts[0])
+	}
+
+	if _, err := ls.ReleaseRWLayer(m); err != nil {
+		t.Fatal(err)
+	}
+	if mounts := ls.Mounts(); len(mounts) != 0 {
+		t.Fatalf("Expected no mount, got %+v", mounts)
+	}
+}
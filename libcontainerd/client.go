@@ -35,6 +35,17 @@ func (clnt *client) deleteContainer(friendlyName string) {
 	clnt.mapMutex.Unlock()
 }
 
+// containerIDs returns the IDs of the containers tracked by the client.
+func (clnt *client) containerIDs() []string {
+	clnt.mapMutex.RLock()
+	defer clnt.mapMutex.RUnlock()
+	ids := make([]string, 0, len(clnt.containers))
+	for id := range clnt.containers {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
 func (clnt *client) getContainer(containerID string) (*container, error) {
 	clnt.mapMutex.RLock()
 	container, ok := clnt.containers[containerID]
@@ -3,8 +3,10 @@ package libcontainerd
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"syscall"
@@ -13,6 +15,7 @@ import (
 	containerd "github.com/docker/containerd/api/grpc/types"
 	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/mount"
+	"github.com/docker/docker/utils"
 	"github.com/opencontainers/specs/specs-go"
 	"golang.org/x/net/context"
 )
@@ -87,7 +90,7 @@ func (clnt *client) AddProcess(containerID, processFriendlyName string, specp Pr
 		return err
 	}
 
-	if _, err := clnt.remote.apiClient.AddProcess(context.Background(), r); err != nil {
+	if _, err := clnt.remote.api().AddProcess(context.Background(), r); err != nil {
 		p.closeFifos(iopipe)
 		return err
 	}
@@ -130,7 +133,23 @@ func (clnt *client) prepareBundleDir(uid, gid int) (string, error) {
 }
 
 //容器的启动方法
-func (clnt *client) Create(containerID string, spec Spec, options ...CreateOption) (err error) {
+func (clnt *client) Create(ctx context.Context, containerID string, spec Spec, options ...CreateOption) error {
+	container, err := clnt.create(ctx, containerID, spec, options...)
+	if err != nil {
+		return err
+	}
+	// wait for the pause requested by WithStartPaused to be reported, the
+	// container lock must be released for the event to be handled.
+	if container.pausedOnStart != nil {
+		container.waitPausedOnStart(ctx)
+	}
+	return nil
+}
+
+func (clnt *client) create(ctx context.Context, containerID string, spec Spec, options ...CreateOption) (_ *container, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	clnt.lock(containerID)
 	defer clnt.unlock(containerID)
 
@@ -140,18 +159,18 @@ func (clnt *client) Create(containerID string, spec Spec, options ...CreateOptio
 			ctr.restartManager.Cancel()
 			ctr.clean()
 		} else {
-			return fmt.Errorf("Container %s is aleady active", containerID)
+			return nil, fmt.Errorf("Container %s is aleady active", containerID)
 		}
 	}
 
 	uid, gid, err := getRootIDs(specs.Spec(spec))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	//准备工作目录。
 	dir, err := clnt.prepareBundleDir(uid, gid)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	//创建容器，容器目录这个时候应该已经有了。
@@ -159,7 +178,7 @@ func (clnt *client) Create(containerID string, spec Spec, options ...CreateOptio
 
 	//删除容器的增量文件，这就保证了容器每次启动时都是初始化的，干净的。
 	if err := container.clean(); err != nil {
-		return err
+		return nil, err
 	}
 
 	defer func() {
@@ -170,29 +189,36 @@ func (clnt *client) Create(containerID string, spec Spec, options ...CreateOptio
 	}()
 
 	if err := idtools.MkdirAllAs(container.dir, 0700, uid, gid); err != nil && !os.IsExist(err) {
-		return err
+		return nil, err
 	}
 
 	//根据根文件创建容器临时文件。
 	f, err := os.Create(filepath.Join(container.dir, configFilename))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer f.Close()
 	//解析spec，保存到/var/lib/docker/containers/目录下，作为容器的元数据。
 	//后面真实启动的时候也是读取这个文件吧。
 	if err := json.NewEncoder(f).Encode(spec); err != nil {
-		return err
+		return nil, err
 	}
 
 	//调用libcontainer/container_linux.go中的start()方法启动容器。
-	return container.start()
+	return container, container.start(ctx)
 }
 
 func (clnt *client) Signal(containerID string, sig int) error {
 	clnt.lock(containerID)
 	defer clnt.unlock(containerID)
-	_, err := clnt.remote.apiClient.Signal(context.Background(), &containerd.SignalRequest{
+	if ctr, err := clnt.getContainer(containerID); err == nil && ctr.signalGroup {
+		err := ctr.signalProcessGroup(sig)
+		if err == nil {
+			return nil
+		}
+		logrus.Warnf("Failed to signal the process group of container %s, signaling its first process only: %v", containerID, err)
+	}
+	_, err := clnt.remote.api().Signal(context.Background(), &containerd.SignalRequest{
 		Id:     containerID,
 		Pid:    InitFriendlyName,
 		Signal: uint32(sig),
@@ -206,7 +232,7 @@ func (clnt *client) Resize(containerID, processFriendlyName string, width, heigh
 	if _, err := clnt.getContainer(containerID); err != nil {
 		return err
 	}
-	_, err := clnt.remote.apiClient.UpdateProcess(context.Background(), &containerd.UpdateProcessRequest{
+	_, err := clnt.remote.api().UpdateProcess(context.Background(), &containerd.UpdateProcessRequest{
 		Id:     containerID,
 		Pid:    processFriendlyName,
 		Width:  uint32(width),
@@ -235,7 +261,7 @@ func (clnt *client) setState(containerID, state string) error {
 		st = "paused"
 	}
 	chstate := make(chan struct{})
-	_, err = clnt.remote.apiClient.UpdateContainer(context.Background(), &containerd.UpdateContainerRequest{
+	_, err = clnt.remote.api().UpdateContainer(context.Background(), &containerd.UpdateContainerRequest{
 		Id:     containerID,
 		Pid:    InitFriendlyName,
 		Status: st,
@@ -255,7 +281,7 @@ func (clnt *client) Resume(containerID string) error {
 }
 
 func (clnt *client) Stats(containerID string) (*Stats, error) {
-	resp, err := clnt.remote.apiClient.Stats(context.Background(), &containerd.StatsRequest{containerID})
+	resp, err := clnt.remote.api().Stats(context.Background(), &containerd.StatsRequest{containerID})
 	if err != nil {
 		return nil, err
 	}
@@ -317,7 +343,7 @@ func (clnt *client) Summary(containerID string) ([]Summary, error) {
 }
 
 func (clnt *client) getContainerdContainer(containerID string) (*containerd.Container, error) {
-	resp, err := clnt.remote.apiClient.State(context.Background(), &containerd.StateRequest{Id: containerID})
+	resp, err := clnt.remote.api().State(context.Background(), &containerd.StateRequest{Id: containerID})
 	if err != nil {
 		return nil, err
 	}
@@ -361,7 +387,7 @@ func (clnt *client) UpdateResources(containerID string, resources Resources) err
 	if container.systemPid == 0 {
 		return fmt.Errorf("No active process for container %s", containerID)
 	}
-	_, err = clnt.remote.apiClient.UpdateContainer(context.Background(), &containerd.UpdateContainerRequest{
+	_, err = clnt.remote.api().UpdateContainer(context.Background(), &containerd.UpdateContainerRequest{
 		Id:        containerID,
 		Pid:       InitFriendlyName,
 		Resources: (*containerd.UpdateResource)(&resources),
@@ -372,6 +398,23 @@ func (clnt *client) UpdateResources(containerID string, resources Resources) err
 	return nil
 }
 
+// Restore restores the state of a container after the daemon restarted. The
+// container is re-attached to and keeps running with live restore, the
+// default of experimental builds, or WithLiveRestore, and is stopped
+// otherwise.
+func (clnt *client) Restore(containerID string, options ...CreateOption) error {
+	ctr := &container{}
+	for _, option := range options {
+		if err := option.Apply(ctr); err != nil {
+			return err
+		}
+	}
+	if utils.ExperimentalBuild() || ctr.liveRestore {
+		return clnt.liveRestore(containerID, options...)
+	}
+	return clnt.shutdownRestore(containerID)
+}
+
 func (clnt *client) getExitNotifier(containerID string) *exitNotifier {
 	clnt.mapMutex.RLock()
 	defer clnt.mapMutex.RUnlock()
@@ -383,7 +426,7 @@ func (clnt *client) getOrCreateExitNotifier(containerID string) *exitNotifier {
 	w, ok := clnt.exitNotifiers[containerID]
 	defer clnt.mapMutex.Unlock()
 	if !ok {
-		w = &exitNotifier{c: make(chan struct{}), client: clnt}
+		w = &exitNotifier{id: containerID, c: make(chan struct{}), client: clnt}
 		clnt.exitNotifiers[containerID] = w
 	}
 	return w
@@ -409,3 +452,189 @@ func (en *exitNotifier) close() {
 func (en *exitNotifier) wait() <-chan struct{} {
 	return en.c
 }
+
+// reconcile reports the exits of the containers and processes which
+// containerd stopped running while the events stream was broken, and
+// notifies the backend once they are reported.
+func (clnt *client) reconcile() {
+	var notifiers []*exitNotifier
+	for _, id := range clnt.containerIDs() {
+		events, err := clnt.missedExits(id)
+		if err != nil {
+			logrus.Warnf("libcontainerd: failed to reconcile the state of container %s: %v", id, err)
+			continue
+		}
+		ctr, err := clnt.getContainer(id)
+		if err != nil {
+			// deleted since the IDs were listed
+			continue
+		}
+		for _, e := range events {
+			logrus.Warnf("libcontainerd: process %s of container %s exited while containerd was unreachable", e.Pid, id)
+			if e.Pid == InitFriendlyName {
+				notifiers = append(notifiers, clnt.getOrCreateExitNotifier(id))
+			}
+			if err := ctr.handleEvent(e); err != nil {
+				logrus.Errorf("error processing state change for %s: %v", id, err)
+			}
+		}
+	}
+	for _, w := range notifiers {
+		<-w.wait()
+	}
+	if err := clnt.backend.ContainerdReconnected(); err != nil {
+		logrus.Error(err)
+	}
+}
+
+// missedExits returns the exit events of the container and of its processes
+// tracked by the client which containerd doesn't run anymore. Their exit
+// code is unknown.
+func (clnt *client) missedExits(containerID string) ([]*containerd.Event, error) {
+	clnt.lock(containerID)
+	defer clnt.unlock(containerID)
+	ctr, err := clnt.getContainer(containerID)
+	if err != nil {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), clnt.remote.healthCheckTimeout)
+	defer cancel()
+	resp, err := clnt.remote.api().State(ctx, &containerd.StateRequest{Id: containerID})
+	if err != nil {
+		return nil, err
+	}
+	running := make(map[string]bool)
+	for _, cont := range resp.Containers {
+		if cont.Id != containerID || cont.Status == "stopped" {
+			continue
+		}
+		for _, p := range cont.Processes {
+			running[p.Pid] = true
+		}
+	}
+	exit := func(name string) *containerd.Event {
+		return &containerd.Event{
+			Type:   StateExit,
+			Id:     containerID,
+			Pid:    name,
+			Status: exitCodeUnknown,
+		}
+	}
+	if !running[InitFriendlyName] {
+		return []*containerd.Event{exit(InitFriendlyName)}, nil
+	}
+	var events []*containerd.Event
+	for name := range ctr.processes {
+		if name != InitFriendlyName && !running[name] {
+			events = append(events, exit(name))
+		}
+	}
+	return events, nil
+}
+
+// OrphanedDirs returns the container directories in the libcontainerd state
+// directory that are not used by any container known to the client or
+// listed in known. Such directories are left behind when the daemon exits
+// between the exit of a container and its cleanup.
+func (clnt *client) OrphanedDirs(known map[string]bool) ([]string, error) {
+	root, err := filepath.Abs(clnt.remote.stateDir)
+	if err != nil {
+		return nil, err
+	}
+	fis, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	var orphans []string
+	for _, fi := range fis {
+		// the socket, pid and event timestamp files of containerd and the
+		// retained bundles live next to the container directories
+		if !fi.IsDir() || fi.Name() == retainedBundlesDirname {
+			continue
+		}
+		id := fi.Name()
+		if known[id] {
+			continue
+		}
+		if _, err := clnt.getContainer(id); err == nil {
+			continue
+		}
+		orphans = append(orphans, filepath.Join(root, id))
+	}
+	return orphans, nil
+}
+
+// CleanupOrphanedDirs removes the directories returned by OrphanedDirs.
+// Nothing is removed if LIBCONTAINERD_NOCLEAN is set to 1, the directories
+// are only reported.
+func (clnt *client) CleanupOrphanedDirs(known map[string]bool) ([]string, error) {
+	orphans, err := clnt.OrphanedDirs(known)
+	if err != nil {
+		return nil, err
+	}
+	if os.Getenv("LIBCONTAINERD_NOCLEAN") == "1" {
+		return orphans, nil
+	}
+	for _, dir := range orphans {
+		if err := os.RemoveAll(dir); err != nil {
+			logrus.Warnf("libcontainerd: failed to remove orphaned container directory %s: %v", dir, err)
+		}
+	}
+	return orphans, nil
+}
+
+// RetainedBundles returns the bundle directories retained for the
+// containers that exited with an error, oldest first.
+func (clnt *client) RetainedBundles() ([]string, error) {
+	return clnt.remote.retainedBundleDirs()
+}
+
+// ListProcesses returns the processes known for each container tracked by
+// the client: the ones it tracks and the ones containerd runs, which were
+// not added by the client since the daemon restarted. The PID of a process
+// containerd doesn't run anymore is 0, its exit was not reported.
+func (clnt *client) ListProcesses() (map[string][]ProcessInfo, error) {
+	resp, err := clnt.remote.api().State(context.Background(), &containerd.StateRequest{})
+	if err != nil {
+		return nil, err
+	}
+	running := make(map[string]map[string]uint32)
+	for _, cont := range resp.Containers {
+		pids := make(map[string]uint32)
+		for _, p := range cont.Processes {
+			pids[p.Pid] = p.SystemPid
+		}
+		running[cont.Id] = pids
+	}
+
+	processes := make(map[string][]ProcessInfo)
+	for _, id := range clnt.containerIDs() {
+		clnt.lock(id)
+		ctr, err := clnt.getContainer(id)
+		if err != nil {
+			// deleted since the IDs were listed
+			clnt.unlock(id)
+			continue
+		}
+		known := map[string]bool{InitFriendlyName: true}
+		for name := range ctr.processes {
+			known[name] = true
+		}
+		clnt.unlock(id)
+		for name := range running[id] {
+			known[name] = true
+		}
+		names := make([]string, 0, len(known))
+		for name := range known {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		infos := make([]ProcessInfo, 0, len(names))
+		for _, name := range names {
+			infos = append(infos, ProcessInfo{FriendlyName: name, Pid: running[id][name]})
+		}
+		processes[id] = infos
+	}
+	return processes, nil
+}
@@ -0,0 +1,228 @@
+package libcontainerd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	containerd "github.com/docker/containerd/api/grpc/types"
+	"github.com/docker/docker/pkg/locker"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+func TestCleanupOrphanedDirs(t *testing.T) {
+	root, err := ioutil.TempDir("", "libcontainerd-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	for _, id := range []string{"known", "running", "orphan"} {
+		if err := os.Mkdir(filepath.Join(root, id), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, containerdPidFilename), []byte("1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	clnt := &client{
+		clientCommon: clientCommon{
+			containers: map[string]*container{"running": {}},
+		},
+		remote: &remote{stateDir: root},
+	}
+	known := map[string]bool{"known": true}
+	expected := []string{filepath.Join(root, "orphan")}
+
+	orphans, err := clnt.OrphanedDirs(known)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(orphans, expected) {
+		t.Fatalf("expected orphans %v, got %v", expected, orphans)
+	}
+
+	os.Setenv("LIBCONTAINERD_NOCLEAN", "1")
+	orphans, err = clnt.CleanupOrphanedDirs(known)
+	os.Unsetenv("LIBCONTAINERD_NOCLEAN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(orphans, expected) {
+		t.Fatalf("expected orphans %v, got %v", expected, orphans)
+	}
+	if _, err := os.Stat(expected[0]); err != nil {
+		t.Fatalf("expected %s to be kept with LIBCONTAINERD_NOCLEAN, got %v", expected[0], err)
+	}
+
+	if _, err := clnt.CleanupOrphanedDirs(known); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(expected[0]); !os.IsNotExist(err) {
+		t.Fatalf("expected %s to be removed, got %v", expected[0], err)
+	}
+	for _, id := range []string{"known", "running"} {
+		if _, err := os.Stat(filepath.Join(root, id)); err != nil {
+			t.Fatalf("expected %s to be kept, got %v", id, err)
+		}
+	}
+}
+
+func TestRetainBundles(t *testing.T) {
+	root, err := ioutil.TempDir("", "libcontainerd-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	clnt := &client{
+		remote: &remote{stateDir: root, retainedBundles: 2},
+	}
+	exit := func(id string, status uint32) {
+		ctr := clnt.newContainer(filepath.Join(root, id))
+		if err := os.Mkdir(ctr.dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(ctr.dir, configFilename), []byte("{}"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := ctr.cleanExited(status); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(ctr.dir); !os.IsNotExist(err) {
+			t.Fatalf("expected the bundle of %s to be moved or removed, got %v", id, err)
+		}
+	}
+	for _, id := range []string{"first", "second", "succeeded", "third"} {
+		status := uint32(1)
+		if id == "succeeded" {
+			status = 0
+		}
+		exit(id, status)
+	}
+
+	retained, err := clnt.RetainedBundles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(retained) != 2 || !strings.HasSuffix(retained[0], "-second") || !strings.HasSuffix(retained[1], "-third") {
+		t.Fatalf("expected the bundles of second and third to be retained, got %v", retained)
+	}
+	if _, err := os.Stat(filepath.Join(retained[1], configFilename)); err != nil {
+		t.Fatalf("expected the spec of third to be retained, got %v", err)
+	}
+
+	orphans, err := clnt.OrphanedDirs(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orphans) != 0 {
+		t.Fatalf("expected the retained bundles not to be reported as orphans, got %v", orphans)
+	}
+}
+
+func TestWithStartPaused(t *testing.T) {
+	clnt := &client{}
+	if ctr := clnt.newContainer("/run/containerd/plain"); ctr.startPaused {
+		t.Fatal("expected the container to start running by default")
+	}
+	ctr := clnt.newContainer("/run/containerd/paused", WithStartPaused())
+	if !ctr.startPaused {
+		t.Fatal("expected the container to start paused")
+	}
+
+	ctr.pausedOnStart = make(chan struct{})
+	ctr.pauseMonitor.append(StatePause, ctr.pausedOnStart)
+	ctr.pauseMonitor.handle(StateResume)
+	select {
+	case <-ctr.pausedOnStart:
+		t.Fatal("expected to wait for the pause event")
+	default:
+	}
+	ctr.pauseMonitor.handle(StatePause)
+	select {
+	case <-ctr.pausedOnStart:
+	default:
+		t.Fatal("expected the pause event to be reported")
+	}
+}
+
+func TestWaitPausedOnStartTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { startPausedTimeout = timeout }(startPausedTimeout)
+	startPausedTimeout = 10 * time.Millisecond
+
+	ctr := (&client{}).newContainer("/run/containerd/paused", WithStartPaused())
+	ctr.pausedOnStart = make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		ctr.waitPausedOnStart(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the wait for the pause event to time out")
+	}
+}
+
+// stateAPIClient reports the containers in its state.
+type stateAPIClient struct {
+	containerd.APIClient
+	containers []*containerd.Container
+}
+
+func (c *stateAPIClient) State(ctx context.Context, r *containerd.StateRequest, opts ...grpc.CallOption) (*containerd.StateResponse, error) {
+	return &containerd.StateResponse{Containers: c.containers}, nil
+}
+
+func TestListProcesses(t *testing.T) {
+	api := &stateAPIClient{
+		containers: []*containerd.Container{
+			{
+				Id: "ctr",
+				Processes: []*containerd.Process{
+					{Pid: InitFriendlyName, SystemPid: 100},
+					{Pid: "exec1", SystemPid: 101},
+					{Pid: "exec2", SystemPid: 102},
+				},
+			},
+			{
+				Id:        "unknown",
+				Processes: []*containerd.Process{{Pid: InitFriendlyName, SystemPid: 200}},
+			},
+		},
+	}
+	clnt := &client{
+		clientCommon: clientCommon{
+			containers: make(map[string]*container),
+			locker:     locker.New(),
+		},
+		remote: &remote{apiClient: api},
+	}
+	ctr := clnt.newContainer("/run/containerd/ctr")
+	ctr.processes["exec1"] = ctr.newProcess("exec1")
+	ctr.processes["exited"] = ctr.newProcess("exited")
+	clnt.appendContainer(ctr)
+
+	processes, err := clnt.ListProcesses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]ProcessInfo{
+		"ctr": {
+			{FriendlyName: "exec1", Pid: 101},
+			{FriendlyName: "exec2", Pid: 102},
+			{FriendlyName: "exited", Pid: 0},
+			{FriendlyName: InitFriendlyName, Pid: 100},
+		},
+	}
+	if !reflect.DeepEqual(processes, expected) {
+		t.Fatalf("Expected processes %v, got %v", expected, processes)
+	}
+}
@@ -1,5 +1,3 @@
-// +build experimental
-
 package libcontainerd
 
 import (
@@ -71,7 +69,9 @@ func (clnt *client) restore(cont *containerd.Container, options ...CreateOption)
 	return nil
 }
 
-func (clnt *client) Restore(containerID string, options ...CreateOption) error {
+// liveRestore re-attaches to the container if it is still running, keeping
+// it running.
+func (clnt *client) liveRestore(containerID string, options ...CreateOption) error {
 	cont, err := clnt.getContainerdContainer(containerID)
 	if err == nil && cont.Status != "stopped" {
 		if err := clnt.restore(cont, options...); err != nil {
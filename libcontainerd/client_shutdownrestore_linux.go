@@ -1,5 +1,3 @@
-// +build !experimental
-
 package libcontainerd
 
 import (
@@ -9,7 +7,8 @@ import (
 	"github.com/Sirupsen/logrus"
 )
 
-func (clnt *client) Restore(containerID string, options ...CreateOption) error {
+// shutdownRestore stops the container if it is still running.
+func (clnt *client) shutdownRestore(containerID string) error {
 	w := clnt.getOrCreateExitNotifier(containerID)
 	defer w.close()
 	cont, err := clnt.getContainerdContainer(containerID)
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -14,6 +15,7 @@ import (
 
 	"github.com/Microsoft/hcsshim"
 	"github.com/Sirupsen/logrus"
+	"golang.org/x/net/context"
 )
 
 type client struct {
@@ -107,9 +109,15 @@ const defaultOwner = "docker"
 
 // Create is the entrypoint to create a container from a spec, and if successfully
 // created, start it too.
-func (clnt *client) Create(containerID string, spec Spec, options ...CreateOption) error {
+func (clnt *client) Create(ctx context.Context, containerID string, spec Spec, options ...CreateOption) error {
 	logrus.Debugln("LCD client.Create() with spec", spec)
 
+	// The compute system cannot be interrupted once it is being created,
+	// so cancellation is only honored before that.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	cu := &containerInit{
 		SystemType: "Container",
 		Name:       containerID,
@@ -565,3 +573,53 @@ func (clnt *client) UpdateResources(containerID string, resources Resources) err
 	// but we should return nil for enabling updating container
 	return nil
 }
+
+// OrphanedDirs returns the container directories left behind in the state
+// directory. Windows does not keep per-container directories, so there is
+// nothing to report.
+func (clnt *client) OrphanedDirs(known map[string]bool) ([]string, error) {
+	return nil, nil
+}
+
+// CleanupOrphanedDirs is a no-op on Windows.
+func (clnt *client) CleanupOrphanedDirs(known map[string]bool) ([]string, error) {
+	return nil, nil
+}
+
+// RetainedBundles returns the retained bundle directories. Windows does not
+// keep per-container directories, so there is nothing to report.
+func (clnt *client) RetainedBundles() ([]string, error) {
+	return nil, nil
+}
+
+// ListProcesses returns the processes tracked for each container by the
+// client.
+func (clnt *client) ListProcesses() (map[string][]ProcessInfo, error) {
+	processes := make(map[string][]ProcessInfo)
+	for _, id := range clnt.containerIDs() {
+		clnt.lock(id)
+		ctr, err := clnt.getContainer(id)
+		if err != nil {
+			// deleted since the IDs were listed
+			clnt.unlock(id)
+			continue
+		}
+		pids := map[string]uint32{InitFriendlyName: ctr.systemPid}
+		for name, p := range ctr.processes {
+			pids[name] = p.systemPid
+		}
+		clnt.unlock(id)
+
+		names := make([]string, 0, len(pids))
+		for name := range pids {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		infos := make([]ProcessInfo, 0, len(names))
+		for _, name := range names {
+			infos = append(infos, ProcessInfo{FriendlyName: name, Pid: pids[name]})
+		}
+		processes[id] = infos
+	}
+	return processes, nil
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/docker/docker/restartmanager"
+	"golang.org/x/net/context"
 )
 
 const (
@@ -17,6 +18,10 @@ const (
 type containerCommon struct {
 	process
 	restartManager restartmanager.RestartManager
+	startLimiter   StartLimiter
+	startPaused    bool
+	signalGroup    bool
+	liveRestore    bool
 	restarting     bool
 	processes      map[string]*process
 	startedAt      time.Time
@@ -38,3 +43,83 @@ func (rm restartManager) Apply(p interface{}) error {
 	}
 	return fmt.Errorf("WithRestartManager option not supported for this client")
 }
+
+// StartLimiter limits the number of containers starting concurrently.
+type StartLimiter interface {
+	// Acquire blocks until a container start can proceed, or ctx is done.
+	Acquire(ctx context.Context) error
+	// Release signals the end of a container start.
+	Release()
+}
+
+// WithStartLimiter sets the limiter the container goes through when it is
+// restarted by its restart manager.
+func WithStartLimiter(l StartLimiter) CreateOption {
+	return startLimiter{l}
+}
+
+type startLimiter struct {
+	l StartLimiter
+}
+
+func (sl startLimiter) Apply(p interface{}) error {
+	if pr, ok := p.(*container); ok {
+		pr.startLimiter = sl.l
+		return nil
+	}
+	return fmt.Errorf("WithStartLimiter option not supported for this client")
+}
+
+// WithStartPaused freezes the container right after its process is started,
+// leaving it paused until it is resumed. containerd starts the process when
+// it creates the container, so the process may run briefly before it is
+// frozen. Only the first start of the container is affected, restarts run it
+// normally.
+func WithStartPaused() CreateOption {
+	return startPaused{}
+}
+
+type startPaused struct{}
+
+func (startPaused) Apply(p interface{}) error {
+	if pr, ok := p.(*container); ok {
+		pr.startPaused = true
+		return nil
+	}
+	return fmt.Errorf("WithStartPaused option not supported for this client")
+}
+
+// WithProcessGroupSignals sends the signals of the container to the process
+// group of its first process rather than to that process only, so that they
+// reach its children even when it doesn't forward them itself.
+func WithProcessGroupSignals() CreateOption {
+	return processGroupSignals{}
+}
+
+type processGroupSignals struct{}
+
+func (processGroupSignals) Apply(p interface{}) error {
+	if pr, ok := p.(*container); ok {
+		pr.signalGroup = true
+		return nil
+	}
+	return fmt.Errorf("WithProcessGroupSignals option not supported for this client")
+}
+
+// WithLiveRestore keeps the container running when it is restored after the
+// daemon restarted, even when live restore, the default of experimental
+// builds, is disabled. The container is re-attached to, with its restart
+// manager, instead of being stopped.
+func WithLiveRestore() CreateOption {
+	return liveRestore{}
+}
+
+type liveRestore struct{}
+
+func (liveRestore) Apply(p interface{}) error {
+	if pr, ok := p.(*container); ok {
+		pr.liveRestore = true
+		return nil
+	}
+	return fmt.Errorf("WithLiveRestore option not supported for this client")
+}
@@ -2,6 +2,7 @@ package libcontainerd
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
@@ -14,6 +15,17 @@ import (
 	"github.com/docker/docker/restartmanager"
 	"github.com/opencontainers/specs/specs-go"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	// createRetryCount is the number of times a create request failing
+	// because containerd is unavailable is retried.
+	createRetryCount = 5
+	// createRetryDelay is the delay before the first retry, it doubles
+	// with every subsequent retry.
+	createRetryDelay = 100 * time.Millisecond
 )
 
 type container struct {
@@ -22,6 +34,9 @@ type container struct {
 	// Platform specific fields are below here.
 	pauseMonitor
 	oom bool
+	// pausedOnStart is closed once the freeze requested by WithStartPaused
+	// has been reported by containerd.
+	pausedOnStart chan struct{}
 }
 
 func (ctr *container) clean() error {
@@ -41,6 +56,17 @@ func (ctr *container) clean() error {
 	return nil
 }
 
+// cleanExited removes the bundle directory of a container which exited
+// with the given status. The bundle of a container which exited with an
+// error is retained instead, when the remote is configured to.
+func (ctr *container) cleanExited(status uint32) error {
+	remote := ctr.client.remote
+	if status == 0 || remote.retainedBundles == 0 || os.Getenv("LIBCONTAINERD_NOCLEAN") == "1" {
+		return ctr.clean()
+	}
+	return remote.retainBundle(ctr.dir, ctr.containerID)
+}
+
 // cleanProcess removes the fifos used by an additional process.
 // Caller needs to lock container ID before calling this method.
 func (ctr *container) cleanProcess(id string) {
@@ -66,7 +92,7 @@ func (ctr *container) spec() (*specs.Spec, error) {
 	return &spec, nil
 }
 
-func (ctr *container) start() error {
+func (ctr *container) start(ctx context.Context) error {
 	//从文件中读取配置，准备启动容器。
 	spec, err := ctr.spec()
 	if err != nil {
@@ -86,7 +112,13 @@ func (ctr *container) start() error {
 		Stdout:     ctr.fifo(syscall.Stdout),
 		Stderr:     ctr.fifo(syscall.Stderr),
 		// check to see if we are running in ramdisk to disable pivot root
-		NoPivotRoot: os.Getenv("DOCKER_RAMDISK") != "",
+		NoPivotRoot: ctr.client.remote.noPivotRoot || os.Getenv("DOCKER_RAMDISK") != "",
+	}
+	// the creation cannot be aborted once containerd was asked to create
+	// the container, so cancellation is only honored before that
+	if err := ctx.Err(); err != nil {
+		ctr.closeFifos(iopipe)
+		return err
 	}
 	//列表中增加容器
 	ctr.client.appendContainer(ctr)
@@ -105,13 +137,21 @@ func (ctr *container) start() error {
 	*/
 	//跟到这里怎么断了啊？这个有点麻烦了。
 	//这里通过restapi协议调用containerd的api接口，由containerd调用containerd-shm再调用runC实现。
-	resp, err := ctr.client.remote.apiClient.CreateContainer(context.Background(), r)
+	resp, err := ctr.createContainer(ctx, r)
 	if err != nil {
 		ctr.closeFifos(iopipe)
-		return err
+		return classifyExecError(err)
 	}
 	ctr.startedAt = time.Now()
 
+	if ctr.startPaused {
+		ctr.startPaused = false
+		if err := ctr.freeze(); err != nil {
+			ctr.closeFifos(iopipe)
+			return err
+		}
+	}
+
 	if err := ctr.client.backend.AttachStreams(ctr.containerID, *iopipe); err != nil {
 		return err
 	}
@@ -124,6 +164,102 @@ func (ctr *container) start() error {
 	})
 }
 
+// createContainer sends the create request r to containerd. Requests failing
+// because containerd is unavailable, e.g. while it restarts, are retried with
+// a backoff as long as the container hasn't been deleted, and ctx hasn't been
+// cancelled, in the meantime. A request is never interrupted by ctx, as the
+// container may then be created without the daemon knowing about it.
+func (ctr *container) createContainer(ctx context.Context, r *containerd.CreateContainerRequest) (*containerd.CreateContainerResponse, error) {
+	delay := createRetryDelay
+	for i := 0; ; i++ {
+		resp, err := ctr.client.remote.api().CreateContainer(context.Background(), r)
+		if err == nil || i == createRetryCount || !isTransientError(err) {
+			return resp, err
+		}
+		logrus.Warnf("Failed to create container %s, retrying in %v: %v", ctr.containerID, delay, err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		delay *= 2
+		if c, err := ctr.client.getContainer(ctr.containerID); err != nil || c != ctr {
+			return nil, fmt.Errorf("container %s was deleted while it was being created", ctr.containerID)
+		}
+	}
+}
+
+// isTransientError returns true if err means containerd couldn't be
+// reached, rather than that it rejected the request.
+func isTransientError(err error) bool {
+	return grpc.Code(err) == codes.Unavailable || err == grpc.ErrClientConnTimeout
+}
+
+// freeze pauses the container right after its creation. The container is
+// killed if it cannot be paused, so that it never runs unnoticed.
+func (ctr *container) freeze() error {
+	_, err := ctr.client.remote.api().UpdateContainer(context.Background(), &containerd.UpdateContainerRequest{
+		Id:     ctr.containerID,
+		Pid:    InitFriendlyName,
+		Status: "paused",
+	})
+	if err != nil {
+		if _, kerr := ctr.client.remote.api().Signal(context.Background(), &containerd.SignalRequest{
+			Id:     ctr.containerID,
+			Pid:    InitFriendlyName,
+			Signal: uint32(syscall.SIGKILL),
+		}); kerr != nil {
+			logrus.Errorf("failed to kill container %s: %v", ctr.containerID, kerr)
+		}
+		return fmt.Errorf("Cannot pause container %s on start: %v", ctr.containerID, err)
+	}
+	ctr.pausedOnStart = make(chan struct{})
+	ctr.pauseMonitor.append(StatePause, ctr.pausedOnStart)
+	return nil
+}
+
+// startPausedTimeout bounds the wait for containerd to report the pause
+// requested by WithStartPaused.
+var startPausedTimeout = 10 * time.Second
+
+// waitPausedOnStart waits for the pause event of a container frozen on
+// start, so that the container is reported paused once it is started. The
+// freezer already holds the container when the event is late, so the start
+// doesn't fail on timeout and the state is updated when the event arrives.
+func (ctr *container) waitPausedOnStart(ctx context.Context) {
+	select {
+	case <-ctr.pausedOnStart:
+	case <-ctx.Done():
+	case <-time.After(startPausedTimeout):
+		logrus.Warnf("libcontainerd: timed out waiting for container %s to be reported paused", ctr.containerID)
+	}
+}
+
+// signalProcessGroup sends sig to the process group of the first process of
+// the container. The first process must lead its group, so that processes
+// outside of the container are never signaled. Its pid is read from the live
+// state of containerd rather than cached, and the caller holds the lock of
+// the container, so that its exit can't be processed meanwhile and its pid
+// reused by an unrelated process.
+func (ctr *container) signalProcessGroup(sig int) error {
+	cont, err := ctr.client.getContainerdContainer(ctr.containerID)
+	if err != nil {
+		return err
+	}
+	pid := int(systemPid(cont))
+	if pid == 0 || uint32(pid) != ctr.systemPid {
+		return fmt.Errorf("container %s has no running process", ctr.containerID)
+	}
+	pgid, err := syscall.Getpgid(pid)
+	if err != nil {
+		return err
+	}
+	if pgid != pid {
+		return fmt.Errorf("process %d of container %s doesn't lead its process group", pid, ctr.containerID)
+	}
+	return syscall.Kill(-pgid, syscall.Signal(sig))
+}
+
 func (ctr *container) newProcess(friendlyName string) *process {
 	return &process{
 		dir: ctr.dir,
@@ -139,19 +275,30 @@ func (ctr *container) handleEvent(e *containerd.Event) error {
 	ctr.client.lock(ctr.containerID)
 	defer ctr.client.unlock(ctr.containerID)
 	switch e.Type {
-	case StateExit, StatePause, StateResume, StateOOM:
+	case StateOOM:
+		// report the OOM right away, the exit that follows it is
+		// reported separately with OOMKilled set.
+		ctr.oom = true
+		ctr.client.q.append(e.Id, func() {
+			if err := ctr.client.backend.OOM(e.Id); err != nil {
+				logrus.Error(err)
+			}
+		})
+	case StateExit, StatePause, StateResume:
 		st := StateInfo{
 			State:     e.Type,
 			ExitCode:  e.Status,
 			OOMKilled: e.Type == StateExit && ctr.oom,
 		}
-		if e.Type == StateOOM {
-			ctr.oom = true
-		}
 		if e.Type == StateExit && e.Pid != InitFriendlyName {
 			st.ProcessID = e.Pid
 			st.State = StateExitProcess
 		}
+		// restartAttempt is the number of the restart scheduled, if any
+		var (
+			restartAttempt int
+			restartDelay   time.Duration
+		)
 		if st.State == StateExit && ctr.restartManager != nil {
 			restart, wait, err := ctr.restartManager.ShouldRestart(e.Status, false, time.Since(ctr.startedAt))
 			if err != nil {
@@ -160,14 +307,19 @@ func (ctr *container) handleEvent(e *containerd.Event) error {
 				st.State = StateRestart
 				ctr.restarting = true
 				ctr.client.deleteContainer(e.Id)
+				restartAttempt, restartDelay = ctr.restartManager.Scheduled()
 				go func() {
 					err := <-wait
+					if err == nil && ctr.startLimiter != nil {
+						ctr.startLimiter.Acquire(context.Background())
+						defer ctr.startLimiter.Release()
+					}
 					ctr.client.lock(ctr.containerID)
 					defer ctr.client.unlock(ctr.containerID)
 					ctr.restarting = false
 					if err != nil {
 						st.State = StateExit
-						ctr.clean()
+						ctr.cleanExited(st.ExitCode)
 						ctr.client.q.append(e.Id, func() {
 							if err := ctr.client.backend.StateChanged(e.Id, st); err != nil {
 								logrus.Error(err)
@@ -177,7 +329,7 @@ func (ctr *container) handleEvent(e *containerd.Event) error {
 							logrus.Error(err)
 						}
 					} else {
-						ctr.start()
+						ctr.start(context.Background())
 					}
 				}()
 			}
@@ -187,7 +339,9 @@ func (ctr *container) handleEvent(e *containerd.Event) error {
 		// We need to do so here in case the Message Handler decides to restart it.
 		switch st.State {
 		case StateExit:
-			ctr.clean()
+			if err := ctr.cleanExited(st.ExitCode); err != nil {
+				logrus.Warnf("libcontainerd: failed to clean up container %s: %v", ctr.containerID, err)
+			}
 			ctr.client.deleteContainer(e.Id)
 		case StateExitProcess:
 			ctr.cleanProcess(st.ProcessID)
@@ -196,6 +350,11 @@ func (ctr *container) handleEvent(e *containerd.Event) error {
 			if err := ctr.client.backend.StateChanged(e.Id, st); err != nil {
 				logrus.Error(err)
 			}
+			if restartAttempt > 0 {
+				if err := ctr.client.backend.RestartScheduled(e.Id, restartAttempt, restartDelay); err != nil {
+					logrus.Error(err)
+				}
+			}
 			if e.Type == StatePause || e.Type == StateResume {
 				ctr.pauseMonitor.handle(e.Type)
 			}
@@ -220,7 +379,7 @@ func (ctr *container) discardFifos() {
 		c := make(chan struct{})
 		go func() {
 			close(c) // this channel is used to not close the writer too early, before readonly open has been called.
-			io.Copy(ioutil.Discard, openReaderFromFifo(f))
+			io.Copy(ioutil.Discard, openReaderFromFifoTimeout(f, ctr.client.remote.fifoTimeout))
 		}()
 		<-c
 		closeReaderFifo(f) // avoid blocking permanently on open if there is no writer side
@@ -0,0 +1,223 @@
+package libcontainerd
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	containerd "github.com/docker/containerd/api/grpc/types"
+	"github.com/docker/docker/pkg/locker"
+	"github.com/docker/docker/restartmanager"
+	containertypes "github.com/docker/engine-api/types/container"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// flakyAPIClient fails the first create requests with err.
+type flakyAPIClient struct {
+	containerd.APIClient
+	failures int
+	err      error
+	calls    int
+}
+
+func (c *flakyAPIClient) CreateContainer(ctx context.Context, r *containerd.CreateContainerRequest, opts ...grpc.CallOption) (*containerd.CreateContainerResponse, error) {
+	c.calls++
+	if c.calls <= c.failures {
+		return nil, c.err
+	}
+	return &containerd.CreateContainerResponse{Container: &containerd.Container{Id: r.Id}}, nil
+}
+
+func newFlakyContainer(api *flakyAPIClient) *container {
+	clnt := &client{
+		clientCommon: clientCommon{containers: make(map[string]*container)},
+		remote:       &remote{apiClient: api},
+	}
+	ctr := clnt.newContainer("/run/containerd/flaky")
+	clnt.appendContainer(ctr)
+	return ctr
+}
+
+func TestCreateContainerRetry(t *testing.T) {
+	api := &flakyAPIClient{failures: 2, err: grpc.Errorf(codes.Unavailable, "transport is closing")}
+	ctr := newFlakyContainer(api)
+
+	resp, err := ctr.createContainer(context.Background(), &containerd.CreateContainerRequest{Id: "flaky"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Container.Id != "flaky" || api.calls != 3 {
+		t.Fatalf("Expected the create request to succeed on the third call, got %d calls", api.calls)
+	}
+}
+
+func TestCreateContainerNoRetry(t *testing.T) {
+	api := &flakyAPIClient{failures: 1, err: errors.New("invalid bundle")}
+	ctr := newFlakyContainer(api)
+
+	if _, err := ctr.createContainer(context.Background(), &containerd.CreateContainerRequest{Id: "flaky"}); err != api.err {
+		t.Fatalf("Expected error %v, got %v", api.err, err)
+	}
+	if api.calls != 1 {
+		t.Fatalf("Expected a permanent error not to be retried, got %d calls", api.calls)
+	}
+}
+
+func TestCreateContainerRetryDeleted(t *testing.T) {
+	api := &flakyAPIClient{failures: 1, err: grpc.Errorf(codes.Unavailable, "transport is closing")}
+	ctr := newFlakyContainer(api)
+	ctr.client.deleteContainer("flaky")
+
+	if _, err := ctr.createContainer(context.Background(), &containerd.CreateContainerRequest{Id: "flaky"}); err == nil {
+		t.Fatal("Expected the create request not to be retried for a deleted container")
+	}
+	if api.calls != 1 {
+		t.Fatalf("Expected a single call, got %d", api.calls)
+	}
+}
+
+// signalAPIClient records the signals sent through containerd.
+type signalAPIClient struct {
+	containerd.APIClient
+	signals []uint32
+	pid     uint32
+}
+
+func (c *signalAPIClient) State(ctx context.Context, r *containerd.StateRequest, opts ...grpc.CallOption) (*containerd.StateResponse, error) {
+	return &containerd.StateResponse{
+		Containers: []*containerd.Container{{
+			Id:        r.Id,
+			Processes: []*containerd.Process{{Pid: InitFriendlyName, SystemPid: c.pid}},
+		}},
+	}, nil
+}
+
+func (c *signalAPIClient) Signal(ctx context.Context, r *containerd.SignalRequest, opts ...grpc.CallOption) (*containerd.SignalResponse, error) {
+	c.signals = append(c.signals, r.Signal)
+	return &containerd.SignalResponse{}, nil
+}
+
+func TestSignalProcessGroup(t *testing.T) {
+	api := &signalAPIClient{}
+	clnt := &client{
+		clientCommon: clientCommon{
+			containers: make(map[string]*container),
+			locker:     locker.New(),
+		},
+		remote: &remote{apiClient: api},
+	}
+	ctr := clnt.newContainer("/run/containerd/ctr", WithProcessGroupSignals())
+	clnt.appendContainer(ctr)
+
+	// the shell doesn't forward the signal to the sleep, which only exits
+	// if it is signaled along with its process group
+	cmd := exec.Command("sh", "-c", "sleep 60; exit 0")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	ctr.systemPid = uint32(cmd.Process.Pid)
+
+	// a cached pid which containerd no longer reports is never signaled
+	api.pid = ctr.systemPid + 1
+	if err := clnt.Signal("ctr", 0); err != nil {
+		t.Fatal(err)
+	}
+	if len(api.signals) != 1 {
+		t.Fatalf("Expected the signal to be sent through containerd, got %v", api.signals)
+	}
+	api.signals = nil
+
+	api.pid = ctr.systemPid
+	if err := clnt.Signal("ctr", int(syscall.SIGTERM)); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("Expected the process group to be terminated")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timeout waiting for the process group to be terminated")
+	}
+	if len(api.signals) != 0 {
+		t.Fatalf("Expected the signal not to be sent through containerd, got %v", api.signals)
+	}
+
+	// a process which doesn't lead its group is signaled through containerd
+	member := exec.Command("sleep", "60")
+	if err := member.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		member.Process.Kill()
+		member.Wait()
+	}()
+	ctr.systemPid = uint32(member.Process.Pid)
+	api.pid = ctr.systemPid
+	if err := clnt.Signal("ctr", int(syscall.SIGTERM)); err != nil {
+		t.Fatal(err)
+	}
+	if len(api.signals) != 1 || api.signals[0] != uint32(syscall.SIGTERM) {
+		t.Fatalf("Expected the signal to be sent through containerd, got %v", api.signals)
+	}
+}
+
+// recordingBackend records the notifications of the client.
+type recordingBackend struct {
+	Backend
+	notifications chan string
+}
+
+func (b *recordingBackend) StateChanged(containerID string, state StateInfo) error {
+	b.notifications <- state.State
+	return nil
+}
+
+func (b *recordingBackend) RestartScheduled(containerID string, attempt int, delay time.Duration) error {
+	b.notifications <- fmt.Sprintf("restart %d after %s", attempt, delay)
+	return nil
+}
+
+func (b *recordingBackend) ContainerdReconnected() error {
+	b.notifications <- "reconnected"
+	return nil
+}
+
+func TestHandleEventRestartScheduled(t *testing.T) {
+	backend := &recordingBackend{notifications: make(chan string, 2)}
+	clnt := &client{
+		clientCommon: clientCommon{
+			backend:    backend,
+			containers: make(map[string]*container),
+			locker:     locker.New(),
+		},
+		remote: &remote{apiClient: &signalAPIClient{}},
+	}
+	rm := restartmanager.NewWithBackoff(containertypes.RestartPolicy{Name: "always"}, restartmanager.Backoff{Base: time.Hour})
+	defer rm.Cancel()
+	ctr := clnt.newContainer("/run/containerd/ctr", WithRestartManager(rm))
+	clnt.appendContainer(ctr)
+
+	if err := ctr.handleEvent(&containerd.Event{Type: StateExit, Id: "ctr", Pid: InitFriendlyName, Status: 1}); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{StateRestart, "restart 1 after 1h0m0s"} {
+		select {
+		case n := <-backend.notifications:
+			if n != expected {
+				t.Fatalf("Expected notification %q, got %q", expected, n)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Timeout waiting for notification %q", expected)
+		}
+	}
+}
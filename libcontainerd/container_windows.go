@@ -8,6 +8,7 @@ import (
 
 	"github.com/Microsoft/hcsshim"
 	"github.com/Sirupsen/logrus"
+	"golang.org/x/net/context"
 )
 
 type container struct {
@@ -77,7 +78,7 @@ func (ctr *container) start() error {
 		} else {
 			logrus.Debugln("Cleaned up after failed CreateProcessInComputeSystem by calling TerminateComputeSystem")
 		}
-		return err
+		return classifyExecError(err)
 	}
 	ctr.startedAt = time.Now()
 
@@ -137,6 +138,12 @@ func (ctr *container) waitExit(pid uint32, processFriendlyName string, isFirstPr
 		ProcessID: processFriendlyName,
 	}
 
+	// restartAttempt is the number of the restart scheduled, if any
+	var (
+		restartAttempt int
+		restartDelay   time.Duration
+	)
+
 	// But it could have been an exec'd process which exited
 	if !isFirstProcessToStart {
 		si.State = StateExitProcess
@@ -172,6 +179,7 @@ func (ctr *container) waitExit(pid uint32, processFriendlyName string, isFirstPr
 			} else if restart {
 				si.State = StateRestart
 				ctr.restarting = true
+				restartAttempt, restartDelay = ctr.restartManager.Scheduled()
 				go func() {
 					err := <-wait
 					ctr.restarting = false
@@ -182,7 +190,11 @@ func (ctr *container) waitExit(pid uint32, processFriendlyName string, isFirstPr
 						}
 						logrus.Error(err)
 					} else {
-						ctr.client.Create(ctr.containerID, ctr.ociSpec, ctr.options...)
+						if ctr.startLimiter != nil {
+							ctr.startLimiter.Acquire(context.Background())
+							defer ctr.startLimiter.Release()
+						}
+						ctr.client.Create(context.Background(), ctr.containerID, ctr.ociSpec, ctr.options...)
 					}
 				}()
 			}
@@ -200,6 +212,11 @@ func (ctr *container) waitExit(pid uint32, processFriendlyName string, isFirstPr
 	if err := ctr.client.backend.StateChanged(ctr.containerID, si); err != nil {
 		logrus.Error(err)
 	}
+	if restartAttempt > 0 {
+		if err := ctr.client.backend.RestartScheduled(ctr.containerID, restartAttempt, restartDelay); err != nil {
+			logrus.Error(err)
+		}
+	}
 
 	logrus.Debugln("waitExit() completed OK")
 	return nil
@@ -0,0 +1,105 @@
+package libcontainerd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrExecNotFound is the kind of ExecError returned when the command
+	// of a container can't be found.
+	ErrExecNotFound = errors.New("executable file not found")
+
+	// ErrExecNotPermitted is the kind of ExecError returned when the
+	// command of a container can't be invoked.
+	ErrExecNotPermitted = errors.New("executable file not permitted")
+)
+
+// ExecError is returned by Create when the process of the container can't
+// be executed. Err is the kind of the error, ErrExecNotFound or
+// ErrExecNotPermitted.
+type ExecError struct {
+	Err    error
+	Detail string
+}
+
+func (e *ExecError) Error() string {
+	return e.Detail
+}
+
+// execNotFoundMessages and execNotPermittedMessages are the messages the
+// runtimes use to report that the process of a container can't be executed.
+var (
+	execNotFoundMessages = []string{
+		"executable file not found",
+		"no such file or directory",
+		"system cannot find the file specified",
+	}
+	execNotPermittedMessages = []string{
+		"permission denied",
+	}
+)
+
+// classifyExecError returns an *ExecError if err, returned by the runtime
+// when starting a container, means the process of the container couldn't
+// be executed. Otherwise it returns err as is.
+func classifyExecError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	for _, m := range execNotFoundMessages {
+		if strings.Contains(msg, m) {
+			return &ExecError{Err: ErrExecNotFound, Detail: msg}
+		}
+	}
+	for _, m := range execNotPermittedMessages {
+		if strings.Contains(msg, m) {
+			return &ExecError{Err: ErrExecNotPermitted, Detail: msg}
+		}
+	}
+	return err
+}
+
+// The kinds of ConnectionError, the causes of the failures to connect to
+// containerd.
+var (
+	// ErrSocketNotFound is the kind of ConnectionError returned when the
+	// socket of containerd doesn't exist.
+	ErrSocketNotFound = errors.New("containerd socket not found")
+
+	// ErrNotASocket is the kind of ConnectionError returned when the path
+	// of the socket of containerd is not a socket.
+	ErrNotASocket = errors.New("containerd address is not a socket")
+
+	// ErrPermissionDenied is the kind of ConnectionError returned when the
+	// daemon isn't allowed to connect to the socket of containerd.
+	ErrPermissionDenied = errors.New("permission denied connecting to containerd")
+
+	// ErrNotListening is the kind of ConnectionError returned when nothing
+	// listens on the address of containerd, e.g. a stale socket.
+	ErrNotListening = errors.New("containerd is not listening")
+
+	// ErrDialTimeout is the kind of ConnectionError returned when
+	// connecting to containerd times out.
+	ErrDialTimeout = errors.New("timeout connecting to containerd")
+
+	// ErrNotResponding is the kind of ConnectionError returned when
+	// containerd accepts connections but fails to answer the API, e.g.
+	// because its version doesn't match the daemon's.
+	ErrNotResponding = errors.New("containerd accepted the connection but its API failed, check its version matches the daemon's")
+)
+
+// ConnectionError is returned by New when containerd can't be reached at
+// Addr. Err is the kind of the error, the cause diagnosed from the address,
+// and Detail the error of the connection.
+type ConnectionError struct {
+	Addr   string
+	Err    error
+	Detail error
+}
+
+func (e *ConnectionError) Error() string {
+	return fmt.Sprintf("Failed to connect to containerd at %s: %v: %v", e.Addr, e.Err, e.Detail)
+}
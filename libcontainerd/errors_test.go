@@ -0,0 +1,40 @@
+package libcontainerd
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClassifyExecError(t *testing.T) {
+	cases := []struct {
+		msg  string
+		kind error
+	}{
+		{`rpc error: code = 2 desc = "oci runtime error: exec: \"foo\": executable file not found in $PATH"`, ErrExecNotFound},
+		{`rpc error: code = 2 desc = "oci runtime error: exec: \"/bin/foo\": stat /bin/foo: no such file or directory"`, ErrExecNotFound},
+		{`container 1234: CreateProcessInComputeSystem failed: The system cannot find the file specified.`, ErrExecNotFound},
+		{`rpc error: code = 2 desc = "oci runtime error: exec: \"/etc/hosts\": permission denied"`, ErrExecNotPermitted},
+		{`rpc error: code = 2 desc = "oci runtime error: could not synchronise with container process: device or resource busy"`, nil},
+	}
+
+	for _, c := range cases {
+		err := classifyExecError(errors.New(c.msg))
+		execErr, ok := err.(*ExecError)
+		if c.kind == nil {
+			if ok {
+				t.Fatalf("Expected %q not to be an exec error, got %v", c.msg, execErr.Err)
+			}
+			continue
+		}
+		if !ok || execErr.Err != c.kind {
+			t.Fatalf("Expected %q to be classified as %v, got %#v", c.msg, c.kind, err)
+		}
+		if execErr.Error() != c.msg {
+			t.Fatalf("Expected the runtime message to be kept, got %q", execErr.Error())
+		}
+	}
+
+	if classifyExecError(nil) != nil {
+		t.Fatal("Expected no error")
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"os"
 	"path/filepath"
 	"syscall"
+	"time"
 
+	"github.com/Sirupsen/logrus"
 	containerd "github.com/docker/containerd/api/grpc/types"
 	"github.com/docker/docker/pkg/ioutils"
 	"golang.org/x/net/context"
@@ -54,7 +56,7 @@ func (p *process) openFifos(terminal bool) (*IOPipe, error) {
 
 	io.Stdin = ioutils.NewWriteCloserWrapper(stdinf, func() error {
 		stdinf.Close()
-		_, err := p.client.remote.apiClient.UpdateProcess(context.Background(), &containerd.UpdateProcessRequest{
+		_, err := p.client.remote.api().UpdateProcess(context.Background(), &containerd.UpdateProcessRequest{
 			Id:         p.containerID,
 			Pid:        p.friendlyName,
 			CloseStdin: true,
@@ -78,11 +80,19 @@ func (r emptyReader) Read(b []byte) (int, error) {
 }
 
 func openReaderFromFifo(fn string) io.Reader {
+	return openReaderFromFifoTimeout(fn, 0)
+}
+
+// openReaderFromFifoTimeout is like openReaderFromFifo but stops waiting for
+// the writer side of the fifo after timeout. A zero timeout waits forever.
+func openReaderFromFifoTimeout(fn string, timeout time.Duration) io.Reader {
 	r, w := io.Pipe()
 	c := make(chan struct{})
+	opened := make(chan struct{})
 	go func() {
 		close(c)
 		stdoutf, err := os.OpenFile(fn, syscall.O_RDONLY, 0)
+		close(opened)
 		if err != nil {
 			r.CloseWithError(err)
 		}
@@ -93,6 +103,16 @@ func openReaderFromFifo(fn string) io.Reader {
 		stdoutf.Close()
 	}()
 	<-c // wait for the goroutine to get scheduled and syscall to block
+	if timeout > 0 {
+		go func() {
+			select {
+			case <-opened:
+			case <-time.After(timeout):
+				logrus.Warnf("libcontainerd: no writer for fifo %s after %v, giving up", fn, timeout)
+				closeReaderFifo(fn) // unblocks the pending open
+			}
+		}()
+	}
 	return r
 }
 
@@ -0,0 +1,37 @@
+package libcontainerd
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestOpenReaderFromFifoTimeout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "libcontainerd-fifo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fn := filepath.Join(dir, "stdout")
+	if err := syscall.Mkfifo(fn, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing opens the writer side of the fifo, so the reader only stops
+	// waiting for it with the timeout
+	done := make(chan struct{})
+	go func() {
+		io.Copy(ioutil.Discard, openReaderFromFifoTimeout(fn, 50*time.Millisecond))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		closeReaderFifo(fn)
+		t.Fatal("Expected the fifo to stop being waited for after the timeout")
+	}
+}
@@ -1,6 +1,7 @@
 package libcontainerd
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -9,6 +10,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -22,6 +24,7 @@ import (
 	"github.com/docker/docker/utils"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/grpclog"
 	"google.golang.org/grpc/transport"
 )
@@ -34,6 +37,24 @@ const (
 	containerdPidFilename     = "docker-containerd.pid"
 	containerdSockFilename    = "docker-containerd.sock"
 	eventTimestampFilename    = "event.ts"
+	// retainedBundlesDirname is the directory of the state directory the
+	// bundles of the containers that exited with an error are moved to.
+	retainedBundlesDirname = "retained"
+	// exitCodeUnknown is the exit code reported for the containers which
+	// exited while the daemon was disconnected from containerd.
+	exitCodeUnknown = 255
+)
+
+var (
+	// healthCheckInterval is the default interval between the checks of
+	// the connection to containerd.
+	healthCheckInterval = 10 * time.Second
+	// healthCheckTimeout is how long containerd has to answer a check by
+	// default.
+	healthCheckTimeout = 5 * time.Second
+	// connectTimeout is how long New waits for the connection to
+	// containerd to be ready.
+	connectTimeout = 10 * time.Second
 )
 
 type remote struct {
@@ -42,6 +63,7 @@ type remote struct {
 	daemonPid     int
 	stateDir      string
 	rpcAddr       string
+	rpcTLS        *tls.Config
 	startDaemon   bool
 	closeManually bool
 	debugLog      bool
@@ -50,20 +72,32 @@ type remote struct {
 	eventTsPath   string
 	pastEvents    map[string]*containerd.Event
 	runtimeArgs   []string
+	fifoTimeout   time.Duration
+	noPivotRoot   bool
+	// retainedBundles is the number of bundles of containers that exited
+	// with an error kept for debugging.
+	retainedBundles   int
+	retainedBundlesMu sync.Mutex
+	// healthCheckInterval and healthCheckTimeout are the interval between
+	// the checks of the connection to containerd and their timeout.
+	healthCheckInterval time.Duration
+	healthCheckTimeout  time.Duration
 }
 
 // New creates a fresh instance of libcontainerd remote.
 func New(stateDir string, options ...RemoteOption) (_ Remote, err error) {
 	defer func() {
-		if err != nil {
+		if _, ok := err.(*ConnectionError); err != nil && !ok {
 			err = fmt.Errorf("Failed to connect to containerd. Please make sure containerd is installed in your PATH or you have specificed the correct address. Got error: %v", err)
 		}
 	}()
 	r := &remote{
-		stateDir:    stateDir,
-		daemonPid:   -1,
-		eventTsPath: filepath.Join(stateDir, eventTimestampFilename),
-		pastEvents:  make(map[string]*containerd.Event),
+		stateDir:            stateDir,
+		daemonPid:           -1,
+		eventTsPath:         filepath.Join(stateDir, eventTimestampFilename),
+		pastEvents:          make(map[string]*containerd.Event),
+		healthCheckInterval: healthCheckInterval,
+		healthCheckTimeout:  healthCheckTimeout,
 	}
 	for _, option := range options {
 		if err := option.Apply(r); err != nil {
@@ -74,6 +108,9 @@ func New(stateDir string, options ...RemoteOption) (_ Remote, err error) {
 	if err := sysinfo.MkdirAll(stateDir, 0700); err != nil {
 		return nil, err
 	}
+	if err := r.pruneRetainedBundles(); err != nil {
+		logrus.Warnf("libcontainerd: failed to prune retained bundles: %v", err)
+	}
 
 	if r.rpcAddr == "" {
 		r.rpcAddr = filepath.Join(stateDir, containerdSockFilename)
@@ -87,39 +124,159 @@ func New(stateDir string, options ...RemoteOption) (_ Remote, err error) {
 
 	// don't output the grpc reconnect logging
 	grpclog.SetLogger(log.New(ioutil.Discard, "", log.LstdFlags))
-	dialOpts := append([]grpc.DialOption{grpc.WithInsecure()},
-		grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
-			return net.DialTimeout("unix", addr, timeout)
-		}),
-	)
-	conn, err := grpc.Dial(r.rpcAddr, dialOpts...)
+	target, dialOpts, err := r.dialOptions()
 	if err != nil {
-		return nil, fmt.Errorf("error connecting to containerd: %v", err)
+		return nil, err
+	}
+	conn, err := grpc.Dial(target, dialOpts...)
+	if err != nil {
+		return nil, diagnoseConnection(r.rpcAddr, fmt.Errorf("error connecting to containerd: %v", err))
 	}
 
 	r.rpcConn = conn
 	r.apiClient = containerd.NewAPIClient(conn)
 
-	go r.handleConnectionChange()
+	go r.handleConnectionChange(conn)
 
+	if err := waitForConnection(conn, connectTimeout); err != nil {
+		conn.Close()
+		return nil, diagnoseConnection(r.rpcAddr, err)
+	}
 	if err := r.startEventsMonitor(); err != nil {
-		return nil, err
+		conn.Close()
+		return nil, diagnoseConnection(r.rpcAddr, err)
 	}
 
+	go r.monitorConnection()
+
 	return r, nil
 }
 
-func (r *remote) handleConnectionChange() {
+// waitForConnection waits for conn to be ready for at most timeout.
+func waitForConnection(conn *grpc.ClientConn, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	state, err := conn.State()
+	for err == nil && state != grpc.Ready {
+		if state == grpc.Shutdown {
+			return fmt.Errorf("connection to containerd shut down")
+		}
+		state, err = conn.WaitForStateChange(ctx, state)
+	}
+	if err != nil {
+		return fmt.Errorf("error waiting for the connection to containerd: %v", err)
+	}
+	return nil
+}
+
+// diagnoseTimeout is how long diagnoseConnection waits for containerd to
+// accept a connection.
+var diagnoseTimeout = 2 * time.Second
+
+// diagnoseConnection returns a *ConnectionError telling why containerd
+// can't be reached at addr, a unix socket or a tcp:// address, after
+// connecting to it failed with err.
+func diagnoseConnection(addr string, err error) *ConnectionError {
+	cerr := &ConnectionError{Addr: addr, Detail: err}
+	network, address := "unix", addr
+	if strings.HasPrefix(addr, "tcp://") {
+		network, address = "tcp", strings.TrimPrefix(addr, "tcp://")
+	} else {
+		fi, statErr := os.Stat(addr)
+		switch {
+		case os.IsNotExist(statErr):
+			cerr.Err = ErrSocketNotFound
+		case os.IsPermission(statErr):
+			cerr.Err = ErrPermissionDenied
+		case statErr == nil && fi.Mode()&os.ModeSocket == 0:
+			cerr.Err = ErrNotASocket
+		}
+		if cerr.Err != nil {
+			return cerr
+		}
+	}
+
+	conn, dialErr := net.DialTimeout(network, address, diagnoseTimeout)
+	if dialErr == nil {
+		conn.Close()
+		cerr.Err = ErrNotResponding
+		return cerr
+	}
+	if nerr, ok := dialErr.(net.Error); ok && nerr.Timeout() {
+		cerr.Err = ErrDialTimeout
+		return cerr
+	}
+	switch dialErrno(dialErr) {
+	case syscall.EACCES, syscall.EPERM:
+		cerr.Err = ErrPermissionDenied
+	case syscall.ETIMEDOUT:
+		cerr.Err = ErrDialTimeout
+	default:
+		cerr.Err = ErrNotListening
+	}
+	return cerr
+}
+
+// dialErrno returns the errno of an error returned by net.Dial, or 0.
+func dialErrno(err error) syscall.Errno {
+	if opErr, ok := err.(*net.OpError); ok {
+		err = opErr.Err
+	}
+	if sysErr, ok := err.(*os.SyscallError); ok {
+		err = sysErr.Err
+	}
+	errno, _ := err.(syscall.Errno)
+	return errno
+}
+
+// api returns the client of the current connection to containerd.
+func (r *remote) api() containerd.APIClient {
+	r.RLock()
+	defer r.RUnlock()
+	return r.apiClient
+}
+
+// dialOptions returns the target and the options to dial containerd with.
+// containerd is reached through its unix socket, or over TCP with mutual TLS
+// when a TLS configuration is set.
+func (r *remote) dialOptions() (string, []grpc.DialOption, error) {
+	if r.rpcTLS == nil {
+		return r.rpcAddr, []grpc.DialOption{
+			grpc.WithInsecure(),
+			grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+				return net.DialTimeout("unix", addr, timeout)
+			}),
+		}, nil
+	}
+	if !strings.HasPrefix(r.rpcAddr, "tcp://") {
+		return "", nil, fmt.Errorf("containerd address %s must be a tcp:// address to connect with TLS", r.rpcAddr)
+	}
+	// the target is the host:port the server name of the certificate of
+	// containerd is checked against
+	return strings.TrimPrefix(r.rpcAddr, "tcp://"), []grpc.DialOption{
+		grpc.WithTransportCredentials(credentials.NewTLS(r.rpcTLS)),
+		grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+			return net.DialTimeout("tcp", addr, timeout)
+		}),
+	}, nil
+}
+
+func (r *remote) handleConnectionChange(conn *grpc.ClientConn) {
 	var transientFailureCount = 0
 	state := grpc.Idle
 	for {
-		s, err := r.rpcConn.WaitForStateChange(context.Background(), state)
+		s, err := conn.WaitForStateChange(context.Background(), state)
 		if err != nil {
 			break
 		}
 		state = s
 		logrus.Debugf("containerd connection state change: %v", s)
 
+		if state == grpc.Shutdown {
+			// the connection was closed, on cleanup or after
+			// reconnecting
+			return
+		}
 		if r.daemonPid != -1 {
 			switch state {
 			case grpc.TransientFailure:
@@ -137,19 +294,80 @@ func (r *remote) handleConnectionChange() {
 					state = grpc.Idle
 					time.Sleep(connectionRetryDelay)
 				}
-			case grpc.Shutdown:
-				// Well, we asked for it to stop, just return
-				return
 			}
 		}
 	}
 }
 
+// isClosed returns whether the connection to containerd was closed manually.
+func (r *remote) isClosed() bool {
+	r.RLock()
+	defer r.RUnlock()
+	return r.closeManually
+}
+
+// monitorConnection checks the connection to containerd every
+// healthCheckInterval, and reconnects when containerd doesn't answer
+// maxConnectionRetryCount checks in a row. gRPC may otherwise keep a stale
+// connection after containerd restarts, failing every call.
+func (r *remote) monitorConnection() {
+	failures := 0
+	for !r.isClosed() {
+		time.Sleep(r.healthCheckInterval)
+		if r.isClosed() {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), r.healthCheckTimeout)
+		_, err := r.api().State(ctx, &containerd.StateRequest{})
+		cancel()
+		if err == nil {
+			failures = 0
+			continue
+		}
+		failures++
+		logrus.Warnf("libcontainerd: containerd health check failed (%d/%d): %v", failures, maxConnectionRetryCount, err)
+		if failures < maxConnectionRetryCount {
+			continue
+		}
+		failures = 0
+		if err := r.reconnect(); err != nil {
+			logrus.Errorf("libcontainerd: failed to reconnect to containerd: %v", err)
+		}
+	}
+}
+
+// reconnect replaces the connection to containerd with a new one. Closing
+// the old connection breaks the events stream, which is subscribed to again
+// on the new connection.
+func (r *remote) reconnect() error {
+	target, dialOpts, err := r.dialOptions()
+	if err != nil {
+		return err
+	}
+	conn, err := grpc.Dial(target, dialOpts...)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("libcontainerd: reconnecting to containerd at %s", r.rpcAddr)
+
+	r.Lock()
+	old := r.rpcConn
+	r.rpcConn = conn
+	r.apiClient = containerd.NewAPIClient(conn)
+	r.Unlock()
+
+	go r.handleConnectionChange(conn)
+	return old.Close()
+}
+
 func (r *remote) Cleanup() {
+	// stop the health checks of the connection
+	r.Lock()
+	r.closeManually = true
+	r.Unlock()
 	if r.daemonPid == -1 {
 		return
 	}
-	r.closeManually = true
 	r.rpcConn.Close()
 	// Ask the daemon to quit
 	syscall.Kill(r.daemonPid, syscall.SIGTERM)
@@ -240,34 +458,75 @@ func (r *remote) getLastEventTimestamp() int64 {
 }
 
 func (r *remote) startEventsMonitor() error {
+	return r.subscribeEvents(false)
+}
+
+// subscribeEvents subscribes to the events of containerd since the last one
+// handled. The past events are recorded for the containers restored when the
+// daemon starts, or handled when replay is set, after the events stream
+// broke.
+func (r *remote) subscribeEvents(replay bool) error {
 	// First, get past events
 	er := &containerd.EventsRequest{
 		Timestamp: uint64(r.getLastEventTimestamp()),
 	}
-	events, err := r.apiClient.Events(context.Background(), er)
+	events, err := r.api().Events(context.Background(), er)
 	if err != nil {
 		return err
 	}
-	go r.handleEventStream(events)
+	go r.handleEventStream(events, replay)
 	return nil
 }
 
-func (r *remote) handleEventStream(events containerd.API_EventsClient) {
+// resubscribe subscribes again to the events of containerd after the events
+// stream broke, until it succeeds or the connection is closed manually.
+func (r *remote) resubscribe() {
+	for !r.isClosed() {
+		err := r.subscribeEvents(true)
+		if err == nil {
+			return
+		}
+		logrus.Errorf("libcontainerd: failed to subscribe to containerd events: %v", err)
+		time.Sleep(connectionRetryDelay)
+	}
+}
+
+// reconcile reports the exits missed by the clients while the events stream
+// was broken.
+func (r *remote) reconcile() {
+	r.RLock()
+	clients := append([]*client(nil), r.clients...)
+	r.RUnlock()
+	for _, c := range clients {
+		c.reconcile()
+	}
+}
+
+func (r *remote) handleEventStream(events containerd.API_EventsClient, replay bool) {
 	live := false
 	for {
 		e, err := events.Recv()
 		if err != nil {
 			if grpc.ErrorDesc(err) == transport.ErrConnClosing.Desc &&
-				r.closeManually {
+				r.isClosed() {
 				// ignore error if grpc remote connection is closed manually
 				return
 			}
 			logrus.Errorf("failed to receive event from containerd: %v", err)
-			go r.startEventsMonitor()
+			go r.resubscribe()
 			return
 		}
 
-		if live == false {
+		if replay && !live && e.Type != stateLive {
+			// the containers the events were missed for are still
+			// tracked, handle them as live events
+			logrus.Debugf("received missed containerd event: %#v", e)
+			r.dispatchEvent(e)
+		} else if replay && !live {
+			live = true
+			r.updateEventTimestamp(time.Unix(int64(e.Timestamp), 0))
+			go r.reconcile()
+		} else if live == false {
 			logrus.Debugf("received past containerd event: %#v", e)
 
 			// Pause/Resume events should never happens after exit one
@@ -284,29 +543,33 @@ func (r *remote) handleEventStream(events containerd.API_EventsClient) {
 			}
 		} else {
 			logrus.Debugf("received containerd event: %#v", e)
+			r.dispatchEvent(e)
+		}
+	}
+}
 
-			var container *container
-			var c *client
-			r.RLock()
-			for _, c = range r.clients {
-				container, err = c.getContainer(e.Id)
-				if err == nil {
-					break
-				}
-			}
-			r.RUnlock()
-			if container == nil {
-				logrus.Errorf("no state for container: %q", err)
-				continue
-			}
-
-			if err := container.handleEvent(e); err != nil {
-				logrus.Errorf("error processing state change for %s: %v", e.Id, err)
-			}
-
-			r.updateEventTimestamp(time.Unix(int64(e.Timestamp), 0))
+// dispatchEvent handles the event with the container it is for.
+func (r *remote) dispatchEvent(e *containerd.Event) {
+	var container *container
+	var err error
+	r.RLock()
+	for _, c := range r.clients {
+		container, err = c.getContainer(e.Id)
+		if err == nil {
+			break
 		}
 	}
+	r.RUnlock()
+	if container == nil {
+		logrus.Errorf("no state for container: %q", err)
+		return
+	}
+
+	if err := container.handleEvent(e); err != nil {
+		logrus.Errorf("error processing state change for %s: %v", e.Id, err)
+	}
+
+	r.updateEventTimestamp(time.Unix(int64(e.Timestamp), 0))
 }
 
 func (r *remote) runContainerdDaemon() error {
@@ -403,6 +666,25 @@ func (a rpcAddr) Apply(r Remote) error {
 	return fmt.Errorf("WithRemoteAddr option not supported for this remote")
 }
 
+// WithRemoteTLS connects to containerd over TCP with mutual TLS, using the
+// client certificate and the certificate authority of config. The address set
+// with WithRemoteAddr must be a tcp:// address.
+func WithRemoteTLS(config *tls.Config) RemoteOption {
+	return rpcTLS{config}
+}
+
+type rpcTLS struct {
+	config *tls.Config
+}
+
+func (t rpcTLS) Apply(r Remote) error {
+	if remote, ok := r.(*remote); ok {
+		remote.rpcTLS = t.config
+		return nil
+	}
+	return fmt.Errorf("WithRemoteTLS option not supported for this remote")
+}
+
 // WithRuntimeArgs sets the list of runtime args passed to containerd
 func WithRuntimeArgs(args []string) RemoteOption {
 	return runtimeArgs(args)
@@ -447,3 +729,120 @@ func (d debugLog) Apply(r Remote) error {
 	}
 	return fmt.Errorf("WithDebugLog option not supported for this remote")
 }
+
+// WithFifoTimeout sets how long libcontainerd waits for the writer side of a
+// container fifo to appear before giving up. Zero waits forever.
+func WithFifoTimeout(timeout time.Duration) RemoteOption {
+	return fifoTimeout(timeout)
+}
+
+type fifoTimeout time.Duration
+
+func (t fifoTimeout) Apply(r Remote) error {
+	if remote, ok := r.(*remote); ok {
+		remote.fifoTimeout = time.Duration(t)
+		return nil
+	}
+	return fmt.Errorf("WithFifoTimeout option not supported for this remote")
+}
+
+// WithNoPivotRoot disables the use of pivot_root when starting containers,
+// as required when the daemon runs on a ramdisk.
+func WithNoPivotRoot(noPivotRoot bool) RemoteOption {
+	return noPivotRootOption(noPivotRoot)
+}
+
+type noPivotRootOption bool
+
+func (n noPivotRootOption) Apply(r Remote) error {
+	if remote, ok := r.(*remote); ok {
+		remote.noPivotRoot = bool(n)
+		return nil
+	}
+	return fmt.Errorf("WithNoPivotRoot option not supported for this remote")
+}
+
+// retainBundle moves the bundle directory of a container which exited with
+// an error to the retained bundles directory, and removes the oldest
+// retained bundles in excess.
+func (r *remote) retainBundle(dir, containerID string) error {
+	r.retainedBundlesMu.Lock()
+	defer r.retainedBundlesMu.Unlock()
+	root := filepath.Join(r.stateDir, retainedBundlesDirname)
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return err
+	}
+	// the names sort in the order the bundles were retained
+	name := fmt.Sprintf("%d-%s", time.Now().UnixNano(), containerID)
+	if err := os.Rename(dir, filepath.Join(root, name)); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return r.pruneRetainedBundlesLocked()
+}
+
+// retainedBundleDirs returns the retained bundle directories, oldest
+// first.
+func (r *remote) retainedBundleDirs() ([]string, error) {
+	root, err := filepath.Abs(filepath.Join(r.stateDir, retainedBundlesDirname))
+	if err != nil {
+		return nil, err
+	}
+	fis, err := ioutil.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var dirs []string
+	for _, fi := range fis {
+		if fi.IsDir() {
+			dirs = append(dirs, filepath.Join(root, fi.Name()))
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+func (r *remote) pruneRetainedBundles() error {
+	r.retainedBundlesMu.Lock()
+	defer r.retainedBundlesMu.Unlock()
+	return r.pruneRetainedBundlesLocked()
+}
+
+func (r *remote) pruneRetainedBundlesLocked() error {
+	dirs, err := r.retainedBundleDirs()
+	if err != nil {
+		return err
+	}
+	for len(dirs) > r.retainedBundles {
+		if err := os.RemoveAll(dirs[0]); err != nil {
+			return err
+		}
+		dirs = dirs[1:]
+	}
+	return nil
+}
+
+// WithRetainedBundles keeps the bundle directories of the last count
+// containers that exited with a non-zero status, for debugging. Older ones
+// are removed. Zero keeps none.
+func WithRetainedBundles(count int) RemoteOption {
+	return retainedBundles(count)
+}
+
+type retainedBundles int
+
+func (n retainedBundles) Apply(r Remote) error {
+	if n < 0 {
+		return fmt.Errorf("invalid number of retained bundles %d, it must be a positive number", n)
+	}
+	if remote, ok := r.(*remote); ok {
+		remote.retainedBundles = int(n)
+		return nil
+	}
+	return fmt.Errorf("WithRetainedBundles option not supported for this remote")
+}
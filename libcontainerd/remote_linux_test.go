@@ -0,0 +1,330 @@
+package libcontainerd
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	containerd "github.com/docker/containerd/api/grpc/types"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// eventsAPIServer is a containerd serving only the events stream and the
+// state of its containers, and reporting the clients subscribing to the
+// events.
+type eventsAPIServer struct {
+	containerd.APIServer
+	subscribed chan struct{}
+	containers []*containerd.Container
+	// stale makes the state requests hang, as a containerd which doesn't
+	// answer anymore.
+	stale bool
+}
+
+func (s *eventsAPIServer) Events(r *containerd.EventsRequest, stream containerd.API_EventsServer) error {
+	if err := stream.Send(&containerd.Event{Type: stateLive, Timestamp: uint64(time.Now().Unix())}); err != nil {
+		return err
+	}
+	s.subscribed <- struct{}{}
+	<-stream.Context().Done()
+	return nil
+}
+
+func (s *eventsAPIServer) State(ctx context.Context, r *containerd.StateRequest) (*containerd.StateResponse, error) {
+	if s.stale {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return &containerd.StateResponse{Containers: s.containers}, nil
+}
+
+// serveUnix serves api on the unix socket at path.
+func serveUnix(t *testing.T, path string, api containerd.APIServer) *grpc.Server {
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	containerd.RegisterAPIServer(server, api)
+	go server.Serve(l)
+	return server
+}
+
+// setHealthCheck makes the connection to containerd of the remotes created
+// next checked every interval, and returns a function restoring the
+// defaults.
+func setHealthCheck(interval time.Duration) func() {
+	defaultInterval, defaultTimeout := healthCheckInterval, healthCheckTimeout
+	healthCheckInterval, healthCheckTimeout = interval, interval
+	return func() {
+		healthCheckInterval, healthCheckTimeout = defaultInterval, defaultTimeout
+	}
+}
+
+// closeRemote stops the health checks of r and closes its connection.
+func closeRemote(r *remote) {
+	r.Lock()
+	defer r.Unlock()
+	r.closeManually = true
+	r.rpcConn.Close()
+}
+
+func expectNotifications(t *testing.T, notifications chan string, expected ...string) {
+	for _, e := range expected {
+		select {
+		case n := <-notifications:
+			if n != e {
+				t.Fatalf("Expected notification %q, got %q", e, n)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Timeout waiting for notification %q", e)
+		}
+	}
+}
+
+// newTestCertificate returns a certificate for 127.0.0.1 signed by parent, or
+// a self-signed certificate authority when parent is nil.
+func newTestCertificate(t *testing.T, serial int64, parent *tls.Certificate) tls.Certificate {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "libcontainerd-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.Leaf, parent.PrivateKey.(*rsa.PrivateKey)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestNewWithRemoteTLS(t *testing.T) {
+	ca := newTestCertificate(t, 1, nil)
+	serverCert := newTestCertificate(t, 2, &ca)
+	clientCert := newTestCertificate(t, 3, &ca)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})))
+	api := &eventsAPIServer{subscribed: make(chan struct{}, 1)}
+	containerd.RegisterAPIServer(server, api)
+	go server.Serve(l)
+	defer server.Stop()
+
+	stateDir, err := ioutil.TempDir("", "libcontainerd-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(stateDir)
+
+	r, err := New(stateDir,
+		WithRemoteAddr("tcp://"+l.Addr().String()),
+		WithRemoteTLS(&tls.Config{
+			Certificates: []tls.Certificate{clientCert},
+			RootCAs:      pool,
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeRemote(r.(*remote))
+
+	select {
+	case <-api.subscribed:
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected the daemon to subscribe to the events of containerd over TLS")
+	}
+}
+
+func TestDialOptionsTLSRequiresTCPAddress(t *testing.T) {
+	r := &remote{rpcAddr: "/run/containerd.sock", rpcTLS: &tls.Config{}}
+	if _, _, err := r.dialOptions(); err == nil {
+		t.Fatal("expected an error connecting with TLS to a socket path")
+	}
+	r.rpcAddr = "tcp://127.0.0.1:2376"
+	target, _, err := r.dialOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target != "127.0.0.1:2376" {
+		t.Fatalf("expected target 127.0.0.1:2376, got %s", target)
+	}
+}
+
+func TestReconnectStaleConnection(t *testing.T) {
+	defer setHealthCheck(50 * time.Millisecond)()
+	stateDir, err := ioutil.TempDir("", "libcontainerd-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(stateDir)
+	sock := filepath.Join(stateDir, "containerd.sock")
+	api := &eventsAPIServer{subscribed: make(chan struct{}, 1), stale: true}
+	server := serveUnix(t, sock, api)
+	defer server.Stop()
+
+	r, err := New(stateDir, WithRemoteAddr(sock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeRemote(r.(*remote))
+	backend := &recordingBackend{notifications: make(chan string, 1)}
+	if _, err := r.Client(backend); err != nil {
+		t.Fatal(err)
+	}
+
+	<-api.subscribed
+	select {
+	case <-api.subscribed:
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected the daemon to reconnect and subscribe again after failed health checks")
+	}
+	expectNotifications(t, backend.notifications, "reconnected")
+}
+
+func TestReconnectAfterContainerdRestart(t *testing.T) {
+	defer setHealthCheck(50 * time.Millisecond)()
+	stateDir, err := ioutil.TempDir("", "libcontainerd-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(stateDir)
+	sock := filepath.Join(stateDir, "containerd.sock")
+	api := &eventsAPIServer{subscribed: make(chan struct{}, 1)}
+	server := serveUnix(t, sock, api)
+
+	r, err := New(stateDir, WithRemoteAddr(sock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeRemote(r.(*remote))
+	backend := &recordingBackend{notifications: make(chan string, 2)}
+	c, err := r.Client(backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clnt := c.(*client)
+	clnt.appendContainer(clnt.newContainer(filepath.Join(stateDir, "ctr")))
+	<-api.subscribed
+
+	// containerd restarts without the container, which exited meanwhile
+	server.Stop()
+	os.Remove(sock)
+	restarted := &eventsAPIServer{subscribed: make(chan struct{}, 1)}
+	server = serveUnix(t, sock, restarted)
+	defer server.Stop()
+
+	select {
+	case <-restarted.subscribed:
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected the daemon to subscribe to the events of the restarted containerd")
+	}
+	expectNotifications(t, backend.notifications, StateExit, "reconnected")
+	if _, err := clnt.getContainer("ctr"); err == nil {
+		t.Fatal("expected the exited container not to be tracked anymore")
+	}
+}
+
+func TestDiagnoseConnection(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "libcontainerd-diagnose")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	file := filepath.Join(tmp, "file")
+	if err := ioutil.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	stale := filepath.Join(tmp, "stale.sock")
+	l, err := net.Listen("unix", stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+
+	listening := filepath.Join(tmp, "listening.sock")
+	l, err = net.Listen("unix", listening)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	dialErr := errors.New("grpc: timed out")
+	for _, c := range []struct {
+		addr string
+		kind error
+	}{
+		{filepath.Join(tmp, "missing.sock"), ErrSocketNotFound},
+		{file, ErrNotASocket},
+		{stale, ErrNotListening},
+		{listening, ErrNotResponding},
+	} {
+		cerr := diagnoseConnection(c.addr, dialErr)
+		if cerr.Err != c.kind {
+			t.Fatalf("Expected %s to be diagnosed as %q, got %q", c.addr, c.kind, cerr.Err)
+		}
+		if cerr.Detail != dialErr || !strings.Contains(cerr.Error(), c.addr) || !strings.Contains(cerr.Error(), dialErr.Error()) {
+			t.Fatalf("Expected the address and the original error in the error, got %q", cerr.Error())
+		}
+	}
+}
+
+func TestNewMissingSocket(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "libcontainerd-missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	defer func(timeout time.Duration) { connectTimeout = timeout }(connectTimeout)
+	connectTimeout = time.Second
+
+	_, err = New(tmp, WithRemoteAddr(filepath.Join(tmp, "missing.sock")))
+	cerr, ok := err.(*ConnectionError)
+	if !ok || cerr.Err != ErrSocketNotFound {
+		t.Fatalf("Expected the missing socket to be diagnosed, got %v", err)
+	}
+}
@@ -1,6 +1,11 @@
 package libcontainerd
 
-import "io"
+import (
+	"io"
+	"time"
+
+	"golang.org/x/net/context"
+)
 
 // State constants used in state change reporting.
 const (
@@ -25,15 +30,38 @@ type StateInfo struct { // FIXME: event?
 	OOMKilled bool // TODO Windows containerd factor out
 }
 
+// ProcessInfo identifies a process of a container.
+type ProcessInfo struct {
+	// FriendlyName is the name the process was added with, or
+	// InitFriendlyName for the main process of the container.
+	FriendlyName string
+	// Pid is the PID of the process on the host, 0 if it is unknown.
+	Pid uint32
+}
+
 // Backend defines callbacks that the client of the library needs to implement.
 type Backend interface {
 	StateChanged(containerID string, state StateInfo) error
 	AttachStreams(processFriendlyName string, io IOPipe) error
+	// OOM is called as soon as a container runs out of memory,
+	// before the exit of the container is reported.
+	OOM(containerID string) error
+	// RestartScheduled is called when the restart manager of a container
+	// decides to restart it after delay, attempt being the number of the
+	// restart. The restart itself is reported through StateChanged.
+	RestartScheduled(containerID string, attempt int, delay time.Duration) error
+	// ContainerdReconnected is called when the events of containerd are
+	// received again after the connection to it was lost, once the exits
+	// missed meanwhile are reported through StateChanged.
+	ContainerdReconnected() error
 }
 
 // Client provides access to containerd features.
 type Client interface {
-	Create(containerID string, spec Spec, options ...CreateOption) error
+	// Create creates and starts a container. Cancelling ctx aborts the
+	// creation if containerd has not been asked to create the container
+	// yet.
+	Create(ctx context.Context, containerID string, spec Spec, options ...CreateOption) error
 	Signal(containerID string, sig int) error
 	AddProcess(containerID, processFriendlyName string, process Process) error
 	Resize(containerID, processFriendlyName string, width, height int) error
@@ -44,6 +72,19 @@ type Client interface {
 	GetPidsForContainer(containerID string) ([]int, error)
 	Summary(containerID string) ([]Summary, error)
 	UpdateResources(containerID string, resources Resources) error
+	// OrphanedDirs returns the container directories left behind in the
+	// state directory that belong neither to a container tracked by the
+	// client nor to one of the given known container IDs.
+	OrphanedDirs(known map[string]bool) ([]string, error)
+	// CleanupOrphanedDirs removes the directories reported by
+	// OrphanedDirs, unless LIBCONTAINERD_NOCLEAN is set, and returns them.
+	CleanupOrphanedDirs(known map[string]bool) ([]string, error)
+	// RetainedBundles returns the bundle directories retained for the
+	// containers that exited with an error, oldest first.
+	RetainedBundles() ([]string, error)
+	// ListProcesses returns the processes known for each container tracked
+	// by the client, by container ID, sorted by friendly name.
+	ListProcesses() (map[string][]ProcessInfo, error)
 }
 
 // CreateOption allows to configure parameters of container creation.
@@ -3,6 +3,7 @@ package aaparser
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os/exec"
 	"path/filepath"
 	"strconv"
@@ -33,6 +34,27 @@ func LoadProfile(profilePath string) error {
 	return nil
 }
 
+// ValidateProfile runs `apparmor_parser -Q -K` on a specified apparmor
+// profile to check that it is valid, without loading it in the kernel.
+func ValidateProfile(profilePath string) error {
+	_, err := cmd(filepath.Dir(profilePath), "-Q", "-K", filepath.Base(profilePath))
+	return err
+}
+
+// ProfileName returns the name of the first profile declared in a specified
+// apparmor profile file.
+func ProfileName(profilePath string) (string, error) {
+	b, err := ioutil.ReadFile(profilePath)
+	if err != nil {
+		return "", err
+	}
+	name := parseProfileName(string(b))
+	if name == "" {
+		return "", fmt.Errorf("no profile is declared in %s", profilePath)
+	}
+	return name, nil
+}
+
 // cmd runs `apparmor_parser` with the passed arguments.
 func cmd(dir string, arg ...string) (string, error) {
 	c := exec.Command(binary, arg...)
@@ -90,3 +112,37 @@ func parseVersion(output string) (int, error) {
 	numericVersion := majorVersion*1e5 + minorVersion*1e3 + patchLevel
 	return numericVersion, nil
 }
+
+// parseProfileName takes the content of a profile file and returns the name
+// of the first profile it declares, either `profile NAME [ATTACHMENT] {` or
+// `/ATTACHMENT {` whose name is the attachment path. It returns an empty
+// string if no profile is declared.
+func parseProfileName(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || !strings.HasSuffix(line, "{") {
+			continue
+		}
+		if strings.HasPrefix(line, "profile ") {
+			return profileToken(strings.TrimSpace(strings.TrimPrefix(line, "profile ")))
+		}
+		if name := profileToken(line); strings.HasPrefix(name, "/") {
+			return name
+		}
+	}
+	return ""
+}
+
+// profileToken returns the first token of s, which may be quoted.
+func profileToken(s string) string {
+	if strings.HasPrefix(s, `"`) {
+		if end := strings.Index(s[1:], `"`); end >= 0 {
+			return s[1 : end+1]
+		}
+		return ""
+	}
+	if end := strings.IndexAny(s, " \t{"); end >= 0 {
+		return s[:end]
+	}
+	return s
+}
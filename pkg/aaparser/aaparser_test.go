@@ -71,3 +71,34 @@ Copyright 2009-2012 Canonical Ltd.
 		}
 	}
 }
+
+func TestParseProfileName(t *testing.T) {
+	profiles := map[string]string{
+		`#include <tunables/global>
+
+profile docker-nginx flags=(attach_disconnected,mediate_deleted) {
+  #include <abstractions/base>
+  network inet tcp,
+}
+`: "docker-nginx",
+		`# vim:syntax=apparmor
+/usr/sbin/nginx {
+  /var/log/nginx/** w,
+}
+`: "/usr/sbin/nginx",
+		`profile "docker app" /usr/bin/app {
+}
+`: "docker app",
+		`#include <tunables/global>
+# profile commented-out {
+`: "",
+		`capability net_admin,
+`: "",
+	}
+
+	for content, expected := range profiles {
+		if name := parseProfileName(content); name != expected {
+			t.Fatalf("expected profile name %q, got %q, for: %s", expected, name, content)
+		}
+	}
+}
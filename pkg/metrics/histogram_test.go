@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHistogramVec(t *testing.T) {
+	h := NewHistogramVec("test_duration_seconds", "Test durations.", "phase", []float64{0.1, 1})
+	h.Observe("mount", 0.05)
+	h.Observe("mount", 0.1)
+	h.Observe("mount", 0.5)
+	h.Observe("mount", 2)
+	h.Observe("create", 0.5)
+
+	var buf bytes.Buffer
+	if err := h.Collect(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# HELP test_duration_seconds Test durations.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{phase="create",le="0.1"} 0
+test_duration_seconds_bucket{phase="create",le="1"} 1
+test_duration_seconds_bucket{phase="create",le="+Inf"} 1
+test_duration_seconds_sum{phase="create"} 0.5
+test_duration_seconds_count{phase="create"} 1
+test_duration_seconds_bucket{phase="mount",le="0.1"} 2
+test_duration_seconds_bucket{phase="mount",le="1"} 3
+test_duration_seconds_bucket{phase="mount",le="+Inf"} 4
+test_duration_seconds_sum{phase="mount"} 2.65
+test_duration_seconds_count{phase="mount"} 4
+`
+	if buf.String() != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
@@ -0,0 +1,54 @@
+// Package metrics provides a minimal set of metric types which can be
+// exposed over HTTP in the Prometheus text format.
+package metrics
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Collector is a metric which can write its samples in the Prometheus text
+// format.
+type Collector interface {
+	Collect(w io.Writer) error
+}
+
+var (
+	mu         sync.Mutex
+	collectors []Collector
+)
+
+// Register adds a collector to the ones exposed by Handler.
+func Register(c Collector) {
+	mu.Lock()
+	collectors = append(collectors, c)
+	mu.Unlock()
+}
+
+// Write writes the samples of all the registered collectors to w.
+func Write(w io.Writer) error {
+	mu.Lock()
+	cs := make([]Collector, len(collectors))
+	copy(cs, collectors)
+	mu.Unlock()
+
+	for _, c := range cs {
+		if err := c.Collect(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handler serves the samples of all the registered collectors.
+func Handler(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	if err := Write(&buf); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	buf.WriteTo(w)
+}
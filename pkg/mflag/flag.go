@@ -328,6 +328,27 @@ type Flag struct {
 	DefValue string   // default value (as text); for usage message
 }
 
+// Deprecated reports whether all the names of the flag are deprecated, in
+// which case the flag is not shown in the usage.
+func (f *Flag) Deprecated() bool {
+	for _, name := range f.Names {
+		if name[0] != '#' {
+			return false
+		}
+	}
+	return true
+}
+
+// Type returns the type of the value of the flag, such as "bool", "string"
+// or "time.Duration": the type of the value returned by Get for the values
+// implementing Getter, the type of the Value itself otherwise.
+func (f *Flag) Type() string {
+	if g, ok := f.Value.(Getter); ok {
+		return fmt.Sprintf("%T", g.Get())
+	}
+	return strings.TrimPrefix(fmt.Sprintf("%T", f.Value), "*")
+}
+
 type flagSlice []string
 
 func (p flagSlice) Len() int { return len(p) }
@@ -402,6 +423,12 @@ func (fs *FlagSet) VisitAll(fn func(*Flag)) {
 	}
 }
 
+// Flags returns all the flags of the set in lexicographical order, including
+// the deprecated ones, for tools describing the options of a command.
+func (fs *FlagSet) Flags() []*Flag {
+	return sortFlags(fs.formal)
+}
+
 // VisitAll visits the command-line flags in lexicographical order, calling
 // fn for each.  It visits all flags, even those not set.
 func VisitAll(fn func(*Flag)) {
@@ -465,6 +492,18 @@ func (fs *FlagSet) Require(nArgRequirementType nArgRequirementType, nArg int) {
 	fs.nArgRequirements = append(fs.nArgRequirements, nArgRequirement{nArgRequirementType, nArg})
 }
 
+// MinArgs returns the minimum number of arguments implied by the
+// requirements set with FlagSet.Require().
+func (fs *FlagSet) MinArgs() int {
+	min := 0
+	for _, req := range fs.nArgRequirements {
+		if (req.Type == Exact || req.Type == Min) && req.N > min {
+			min = req.N
+		}
+	}
+	return min
+}
+
 // CheckArgs uses the requirements set by FlagSet.Require() to validate
 // the number of arguments. If the requirements are not met,
 // an error message string is returned.
@@ -618,11 +657,8 @@ func (fs *FlagSet) FlagCount() int { return len(sortFlags(fs.formal)) }
 func (fs *FlagSet) FlagCountUndeprecated() int {
 	count := 0
 	for _, flag := range sortFlags(fs.formal) {
-		for _, name := range flag.Names {
-			if name[0] != '#' {
-				count++
-				break
-			}
+		if !flag.Deprecated() {
+			count++
 		}
 	}
 	return count
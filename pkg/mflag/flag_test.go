@@ -468,6 +468,47 @@ func TestFlagCounts(t *testing.T) {
 	}
 }
 
+func TestFlags(t *testing.T) {
+	fs := NewFlagSet("flags test", ContinueOnError)
+	var v flagVar
+	fs.Bool([]string{"b", "-bool"}, false, "bool flag")
+	fs.Duration([]string{"#d", "#-duration"}, time.Second, "deprecated flag")
+	fs.String([]string{"#s", "-string"}, "foo", "string flag")
+	fs.Var(&v, []string{"-var"}, "user-defined flag")
+
+	expected := []struct {
+		names      []string
+		defValue   string
+		usage      string
+		typ        string
+		deprecated bool
+	}{
+		{[]string{"b", "-bool"}, "false", "bool flag", "bool", false},
+		{[]string{"#d", "#-duration"}, "1s", "deprecated flag", "time.Duration", true},
+		{[]string{"#s", "-string"}, "foo", "string flag", "string", false},
+		{[]string{"-var"}, "[]", "user-defined flag", "mflag.flagVar", false},
+	}
+	flags := fs.Flags()
+	if len(flags) != len(expected) {
+		t.Fatalf("expected %d flags, got %d", len(expected), len(flags))
+	}
+	for i, e := range expected {
+		f := flags[i]
+		if strings.Join(f.Names, " ") != strings.Join(e.names, " ") {
+			t.Fatalf("expected flag %d to be named %v, got %v", i, e.names, f.Names)
+		}
+		if f.DefValue != e.defValue || f.Usage != e.usage {
+			t.Fatalf("expected %v to default to %q with usage %q, got %q and %q", e.names, e.defValue, e.usage, f.DefValue, f.Usage)
+		}
+		if f.Type() != e.typ {
+			t.Fatalf("expected %v to be of type %q, got %q", e.names, e.typ, f.Type())
+		}
+		if f.Deprecated() != e.deprecated {
+			t.Fatalf("expected %v to be deprecated: %v, got %v", e.names, e.deprecated, f.Deprecated())
+		}
+	}
+}
+
 // Show up bug in sortFlags
 func TestSortFlags(t *testing.T) {
 	fs := NewFlagSet("help TestSortFlags", ContinueOnError)
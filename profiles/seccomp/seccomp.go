@@ -5,6 +5,7 @@ package seccomp
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/docker/engine-api/types"
 	"github.com/opencontainers/specs/specs-go"
@@ -17,16 +18,101 @@ func GetDefaultProfile() (*specs.Seccomp, error) {
 	return setupSeccomp(DefaultProfile)
 }
 
-// LoadProfile takes a file path and decodes the seccomp profile.
+// LoadProfile takes the JSON body of a profile and decodes the seccomp profile.
 func LoadProfile(body string) (*specs.Seccomp, error) {
 	var config types.Seccomp
 	if err := json.Unmarshal([]byte(body), &config); err != nil {
-		return nil, fmt.Errorf("Decoding seccomp profile failed: %v", err)
+		return nil, fmt.Errorf("Decoding seccomp profile failed: %v", decodeError(body, err))
+	}
+	if err := validateProfile(&config); err != nil {
+		return nil, fmt.Errorf("Invalid seccomp profile: %v", err)
 	}
 
 	return setupSeccomp(&config)
 }
 
+// decodeError adds the line and column of body at which decoding failed
+// to err, when it is known.
+func decodeError(body string, err error) error {
+	var offset int64
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		offset = e.Offset
+	case *json.UnmarshalTypeError:
+		offset = e.Offset
+	default:
+		return err
+	}
+	if offset > int64(len(body)) {
+		offset = int64(len(body))
+	}
+	line, column := 1, 1
+	for _, c := range body[:offset] {
+		if c == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return fmt.Errorf("line %d, column %d: %v", line, column, err)
+}
+
+var (
+	validActions = map[types.Action]bool{
+		types.ActKill:  true,
+		types.ActTrap:  true,
+		types.ActErrno: true,
+		types.ActTrace: true,
+		types.ActAllow: true,
+	}
+	validOperators = map[types.Operator]bool{
+		types.OpNotEqual:     true,
+		types.OpLessThan:     true,
+		types.OpLessEqual:    true,
+		types.OpEqualTo:      true,
+		types.OpGreaterEqual: true,
+		types.OpGreaterThan:  true,
+		types.OpMaskedEqual:  true,
+	}
+)
+
+// maxSyscallArgs is the number of arguments of a system call a rule can match.
+const maxSyscallArgs = 6
+
+// validateProfile checks the actions, architectures, system calls and
+// arguments of a decoded profile, which json.Unmarshal accepts as any string.
+func validateProfile(config *types.Seccomp) error {
+	if config.DefaultAction != "" && !validActions[config.DefaultAction] {
+		return fmt.Errorf("unknown defaultAction %q", config.DefaultAction)
+	}
+	for _, arch := range config.Architectures {
+		if !strings.HasPrefix(string(arch), "SCMP_ARCH_") {
+			return fmt.Errorf("unknown architecture %q", arch)
+		}
+	}
+	for i, call := range config.Syscalls {
+		if call == nil || call.Name == "" {
+			return fmt.Errorf("syscalls[%d]: missing name", i)
+		}
+		if !validActions[call.Action] {
+			return fmt.Errorf("syscalls[%d] (%s): unknown action %q", i, call.Name, call.Action)
+		}
+		for j, arg := range call.Args {
+			if arg == nil {
+				return fmt.Errorf("syscalls[%d] (%s): args[%d]: missing argument", i, call.Name, j)
+			}
+			if arg.Index >= maxSyscallArgs {
+				return fmt.Errorf("syscalls[%d] (%s): args[%d]: index %d out of range, it must be lower than %d", i, call.Name, j, arg.Index, maxSyscallArgs)
+			}
+			if !validOperators[arg.Op] {
+				return fmt.Errorf("syscalls[%d] (%s): args[%d]: unknown op %q", i, call.Name, j, arg.Op)
+			}
+		}
+	}
+	return nil
+}
+
 func setupSeccomp(config *types.Seccomp) (newConfig *specs.Seccomp, err error) {
 	if config == nil {
 		return nil, nil
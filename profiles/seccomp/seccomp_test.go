@@ -4,6 +4,7 @@ package seccomp
 
 import (
 	"io/ioutil"
+	"strings"
 	"testing"
 )
 
@@ -26,3 +27,33 @@ func TestLoadDefaultProfile(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestLoadProfileDecodeError(t *testing.T) {
+	_, err := LoadProfile("{\n  \"defaultAction\": \"SCMP_ACT_ALLOW\",\n  \"syscalls\": [,]\n}")
+	if err == nil || !strings.Contains(err.Error(), "line 3, column 17") {
+		t.Fatalf("Expected the line and column of the syntax error, got %v", err)
+	}
+	_, err = LoadProfile(`{"defaultAction": 1}`)
+	if err == nil || !strings.Contains(err.Error(), "line 1, column 20") {
+		t.Fatalf("Expected the line and column of the type error, got %v", err)
+	}
+}
+
+func TestLoadProfileValidation(t *testing.T) {
+	for _, profile := range []string{
+		`{"defaultAction": "SCMP_ACT_NOPE"}`,
+		`{"defaultAction": "SCMP_ACT_ERRNO", "architectures": ["X86"]}`,
+		`{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"action": "SCMP_ACT_ALLOW"}]}`,
+		`{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"name": "read", "action": "allow"}]}`,
+		`{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"name": "read", "action": "SCMP_ACT_ALLOW", "args": [{"index": 6, "op": "SCMP_CMP_EQ"}]}]}`,
+		`{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"name": "read", "action": "SCMP_ACT_ALLOW", "args": [{"index": 0, "op": "=="}]}]}`,
+	} {
+		if _, err := LoadProfile(profile); err == nil || !strings.Contains(err.Error(), "Invalid seccomp profile") {
+			t.Fatalf("Expected %s to be rejected, got %v", profile, err)
+		}
+	}
+	profile := `{"defaultAction": "SCMP_ACT_ERRNO", "architectures": ["SCMP_ARCH_X86_64"], "syscalls": [{"name": "read", "action": "SCMP_ACT_ALLOW", "args": [{"index": 0, "value": 1, "op": "SCMP_CMP_EQ"}]}]}`
+	if _, err := LoadProfile(profile); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -3,10 +3,13 @@ package registry
 import (
 	"errors"
 	"fmt"
+	"math/rand"
 	"net"
 	"net/url"
+	"strconv"
 	"strings"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/opts"
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/reference"
@@ -18,6 +21,12 @@ type ServiceOptions struct {
 	Mirrors            []string `json:"registry-mirrors,omitempty"`
 	InsecureRegistries []string `json:"insecure-registries,omitempty"`
 
+	// MirrorWeights are the weights of the mirrors, as MIRROR=WEIGHT. The
+	// mirrors are tried in an order drawn at random according to their
+	// weights, a mirror without weight weighs 1. Without weights, the
+	// mirrors are tried in the order they are configured.
+	MirrorWeights []string `json:"registry-mirror-weights,omitempty"`
+
 	// V2Only controls access to legacy registries.  If it is set to true via the
 	// command line flag the daemon will not attempt to contact v1 legacy registries
 	V2Only bool `json:"disable-legacy-registry,omitempty"`
@@ -27,6 +36,9 @@ type ServiceOptions struct {
 type serviceConfig struct {
 	registrytypes.ServiceConfig
 	V2Only bool
+	// MirrorWeights are the weights of the mirrors, by mirror as returned
+	// by ValidateMirror.
+	MirrorWeights map[string]int
 }
 
 var (
@@ -66,7 +78,10 @@ var (
 )
 
 // for mocking in unit tests
-var lookupIP = net.LookupIP
+var (
+	lookupIP = net.LookupIP
+	randIntn = rand.Intn
+)
 
 // InstallCliFlags adds command-line options to the top-level flag parser for
 // the current process.
@@ -74,6 +89,9 @@ func (options *ServiceOptions) InstallCliFlags(cmd *flag.FlagSet, usageFn func(s
 	mirrors := opts.NewNamedListOptsRef("registry-mirrors", &options.Mirrors, ValidateMirror)
 	cmd.Var(mirrors, []string{"-registry-mirror"}, usageFn("Preferred Docker registry mirror"))
 
+	mirrorWeights := opts.NewNamedListOptsRef("registry-mirror-weights", &options.MirrorWeights, ValidateMirrorWeight)
+	cmd.Var(mirrorWeights, []string{"-registry-mirror-weight"}, usageFn("Set the weight of a registry mirror as MIRROR=WEIGHT to spread the pulls across the mirrors"))
+
 	insecureRegistries := opts.NewNamedListOptsRef("insecure-registries", &options.InsecureRegistries, ValidateIndexName)
 	cmd.Var(insecureRegistries, []string{"-insecure-registry"}, usageFn("Enable insecure registry communication"))
 
@@ -97,7 +115,16 @@ func newServiceConfig(options ServiceOptions) *serviceConfig {
 			// and Mirrors are only for the official registry anyways.
 			Mirrors: options.Mirrors,
 		},
-		V2Only: options.V2Only,
+		V2Only:        options.V2Only,
+		MirrorWeights: make(map[string]int),
+	}
+	for _, val := range options.MirrorWeights {
+		mirror, weight, err := parseMirrorWeight(val)
+		if err != nil {
+			logrus.Warnf("Ignoring registry mirror weight: %v", err)
+			continue
+		}
+		config.MirrorWeights[mirror] = weight
 	}
 	// Split --insecure-registry into CIDR and registry-specific settings.
 	for _, r := range options.InsecureRegistries {
@@ -195,6 +222,34 @@ func ValidateMirror(val string) (string, error) {
 	return fmt.Sprintf("%s://%s/", uri.Scheme, uri.Host), nil
 }
 
+// ValidateMirrorWeight validates the weight of a registry mirror, given as
+// MIRROR=WEIGHT where WEIGHT is a positive integer.
+func ValidateMirrorWeight(val string) (string, error) {
+	mirror, weight, err := parseMirrorWeight(val)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s=%d", mirror, weight), nil
+}
+
+// parseMirrorWeight parses a MIRROR=WEIGHT mirror weight, the mirror is
+// returned as returned by ValidateMirror.
+func parseMirrorWeight(val string) (string, int, error) {
+	i := strings.LastIndex(val, "=")
+	if i < 0 {
+		return "", 0, fmt.Errorf("invalid registry mirror weight %s, it must be MIRROR=WEIGHT", val)
+	}
+	mirror, err := ValidateMirror(strings.TrimSuffix(val[:i], "/"))
+	if err != nil {
+		return "", 0, err
+	}
+	weight, err := strconv.Atoi(val[i+1:])
+	if err != nil || weight < 1 {
+		return "", 0, fmt.Errorf("invalid registry mirror weight %s, the weight must be a positive integer", val)
+	}
+	return mirror, weight, nil
+}
+
 // ValidateIndexName validates an index name.
 func ValidateIndexName(val string) (string, error) {
 	if val == reference.LegacyDefaultHostname {
@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -47,3 +48,80 @@ func TestValidateMirror(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateMirrorWeight(t *testing.T) {
+	valid := map[string]string{
+		"https://mirror-1.com=5":  "https://mirror-1.com/=5",
+		"https://mirror-1.com/=5": "https://mirror-1.com/=5",
+		"http://localhost:5000=1": "http://localhost:5000/=1",
+	}
+	for val, expected := range valid {
+		if ret, err := ValidateMirrorWeight(val); err != nil || ret != expected {
+			t.Errorf("ValidateMirrorWeight(`%s`) got %s %v, expected %s", val, ret, err, expected)
+		}
+	}
+
+	invalid := []string{
+		"https://mirror-1.com",
+		"https://mirror-1.com=",
+		"https://mirror-1.com=0",
+		"https://mirror-1.com=-1",
+		"https://mirror-1.com=heavy",
+		"ftp://mirror-1.com=5",
+		"https://mirror-1.com/v1/=5",
+	}
+	for _, val := range invalid {
+		if ret, err := ValidateMirrorWeight(val); err == nil || ret != "" {
+			t.Errorf("ValidateMirrorWeight(`%s`) got %s %v", val, ret, err)
+		}
+	}
+}
+
+func TestWeightedMirrors(t *testing.T) {
+	defer func(f func(int) int) { randIntn = f }(randIntn)
+
+	mirrors := []string{"https://mirror-1.com/", "https://mirror-2.com/", "https://mirror-3.com/"}
+	config := newServiceConfig(ServiceOptions{Mirrors: mirrors})
+	randIntn = func(int) int {
+		t.Fatal("expected the mirrors without weights not to be shuffled")
+		return 0
+	}
+	if m := config.weightedMirrors(); !reflect.DeepEqual(m, mirrors) {
+		t.Fatalf("expected mirrors %v, got %v", mirrors, m)
+	}
+
+	config = newServiceConfig(ServiceOptions{
+		Mirrors:       mirrors,
+		MirrorWeights: []string{"https://mirror-1.com/=5", "https://mirror-2.com/=3", "https://mirror-3.com/=2"},
+	})
+	// draw every value once for the first mirror, and the first value for
+	// the next ones
+	first := map[string]int{}
+	for draw := 0; draw < 10; draw++ {
+		calls := 0
+		randIntn = func(n int) int {
+			if calls++; calls == 1 {
+				return draw
+			}
+			return 0
+		}
+		m := config.weightedMirrors()
+		if len(m) != len(mirrors) {
+			t.Fatalf("expected all the mirrors to be tried, got %v", m)
+		}
+		first[m[0]]++
+	}
+	expected := map[string]int{"https://mirror-1.com/": 5, "https://mirror-2.com/": 3, "https://mirror-3.com/": 2}
+	if !reflect.DeepEqual(first, expected) {
+		t.Fatalf("expected the mirrors to come first %v times, got %v", expected, first)
+	}
+
+	randIntn = func(n int) int { return n - 1 }
+	expectedOrder := []string{"https://mirror-3.com/", "https://mirror-2.com/", "https://mirror-1.com/"}
+	if m := config.weightedMirrors(); !reflect.DeepEqual(m, expectedOrder) {
+		t.Fatalf("expected the remaining mirrors to be tried next, got %v", m)
+	}
+	if !reflect.DeepEqual(config.Mirrors, mirrors) {
+		t.Fatalf("expected the configured mirrors to be left unchanged, got %v", config.Mirrors)
+	}
+}
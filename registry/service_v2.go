@@ -12,7 +12,7 @@ func (s *Service) lookupV2Endpoints(hostname string) (endpoints []APIEndpoint, e
 	tlsConfig := &cfg
 	if hostname == DefaultNamespace || hostname == DefaultV1Registry.Host {
 		// v2 mirrors
-		for _, mirror := range s.config.Mirrors {
+		for _, mirror := range s.config.weightedMirrors() {
 			if !strings.HasPrefix(mirror, "http://") && !strings.HasPrefix(mirror, "https://") {
 				mirror = "https://" + mirror
 			}
@@ -77,3 +77,43 @@ func (s *Service) lookupV2Endpoints(hostname string) (endpoints []APIEndpoint, e
 
 	return endpoints, nil
 }
+
+// weightedMirrors returns the mirrors in the order they are tried. When
+// mirror weights are configured, the order is drawn at random, the
+// probability of a mirror to come first being proportional to its weight.
+func (config *serviceConfig) weightedMirrors() []string {
+	if len(config.MirrorWeights) == 0 {
+		return config.Mirrors
+	}
+	remaining := make([]string, len(config.Mirrors))
+	copy(remaining, config.Mirrors)
+	mirrors := make([]string, 0, len(remaining))
+	for len(remaining) > 0 {
+		total := 0
+		for _, mirror := range remaining {
+			total += config.mirrorWeight(mirror)
+		}
+		n := randIntn(total)
+		for i, mirror := range remaining {
+			if n -= config.mirrorWeight(mirror); n < 0 {
+				mirrors = append(mirrors, mirror)
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				break
+			}
+		}
+	}
+	return mirrors
+}
+
+// mirrorWeight returns the weight of a mirror, 1 if it has none.
+func (config *serviceConfig) mirrorWeight(mirror string) int {
+	if !strings.HasPrefix(mirror, "http://") && !strings.HasPrefix(mirror, "https://") {
+		mirror = "https://" + mirror
+	}
+	if m, err := ValidateMirror(strings.TrimSuffix(mirror, "/")); err == nil {
+		if weight, ok := config.MirrorWeights[m]; ok {
+			return weight
+		}
+	}
+	return 1
+}
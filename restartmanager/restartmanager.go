@@ -22,13 +22,26 @@ var ErrRestartCanceled = errors.New("restart canceled")
 type RestartManager interface {
 	Cancel() error
 	ShouldRestart(exitCode uint32, hasBeenManuallyStopped bool, executionDuration time.Duration) (bool, chan error, error)
+	// Scheduled returns the number of restarts scheduled by ShouldRestart
+	// and the delay before the last one.
+	Scheduled() (attempt int, delay time.Duration)
+}
+
+// Backoff configures the delay between restarts. The delay starts at Base
+// and is doubled on every restart, up to Max. Zero values use the defaults:
+// a base of 100ms and no upper limit.
+type Backoff struct {
+	Base time.Duration
+	Max  time.Duration
 }
 
 type restartManager struct {
 	sync.Mutex
 	sync.Once
 	policy       container.RestartPolicy
+	backoff      Backoff
 	failureCount int
+	restartCount int
 	timeout      time.Duration
 	active       bool
 	cancel       chan struct{}
@@ -37,7 +50,13 @@ type restartManager struct {
 
 // New returns a new restartmanager based on a policy.
 func New(policy container.RestartPolicy) RestartManager {
-	return &restartManager{policy: policy, cancel: make(chan struct{})}
+	return NewWithBackoff(policy, Backoff{})
+}
+
+// NewWithBackoff returns a new restartmanager based on a policy that
+// waits between restarts as configured by backoff.
+func NewWithBackoff(policy container.RestartPolicy, backoff Backoff) RestartManager {
+	return &restartManager{policy: policy, backoff: backoff, cancel: make(chan struct{})}
 }
 
 func (rm *restartManager) SetPolicy(policy container.RestartPolicy) {
@@ -79,9 +98,15 @@ func (rm *restartManager) ShouldRestart(exitCode uint32, hasBeenManuallyStopped
 	}
 	if rm.timeout == 0 {
 		rm.timeout = defaultTimeout
+		if rm.backoff.Base > 0 {
+			rm.timeout = rm.backoff.Base
+		}
 	} else {
 		rm.timeout *= backoffMultiplier
 	}
+	if rm.backoff.Max > 0 && rm.timeout > rm.backoff.Max {
+		rm.timeout = rm.backoff.Max
+	}
 
 	var restart bool
 	switch {
@@ -101,6 +126,7 @@ func (rm *restartManager) ShouldRestart(exitCode uint32, hasBeenManuallyStopped
 
 	unlockOnExit = false
 	rm.active = true
+	rm.restartCount++
 	rm.Unlock()
 
 	ch := make(chan error)
@@ -120,6 +146,12 @@ func (rm *restartManager) ShouldRestart(exitCode uint32, hasBeenManuallyStopped
 	return true, ch, nil
 }
 
+func (rm *restartManager) Scheduled() (int, time.Duration) {
+	rm.Lock()
+	defer rm.Unlock()
+	return rm.restartCount, rm.timeout
+}
+
 func (rm *restartManager) Cancel() error {
 	rm.Do(func() {
 		rm.Lock()
@@ -32,3 +32,37 @@ func TestRestartManagerTimeoutReset(t *testing.T) {
 		t.Fatalf("restart manager should have a timeout of 100ms but has %s", rm.timeout)
 	}
 }
+
+func TestRestartManagerBackoff(t *testing.T) {
+	backoff := Backoff{Base: 10 * time.Millisecond, Max: 40 * time.Millisecond}
+	rm := NewWithBackoff(container.RestartPolicy{Name: "always", MaximumRetryCount: 0}, backoff).(*restartManager)
+
+	expected := []time.Duration{
+		10 * time.Millisecond,
+		20 * time.Millisecond,
+		40 * time.Millisecond,
+		40 * time.Millisecond,
+	}
+	for i, timeout := range expected {
+		start := time.Now()
+		should, wait, err := rm.ShouldRestart(1, false, 1*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !should {
+			t.Fatal("container should be restarted")
+		}
+		if rm.timeout != timeout {
+			t.Fatalf("restart %d: restart manager should have a timeout of %s but has %s", i, timeout, rm.timeout)
+		}
+		if attempt, delay := rm.Scheduled(); attempt != i+1 || delay != timeout {
+			t.Fatalf("restart %d: restart manager should have scheduled restart %d after %s but scheduled restart %d after %s", i, i+1, timeout, attempt, delay)
+		}
+		if err := <-wait; err != nil {
+			t.Fatal(err)
+		}
+		if elapsed := time.Since(start); elapsed < timeout {
+			t.Fatalf("restart %d: restart manager should have waited %s but waited %s", i, timeout, elapsed)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package runconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/pkg/idtools"
+)
+
+// The settings of a container that the engine API has no field for are
+// carried in its labels, and validated when the container is created.
+const (
+	// InitLabel is the container label that runs the init binary of the
+	// daemon as the first process of the container.
+	InitLabel = "com.docker.init"
+	// StopTimeoutLabel is the container label that sets the number of
+	// seconds to wait for the container to stop before killing it.
+	StopTimeoutLabel = "com.docker.stop-timeout"
+	// SignalForwardingLabel is the container label that sets whether the
+	// signals sent to the container are forwarded to its first process
+	// (process), or to the process group of its first process (group).
+	SignalForwardingLabel = "com.docker.signal-forwarding"
+	// LiveRestoreLabel is the container label that keeps the container
+	// running when the daemon restarts, supervised by its restart policy,
+	// even when live restore is disabled.
+	LiveRestoreLabel = "com.docker.live-restore"
+	// UIDMapsLabel and GIDMapsLabel are the container labels that map the
+	// UIDs and GIDs of the user namespace of the container to host IDs of
+	// its own, as a comma separated list of containerID:hostID:size.
+	UIDMapsLabel = "com.docker.userns.uidmaps"
+	GIDMapsLabel = "com.docker.userns.gidmaps"
+	// RestartBackoffBaseLabel is the container label that sets the delay
+	// before the first restart of the container, as a duration.
+	RestartBackoffBaseLabel = "com.docker.restart.backoff-base"
+	// RestartBackoffMaxLabel is the container label that caps the delay
+	// between the restarts of the container, as a duration.
+	RestartBackoffMaxLabel = "com.docker.restart.backoff-max"
+)
+
+// InitFromLabels returns whether the labels of a container request an init
+// process.
+func InitFromLabels(labels map[string]string) (bool, error) {
+	return boolFromLabels(labels, InitLabel)
+}
+
+// StopTimeoutFromLabels returns the stop timeout set in the labels of a
+// container, or defaultTimeout if they don't set it.
+func StopTimeoutFromLabels(labels map[string]string, defaultTimeout int) (int, error) {
+	v, ok := labels[StopTimeoutLabel]
+	if !ok {
+		return defaultTimeout, nil
+	}
+	timeout, err := strconv.Atoi(v)
+	if err != nil || timeout < 0 {
+		return defaultTimeout, fmt.Errorf("invalid value for %s: %q, it must be a positive number of seconds", StopTimeoutLabel, v)
+	}
+	return timeout, nil
+}
+
+// LiveRestoreFromLabels returns whether the labels of a container keep it
+// running when the daemon restarts.
+func LiveRestoreFromLabels(labels map[string]string) (bool, error) {
+	return boolFromLabels(labels, LiveRestoreLabel)
+}
+
+// ProcessGroupSignalsFromLabels returns whether the labels of a container
+// forward the signals sent to it to the process group of its first process.
+func ProcessGroupSignalsFromLabels(labels map[string]string) (bool, error) {
+	switch v := labels[SignalForwardingLabel]; v {
+	case "", "process":
+		return false, nil
+	case "group":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid value for %s: %q, it must be process or group", SignalForwardingLabel, v)
+	}
+}
+
+// RestartBackoffFromLabels returns the delay before the first restart of a
+// container and the maximum delay between its restarts set in its labels,
+// zero for the ones they don't set.
+func RestartBackoffFromLabels(labels map[string]string) (time.Duration, time.Duration, error) {
+	var durations [2]time.Duration
+	for i, label := range []string{RestartBackoffBaseLabel, RestartBackoffMaxLabel} {
+		v, ok := labels[label]
+		if !ok {
+			continue
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return 0, 0, fmt.Errorf("invalid value for %s: %q, it must be a positive duration", label, v)
+		}
+		durations[i] = d
+	}
+	base, max := durations[0], durations[1]
+	if base > 0 && max > 0 && base > max {
+		return 0, 0, fmt.Errorf("%s can't be greater than %s", RestartBackoffBaseLabel, RestartBackoffMaxLabel)
+	}
+	return base, max, nil
+}
+
+// IDMapsFromLabels returns the UID and GID maps set in the labels of a
+// container, nil if they don't set them.
+func IDMapsFromLabels(labels map[string]string) ([]idtools.IDMap, []idtools.IDMap, error) {
+	var maps [2][]idtools.IDMap
+	for i, label := range []string{UIDMapsLabel, GIDMapsLabel} {
+		v, ok := labels[label]
+		if !ok {
+			continue
+		}
+		m, err := ParseIDMaps(v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid value for %s: %v", label, err)
+		}
+		maps[i] = m
+	}
+	return maps[0], maps[1], nil
+}
+
+// ParseIDMaps parses a comma separated list of ID maps, each as
+// containerID:hostID:size.
+func ParseIDMaps(s string) ([]idtools.IDMap, error) {
+	var maps []idtools.IDMap
+	for _, m := range strings.Split(s, ",") {
+		parts := strings.Split(m, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("%q, expected containerID:hostID:size", m)
+		}
+		var ids [3]int
+		for i, part := range parts {
+			id, err := strconv.Atoi(part)
+			if err != nil || id < 0 {
+				return nil, fmt.Errorf("%q, expected containerID:hostID:size", m)
+			}
+			ids[i] = id
+		}
+		if ids[2] == 0 {
+			return nil, fmt.Errorf("%q, the size must be positive", m)
+		}
+		maps = append(maps, idtools.IDMap{ContainerID: ids[0], HostID: ids[1], Size: ids[2]})
+	}
+	return maps, nil
+}
+
+func boolFromLabels(labels map[string]string, label string) (bool, error) {
+	v, ok := labels[label]
+	if !ok {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid value for %s: %q", label, v)
+	}
+	return b, nil
+}
@@ -0,0 +1,69 @@
+package runconfig
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInitFromLabels(t *testing.T) {
+	for value, expected := range map[string]bool{
+		"":      false,
+		"true":  true,
+		"1":     true,
+		"false": false,
+	} {
+		l := map[string]string{}
+		if value != "" {
+			l[InitLabel] = value
+		}
+		withInit, err := InitFromLabels(l)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if withInit != expected {
+			t.Fatalf("Expected %v for %q, got %v", expected, value, withInit)
+		}
+	}
+	if _, err := InitFromLabels(map[string]string{InitLabel: "sometimes"}); err == nil {
+		t.Fatal("Expected an error for an invalid value")
+	}
+}
+
+func TestStopTimeoutFromLabels(t *testing.T) {
+	if timeout, err := StopTimeoutFromLabels(nil, 10); err != nil || timeout != 10 {
+		t.Fatalf("Expected the default timeout 10, got %d (%v)", timeout, err)
+	}
+	if timeout, err := StopTimeoutFromLabels(map[string]string{StopTimeoutLabel: "30"}, 10); err != nil || timeout != 30 {
+		t.Fatalf("Expected the timeout 30 of the label, got %d (%v)", timeout, err)
+	}
+	for _, v := range []string{"-1", "10s", ""} {
+		if _, err := StopTimeoutFromLabels(map[string]string{StopTimeoutLabel: v}, 10); err == nil {
+			t.Fatalf("Expected an error for %q", v)
+		}
+	}
+}
+
+func TestRestartBackoffFromLabels(t *testing.T) {
+	base, max, err := RestartBackoffFromLabels(map[string]string{
+		RestartBackoffBaseLabel: "1s",
+		RestartBackoffMaxLabel:  "30s",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base != 1*time.Second || max != 30*time.Second {
+		t.Fatalf("Expected the backoff 1s to 30s of the labels, got %s to %s", base, max)
+	}
+	if base, max, err := RestartBackoffFromLabels(nil); err != nil || base != 0 || max != 0 {
+		t.Fatalf("Expected no backoff, got %s to %s (%v)", base, max, err)
+	}
+	for _, labels := range []map[string]string{
+		{RestartBackoffBaseLabel: "foo"},
+		{RestartBackoffMaxLabel: "-1s"},
+		{RestartBackoffBaseLabel: "1m", RestartBackoffMaxLabel: "30s"},
+	} {
+		if _, _, err := RestartBackoffFromLabels(labels); err == nil {
+			t.Fatalf("Expected an error for %v", labels)
+		}
+	}
+}
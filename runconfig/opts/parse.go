@@ -14,6 +14,7 @@ import (
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/mount"
 	"github.com/docker/docker/pkg/signal"
+	"github.com/docker/docker/runconfig"
 	"github.com/docker/engine-api/types/container"
 	networktypes "github.com/docker/engine-api/types/network"
 	"github.com/docker/engine-api/types/strslice"
@@ -55,6 +56,9 @@ func Parse(cmd *flag.FlagSet, args []string) (*container.Config, *container.Host
 		flCapDrop           = opts.NewListOpts(nil)
 		flGroupAdd          = opts.NewListOpts(nil)
 		flSecurityOpt       = opts.NewListOpts(nil)
+		flStorageOpt        = opts.NewListOpts(nil)
+		flUIDMaps           = opts.NewListOpts(nil)
+		flGIDMaps           = opts.NewListOpts(nil)
 		flLabelsFile        = opts.NewListOpts(nil)
 		flLoggingOpts       = opts.NewListOpts(nil)
 		flPrivileged        = cmd.Bool([]string{"-privileged"}, false, "Give extended privileges to this container")
@@ -94,8 +98,12 @@ func Parse(cmd *flag.FlagSet, args []string) (*container.Config, *container.Host
 		flCgroupParent      = cmd.String([]string{"-cgroup-parent"}, "", "Optional parent cgroup for the container")
 		flVolumeDriver      = cmd.String([]string{"-volume-driver"}, "", "Optional volume driver for the container")
 		flStopSignal        = cmd.String([]string{"-stop-signal"}, signal.DefaultStopSignal, fmt.Sprintf("Signal to stop a container, %v by default", signal.DefaultStopSignal))
+		flStopTimeout       = cmd.Int([]string{"-stop-timeout"}, 0, "Timeout (in seconds) to stop a container")
 		flIsolation         = cmd.String([]string{"-isolation"}, "", "Container isolation technology")
 		flShmSize           = cmd.String([]string{"-shm-size"}, "", "Size of /dev/shm, default value is 64MB")
+		flInit              = cmd.Bool([]string{"-init"}, false, "Run an init inside the container that forwards signals and reaps processes")
+		flSignalForwarding  = cmd.String([]string{"-signal-forwarding"}, "", "Forward signals to the first process (process) or to its process group (group)")
+		flLiveRestore       = cmd.Bool([]string{"-live-restore"}, false, "Keep the container running across daemon restarts, supervised by its restart policy")
 	)
 
 	cmd.Var(&flAttach, []string{"a", "-attach"}, "Attach to STDIN, STDOUT or STDERR")
@@ -124,6 +132,9 @@ func Parse(cmd *flag.FlagSet, args []string) (*container.Config, *container.Host
 	cmd.Var(&flCapDrop, []string{"-cap-drop"}, "Drop Linux capabilities")
 	cmd.Var(&flGroupAdd, []string{"-group-add"}, "Add additional groups to join")
 	cmd.Var(&flSecurityOpt, []string{"-security-opt"}, "Security Options")
+	cmd.Var(&flStorageOpt, []string{"-storage-opt"}, "Set storage driver options per container")
+	cmd.Var(&flUIDMaps, []string{"-uid-map"}, "Map container UIDs to host UIDs in the user namespace, as containerID:hostID:size")
+	cmd.Var(&flGIDMaps, []string{"-gid-map"}, "Map container GIDs to host GIDs in the user namespace, as containerID:hostID:size")
 	cmd.Var(flUlimits, []string{"-ulimit"}, "Ulimit options")
 	cmd.Var(&flLoggingOpts, []string{"-log-opt"}, "Log driver options")
 
@@ -337,6 +348,11 @@ func Parse(cmd *flag.FlagSet, args []string) (*container.Config, *container.Host
 		return nil, nil, nil, cmd, err
 	}
 
+	storageOpts, err := parseStorageOpts(flStorageOpt.GetAll())
+	if err != nil {
+		return nil, nil, nil, cmd, err
+	}
+
 	resources := container.Resources{
 		CgroupParent:         *flCgroupParent,
 		Memory:               flMemory,
@@ -386,6 +402,30 @@ func Parse(cmd *flag.FlagSet, args []string) (*container.Config, *container.Host
 	if cmd.IsSet("-stop-signal") {
 		config.StopSignal = *flStopSignal
 	}
+	if cmd.IsSet("-stop-timeout") {
+		config.Labels[runconfig.StopTimeoutLabel] = strconv.Itoa(*flStopTimeout)
+	}
+	if *flInit {
+		config.Labels[runconfig.InitLabel] = "true"
+	}
+	if *flLiveRestore {
+		config.Labels[runconfig.LiveRestoreLabel] = "true"
+	}
+	if *flSignalForwarding != "" {
+		config.Labels[runconfig.SignalForwardingLabel] = *flSignalForwarding
+		if _, err := runconfig.ProcessGroupSignalsFromLabels(config.Labels); err != nil {
+			return nil, nil, nil, cmd, fmt.Errorf("--signal-forwarding: invalid signal forwarding mode")
+		}
+	}
+	if maps := flUIDMaps.GetAll(); len(maps) > 0 {
+		config.Labels[runconfig.UIDMapsLabel] = strings.Join(maps, ",")
+	}
+	if maps := flGIDMaps.GetAll(); len(maps) > 0 {
+		config.Labels[runconfig.GIDMapsLabel] = strings.Join(maps, ",")
+	}
+	if _, _, err := runconfig.IDMapsFromLabels(config.Labels); err != nil {
+		return nil, nil, nil, cmd, err
+	}
 
 	hostConfig := &container.HostConfig{
 		Binds:           binds,
@@ -415,6 +455,7 @@ func Parse(cmd *flag.FlagSet, args []string) (*container.Config, *container.Host
 		GroupAdd:       flGroupAdd.GetAll(),
 		RestartPolicy:  restartPolicy,
 		SecurityOpt:    securityOpts,
+		StorageOpt:     storageOpts,
 		ReadonlyRootfs: *flReadonlyRootfs,
 		LogConfig:      container.LogConfig{Type: *flLoggingDriver, Config: loggingOpts},
 		VolumeDriver:   *flVolumeDriver,
@@ -516,6 +557,16 @@ func parseSecurityOpts(securityOpts []string) ([]string, error) {
 			}
 		}
 		if con[0] == "seccomp" && con[1] != "unconfined" {
+			// a profile starting with { is given inline rather than as the
+			// path of its file
+			if strings.HasPrefix(strings.TrimSpace(con[1]), "{") {
+				b := bytes.NewBuffer(nil)
+				if err := json.Compact(b, []byte(con[1])); err != nil {
+					return securityOpts, fmt.Errorf("parsing inline seccomp profile failed: %v", jsonErrorOffset(err))
+				}
+				securityOpts[key] = fmt.Sprintf("seccomp=%s", b.Bytes())
+				continue
+			}
 			f, err := ioutil.ReadFile(con[1])
 			if err != nil {
 				return securityOpts, fmt.Errorf("opening seccomp profile (%s) failed: %v", con[1], err)
@@ -531,6 +582,27 @@ func parseSecurityOpts(securityOpts []string) ([]string, error) {
 	return securityOpts, nil
 }
 
+// parses storage options per container into a map
+func parseStorageOpts(storageOpts []string) (map[string]string, error) {
+	m := make(map[string]string)
+	for _, option := range storageOpts {
+		opt := strings.SplitN(option, "=", 2)
+		if len(opt) != 2 {
+			return nil, fmt.Errorf("Invalid --storage-opt: %q", option)
+		}
+		m[opt[0]] = opt[1]
+	}
+	return m, nil
+}
+
+// jsonErrorOffset adds the offset at which a JSON syntax error occurred to err.
+func jsonErrorOffset(err error) error {
+	if e, ok := err.(*json.SyntaxError); ok {
+		return fmt.Errorf("%v at offset %d", e, e.Offset)
+	}
+	return err
+}
+
 // ParseRestartPolicy returns the parsed policy or an error indicating what is incorrect
 func ParseRestartPolicy(policy string) (container.RestartPolicy, error) {
 	p := container.RestartPolicy{}
@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"reflect"
 	"runtime"
 	"strings"
 	"testing"
 
+	"github.com/docker/docker/pkg/idtools"
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/engine-api/types/container"
@@ -384,6 +386,59 @@ func TestParseWithMemorySwap(t *testing.T) {
 	}
 }
 
+func TestParseInit(t *testing.T) {
+	if config, _ := mustParse(t, ""); config.Labels[runconfig.InitLabel] != "" {
+		t.Fatalf("Expected no init by default, got %q", config.Labels[runconfig.InitLabel])
+	}
+	if config, _ := mustParse(t, "--init"); config.Labels[runconfig.InitLabel] != "true" {
+		t.Fatalf("Expected init to be enabled with --init, got %q", config.Labels[runconfig.InitLabel])
+	}
+}
+
+func TestParseSignalForwarding(t *testing.T) {
+	if config, _ := mustParse(t, ""); config.Labels[runconfig.SignalForwardingLabel] != "" {
+		t.Fatalf("Expected no signal forwarding mode by default, got %q", config.Labels[runconfig.SignalForwardingLabel])
+	}
+	if config, _ := mustParse(t, "--signal-forwarding=group"); config.Labels[runconfig.SignalForwardingLabel] != "group" {
+		t.Fatalf("Expected the signals to be forwarded to the process group, got %q", config.Labels[runconfig.SignalForwardingLabel])
+	}
+	if _, _, err := parse(t, "--signal-forwarding=children"); err == nil || !strings.Contains(err.Error(), "invalid signal forwarding mode") {
+		t.Fatalf("Expected an invalid signal forwarding mode error, got %v", err)
+	}
+}
+
+func TestParseLiveRestore(t *testing.T) {
+	if config, _ := mustParse(t, ""); config.Labels[runconfig.LiveRestoreLabel] != "" {
+		t.Fatalf("Expected the daemon's live restore settings by default, got %q", config.Labels[runconfig.LiveRestoreLabel])
+	}
+	if config, _ := mustParse(t, "--live-restore"); config.Labels[runconfig.LiveRestoreLabel] != "true" {
+		t.Fatalf("Expected live restore to be enabled with --live-restore, got %q", config.Labels[runconfig.LiveRestoreLabel])
+	}
+}
+
+func TestParseIDMaps(t *testing.T) {
+	config, _ := mustParse(t, "--uid-map=0:200000:65536 --uid-map=65536:300000:10 --gid-map=0:200000:65536")
+	if v := config.Labels[runconfig.UIDMapsLabel]; v != "0:200000:65536,65536:300000:10" {
+		t.Fatalf("Expected the UID maps label to be set, got %q", v)
+	}
+	uidMaps, gidMaps, err := runconfig.IDMapsFromLabels(config.Labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []idtools.IDMap{{ContainerID: 0, HostID: 200000, Size: 65536}, {ContainerID: 65536, HostID: 300000, Size: 10}}
+	if !reflect.DeepEqual(uidMaps, expected) {
+		t.Fatalf("Expected UID maps %v, got %v", expected, uidMaps)
+	}
+	if len(gidMaps) != 1 || gidMaps[0].HostID != 200000 {
+		t.Fatalf("Expected the GID map to be parsed, got %v", gidMaps)
+	}
+	for _, invalid := range []string{"--uid-map=0:200000", "--uid-map=0:-1:10", "--gid-map=0:200000:0", "--gid-map=a:b:c"} {
+		if _, _, _, _, err := parseRun([]string{invalid, "img", "cmd"}); err == nil {
+			t.Fatalf("Expected %s to be rejected", invalid)
+		}
+	}
+}
+
 func TestParseHostname(t *testing.T) {
 	validHostnames := map[string]string{
 		"hostname":    "hostname",
@@ -612,6 +667,34 @@ func TestParseLoggingOpts(t *testing.T) {
 	}
 }
 
+func TestParseStorageOpts(t *testing.T) {
+	if _, _, _, _, err := parseRun([]string{"--storage-opt=size", "img", "cmd"}); err == nil || err.Error() != `Invalid --storage-opt: "size"` {
+		t.Fatalf("Expected an error with message 'Invalid --storage-opt: \"size\"', got %v", err)
+	}
+	_, hostconfig, _, _, err := parseRun([]string{"--storage-opt=size=10G", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostconfig.StorageOpt["size"] != "10G" {
+		t.Fatalf("Expected a size storage option of 10G, got %v", hostconfig.StorageOpt)
+	}
+}
+
+func TestParseInlineSeccompProfile(t *testing.T) {
+	_, hostconfig, _, _, err := parseRun([]string{`--security-opt=seccomp={ "defaultAction": "SCMP_ACT_ALLOW" }`, "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hostconfig.SecurityOpt) != 1 || hostconfig.SecurityOpt[0] != `seccomp={"defaultAction":"SCMP_ACT_ALLOW"}` {
+		t.Fatalf("Expected the compacted inline seccomp profile, got %v", hostconfig.SecurityOpt)
+	}
+
+	_, _, _, _, err = parseRun([]string{`--security-opt=seccomp={"defaultAction": SCMP_ACT_ALLOW}`, "img", "cmd"})
+	if err == nil || !strings.Contains(err.Error(), "parsing inline seccomp profile failed") || !strings.Contains(err.Error(), "at offset 19") {
+		t.Fatalf("Expected a parse error with its offset, got %v", err)
+	}
+}
+
 func TestParseEnvfileVariables(t *testing.T) {
 	e := "open nonexistent: no such file or directory"
 	if runtime.GOOS == "windows" {
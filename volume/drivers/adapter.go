@@ -3,6 +3,7 @@ package volumedrivers
 import (
 	"fmt"
 
+	"github.com/docker/docker/pkg/plugins"
 	"github.com/docker/docker/volume"
 )
 
@@ -67,6 +68,19 @@ func (a *volumeDriverAdapter) Get(name string) (volume.Volume, error) {
 	}, nil
 }
 
+// Capabilities returns the capabilities advertised by the plugin. Plugins
+// which don't implement VolumeDriver.Capabilities don't restrict anything.
+func (a *volumeDriverAdapter) Capabilities() (volume.Capability, error) {
+	c, err := a.proxy.Capabilities()
+	if err != nil {
+		if plugins.IsNotFound(err) {
+			return volume.Capability{}, nil
+		}
+		return volume.Capability{}, err
+	}
+	return volume.Capability(c), nil
+}
+
 type volumeAdapter struct {
 	proxy      *volumeDriverProxy
 	name       string
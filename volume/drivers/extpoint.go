@@ -26,6 +26,7 @@ func NewVolumeDriver(name string, c client) volume.Driver {
 
 type opts map[string]string
 type list []*proxyVolume
+type capability volume.Capability
 
 // volumeDriver defines the available functions that volume plugins must implement.
 // This interface is only defined to generate the proxy objects.
@@ -45,6 +46,8 @@ type volumeDriver interface {
 	List() (volumes list, err error)
 	// Get retrieves the volume with the requested name
 	Get(name string) (volume *proxyVolume, err error)
+	// Capabilities gets the capabilities of the driver
+	Capabilities() (capabilities capability, err error)
 }
 
 type driverExtpoint struct {
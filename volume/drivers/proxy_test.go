@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/docker/docker/pkg/plugins"
+	"github.com/docker/docker/volume"
 	"github.com/docker/go-connections/tlsconfig"
 )
 
@@ -120,3 +121,46 @@ func TestVolumeRequestError(t *testing.T) {
 		t.Fatalf("Unexpected error: %v\n", err)
 	}
 }
+
+func TestVolumeDriverCapabilities(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/VolumeDriver.Capabilities", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.docker.plugins.v1+json")
+		fmt.Fprintln(w, `{"Capabilities": {"Opts": ["size"]}}`)
+	})
+
+	u, _ := url.Parse(server.URL)
+	client, err := plugins.NewClient("tcp://"+u.Host, tlsconfig.Options{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	driver := NewVolumeDriver("capable", client).(volume.CapabilityDriver)
+	if err := volume.ValidateOpts(driver, map[string]string{"size": "10G"}); err != nil {
+		t.Fatal(err)
+	}
+	err = volume.ValidateOpts(driver, map[string]string{"sise": "10G"})
+	if err == nil || !strings.Contains(err.Error(), "sise") || !strings.Contains(err.Error(), "valid keys: size") {
+		t.Fatalf("Expected an error listing the valid keys, got %v", err)
+	}
+}
+
+func TestVolumeDriverCapabilitiesNotImplemented(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	client, err := plugins.NewClient("tcp://"+u.Host, tlsconfig.Options{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	driver := NewVolumeDriver("legacy", client)
+	if err := volume.ValidateOpts(driver, map[string]string{"anything": "goes"}); err != nil {
+		t.Fatalf("Expected drivers without capabilities to accept any option, got %v", err)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 
 	"github.com/Sirupsen/logrus"
@@ -139,6 +140,16 @@ func (r *Root) Name() string {
 	return volume.DefaultDriverName
 }
 
+// Capabilities returns the option keys accepted by the local driver.
+func (r *Root) Capabilities() (volume.Capability, error) {
+	opts := []string{}
+	for opt := range validOpts {
+		opts = append(opts, opt)
+	}
+	sort.Strings(opts)
+	return volume.Capability{Opts: opts}, nil
+}
+
 // Create creates a new volume.Volume with the provided name, creating
 // the underlying directory tree required for this volume in the
 // process.
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/docker/docker/pkg/mount"
+	"github.com/docker/docker/volume"
 )
 
 func TestRemove(t *testing.T) {
@@ -247,3 +248,28 @@ func TestCreateWithOpts(t *testing.T) {
 		t.Fatal("expected mount to still be active")
 	}
 }
+
+func TestValidateOptsAgainstCapabilities(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip()
+	}
+
+	rootDir, err := ioutil.TempDir("", "local-volume-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootDir)
+
+	r, err := New(rootDir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := volume.ValidateOpts(r, map[string]string{"type": "tmpfs", "device": "tmpfs", "o": "size=1m"}); err != nil {
+		t.Fatal(err)
+	}
+	err = volume.ValidateOpts(r, map[string]string{"typ": "tmpfs"})
+	if err == nil || !strings.Contains(err.Error(), "valid keys: device, o, type") {
+		t.Fatalf("expected an error listing the valid keys, got %v", err)
+	}
+}
@@ -21,8 +21,9 @@ const (
 )
 
 type volumeMetadata struct {
-	Name   string
-	Labels map[string]string
+	Name    string
+	Labels  map[string]string
+	Options map[string]string `json:",omitempty"`
 }
 
 type volumeWithLabels struct {
@@ -38,10 +39,11 @@ func (v volumeWithLabels) Labels() map[string]string {
 // reference counting of volumes in the system.
 func New(rootPath string) (*VolumeStore, error) {
 	vs := &VolumeStore{
-		locks:  &locker.Locker{},
-		names:  make(map[string]volume.Volume),
-		refs:   make(map[string][]string),
-		labels: make(map[string]map[string]string),
+		locks:   &locker.Locker{},
+		names:   make(map[string]volume.Volume),
+		refs:    make(map[string][]string),
+		labels:  make(map[string]map[string]string),
+		options: make(map[string]map[string]string),
 	}
 
 	if rootPath != "" {
@@ -95,6 +97,7 @@ func (s *VolumeStore) purge(name string) {
 	delete(s.names, name)
 	delete(s.refs, name)
 	delete(s.labels, name)
+	delete(s.options, name)
 	s.globalLock.Unlock()
 }
 
@@ -109,7 +112,9 @@ type VolumeStore struct {
 	refs map[string][]string
 	// labels stores volume labels for each volume
 	labels map[string]map[string]string
-	db     *bolt.DB
+	// options stores the options each volume was created with
+	options map[string]map[string]string
+	db      *bolt.DB
 }
 
 // List proxies to all registered volume drivers to get the full list of volumes
@@ -223,6 +228,59 @@ func (s *VolumeStore) Create(name, driverName string, opts, labels map[string]st
 	return v, nil
 }
 
+// CreateOrGet creates a volume with the given name and driver. If a volume
+// with that name already exists and it was created with the same driver,
+// options and labels, the existing volume is returned instead. An empty
+// driver name is the default driver. The returned boolean reports whether
+// the volume was created.
+func (s *VolumeStore) CreateOrGet(name, driverName string, opts, labels map[string]string) (volume.Volume, bool, error) {
+	name = normaliseVolumeName(name)
+	s.locks.Lock(name)
+	defer s.locks.Unlock(name)
+
+	if driverName == "" {
+		driverName = volume.DefaultDriverName
+	}
+	v, err := s.getVolume(name)
+	switch {
+	case err == nil:
+		if v.DriverName() != driverName {
+			return nil, false, &OpErr{Err: errNameConflict, Name: name, Op: "create"}
+		}
+		currentOpts, currentLabels, err := s.getOptionsAndLabels(name)
+		if err != nil {
+			return nil, false, &OpErr{Err: err, Name: name, Op: "create"}
+		}
+		if !sameMap(currentOpts, opts) || !sameMap(currentLabels, labels) {
+			return nil, false, &OpErr{Err: errNameConflict, Name: name, Op: "create"}
+		}
+		s.setNamed(v, "")
+		return v, false, nil
+	case err != errNoSuchVolume:
+		return nil, false, &OpErr{Err: err, Name: name, Op: "create"}
+	}
+
+	v, err = s.create(name, driverName, opts, labels)
+	if err != nil {
+		return nil, false, &OpErr{Err: err, Name: name, Op: "create"}
+	}
+	s.setNamed(v, "")
+	return v, true, nil
+}
+
+// sameMap returns true if both sets of volume options, or labels, are equal.
+func sameMap(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
 // create asks the given driver to create a volume with the name/opts.
 // If a volume with the name is already known, it will ask the stored driver for the volume.
 // If the passed in driver name does not match the driver name which is stored for the given volume name, an error is returned.
@@ -269,12 +327,14 @@ func (s *VolumeStore) create(name, driverName string, opts, labels map[string]st
 	}
 	s.globalLock.Lock()
 	s.labels[name] = labels
+	s.options[name] = opts
 	s.globalLock.Unlock()
 
 	if s.db != nil {
 		metadata := &volumeMetadata{
-			Name:   name,
-			Labels: labels,
+			Name:    name,
+			Labels:  labels,
+			Options: opts,
 		}
 
 		volData, err := json.Marshal(metadata)
@@ -333,34 +393,64 @@ func (s *VolumeStore) Get(name string) (volume.Volume, error) {
 	return v, nil
 }
 
-// getVolume requests the volume, if the driver info is stored it just accesses that driver,
-// if the driver is unknown it probes all drivers until it finds the first volume with that name.
+// getMeta returns the metadata stored for the volume with the given name.
+// It returns nil if there is no metadata for the volume.
 // it is expected that callers of this function hold any necessary locks
-func (s *VolumeStore) getVolume(name string) (volume.Volume, error) {
-	labels := map[string]string{}
+func (s *VolumeStore) getMeta(name string) (*volumeMetadata, error) {
+	if s.db == nil {
+		return nil, nil
+	}
 
-	if s.db != nil {
-		// get meta
-		if err := s.db.Update(func(tx *bolt.Tx) error {
-			b := tx.Bucket([]byte(volumeBucketName))
-			data := b.Get([]byte(name))
+	var meta *volumeMetadata
+	if err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(volumeBucketName))
+		data := b.Get([]byte(name))
 
-			if string(data) == "" {
-				return nil
-			}
+		if string(data) == "" {
+			return nil
+		}
 
-			var meta volumeMetadata
-			buf := bytes.NewBuffer(data)
+		meta = &volumeMetadata{}
+		buf := bytes.NewBuffer(data)
+		return json.NewDecoder(buf).Decode(meta)
+	}); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
 
-			if err := json.NewDecoder(buf).Decode(&meta); err != nil {
-				return err
-			}
-			labels = meta.Labels
+// getOptionsAndLabels returns the options and labels the volume with the
+// given name was created with.
+// it is expected that callers of this function hold any necessary locks
+func (s *VolumeStore) getOptionsAndLabels(name string) (map[string]string, map[string]string, error) {
+	s.globalLock.Lock()
+	opts, ok := s.options[name]
+	labels := s.labels[name]
+	s.globalLock.Unlock()
+	if ok {
+		return opts, labels, nil
+	}
 
-			return nil
-		}); err != nil {
-			return nil, err
-		}
+	meta, err := s.getMeta(name)
+	if err != nil || meta == nil {
+		return nil, nil, err
+	}
+	return meta.Options, meta.Labels, nil
+}
+
+// getVolume requests the volume, if the driver info is stored it just accesses that driver,
+// if the driver is unknown it probes all drivers until it finds the first volume with that name.
+// it is expected that callers of this function hold any necessary locks
+func (s *VolumeStore) getVolume(name string) (volume.Volume, error) {
+	labels := map[string]string{}
+
+	// get meta
+	meta, err := s.getMeta(name)
+	if err != nil {
+		return nil, err
+	}
+	if meta != nil {
+		labels = meta.Labels
 	}
 
 	logrus.Debugf("Getting volume reference for name: %s", name)
@@ -38,6 +38,50 @@ func TestCreate(t *testing.T) {
 	}
 }
 
+func TestCreateOrGet(t *testing.T) {
+	volumedrivers.Register(vt.NewFakeDriver("fake"), "fake")
+	volumedrivers.Register(vt.NewFakeDriver("noop"), "noop")
+	defer volumedrivers.Unregister("fake")
+	defer volumedrivers.Unregister("noop")
+	s, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := map[string]string{"size": "1g"}
+	v, created, err := s.CreateOrGet("fake1", "fake", opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created {
+		t.Fatal("Expected volume fake1 to be created")
+	}
+
+	v2, created, err := s.CreateOrGet("fake1", "fake", map[string]string{"size": "1g"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created {
+		t.Fatal("Expected existing volume fake1 to be returned")
+	}
+	if v2.Name() != v.Name() || v2.DriverName() != v.DriverName() {
+		t.Fatalf("Expected volume %v, got %v", v, v2)
+	}
+
+	if _, _, err := s.CreateOrGet("fake1", "noop", opts, nil); !IsNameConflict(err) {
+		t.Fatalf("Expected name conflict with a different driver, got %v", err)
+	}
+	if _, _, err := s.CreateOrGet("fake1", "fake", map[string]string{"size": "2g"}, nil); !IsNameConflict(err) {
+		t.Fatalf("Expected name conflict with different options, got %v", err)
+	}
+	if _, _, err := s.CreateOrGet("fake1", "fake", opts, map[string]string{"a": "b"}); !IsNameConflict(err) {
+		t.Fatalf("Expected name conflict with different labels, got %v", err)
+	}
+	if _, _, err := s.CreateOrGet("fake1", "", opts, nil); !IsNameConflict(err) {
+		t.Fatalf("Expected name conflict with the default driver, got %v", err)
+	}
+}
+
 func TestRemove(t *testing.T) {
 	volumedrivers.Register(vt.NewFakeDriver("fake"), "fake")
 	volumedrivers.Register(vt.NewFakeDriver("noop"), "noop")
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"sort"
 	"strings"
 )
 
@@ -25,6 +26,58 @@ type Driver interface {
 	Get(name string) (Volume, error)
 }
 
+// Capability describes what a driver supports.
+type Capability struct {
+	// Opts lists the option keys accepted when creating a volume. A nil
+	// list means the driver does not restrict the options.
+	Opts []string
+}
+
+// CapabilityDriver is implemented by the drivers able to advertise their
+// capabilities.
+type CapabilityDriver interface {
+	Driver
+	// Capabilities returns the capabilities of the driver.
+	Capabilities() (Capability, error)
+}
+
+// ValidateOpts checks that the driver accepts the options given to create
+// a volume. Drivers which don't advertise their capabilities accept any
+// option.
+func ValidateOpts(d Driver, opts map[string]string) error {
+	cd, ok := d.(CapabilityDriver)
+	if !ok || len(opts) == 0 {
+		return nil
+	}
+	c, err := cd.Capabilities()
+	if err != nil {
+		return err
+	}
+	if c.Opts == nil {
+		return nil
+	}
+
+	valid := make(map[string]bool, len(c.Opts))
+	for _, k := range c.Opts {
+		valid[k] = true
+	}
+	var invalid []string
+	for k := range opts {
+		if !valid[k] {
+			invalid = append(invalid, k)
+		}
+	}
+	if len(invalid) == 0 {
+		return nil
+	}
+	sort.Strings(invalid)
+	validKeys := "none"
+	if len(c.Opts) > 0 {
+		validKeys = strings.Join(c.Opts, ", ")
+	}
+	return fmt.Errorf("invalid option keys for volume driver %s: %s (valid keys: %s)", d.Name(), strings.Join(invalid, ", "), validKeys)
+}
+
 // Volume is a place to store data. It is backed by a specific driver, and can be mounted.
 type Volume interface {
 	// Name returns the name of the volume